@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strings"
+
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/positions"
+)
+
+// Reports alert precision from the alert history: how often game and prop
+// alerts won, traded or not, against the probability they were alerted at.
+//
+//	go run ./cmd/alerts -db /data/positions.db
+func main() {
+	cfg := config.Load()
+
+	flag.StringVar(&cfg.DBPath, "db", cfg.DBPath, "positions database path")
+	flag.Parse()
+
+	db, err := positions.NewDB(cfg.DBPath)
+	if err != nil {
+		log.Fatalf("Opening DB: %v", err)
+	}
+	defer db.Close()
+
+	outcomes, err := db.GetAlertOutcomes()
+	if err != nil {
+		log.Fatalf("Reading alert history: %v", err)
+	}
+	if len(outcomes) == 0 {
+		fmt.Println("No alerts recorded")
+		return
+	}
+
+	var hedges, hedgesFilled int
+	for _, o := range outcomes {
+		if o.Kind == positions.AlertKindHedge && !o.Suppressed {
+			hedges++
+			if o.Traded() {
+				hedgesFilled++
+			}
+		}
+	}
+
+	fmt.Printf("%-8s %-8s %7s %9s %7s %9s %10s\n",
+		"KIND", "TRADED", "ALERTS", "RESOLVED", "HIT%", "EXPECT%", "P&L")
+	fmt.Println(strings.Repeat("-", 64))
+	for _, p := range positions.SummarizeAlertPrecision(outcomes) {
+		traded := "no"
+		if p.Traded {
+			traded = "yes"
+		}
+		fmt.Printf("%-8s %-8s %7d %9d %6.1f%% %8.1f%% %10.2f\n",
+			p.Kind, traded, p.Alerts, p.Resolved, p.HitRate()*100, p.ExpectedRate()*100, p.PnL)
+	}
+	fmt.Printf("\nHedge alerts: %d, filled: %d\n", hedges, hedgesFilled)
+}
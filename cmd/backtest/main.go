@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+
+	"sports-betting-bot/internal/analysis"
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/backtest"
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/snapshot"
+)
+
+// Replays recorded snapshots through engine.Scan with a simulated exchange.
+// Parameters default to the same env vars as the bot; flags override them.
+//
+//	go run ./cmd/backtest -data /data/snapshots -ev 0.04 -kelly 0.2
+//	go run ./cmd/backtest -data /data/snapshots -weights vendor_weights.json
+func main() {
+	cfg := config.Load()
+
+	data := flag.String("data", "", "snapshot file or directory (required)")
+	bankroll := flag.Float64("bankroll", 1000, "starting simulated balance in dollars")
+	flag.Float64Var(&cfg.EVThreshold, "ev", cfg.EVThreshold, "EV threshold")
+	flag.Float64Var(&cfg.KellyFraction, "kelly", cfg.KellyFraction, "Kelly fraction")
+	flag.Float64Var(&cfg.MaxBetDollars, "max-bet", cfg.MaxBetDollars, "max bet in dollars (0 = no cap)")
+	flag.Float64Var(&cfg.MaxSlippagePct, "slippage", cfg.MaxSlippagePct, "max slippage fraction")
+	flag.IntVar(&cfg.MinLiquidityContracts, "min-liq", cfg.MinLiquidityContracts, "min liquidity in contracts")
+	flag.IntVar(&cfg.MaxOddsAgeSec, "max-odds-age", cfg.MaxOddsAgeSec, "max vendor odds age in seconds (0 = no filter)")
+	flag.StringVar(&cfg.VendorWeightsFile, "weights", cfg.VendorWeightsFile, "vendor weights file (empty = compiled-in defaults)")
+	verbose := flag.Bool("v", false, "show engine logs")
+	flag.Parse()
+
+	if *data == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := config.Validate(cfg); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	kalshi.ConfigureFees(cfg.TakerFeeCoeff, cfg.TakerFeeCap)
+	if cfg.VendorWeightsFile != "" {
+		weights, err := api.LoadVendorWeights(cfg.VendorWeightsFile)
+		if err != nil {
+			log.Fatalf("Loading vendor weights: %v", err)
+		}
+		api.SetVendorWeights(weights)
+	}
+
+	records, err := snapshot.ReadPath(*data)
+	if err != nil {
+		log.Fatalf("Reading snapshots: %v", err)
+	}
+	if len(records) == 0 {
+		log.Fatalf("No snapshot records in %s", *data)
+	}
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+		slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	}
+
+	report, err := backtest.Run(records, backtest.Config{
+		Engine: cfg,
+		Analysis: analysis.Config{
+			EVThreshold:   cfg.EVThreshold,
+			KellyFraction: cfg.KellyFraction,
+			MinBookCount:  config.DefaultMinBookCount,
+		},
+		Exec: kalshi.OrderConfig{
+			MaxSlippagePct:        cfg.MaxSlippagePct,
+			MinLiquidityContracts: cfg.MinLiquidityContracts,
+		},
+		Bankroll: *bankroll,
+	})
+	if err != nil {
+		log.SetOutput(os.Stderr)
+		log.Fatalf("Backtest failed: %v", err)
+	}
+
+	report.WriteText(os.Stdout)
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"sports-betting-bot/internal/alerts"
@@ -15,7 +16,9 @@ import (
 	"sports-betting-bot/internal/config"
 	"sports-betting-bot/internal/engine"
 	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/metrics"
 	"sports-betting-bot/internal/positions"
+	"sports-betting-bot/internal/snapshot"
 )
 
 func main() {
@@ -32,9 +35,13 @@ func main() {
 	// Configure fee parameters (before any fee calculations)
 	kalshi.ConfigureFees(cfg.TakerFeeCoeff, cfg.TakerFeeCap)
 
+	// Fitted consensus weights (before any consensus is computed)
+	initVendorWeights(cfg.VendorWeightsFile)
+
 	// Initialize components
 	client := api.NewBallDontLieClient(cfg.APIKey)
 	notifier := alerts.NewNotifier(config.DefaultAlertCooldown)
+	initSinks(cfg, notifier)
 	kalshiClient := initKalshi(cfg)
 	db := initDB(cfg.DBPath)
 	if db != nil {
@@ -75,9 +82,42 @@ func main() {
 		cancel()
 	}()
 
-	// Create engine and run
-	eng := engine.New(client, kalshiClient, notifier, db, cfg, analysisCfg, execConfig)
+	// Create engine and run. A nil *KalshiClient must stay a nil interface
+	// so the engine falls back to alerts-only mode.
+	var odds engine.OddsProvider = client
+	var exchange engine.Exchange
+	var feed *kalshi.MarketFeed
+	if kalshiClient != nil {
+		exchange = kalshiClient
+		if cfg.KalshiWebSocket {
+			feed = kalshiClient.StartMarketFeed(ctx)
+			log.Printf("Kalshi market feed started")
+		}
+	}
+
+	if recorder := initRecorder(cfg); recorder != nil {
+		defer recorder.Close()
+		odds = engine.RecordOdds(odds, recorder)
+		if exchange != nil {
+			exchange = engine.RecordExchange(exchange, recorder)
+		}
+	}
+
+	eng := engine.New(odds, exchange, notifier, db, cfg, analysisCfg, execConfig)
+	if feed != nil {
+		eng.SetMarketFeed(feed)
+	}
+	if kalshiClient != nil && cfg.ExecutionMode == config.ExecMaker {
+		eng.SetOrderManager(kalshiClient)
+	}
+	if kalshiClient != nil && cfg.CrossArb {
+		eng.SetMarketLister(kalshiClient)
+	}
+	if cfg.InPlay {
+		eng.SetLiveScores(client)
+	}
 	eng.Run(ctx)
+	notifier.Flush()
 }
 
 func initKalshi(cfg config.Config) *kalshi.KalshiClient {
@@ -122,12 +162,90 @@ func initDB(dbPath string) *positions.DB {
 	return db
 }
 
+func initVendorWeights(path string) {
+	if path == "" {
+		return
+	}
+	weights, err := api.LoadVendorWeights(path)
+	if err != nil {
+		log.Printf("Using default vendor weights: %v", err)
+		return
+	}
+	api.SetVendorWeights(weights)
+	log.Printf("Loaded vendor weights for %d markets from %s", len(weights), path)
+}
+
+func initRecorder(cfg config.Config) *snapshot.Recorder {
+	if cfg.SnapshotDir == "" {
+		return nil
+	}
+	const mb = 1 << 20
+	recorder, err := snapshot.NewRecorder(cfg.SnapshotDir,
+		int64(cfg.SnapshotMaxFileMB)*mb, int64(cfg.SnapshotMaxTotalMB)*mb)
+	if err != nil {
+		log.Printf("Snapshot recording disabled: %v", err)
+		return nil
+	}
+	log.Printf("Recording snapshots to %s", cfg.SnapshotDir)
+	return recorder
+}
+
+func initSinks(cfg config.Config, notifier *alerts.Notifier) {
+	add := func(sinkCfg config.AlertSinkConfig, sink alerts.Sink) {
+		types, err := alerts.ParseAlertTypes(sinkCfg.Types)
+		if err != nil {
+			log.Fatalf("Alert sink %s: %v", sink.Name(), err)
+		}
+		notifier.AddSink(sink, alerts.Filter{MinEV: sinkCfg.MinEV, Types: types})
+		log.Printf("Alert sink enabled: %s", sink.Name())
+	}
+
+	if cfg.SlackAlerts.Target != "" {
+		add(cfg.SlackAlerts, alerts.NewSlackWebhook(cfg.SlackAlerts.Target))
+	}
+	if cfg.DiscordAlerts.Target != "" {
+		add(cfg.DiscordAlerts, alerts.NewDiscordWebhook(cfg.DiscordAlerts.Target))
+	}
+	if cfg.WebhookAlerts.Target != "" {
+		add(cfg.WebhookAlerts, alerts.NewJSONWebhook(cfg.WebhookAlerts.Target))
+	}
+	if cfg.EmailAlerts.Target != "" {
+		var to []string
+		for _, addr := range strings.Split(cfg.EmailAlerts.Target, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				to = append(to, addr)
+			}
+		}
+		add(cfg.EmailAlerts, alerts.NewEmailSink(alerts.EmailConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+			To:       to,
+		}))
+	}
+}
+
 func buildExecModeString(cfg config.Config, kalshiClient *kalshi.KalshiClient) string {
 	if cfg.AutoExecute && kalshiClient != nil {
+		mode := "AUTO EXECUTE"
+		if cfg.ExecutionMode == config.ExecMaker {
+			mode += " (MAKER)"
+		}
+		if cfg.ExitTakeProfit > 0 || cfg.ExitStopEdge > 0 || cfg.ExitCloseBefore > 0 {
+			mode += " (EXITS)"
+		}
+		if cfg.AutoHedge {
+			mode += " (AUTO HEDGE)"
+		}
+		if cfg.CrossArb {
+			mode += " (CROSS ARB)"
+		}
 		if cfg.KalshiDemo {
-			return "AUTO EXECUTE (DEMO)"
+			mode += " (DEMO)"
 		}
-		return "AUTO EXECUTE"
+		return mode
 	}
 	if kalshiClient != nil {
 		return "dry run (balance/liquidity checks enabled)"
@@ -143,6 +261,8 @@ func startHealthServer(port string) {
 		w.Write([]byte("OK"))
 	})
 
+	mux.Handle("/metrics", metrics.Default.Handler())
+
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("Sports Betting Bot - Running"))
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/positions"
+)
+
+// Shows the loss circuit breaker's persisted state. With -reset it clears
+// the breaker so the bot resumes execution and re-captures its start-of-day
+// balance on the next check.
+//
+//	go run ./cmd/breaker -db /data/positions.db
+//	go run ./cmd/breaker -db /data/positions.db -reset
+func main() {
+	cfg := config.Load()
+
+	flag.StringVar(&cfg.DBPath, "db", cfg.DBPath, "positions database path")
+	reset := flag.Bool("reset", false, "clear a tripped breaker and resume execution")
+	flag.Parse()
+
+	db, err := positions.NewDB(cfg.DBPath)
+	if err != nil {
+		log.Fatalf("Opening DB: %v", err)
+	}
+	defer db.Close()
+
+	state, err := db.GetBreakerState()
+	if err != nil {
+		log.Fatalf("Reading breaker state: %v", err)
+	}
+
+	if state.Day == "" {
+		fmt.Println("Circuit breaker has no recorded state")
+	} else {
+		fmt.Printf("Day %s: start balance $%.2f, start equity $%.2f, peak equity $%.2f\n",
+			state.Day, state.StartBalance, state.StartEquity, state.PeakEquity)
+		if state.Tripped {
+			fmt.Printf("TRIPPED at %s: %s\n", state.TrippedAt.Format("2006-01-02 15:04:05 MST"), state.Reason)
+		} else {
+			fmt.Println("Not tripped")
+		}
+	}
+
+	if !*reset {
+		return
+	}
+	if err := db.ResetBreaker(); err != nil {
+		log.Fatalf("Reset failed: %v", err)
+	}
+	fmt.Println("Circuit breaker reset; execution resumes on the next scan")
+}
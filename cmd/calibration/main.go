@@ -0,0 +1,120 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"sports-betting-bot/internal/calibration"
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/positions"
+)
+
+// Checks the consensus probabilities the bot recorded at its last pre-game
+// scan against the settled Kalshi results: reliability curves, Brier score
+// and log loss per market type, prop type and book-count bucket.
+//
+//	go run ./cmd/calibration -db /data/positions.db
+//	go run ./cmd/calibration -db /data/positions.db -by books -bins 5
+//	go run ./cmd/calibration -db /data/positions.db -format csv -out calibration.csv
+func main() {
+	cfg := config.Load()
+
+	flag.StringVar(&cfg.DBPath, "db", cfg.DBPath, "positions database path")
+	by := flag.String("by", "", "breakdown: "+strings.Join(calibration.Dimensions, ", ")+" (default all)")
+	bins := flag.Int("bins", 10, "reliability curve bins")
+	format := flag.String("format", "text", "output format: text, csv or json")
+	out := flag.String("out", "", "output file (default stdout)")
+	flag.Parse()
+
+	if *format != "text" && *format != "csv" && *format != "json" {
+		log.Fatalf("Unknown format %q (want text, csv or json)", *format)
+	}
+
+	db, err := positions.NewDB(cfg.DBPath)
+	if err != nil {
+		log.Fatalf("Opening DB: %v", err)
+	}
+	defer db.Close()
+
+	preds, err := db.GetResolvedPredictions()
+	if err != nil {
+		log.Fatalf("Reading predictions: %v", err)
+	}
+	if len(preds) == 0 {
+		fmt.Println("No settled predictions recorded")
+		return
+	}
+
+	dims := calibration.Dimensions
+	if *by != "" {
+		dims = []string{*by}
+	}
+	var reports []calibration.Report
+	for _, dim := range dims {
+		r, err := calibration.Build(preds, dim, *bins)
+		if err != nil {
+			log.Fatal(err)
+		}
+		reports = append(reports, r)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("Creating output: %v", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "text":
+		for i, r := range reports {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			r.WriteText(w)
+		}
+	case "csv":
+		err = writeCSV(w, reports)
+	case "json":
+		err = writeJSON(w, reports)
+	}
+	if err != nil {
+		log.Fatalf("Writing report: %v", err)
+	}
+}
+
+// writeCSV writes every breakdown under a single header.
+func writeCSV(w io.Writer, reports []calibration.Report) error {
+	for i, r := range reports {
+		var sb strings.Builder
+		if err := r.WriteCSV(&sb); err != nil {
+			return err
+		}
+		body := sb.String()
+		if i > 0 {
+			_, body, _ = strings.Cut(body, "\n")
+		}
+		if _, err := io.WriteString(w, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeJSON writes one report as an object, several as an array.
+func writeJSON(w io.Writer, reports []calibration.Report) error {
+	if len(reports) == 1 {
+		return reports[0].WriteJSON(w)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(reports)
+}
@@ -28,7 +28,8 @@ func main() {
 		return
 	}
 
-	fmt.Println("=== MARKETS WITH LIQUIDITY ===\n")
+	fmt.Println("=== MARKETS WITH LIQUIDITY ===")
+	fmt.Println()
 
 	// Check points markets
 	withLiquidity := 0
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/positions"
+)
+
+// Summarizes closing-line value: how the entries on alerted and traded
+// tickers compare with the consensus and Kalshi price at the last scan
+// before tip-off. Without -by it prints every breakdown.
+//
+//	go run ./cmd/clv -db /data/positions.db
+//	go run ./cmd/clv -db /data/positions.db -by ev
+func main() {
+	cfg := config.Load()
+
+	flag.StringVar(&cfg.DBPath, "db", cfg.DBPath, "positions database path")
+	by := flag.String("by", "", "breakdown: "+strings.Join(positions.CLVDimensions, ", ")+" (default all)")
+	flag.Parse()
+
+	db, err := positions.NewDB(cfg.DBPath)
+	if err != nil {
+		log.Fatalf("Opening DB: %v", err)
+	}
+	defer db.Close()
+
+	lines, err := db.GetClosingLines()
+	if err != nil {
+		log.Fatalf("Reading closing lines: %v", err)
+	}
+	if len(lines) == 0 {
+		fmt.Println("No closing lines recorded")
+		return
+	}
+
+	dims := positions.CLVDimensions
+	if *by != "" {
+		dims = []string{*by}
+	}
+	for i, dim := range dims {
+		summaries, err := positions.SummarizeCLV(lines, dim)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		writeTable(dim, summaries)
+	}
+}
+
+// writeTable prints one breakdown. CLV columns are cents per contract:
+// CONS against the closing consensus, PRICE against the closing Kalshi ask
+// and FILL the consensus CLV at the average fill price.
+func writeTable(dim string, summaries []positions.CLVSummary) {
+	w := os.Stdout
+	fmt.Fprintf(w, "%-16s %7s %7s %7s %9s %9s %6s %9s\n",
+		"BY "+strings.ToUpper(dim), "ENTRIES", "CLOSED", "BEAT%", "CONS(¢)", "PRICE(¢)", "FILLS", "FILL(¢)")
+	fmt.Fprintln(w, strings.Repeat("-", 78))
+	for _, s := range summaries {
+		fmt.Fprintf(w, "%-16s %7d %7d %6.1f%% %9.2f %9.2f %6d %9.2f\n",
+			s.Group, s.Entries, s.Closed, s.BeatRate()*100,
+			s.AvgConsensusCLV(), s.AvgPriceCLV(), s.Fills, s.AvgFillCLV())
+	}
+}
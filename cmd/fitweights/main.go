@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/positions"
+	"sports-betting-bot/internal/snapshot"
+	"sports-betting-bot/internal/vendorfit"
+)
+
+// Fits per-vendor consensus weights, per market type, from the vendor lines
+// in recorded snapshots and the settled predictions in the positions DB, and
+// writes them as a weights file for VENDOR_WEIGHTS_FILE.
+//
+//	go run ./cmd/fitweights -data /data/snapshots -db /data/positions.db
+//	go run ./cmd/fitweights -data /data/snapshots -min-samples 100 -out /data/vendor_weights.json
+func main() {
+	cfg := config.Load()
+
+	data := flag.String("data", cfg.SnapshotDir, "snapshot file or directory (required)")
+	flag.StringVar(&cfg.DBPath, "db", cfg.DBPath, "positions database path")
+	out := flag.String("out", "vendor_weights.json", "weights file to write")
+	minSamples := flag.Int("min-samples", vendorfit.DefaultMinSamples, "markets a vendor must quote to be fitted")
+	l2 := flag.Float64("l2", vendorfit.DefaultL2, "penalty on each log-weight's distance from its default")
+	flag.Parse()
+
+	if *data == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := positions.NewDB(cfg.DBPath)
+	if err != nil {
+		log.Fatalf("Opening DB: %v", err)
+	}
+	defer db.Close()
+
+	preds, err := db.GetResolvedPredictions()
+	if err != nil {
+		log.Fatalf("Reading predictions: %v", err)
+	}
+	records, err := snapshot.ReadPath(*data)
+	if err != nil {
+		log.Fatalf("Reading snapshots: %v", err)
+	}
+
+	samples, err := vendorfit.Collect(records, preds)
+	if err != nil {
+		log.Fatalf("Collecting samples: %v", err)
+	}
+	if len(samples) == 0 {
+		fmt.Println("No settled predictions with recorded vendor lines")
+		return
+	}
+
+	fits := vendorfit.Fit(samples, vendorfit.Config{MinSamples: *minSamples, L2: *l2})
+	vendorfit.WriteText(os.Stdout, fits)
+
+	body, err := json.MarshalIndent(vendorfit.Weights(fits), "", "  ")
+	if err != nil {
+		log.Fatalf("Encoding weights: %v", err)
+	}
+	if err := os.WriteFile(*out, append(body, '\n'), 0o644); err != nil {
+		log.Fatalf("Writing weights: %v", err)
+	}
+	fmt.Printf("\nWrote %s\n", *out)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/engine"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/positions"
+)
+
+// Compares the positions DB with Kalshi and prints every mismatch.
+// Without -fix nothing is written.
+//
+//	go run ./cmd/reconcile -db /data/positions.db
+//	go run ./cmd/reconcile -db /data/positions.db -fix
+func main() {
+	cfg := config.Load()
+
+	flag.StringVar(&cfg.DBPath, "db", cfg.DBPath, "positions database path")
+	fix := flag.Bool("fix", false, "import missing positions and correct contract counts")
+	flag.Parse()
+
+	var client *kalshi.KalshiClient
+	var err error
+	if cfg.KalshiPrivateKey != "" {
+		client, err = kalshi.NewKalshiClientFromKey(cfg.KalshiAPIKeyID, cfg.KalshiPrivateKey, cfg.KalshiDemo)
+	} else {
+		client, err = kalshi.NewKalshiClient(cfg.KalshiAPIKeyID, cfg.KalshiAPIKeyPath, cfg.KalshiDemo)
+	}
+	if err != nil {
+		log.Fatalf("Kalshi client error: %v", err)
+	}
+
+	db, err := positions.NewDB(cfg.DBPath)
+	if err != nil {
+		log.Fatalf("Opening DB: %v", err)
+	}
+	defer db.Close()
+
+	diffs, err := engine.ReconcilePositions(client, db, *fix)
+	if err != nil {
+		log.Fatalf("Reconcile failed: %v", err)
+	}
+
+	if len(diffs) == 0 {
+		fmt.Println("DB and Kalshi positions match")
+		return
+	}
+
+	for _, d := range diffs {
+		status := ""
+		if *fix && d.Kind != positions.DiscrepancyOrphan {
+			status = " (fixed)"
+		}
+		fmt.Printf("%s%s\n", d, status)
+	}
+	if !*fix {
+		fmt.Printf("\n%d discrepancies; rerun with -fix to import missing positions and correct counts\n", len(diffs))
+	}
+}
@@ -7,4 +7,4 @@ require (
 	github.com/mattn/go-sqlite3 v1.14.33
 )
 
-require github.com/google/uuid v1.6.0 // indirect
+require github.com/google/uuid v1.6.0
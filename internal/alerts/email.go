@@ -0,0 +1,103 @@
+package alerts
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// smtpTimeout bounds the whole SMTP conversation for one alert.
+const smtpTimeout = 30 * time.Second
+
+// EmailConfig configures an SMTP server and the alert recipients.
+type EmailConfig struct {
+	Host     string
+	Port     int
+	Username string // Empty skips authentication
+	Password string
+	From     string
+	To       []string
+}
+
+// EmailSink sends each alert as a plain-text email.
+type EmailSink struct {
+	cfg EmailConfig
+}
+
+// NewEmailSink creates an SMTP email sink.
+func NewEmailSink(cfg EmailConfig) *EmailSink {
+	return &EmailSink{cfg: cfg}
+}
+
+// Name identifies the sink in logs.
+func (e *EmailSink) Name() string {
+	return "email"
+}
+
+// Send delivers the alert, upgrading to TLS when the server offers
+// STARTTLS. Authentication is only attempted when a username is set.
+func (e *EmailSink) Send(a Alert) error {
+	addr := net.JoinHostPort(e.cfg.Host, fmt.Sprintf("%d", e.cfg.Port))
+	conn, err := net.DialTimeout("tcp", addr, smtpTimeout)
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(smtpTimeout))
+
+	c, err := smtp.NewClient(conn, e.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("starting SMTP session: %w", err)
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: e.cfg.Host}); err != nil {
+			return fmt.Errorf("STARTTLS: %w", err)
+		}
+	}
+	if e.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)); err != nil {
+			return fmt.Errorf("authenticating: %w", err)
+		}
+	}
+
+	if err := c.Mail(e.cfg.From); err != nil {
+		return fmt.Errorf("MAIL FROM: %w", err)
+	}
+	for _, to := range e.cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("RCPT TO %s: %w", to, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("DATA: %w", err)
+	}
+	if _, err := w.Write(e.message(a)); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("sending message: %w", err)
+	}
+	return c.Quit()
+}
+
+// message formats the alert as an RFC 5322 message.
+func (e *EmailSink) message(a Alert) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: [%s] %s\r\n", a.Type, a.Title)
+	fmt.Fprintf(&b, "Date: %s\r\n", a.Time.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(a.Message)
+	b.WriteString("\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,116 @@
+package alerts
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP accepts one message on a local port and returns the envelope
+// recipients and message data on the returned channel.
+func fakeSMTP(t *testing.T) (host string, port int, got <-chan [2]string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	out := make(chan [2]string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+		var rcpts []string
+		reply("220 localhost ESMTP test")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case strings.HasPrefix(cmd, "MAIL FROM"):
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO"):
+				rcpts = append(rcpts, strings.TrimSpace(line[len("RCPT TO:"):]))
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				out <- [2]string{strings.Join(rcpts, ","), data.String()}
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("502 Not implemented")
+			}
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, out
+}
+
+func TestEmailSink(t *testing.T) {
+	host, port, got := fakeSMTP(t)
+	sink := NewEmailSink(EmailConfig{
+		Host: host,
+		Port: port,
+		From: "bot@example.com",
+		To:   []string{"a@example.com", "b@example.com"},
+	})
+
+	alert := Alert{Type: AlertHedge, Title: "HEDGE: moneyline home LOCK (GSW@PHX)",
+		Message: "🔒 HEDGE: moneyline home LOCK (GSW@PHX) entry=$0.40×10", Time: time.Now()}
+	if err := sink.Send(alert); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-got:
+		if msg[0] != "<a@example.com>,<b@example.com>" {
+			t.Errorf("recipients = %s, want both addresses", msg[0])
+		}
+		if !strings.Contains(msg[1], "Subject: [hedge] "+alert.Title+"\r\n") {
+			t.Errorf("message missing subject:\n%s", msg[1])
+		}
+		if !strings.Contains(msg[1], "\r\n\r\n"+alert.Message) {
+			t.Errorf("message missing body:\n%s", msg[1])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SMTP stand-in received no message")
+	}
+}
+
+func TestEmailSinkConnectionRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	sink := NewEmailSink(EmailConfig{Host: "127.0.0.1", Port: port, From: "bot@example.com", To: []string{"a@example.com"}})
+	if err := sink.Send(Alert{Title: "x"}); err == nil || !strings.Contains(err.Error(), strconv.Itoa(port)) {
+		t.Errorf("Send = %v, want a connection error naming port %d", err, port)
+	}
+}
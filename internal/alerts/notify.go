@@ -11,11 +11,14 @@ import (
 	"sports-betting-bot/internal/positions"
 )
 
-// Notifier handles alert notifications
+// Notifier handles alert notifications. Every alert is logged; alerts
+// that pass the cooldown are also fanned out to any registered sinks.
 type Notifier struct {
 	mu         sync.Mutex
 	lastAlerts map[string]time.Time // Dedupe alerts
 	cooldown   time.Duration        // Minimum time between same alerts
+	sinks      []filteredSink
+	pending    sync.WaitGroup // In-flight sink deliveries
 }
 
 // NewNotifier creates a new notifier
@@ -40,11 +43,12 @@ func (n *Notifier) checkCooldown(key string) bool {
 	return false
 }
 
-// AlertOpportunity sends an alert for a +EV opportunity
-func (n *Notifier) AlertOpportunity(opp analysis.Opportunity) {
+// AlertOpportunity sends an alert for a +EV opportunity. Returns false
+// when the alert was suppressed by the cooldown.
+func (n *Notifier) AlertOpportunity(opp analysis.Opportunity) bool {
 	key := fmt.Sprintf("%d-%s-%s", opp.GameID, opp.MarketType, opp.Side)
 	if n.checkCooldown(key) {
-		return
+		return false
 	}
 
 	var sideDesc string
@@ -57,33 +61,44 @@ func (n *Notifier) AlertOpportunity(opp analysis.Opportunity) {
 		sideDesc = strings.ToUpper(opp.Side)
 	}
 
-	log.Printf("+EV GAME: %s %s (%s@%s) | prob=%.1f%%/%dbk kalshi=$%.2f ev=%.2f%% kelly=%.1f%%",
-		sideDesc, opp.MarketType, opp.AwayTeam, opp.HomeTeam,
+	title := fmt.Sprintf("+EV GAME: %s %s (%s@%s)", sideDesc, opp.MarketType, opp.AwayTeam, opp.HomeTeam)
+	msg := fmt.Sprintf("%s | prob=%.1f%%/%dbk kalshi=$%.2f ev=%.2f%% kelly=%.1f%%",
+		title,
 		opp.TrueProb*100, opp.BookCount,
 		opp.KalshiPrice, opp.AdjustedEV*100, opp.KellyStake*100,
 	)
+	log.Print(msg)
+	n.dispatch(Alert{Type: AlertGame, Title: title, Message: msg, EV: opp.AdjustedEV, Data: opp})
+	return true
 }
 
-// AlertPlayerProp sends an alert for a +EV player prop opportunity
-func (n *Notifier) AlertPlayerProp(opp analysis.PlayerPropOpportunity) {
+// AlertPlayerProp sends an alert for a +EV player prop opportunity.
+// Returns false when the alert was suppressed by the cooldown.
+func (n *Notifier) AlertPlayerProp(opp analysis.PlayerPropOpportunity) bool {
 	key := fmt.Sprintf("prop-%d-%s-%s-%.1f-%s", opp.PlayerID, opp.PropType, opp.PlayerName, opp.Line, opp.Side)
 	if n.checkCooldown(key) {
-		return
+		return false
 	}
 
-	log.Printf("+EV PROP: %s %s %.0f %s (%s@%s) | prob=%.1f%%/%dbk kalshi=$%.2f ev=%.2f%% kelly=%.1f%%",
+	title := fmt.Sprintf("+EV PROP: %s %s %.0f %s (%s@%s)",
 		opp.PlayerName, strings.ToUpper(opp.Side), opp.Line, opp.PropType,
-		opp.AwayTeam, opp.HomeTeam,
+		opp.AwayTeam, opp.HomeTeam)
+	msg := fmt.Sprintf("%s | prob=%.1f%%/%dbk kalshi=$%.2f ev=%.2f%% kelly=%.1f%%",
+		title,
 		opp.TrueProb*100, opp.BookCount,
 		opp.KalshiPrice, opp.AdjustedEV*100, opp.KellyStake*100,
 	)
+	log.Print(msg)
+	n.dispatch(Alert{Type: AlertProp, Title: title, Message: msg, EV: opp.AdjustedEV, Data: opp})
+	return true
 }
 
-// AlertHedge sends an alert for a hedge opportunity
-func (n *Notifier) AlertHedge(hedge positions.HedgeOpportunity) {
+// AlertHedge sends an alert for a hedge opportunity. Returns false when
+// the alert was suppressed by the cooldown.
+func (n *Notifier) AlertHedge(hedge positions.HedgeOpportunity) bool {
 	key := fmt.Sprintf("hedge-%d-%s-%s", hedge.Position.ID, hedge.Position.MarketType, hedge.Position.Side)
 	if n.checkCooldown(key) {
-		return
+		return false
 	}
 
 	emoji := "🔒"
@@ -91,16 +106,45 @@ func (n *Notifier) AlertHedge(hedge positions.HedgeOpportunity) {
 		emoji = "💰"
 	}
 
-	log.Printf("%s HEDGE: %s %s %s (%s@%s) entry=$%.2f×%d cur=$%.2f action=%s | %s",
-		emoji,
+	title := fmt.Sprintf("HEDGE: %s %s %s (%s@%s)",
 		hedge.Position.MarketType, hedge.Position.Side,
 		strings.ToUpper(hedge.Action),
-		hedge.Position.AwayTeam, hedge.Position.HomeTeam,
+		hedge.Position.AwayTeam, hedge.Position.HomeTeam)
+	msg := fmt.Sprintf("%s %s entry=$%.2f×%d cur=$%.2f action=%s | %s",
+		emoji, title,
 		hedge.Position.EntryPrice, hedge.Position.Contracts,
 		hedge.CurrentPrice,
 		strings.ToUpper(hedge.Action),
 		hedge.Description,
 	)
+	log.Print(msg)
+	n.dispatch(Alert{Type: AlertHedge, Title: title, Message: msg, Data: hedge})
+	return true
+}
+
+// AlertArb sends an alert for an executed arb once its legs are squared,
+// with the P&L locked in by its pairs and realized unwinding leftovers
+func (n *Notifier) AlertArb(fill positions.ArbFill) {
+	title := fmt.Sprintf("ARB FILLED: %s", fill.Event)
+	msg := fmt.Sprintf("⚖️ %s pairs=%d cost=$%.2f locked=$%+.2f sold=%d unwind=$%+.2f open=%d | pnl=$%+.2f",
+		title, fill.Pairs, fill.Cost, fill.LockedPnL(), fill.Sold, fill.UnwindPnL, fill.Open, fill.PnL())
+	log.Print(msg)
+	n.dispatch(Alert{Type: AlertArb, Title: title, Message: msg, Data: fill})
+}
+
+// LogSettlement logs a position resolved by its market's result
+func (n *Notifier) LogSettlement(s positions.Settlement) {
+	log.Printf("SETTLED: %s result=%s payout=$%.2f fees=$%.2f pnl=$%+.2f",
+		s.Ticker, strings.ToUpper(s.Result), s.Payout, s.Fees, s.RealizedPnL)
+}
+
+// AlertCircuitBreaker reports that the loss circuit breaker tripped and
+// execution is halted until it is reset
+func (n *Notifier) AlertCircuitBreaker(reason string) {
+	msg := fmt.Sprintf("CIRCUIT BREAKER TRIPPED: %s | execution halted, alerts continue | reset with: go run ./cmd/breaker -reset",
+		reason)
+	log.Print(msg)
+	n.dispatch(Alert{Type: AlertError, Title: "CIRCUIT BREAKER TRIPPED", Message: msg})
 }
 
 // LogScanWithProps logs a scan completion with player props
@@ -108,9 +152,15 @@ func (n *Notifier) LogScanWithProps(gamesScanned, gameOpps, propOpps int) {
 	log.Printf("Scan complete: %d games, %d game opps, %d prop opps", gamesScanned, gameOpps, propOpps)
 }
 
-// LogError logs an error
+// LogError logs an error. Sinks receive at most one error per context
+// per cooldown, since a failing upstream repeats on every poll.
 func (n *Notifier) LogError(context string, err error) {
-	log.Printf("ERROR [%s]: %v", context, err)
+	msg := fmt.Sprintf("ERROR [%s]: %v", context, err)
+	log.Print(msg)
+	if n.checkCooldown("error-" + context) {
+		return
+	}
+	n.dispatch(Alert{Type: AlertError, Title: fmt.Sprintf("ERROR [%s]", context), Message: msg})
 }
 
 // LogStartup logs bot startup
@@ -70,10 +70,14 @@ func TestAlertOpportunityCooldown(t *testing.T) {
 	}
 
 	// Should not panic and should log the first time
-	n.AlertOpportunity(opp)
+	if !n.AlertOpportunity(opp) {
+		t.Error("first alert reported as suppressed")
+	}
 
 	// Second call should be suppressed (no log)
-	n.AlertOpportunity(opp)
+	if n.AlertOpportunity(opp) {
+		t.Error("repeat alert inside the cooldown reported as sent")
+	}
 }
 
 func TestCleanupOldAlerts(t *testing.T) {
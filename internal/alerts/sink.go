@@ -0,0 +1,114 @@
+package alerts
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// AlertType classifies alerts for per-sink filtering.
+type AlertType string
+
+// Alert types.
+const (
+	AlertGame  AlertType = "game"
+	AlertProp  AlertType = "prop"
+	AlertHedge AlertType = "hedge"
+	AlertArb   AlertType = "arb"
+	AlertError AlertType = "error"
+)
+
+// Alert is one notification fanned out to the configured sinks.
+type Alert struct {
+	Type    AlertType `json:"type"`
+	Title   string    `json:"title"`   // Short summary, used as the email subject
+	Message string    `json:"message"` // The full line written to the log
+	EV      float64   `json:"adjusted_ev,omitempty"`
+	Time    time.Time `json:"time"`
+	Data    any       `json:"data,omitempty"` // The opportunity or hedge behind the alert
+}
+
+// Sink delivers alerts to an external destination.
+type Sink interface {
+	Name() string
+	Send(a Alert) error
+}
+
+// Filter selects the alerts a sink receives. The zero Filter passes all.
+type Filter struct {
+	MinEV float64     // Minimum AdjustedEV for game and prop alerts
+	Types []AlertType // Types to send; empty sends every type
+}
+
+// Allows reports whether a passes the filter.
+func (f Filter) Allows(a Alert) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, a.Type) {
+		return false
+	}
+	if (a.Type == AlertGame || a.Type == AlertProp) && a.EV < f.MinEV {
+		return false
+	}
+	return true
+}
+
+// ParseAlertTypes parses a comma-separated list such as "game,prop,error".
+// An empty string returns nil, which allows every type.
+func ParseAlertTypes(s string) ([]AlertType, error) {
+	var types []AlertType
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(strings.ToLower(part))
+		switch t := AlertType(part); t {
+		case "":
+		case AlertGame, AlertProp, AlertHedge, AlertArb, AlertError:
+			types = append(types, t)
+		default:
+			return nil, fmt.Errorf("unknown alert type %q (want game, prop, hedge, arb or error)", part)
+		}
+	}
+	return types, nil
+}
+
+type filteredSink struct {
+	sink   Sink
+	filter Filter
+}
+
+// AddSink registers a sink. Alerts already deduped by the notifier's
+// cooldown are sent to every sink whose filter allows them.
+func (n *Notifier) AddSink(s Sink, f Filter) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sinks = append(n.sinks, filteredSink{sink: s, filter: f})
+}
+
+// dispatch sends a to the matching sinks in the background so a slow
+// webhook or mail server never delays a scan. Delivery failures are logged.
+func (n *Notifier) dispatch(a Alert) {
+	if a.Time.IsZero() {
+		a.Time = time.Now()
+	}
+
+	n.mu.Lock()
+	sinks := n.sinks
+	n.mu.Unlock()
+
+	for _, s := range sinks {
+		if !s.filter.Allows(a) {
+			continue
+		}
+		n.pending.Add(1)
+		go func(s Sink) {
+			defer n.pending.Done()
+			if err := s.Send(a); err != nil {
+				log.Printf("ERROR [alert sink %s]: %v", s.Name(), err)
+			}
+		}(s.sink)
+	}
+}
+
+// Flush waits for in-flight sink deliveries to finish.
+func (n *Notifier) Flush() {
+	n.pending.Wait()
+}
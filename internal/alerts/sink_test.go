@@ -0,0 +1,157 @@
+package alerts
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/analysis"
+	"sports-betting-bot/internal/odds"
+)
+
+// recordingSink keeps every alert it is sent.
+type recordingSink struct {
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+func (r *recordingSink) Name() string { return "recording" }
+
+func (r *recordingSink) Send(a Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, a)
+	return nil
+}
+
+func (r *recordingSink) types() []AlertType {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var types []AlertType
+	for _, a := range r.alerts {
+		types = append(types, a.Type)
+	}
+	return types
+}
+
+func TestFilterAllows(t *testing.T) {
+	game := Alert{Type: AlertGame, EV: 0.04}
+	hedge := Alert{Type: AlertHedge}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		alert  Alert
+		want   bool
+	}{
+		{"zero filter", Filter{}, game, true},
+		{"EV above min", Filter{MinEV: 0.03}, game, true},
+		{"EV below min", Filter{MinEV: 0.05}, game, false},
+		{"min EV ignores hedges", Filter{MinEV: 0.05}, hedge, true},
+		{"type listed", Filter{Types: []AlertType{AlertGame, AlertProp}}, game, true},
+		{"type not listed", Filter{Types: []AlertType{AlertGame, AlertProp}}, hedge, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Allows(tt.alert); got != tt.want {
+				t.Errorf("Allows = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAlertTypes(t *testing.T) {
+	got, err := ParseAlertTypes(" game, PROP ,error")
+	if err != nil || !slices.Equal(got, []AlertType{AlertGame, AlertProp, AlertError}) {
+		t.Errorf("ParseAlertTypes = %v, %v, want [game prop error]", got, err)
+	}
+	if got, err := ParseAlertTypes(""); err != nil || got != nil {
+		t.Errorf("ParseAlertTypes(\"\") = %v, %v, want nil", got, err)
+	}
+	if _, err := ParseAlertTypes("game,trade"); err == nil {
+		t.Error("ParseAlertTypes accepted unknown type")
+	}
+}
+
+func TestNotifierFansOutAfterCooldown(t *testing.T) {
+	n := NewNotifier(time.Minute)
+	all := &recordingSink{}
+	highEV := &recordingSink{}
+	errorsOnly := &recordingSink{}
+	n.AddSink(all, Filter{})
+	n.AddSink(highEV, Filter{MinEV: 0.05})
+	n.AddSink(errorsOnly, Filter{Types: []AlertType{AlertError}})
+
+	opp := analysis.Opportunity{GameID: 1, MarketType: odds.MarketMoneyline, Side: "home",
+		HomeTeam: "PHX", AwayTeam: "GSW", AdjustedEV: 0.04}
+	n.AlertOpportunity(opp)
+	n.AlertOpportunity(opp) // deduped before any sink sees it
+	n.AlertPlayerProp(analysis.PlayerPropOpportunity{PlayerID: 7, PlayerName: "Stephen Curry",
+		PropType: "points", Line: 29.5, Side: "over", AdjustedEV: 0.08})
+	n.LogError("fetching odds", io.ErrUnexpectedEOF)
+	n.LogError("fetching odds", io.ErrUnexpectedEOF) // sinks get one per cooldown
+	n.Flush()
+
+	if got := all.types(); len(got) != 3 {
+		t.Errorf("unfiltered sink got %v, want game, prop and error once each", got)
+	}
+	if got := highEV.types(); !slices.Equal(got, []AlertType{AlertProp, AlertError}) {
+		t.Errorf("min-EV sink got %v, want [prop error]", got)
+	}
+	if got := errorsOnly.types(); !slices.Equal(got, []AlertType{AlertError}) {
+		t.Errorf("error sink got %v, want [error]", got)
+	}
+}
+
+func TestWebhookSinks(t *testing.T) {
+	var mu sync.Mutex
+	bodies := make(map[string]map[string]any)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("got %s with Content-Type %q, want JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("decoding %s body: %v", r.URL.Path, err)
+		}
+		mu.Lock()
+		bodies[r.URL.Path] = body
+		mu.Unlock()
+		if r.URL.Path == "/broken" {
+			http.Error(w, "invalid token", http.StatusForbidden)
+		}
+	}))
+	defer srv.Close()
+
+	alert := Alert{Type: AlertGame, Title: "+EV GAME", Message: "+EV GAME: PHX moneyline", EV: 0.04,
+		Time: time.Date(2026, 2, 5, 18, 0, 0, 0, time.UTC)}
+	for _, s := range []Sink{
+		NewSlackWebhook(srv.URL + "/slack"),
+		NewDiscordWebhook(srv.URL + "/discord"),
+		NewJSONWebhook(srv.URL + "/json"),
+	} {
+		if err := s.Send(alert); err != nil {
+			t.Errorf("%s: %v", s.Name(), err)
+		}
+	}
+
+	if got := bodies["/slack"]["text"]; got != alert.Message {
+		t.Errorf("slack text = %v, want %q", got, alert.Message)
+	}
+	if got := bodies["/discord"]["content"]; got != alert.Message {
+		t.Errorf("discord content = %v, want %q", got, alert.Message)
+	}
+	if got := bodies["/json"]; got["type"] != "game" || got["adjusted_ev"] != 0.04 || got["title"] != "+EV GAME" {
+		t.Errorf("generic body = %v, want type, title and adjusted_ev", got)
+	}
+
+	if err := NewJSONWebhook(srv.URL + "/broken").Send(alert); err == nil {
+		t.Error("Send succeeded on a 403")
+	}
+}
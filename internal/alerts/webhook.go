@@ -0,0 +1,76 @@
+package alerts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// webhookTimeout bounds each webhook POST.
+const webhookTimeout = 10 * time.Second
+
+// WebhookSink POSTs alerts as JSON. The payload shape depends on the
+// destination: Slack and Discord incoming webhooks take a single text
+// field, while the generic format sends the whole Alert.
+type WebhookSink struct {
+	name    string
+	url     string
+	payload func(Alert) any
+	client  *http.Client
+}
+
+// NewSlackWebhook creates a sink for a Slack incoming webhook URL.
+func NewSlackWebhook(url string) *WebhookSink {
+	return newWebhookSink("slack", url, func(a Alert) any {
+		return map[string]string{"text": a.Message}
+	})
+}
+
+// NewDiscordWebhook creates a sink for a Discord webhook URL.
+func NewDiscordWebhook(url string) *WebhookSink {
+	return newWebhookSink("discord", url, func(a Alert) any {
+		return map[string]string{"content": a.Message}
+	})
+}
+
+// NewJSONWebhook creates a sink that POSTs each Alert as JSON to url.
+func NewJSONWebhook(url string) *WebhookSink {
+	return newWebhookSink("webhook", url, func(a Alert) any { return a })
+}
+
+func newWebhookSink(name, url string, payload func(Alert) any) *WebhookSink {
+	return &WebhookSink{
+		name:    name,
+		url:     url,
+		payload: payload,
+		client:  &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// Name identifies the sink in logs.
+func (w *WebhookSink) Name() string {
+	return w.name
+}
+
+// Send POSTs the alert and fails on any non-2xx response.
+func (w *WebhookSink) Send(a Alert) error {
+	body, err := json.Marshal(w.payload(a))
+	if err != nil {
+		return fmt.Errorf("encoding payload: %w", err)
+	}
+
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("posting alert: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+	return nil
+}
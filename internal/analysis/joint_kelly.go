@@ -0,0 +1,349 @@
+package analysis
+
+import (
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"sports-betting-bot/internal/kalshi"
+)
+
+// Latent factors that same-game bets settle on. Player factors are
+// "player:<name>".
+const (
+	FactorMargin = "margin" // Home minus away score: moneyline and spread
+	FactorTotal  = "total"  // Combined score: game totals
+)
+
+// PlayerFactor returns the latent factor for a player's stat line.
+func PlayerFactor(name string) string {
+	return "player:" + name
+}
+
+// Correlations between a game's latent factors, used to build the joint
+// outcome distribution for simultaneous Kelly sizing.
+type Correlations struct {
+	MarginTotal  float64 // Home margin vs game total
+	PlayerTotal  float64 // Any player's stat vs game total
+	PlayerMargin float64 // Player stat vs their own team's margin
+	PlayerPlayer float64 // Two players' stats
+}
+
+// DefaultCorrelations returns rough NBA values: margin and total are close
+// to independent, while a player's counting stats rise with pace (the
+// total) and, less so, when their team is winning.
+func DefaultCorrelations() Correlations {
+	return Correlations{
+		MarginTotal:  0.0,
+		PlayerTotal:  0.35,
+		PlayerMargin: 0.15,
+		PlayerPlayer: 0.10,
+	}
+}
+
+// Outcome places a binary bet on one of a game's latent factors. A bet with
+// Dir +1 wins on a high draw (home side, over); Dir -1 on a low draw. Bets
+// on the same factor win in nested scenarios, so home moneyline and home
+// spread are strongly correlated and home vs away are mutually exclusive.
+type Outcome struct {
+	Factor string
+	Home   int     // Player factors: +1 home player, -1 away, 0 unknown
+	Dir    int     // +1 home/over, -1 away/under
+	Prob   float64 // Marginal win probability
+}
+
+// JointBet is a candidate bet bought at Price (0-1) plus the taker fee.
+type JointBet struct {
+	Outcome
+	Price float64
+}
+
+// Holding is an open position that pays Payout dollars if it wins. Its
+// cost is already spent, so only the payout affects sizing.
+type Holding struct {
+	Outcome
+	Payout float64
+}
+
+const (
+	jointScenarios = 4000
+	jointSeed      = 0x5eed
+	// maxJointStake bounds total stake so wealth stays positive in the
+	// scenario where every bet loses.
+	maxJointStake = 0.99
+)
+
+// SimultaneousKelly sizes bets on one game together with the open positions
+// on it, maximizing expected log wealth over a Gaussian-copula model of the
+// game's outcomes. Returns the stake for each bet as a fraction of bankroll,
+// scaled by fraction like CalculateKelly.
+//
+// A single bet with no holdings gets the same stake as CalculateKelly. Bets
+// that move together share a smaller combined stake, and holdings on the
+// other side of a bet act as a hedge and increase it.
+func SimultaneousKelly(bets []JointBet, held []Holding, bankroll float64, corr Correlations, fraction float64) []float64 {
+	stakes := make([]float64, len(bets))
+	if len(bets) == 0 || bankroll <= 0 {
+		return stakes
+	}
+
+	outcomes := make([]Outcome, 0, len(bets)+len(held))
+	for _, b := range bets {
+		outcomes = append(outcomes, b.Outcome)
+	}
+	for _, h := range held {
+		outcomes = append(outcomes, h.Outcome)
+	}
+	wins := simulateOutcomes(outcomes, corr)
+
+	// Net return per dollar staked on each bet in each scenario
+	returns := make([][]float64, len(bets))
+	for i, b := range bets {
+		returns[i] = make([]float64, jointScenarios)
+		win := 0.0
+		if b.Price > 0 && b.Price < 1 {
+			fee := kalshi.TakerFee(b.Price)
+			if profit := 1 - b.Price - fee; profit > 0 {
+				win = profit / (b.Price + fee)
+			}
+		}
+		for s := range jointScenarios {
+			if win > 0 && wins[i][s] {
+				returns[i][s] = win
+			} else {
+				returns[i][s] = -1
+			}
+		}
+	}
+
+	// Starting wealth per scenario, relative to bankroll
+	wealth := make([]float64, jointScenarios)
+	for s := range wealth {
+		wealth[s] = 1
+	}
+	for j, h := range held {
+		for s := range jointScenarios {
+			if wins[len(bets)+j][s] {
+				wealth[s] += h.Payout / bankroll
+			}
+		}
+	}
+
+	solveLogOptimal(stakes, returns, wealth)
+
+	for i := range stakes {
+		stakes[i] = math.Min(stakes[i], 1) * fraction
+	}
+	return stakes
+}
+
+// simulateOutcomes draws correlated factor values and marks which outcomes
+// win in each scenario. Win thresholds are set from the draws themselves so
+// each outcome wins in exactly its marginal share of scenarios.
+func simulateOutcomes(outcomes []Outcome, corr Correlations) [][]bool {
+	var factors []Outcome
+	index := make(map[string]int)
+	for _, o := range outcomes {
+		if _, ok := index[o.Factor]; !ok {
+			index[o.Factor] = len(factors)
+			factors = append(factors, o)
+		}
+	}
+
+	n := len(factors)
+	matrix := make([][]float64, n)
+	for i := range matrix {
+		matrix[i] = make([]float64, n)
+		for j := range matrix[i] {
+			if i == j {
+				matrix[i][j] = 1
+			} else {
+				matrix[i][j] = factorCorrelation(factors[i], factors[j], corr)
+			}
+		}
+	}
+	chol := choleskyShrunk(matrix)
+
+	rng := rand.New(rand.NewPCG(jointSeed, jointSeed))
+	draws := make([][]float64, n)
+	for i := range draws {
+		draws[i] = make([]float64, jointScenarios)
+	}
+	normals := make([]float64, n)
+	for s := range jointScenarios {
+		for i := range normals {
+			normals[i] = rng.NormFloat64()
+		}
+		for i := range n {
+			var z float64
+			for k := 0; k <= i; k++ {
+				z += chol[i][k] * normals[k]
+			}
+			draws[i][s] = z
+		}
+	}
+
+	wins := make([][]bool, len(outcomes))
+	sorted := make([]float64, jointScenarios)
+	for i, o := range outcomes {
+		wins[i] = make([]bool, jointScenarios)
+		dir := float64(o.Dir)
+		if dir == 0 {
+			dir = 1
+		}
+		z := draws[index[o.Factor]]
+
+		winners := int(math.Round(math.Max(0, math.Min(1, o.Prob)) * jointScenarios))
+		if winners == 0 {
+			continue
+		}
+		for s := range sorted {
+			sorted[s] = dir * z[s]
+		}
+		sort.Float64s(sorted)
+		threshold := math.Inf(-1)
+		if winners < jointScenarios {
+			threshold = sorted[jointScenarios-winners-1]
+		}
+		for s := range jointScenarios {
+			wins[i][s] = dir*z[s] > threshold
+		}
+	}
+	return wins
+}
+
+// factorCorrelation returns the modelled correlation between two distinct
+// factors. Player-margin correlation takes the sign of the player's side.
+func factorCorrelation(a, b Outcome, corr Correlations) float64 {
+	isPlayer := func(o Outcome) bool { return o.Factor != FactorMargin && o.Factor != FactorTotal }
+
+	switch {
+	case !isPlayer(a) && !isPlayer(b):
+		return corr.MarginTotal
+	case isPlayer(a) && isPlayer(b):
+		return corr.PlayerPlayer
+	}
+
+	player, other := a, b
+	if !isPlayer(a) {
+		player, other = b, a
+	}
+	if other.Factor == FactorTotal {
+		return corr.PlayerTotal
+	}
+	return corr.PlayerMargin * float64(player.Home)
+}
+
+// choleskyShrunk factors a correlation matrix, pulling the off-diagonal
+// entries toward zero until it is positive definite. Hand-set correlations
+// across several players are easily inconsistent.
+func choleskyShrunk(matrix [][]float64) [][]float64 {
+	n := len(matrix)
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = append([]float64(nil), matrix[i]...)
+	}
+
+	for range 50 {
+		if l, ok := cholesky(m); ok {
+			return l
+		}
+		for i := range m {
+			for j := range m[i] {
+				if i != j {
+					m[i][j] *= 0.9
+				}
+			}
+		}
+	}
+
+	identity := make([][]float64, n)
+	for i := range identity {
+		identity[i] = make([]float64, n)
+		identity[i][i] = 1
+	}
+	return identity
+}
+
+// cholesky returns the lower-triangular L with L·Lᵀ = m, or false if m is
+// not positive definite.
+func cholesky(m [][]float64) ([][]float64, bool) {
+	n := len(m)
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+	}
+	for i := range n {
+		for j := 0; j <= i; j++ {
+			sum := m[i][j]
+			for k := 0; k < j; k++ {
+				sum -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if sum <= 1e-9 {
+					return nil, false
+				}
+				l[i][i] = math.Sqrt(sum)
+			} else {
+				l[i][j] = sum / l[j][j]
+			}
+		}
+	}
+	return l, true
+}
+
+// solveLogOptimal maximizes mean log(wealth + Σ stakes[i]·returns[i]) by
+// coordinate-wise Newton steps, keeping stakes non-negative and their sum
+// at most maxJointStake. wealth is updated in place.
+func solveLogOptimal(stakes []float64, returns [][]float64, wealth []float64) {
+	objective := func(i int, delta float64) float64 {
+		var sum float64
+		for s, w := range wealth {
+			sum += math.Log(w + delta*returns[i][s])
+		}
+		return sum
+	}
+
+	for range 200 {
+		moved := 0.0
+		for i := range stakes {
+			var grad, curv float64
+			for s, w := range wealth {
+				r := returns[i][s] / w
+				grad += r
+				curv += r * r
+			}
+			if curv == 0 {
+				continue
+			}
+
+			total := 0.0
+			for _, f := range stakes {
+				total += f
+			}
+			target := math.Max(0, math.Min(stakes[i]+grad/curv, maxJointStake-(total-stakes[i])))
+			delta := target - stakes[i]
+
+			// Newton can overshoot on a log objective; back off until it improves
+			base := objective(i, 0)
+			for range 30 {
+				if delta == 0 || objective(i, delta) >= base {
+					break
+				}
+				delta /= 2
+			}
+			if delta == 0 || objective(i, delta) < base {
+				continue
+			}
+
+			stakes[i] += delta
+			for s := range wealth {
+				wealth[s] += delta * returns[i][s]
+			}
+			moved = math.Max(moved, math.Abs(delta))
+		}
+		if moved < 1e-7 {
+			return
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+)
+
+func homeML(prob, price float64) JointBet {
+	return JointBet{Outcome{Factor: FactorMargin, Dir: 1, Prob: prob}, price}
+}
+
+func TestSimultaneousKellySingleBetMatchesKelly(t *testing.T) {
+	for _, tt := range []struct{ prob, price float64 }{
+		{0.65, 0.50},
+		{0.55, 0.50},
+		{0.30, 0.20},
+		{0.45, 0.50}, // no edge
+	} {
+		got := SimultaneousKelly([]JointBet{homeML(tt.prob, tt.price)}, nil, 1000, DefaultCorrelations(), 0.25)
+		want := CalculateKelly(tt.prob, tt.price, 0.25)
+		if math.Abs(got[0]-want) > 1e-4 {
+			t.Errorf("p=%.2f c=%.2f: stake = %.5f, want Kelly %.5f", tt.prob, tt.price, got[0], want)
+		}
+	}
+}
+
+func TestSimultaneousKellyCorrelatedBets(t *testing.T) {
+	ml := homeML(0.65, 0.50)
+	spread := JointBet{Outcome{Factor: FactorMargin, Dir: 1, Prob: 0.58}, 0.45}
+	over := JointBet{Outcome{Factor: FactorTotal, Dir: 1, Prob: 0.58}, 0.45}
+
+	mlAlone := CalculateKelly(ml.Prob, ml.Price, 1)
+	spreadAlone := CalculateKelly(spread.Prob, spread.Price, 1)
+
+	joint := SimultaneousKelly([]JointBet{ml, spread}, nil, 1000, DefaultCorrelations(), 1)
+	if sum := joint[0] + joint[1]; sum >= 0.8*(mlAlone+spreadAlone) {
+		t.Errorf("ML+spread stakes = %.4f + %.4f, want well below independent %.4f + %.4f",
+			joint[0], joint[1], mlAlone, spreadAlone)
+	}
+
+	// Margin and total are uncorrelated by default: each keeps most of its stake
+	indep := SimultaneousKelly([]JointBet{ml, over}, nil, 1000, DefaultCorrelations(), 1)
+	if indep[0] < 0.85*mlAlone || indep[1] < 0.85*CalculateKelly(over.Prob, over.Price, 1) {
+		t.Errorf("ML+over stakes = %.4f, %.4f, want close to independent sizing", indep[0], indep[1])
+	}
+}
+
+func TestSimultaneousKellyHoldings(t *testing.T) {
+	bet := []JointBet{homeML(0.65, 0.50)}
+	alone := SimultaneousKelly(bet, nil, 1000, DefaultCorrelations(), 1)[0]
+
+	// $300 payout if the home side covers: more of the same risk
+	sameSide := []Holding{{Outcome{Factor: FactorMargin, Dir: 1, Prob: 0.55}, 300}}
+	if got := SimultaneousKelly(bet, sameSide, 1000, DefaultCorrelations(), 1)[0]; got >= alone {
+		t.Errorf("stake with same-side holding = %.4f, want below %.4f", got, alone)
+	}
+
+	// $300 payout if the away side wins: the bet now hedges it
+	otherSide := []Holding{{Outcome{Factor: FactorMargin, Dir: -1, Prob: 0.35}, 300}}
+	if got := SimultaneousKelly(bet, otherSide, 1000, DefaultCorrelations(), 1)[0]; got <= alone {
+		t.Errorf("stake with opposite holding = %.4f, want above %.4f", got, alone)
+	}
+}
+
+func TestSimultaneousKellyPlayerCorrelation(t *testing.T) {
+	over := JointBet{Outcome{Factor: FactorTotal, Dir: 1, Prob: 0.60}, 0.50}
+	homeOver := JointBet{Outcome{Factor: PlayerFactor("Home Player"), Home: 1, Dir: 1, Prob: 0.60}, 0.50}
+	homeUnder := JointBet{Outcome{Factor: PlayerFactor("Home Player"), Home: 1, Dir: -1, Prob: 0.60}, 0.50}
+
+	withOver := SimultaneousKelly([]JointBet{over, homeOver}, nil, 1000, DefaultCorrelations(), 1)
+	withUnder := SimultaneousKelly([]JointBet{over, homeUnder}, nil, 1000, DefaultCorrelations(), 1)
+	if withOver[1] >= withUnder[1] {
+		t.Errorf("player over stake %.4f should be below player under stake %.4f next to a game over",
+			withOver[1], withUnder[1])
+	}
+}
+
+func TestCholeskyShrunkRepairsInconsistentMatrix(t *testing.T) {
+	// Three players pairwise at -0.9 is not a valid correlation matrix
+	m := [][]float64{
+		{1, -0.9, -0.9},
+		{-0.9, 1, -0.9},
+		{-0.9, -0.9, 1},
+	}
+	if _, ok := cholesky(m); ok {
+		t.Fatal("cholesky accepted a non-PSD matrix")
+	}
+
+	l := choleskyShrunk(m)
+	for i := range l {
+		var norm float64
+		for _, v := range l[i] {
+			norm += v * v
+		}
+		if math.Abs(norm-1) > 1e-9 {
+			t.Errorf("row %d variance = %v, want 1", i, norm)
+		}
+	}
+	if l[1][0] >= 0 || l[1][0] < -0.9 {
+		t.Errorf("L[1][0] = %v, want a shrunk negative correlation", l[1][0])
+	}
+}
@@ -115,12 +115,19 @@ func CalculateKellyBetSize(trueProb, kalshiPrice, fraction, bankroll, maxBet flo
 // Returns the number of contracts to buy.
 func CalculateKellyContracts(trueProb, kalshiPrice, fraction, bankrollDollars, maxBetDollars float64, priceInCents int, askDepth ...int) int {
 	betSize := CalculateKellyBetSize(trueProb, kalshiPrice, fraction, bankrollDollars, maxBetDollars)
-	if betSize <= 0 || priceInCents <= 0 {
+	return StakeContracts(betSize, priceInCents, askDepth...)
+}
+
+// StakeContracts converts a bet size in dollars to a number of contracts at
+// priceInCents, including the taker fee in the cost of each contract.
+// Optional askDepth caps the result at available liquidity.
+func StakeContracts(betSizeDollars float64, priceInCents int, askDepth ...int) int {
+	if betSizeDollars <= 0 || priceInCents <= 0 {
 		return 0
 	}
 
 	// Convert bet size to cents, then divide by total cost per contract (price + fee)
-	betSizeCents := betSize * 100
+	betSizeCents := betSizeDollars * 100
 	feeCents := kalshi.TakerFeeCents(priceInCents)
 	costPerContract := float64(priceInCents) + feeCents
 	contracts := int(betSizeCents / costPerContract)
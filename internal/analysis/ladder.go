@@ -0,0 +1,262 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/mathutil"
+)
+
+// Strike ladders: Kalshi lists several thresholds per player and stat
+// (15+, 20+, 25+ points). Rather than shifting each sportsbook line to each
+// strike on its own, one distribution is fitted to all of a player's lines
+// at once and prices every strike, so fair probabilities always fall as
+// the strike rises. Kalshi rungs that break that order, or sit far off the
+// curve, are flagged.
+
+// LadderTolerance is how far a rung's mid price may sit from the fitted
+// curve, in probability, before it is flagged.
+const LadderTolerance = 0.10
+
+// Ladder flag kinds.
+const (
+	LadderNonMonotone = "non_monotone" // Priced above a lower strike
+	LadderOffCurve    = "off_curve"    // More than LadderTolerance from the curve
+)
+
+// LadderCurve is the distribution of one player's stat: Normal for points,
+// Negative Binomial for counts, with the spread tied to the mean by
+// DefaultStdDev and DefaultDispersion as in EstimateProbabilityAtLine.
+type LadderCurve struct {
+	PropType string
+	Mean     float64
+}
+
+// ProbAtLeast returns P(X >= k).
+func (c LadderCurve) ProbAtLeast(k float64) float64 {
+	if c.PropType == "points" {
+		return NormalCDFOver(k, c.Mean, DefaultStdDev(c.PropType, c.Mean))
+	}
+	return NegBinCDFOver(int(k), c.Mean, DefaultDispersion(c.PropType, c.Mean))
+}
+
+// FitLadder fits one curve to sportsbook over probabilities at lines,
+// weighting each line by weights (e.g. its book count). The mean minimizes
+// the weighted squared logit error across every line. Sportsbook "over
+// 19.5" is P(X >= 20), as in EstimateProbabilityAtLine. Returns false when
+// no line has a usable probability.
+func FitLadder(propType string, lines, overProbs, weights []float64) (LadderCurve, bool) {
+	type point struct{ threshold, logit, weight float64 }
+	var points []point
+	for i, line := range lines {
+		if i >= len(overProbs) || overProbs[i] <= 0 || overProbs[i] >= 1 {
+			continue
+		}
+		w := 1.0
+		if i < len(weights) && weights[i] > 0 {
+			w = weights[i]
+		}
+		points = append(points, point{float64(int(line) + 1), mathutil.Logit(overProbs[i]), w})
+	}
+	if len(points) == 0 {
+		return LadderCurve{}, false
+	}
+
+	loss := func(mean float64) float64 {
+		c := LadderCurve{PropType: propType, Mean: mean}
+		sum := 0.0
+		for _, p := range points {
+			d := mathutil.Logit(c.ProbAtLeast(p.threshold)) - p.logit
+			sum += p.weight * d * d
+		}
+		return sum
+	}
+
+	// Coarse grid, then golden-section search around the best point
+	const step = 0.5
+	best, bestLoss := step, math.Inf(1)
+	for mean := step; mean <= 80; mean += step {
+		if l := loss(mean); l < bestLoss {
+			best, bestLoss = mean, l
+		}
+	}
+	lo, hi := math.Max(best-step, 0.05), best+step
+	const phi = 0.6180339887498949
+	for i := 0; i < 40; i++ {
+		a := hi - phi*(hi-lo)
+		b := lo + phi*(hi-lo)
+		if loss(a) < loss(b) {
+			hi = b
+		} else {
+			lo = a
+		}
+	}
+	return LadderCurve{PropType: propType, Mean: (lo + hi) / 2}, true
+}
+
+// PropLadder is one player's Kalshi strikes on a stat with the curve
+// fitted to the sportsbook lines.
+type PropLadder struct {
+	PlayerID   int
+	PlayerName string
+	PropType   string
+	Curve      LadderCurve
+	BookCount  int                       // Average books per sportsbook line
+	Markets    []kalshi.PlayerPropMarket // Sorted by line
+}
+
+// FitPropLadders groups sportsbook props per player and prop type, keeps
+// the lines with at least cfg.MinBookCount books, fits a curve to them and
+// pairs it with the player's Kalshi markets of that type.
+func FitPropLadders(
+	bdlProps []api.PlayerProp,
+	kalshiProps map[string][]kalshi.PlayerPropMarket,
+	playerNames map[int]string,
+	cfg Config,
+) []PropLadder {
+	type propKey struct {
+		PlayerID int
+		PropType string
+		Line     float64
+	}
+	grouped := make(map[propKey][]api.PlayerProp)
+	for _, prop := range bdlProps {
+		if !api.IsKalshiSupportedPropType(prop.PropType) {
+			continue
+		}
+		key := propKey{PlayerID: prop.PlayerID, PropType: prop.PropType, Line: prop.Line()}
+		grouped[key] = append(grouped[key], prop)
+	}
+
+	type ladderKey struct {
+		PlayerID int
+		PropType string
+	}
+	type lineData struct {
+		lines, overProbs, books []float64
+	}
+	byPlayer := make(map[ladderKey]*lineData)
+	for key, group := range grouped {
+		consensus := calculateBDLConsensus(group)
+		if consensus == nil || consensus.BookCount < cfg.MinBookCount {
+			continue
+		}
+		lk := ladderKey{key.PlayerID, key.PropType}
+		if byPlayer[lk] == nil {
+			byPlayer[lk] = &lineData{}
+		}
+		d := byPlayer[lk]
+		d.lines = append(d.lines, key.Line)
+		d.overProbs = append(d.overProbs, consensus.OverTrueProb)
+		d.books = append(d.books, float64(consensus.BookCount))
+	}
+
+	var ladders []PropLadder
+	for key, d := range byPlayer {
+		playerName := playerNames[key.PlayerID]
+		if playerName == "" {
+			continue
+		}
+		var markets []kalshi.PlayerPropMarket
+		for _, km := range kalshiProps[key.PropType] {
+			if kalshi.PlayerNamesMatch(playerName, km.PlayerName) {
+				markets = append(markets, km)
+			}
+		}
+		if len(markets) == 0 {
+			continue
+		}
+		curve, ok := FitLadder(key.PropType, d.lines, d.overProbs, d.books)
+		if !ok {
+			continue
+		}
+		sort.Slice(markets, func(i, j int) bool { return markets[i].Line < markets[j].Line })
+
+		totalBooks := 0.0
+		for _, b := range d.books {
+			totalBooks += b
+		}
+		ladders = append(ladders, PropLadder{
+			PlayerID:   key.PlayerID,
+			PlayerName: playerName,
+			PropType:   key.PropType,
+			Curve:      curve,
+			BookCount:  int(totalBooks) / len(d.books),
+			Markets:    markets,
+		})
+	}
+	sort.Slice(ladders, func(i, j int) bool {
+		if ladders[i].PlayerID != ladders[j].PlayerID {
+			return ladders[i].PlayerID < ladders[j].PlayerID
+		}
+		return ladders[i].PropType < ladders[j].PropType
+	})
+	return ladders
+}
+
+// LadderFlag is a Kalshi rung priced out of line with its ladder.
+type LadderFlag struct {
+	Ticker      string
+	Line        float64
+	Kind        string  // LadderNonMonotone or LadderOffCurve
+	Price       float64 // The rung's YES mid as a probability
+	FairProb    float64 // The curve's P(X >= Line)
+	Description string
+}
+
+// Flags checks the ladder's Kalshi prices. Reaching a higher strike means
+// reaching every lower one, so a rung whose YES mid is above a lower
+// strike's is non-monotone. A rung whose mid sits more than tolerance from
+// the curve is off the curve. Rungs without quotes are skipped.
+func (l PropLadder) Flags(tolerance float64) []LadderFlag {
+	var flags []LadderFlag
+	var below *kalshi.PlayerPropMarket
+	belowPrice := 0.0
+	for i := range l.Markets {
+		km := &l.Markets[i]
+		price := rungPrice(*km)
+		if price == 0 {
+			continue
+		}
+		fair := l.Curve.ProbAtLeast(km.Line)
+		if below != nil && km.Line > below.Line && price > belowPrice {
+			flags = append(flags, LadderFlag{
+				Ticker:   km.Ticker,
+				Line:     km.Line,
+				Kind:     LadderNonMonotone,
+				Price:    price,
+				FairProb: fair,
+				Description: fmt.Sprintf("%s %.0f+ %s at %.0f¢ above %.0f+ at %.0f¢",
+					l.PlayerName, km.Line, l.PropType, price*100, below.Line, belowPrice*100),
+			})
+		}
+		if math.Abs(price-fair) > tolerance {
+			flags = append(flags, LadderFlag{
+				Ticker:   km.Ticker,
+				Line:     km.Line,
+				Kind:     LadderOffCurve,
+				Price:    price,
+				FairProb: fair,
+				Description: fmt.Sprintf("%s %.0f+ %s at %.0f¢, curve %.1f%% (mean %.1f)",
+					l.PlayerName, km.Line, l.PropType, price*100, fair*100, l.Curve.Mean),
+			})
+		}
+		below, belowPrice = km, price
+	}
+	return flags
+}
+
+// rungPrice is a market's YES mid, or its one-sided quote, as a probability.
+func rungPrice(km kalshi.PlayerPropMarket) float64 {
+	switch {
+	case km.YesBid > 0 && km.YesAsk > 0:
+		return float64(km.YesBid+km.YesAsk) / 200
+	case km.YesAsk > 0:
+		return float64(km.YesAsk) / 100
+	default:
+		return float64(km.YesBid) / 100
+	}
+}
@@ -0,0 +1,119 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+)
+
+func TestFitLadderMatchesSingleLine(t *testing.T) {
+	for _, propType := range []string{"points", "rebounds"} {
+		line := map[string]float64{"points": 19.5, "rebounds": 7.5}[propType]
+		curve, ok := FitLadder(propType, []float64{line}, []float64{0.6}, nil)
+		if !ok {
+			t.Fatalf("%s: FitLadder failed", propType)
+		}
+		if got := curve.ProbAtLeast(line + 0.5); math.Abs(got-0.6) > 0.01 {
+			t.Errorf("%s: P(X >= %.0f) = %.4f, want the line's 0.60", propType, line+0.5, got)
+		}
+		// One line fits the same curve EstimateProbabilityAtLine shifts along
+		want := EstimateProbabilityAtLine(line, 0.6, line+5.5, propType)
+		if got := curve.ProbAtLeast(line + 5.5); math.Abs(got-want) > 0.02 {
+			t.Errorf("%s: P(X >= %.0f) = %.4f, want ~%.4f", propType, line+5.5, got, want)
+		}
+	}
+}
+
+func TestFitLadderIsMonotone(t *testing.T) {
+	// The middle line is priced above the one below it
+	lines := []float64{6.5, 7.5, 8.5}
+	probs := []float64{0.60, 0.62, 0.40}
+	curve, ok := FitLadder("rebounds", lines, probs, []float64{6, 2, 6})
+	if !ok {
+		t.Fatal("FitLadder failed")
+	}
+	prev := 1.0
+	for k := 4.0; k <= 14; k++ {
+		p := curve.ProbAtLeast(k)
+		if p >= prev {
+			t.Errorf("P(X >= %.0f) = %.4f, not below P(X >= %.0f) = %.4f", k, p, k-1, prev)
+		}
+		prev = p
+	}
+	// The heavier-weighted outer lines dominate the fit
+	if p := curve.ProbAtLeast(8); p < 0.45 || p > 0.6 {
+		t.Errorf("P(X >= 8) = %.4f, want between the 7+ and 9+ consensus", p)
+	}
+
+	if _, ok := FitLadder("points", []float64{19.5}, []float64{1}, nil); ok {
+		t.Error("FitLadder accepted a line without a usable probability")
+	}
+}
+
+func TestPropLadderFlags(t *testing.T) {
+	ladder := PropLadder{
+		PlayerName: "Jalen Brunson",
+		PropType:   "points",
+		Curve:      LadderCurve{PropType: "points", Mean: 20}, // SD 7
+		Markets: []kalshi.PlayerPropMarket{
+			{Ticker: "PTS-15", Line: 15, YesBid: 80, YesAsk: 82},
+			{Ticker: "PTS-20", Line: 20, YesBid: 54, YesAsk: 56},
+			{Ticker: "PTS-25", Line: 25, YesBid: 60, YesAsk: 62},
+			{Ticker: "PTS-30", Line: 30, YesBid: 1, YesAsk: 3},
+			{Ticker: "PTS-35", Line: 35},
+		},
+	}
+
+	flags := ladder.Flags(LadderTolerance)
+	kinds := make(map[string]string)
+	for _, f := range flags {
+		kinds[f.Ticker+" "+f.Kind] = f.Description
+	}
+	if len(flags) != 2 || kinds["PTS-25 "+LadderNonMonotone] == "" || kinds["PTS-25 "+LadderOffCurve] == "" {
+		t.Fatalf("flags = %+v, want 25+ flagged non-monotone and off the curve", flags)
+	}
+	for _, f := range flags {
+		if f.Price != 0.61 || math.Abs(f.FairProb-0.26) > 0.01 {
+			t.Errorf("flag = %+v, want price 0.61 against a fair ~0.26", f)
+		}
+	}
+}
+
+func TestFitPropLaddersGroupsStrikes(t *testing.T) {
+	prop := func(playerID int, vendor, line string, over, under int) api.PlayerProp {
+		return api.PlayerProp{
+			PlayerID: playerID,
+			Vendor:   vendor,
+			PropType: "points",
+			LineStr:  line,
+			Market:   api.PlayerPropMarket{Type: "over_under", OverOdds: over, UnderOdds: under},
+		}
+	}
+	props := []api.PlayerProp{
+		prop(1, "fanduel", "19.5", -150, 120),
+		prop(1, "draftkings", "19.5", -145, 115),
+		prop(1, "fanduel", "24.5", 160, -200),
+		prop(1, "draftkings", "24.5", 155, -190),
+		prop(1, "betmgm", "29.5", 400, -600), // One book: below MinBookCount
+	}
+	markets := map[string][]kalshi.PlayerPropMarket{"points": {
+		{Ticker: "PTS-25", PlayerName: "Jalen Brunson", Line: 25, YesAsk: 30},
+		{Ticker: "PTS-20", PlayerName: "Jalen Brunson", Line: 20, YesAsk: 58},
+		{Ticker: "OTHER-20", PlayerName: "Josh Hart", Line: 20, YesAsk: 10},
+	}}
+
+	ladders := FitPropLadders(props, markets, map[int]string{1: "Jalen Brunson"}, Config{MinBookCount: 2})
+	if len(ladders) != 1 {
+		t.Fatalf("ladders = %+v, want one", ladders)
+	}
+	l := ladders[0]
+	if len(l.Markets) != 2 || l.Markets[0].Ticker != "PTS-20" || l.Markets[1].Ticker != "PTS-25" || l.BookCount != 2 {
+		t.Errorf("ladder = %+v, want Brunson's 20+ and 25+ from two books", l)
+	}
+	p20, p25 := l.Curve.ProbAtLeast(20), l.Curve.ProbAtLeast(25)
+	if p20 < 0.5 || p20 > 0.65 || p25 < 0.3 || p25 > 0.4 || p25 >= p20 {
+		t.Errorf("P(20+) = %.4f, P(25+) = %.4f, want both near their sportsbook lines", p20, p25)
+	}
+}
@@ -100,7 +100,7 @@ func CalculatePlayerPropConsensus(props []api.PlayerProp) *PlayerPropConsensus {
 		// Remove vig for other books using Power method (accounts for FLB bias)
 		overProb, underProb := odds.RemoveVigPowerFromAmerican(prop.Market.OverOdds, prop.Market.UnderOdds)
 		if overProb > 0 && underProb > 0 {
-			probs = append(probs, wp{overProb, underProb, api.VendorPropWeight(prop.Vendor)})
+			probs = append(probs, wp{overProb, underProb, api.VendorWeight("prop_"+prop.PropType, prop.Vendor)})
 		}
 	}
 
@@ -365,7 +365,8 @@ func FindPlayerPropOpportunitiesWithKalshi(
 
 // FindPlayerPropOpportunitiesWithInterpolation finds +EV player prop bets using distribution interpolation
 // This allows comparing BDL lines (e.g., over 19.5) with different Kalshi lines (e.g., 25+)
-// by fitting a probability distribution and estimating the true probability at any threshold
+// by fitting one probability distribution per player and stat to all BDL lines and
+// estimating the true probability at any threshold
 func FindPlayerPropOpportunitiesWithInterpolation(
 	bdlProps []api.PlayerProp,
 	kalshiProps map[string][]kalshi.PlayerPropMarket,
@@ -374,99 +375,27 @@ func FindPlayerPropOpportunitiesWithInterpolation(
 	gameID int,
 	cfg Config,
 ) []PlayerPropOpportunity {
-	var opportunities []PlayerPropOpportunity
-
-	// Group BDL props by player ID + prop type (NOT line - we want all lines for a player)
-	type playerPropKey struct {
-		PlayerID int
-		PropType string
-	}
-	type lineData struct {
-		Line      float64
-		OverProb  float64
-		UnderProb float64
-		BookCount int
-	}
-	playerProps := make(map[playerPropKey][]lineData)
-
-	// First pass: collect all BDL lines for each player+propType
-	type propKey struct {
-		PlayerID int
-		PropType string
-		Line     float64
-	}
-	grouped := make(map[propKey][]api.PlayerProp)
-
-	for _, prop := range bdlProps {
-		if !api.IsKalshiSupportedPropType(prop.PropType) {
-			continue
-		}
-		key := propKey{
-			PlayerID: prop.PlayerID,
-			PropType: prop.PropType,
-			Line:     prop.Line(),
-		}
-		grouped[key] = append(grouped[key], prop)
-	}
-
-	// Calculate consensus for each line
-	for key, group := range grouped {
-		consensus := calculateBDLConsensus(group)
-		if consensus == nil || consensus.BookCount < cfg.MinBookCount {
-			continue
-		}
-
-		ppKey := playerPropKey{PlayerID: key.PlayerID, PropType: key.PropType}
-		playerProps[ppKey] = append(playerProps[ppKey], lineData{
-			Line:      key.Line,
-			OverProb:  consensus.OverTrueProb,
-			UnderProb: consensus.UnderTrueProb,
-			BookCount: consensus.BookCount,
-		})
-	}
-
-	// For each player+propType, find Kalshi markets and estimate probabilities
-	for ppKey, lines := range playerProps {
-		playerName, ok := playerNames[ppKey.PlayerID]
-		if !ok || playerName == "" {
-			continue
-		}
-
-		kalshiMarkets, ok := kalshiProps[ppKey.PropType]
-		if !ok || len(kalshiMarkets) == 0 {
-			continue
-		}
+	ladders := FitPropLadders(bdlProps, kalshiProps, playerNames, cfg)
+	return FindLadderOpportunities(ladders, gameDate, homeTeam, awayTeam, gameID, cfg)
+}
 
-		// Find all Kalshi markets for this player
-		for _, km := range kalshiMarkets {
-			if !kalshi.PlayerNamesMatch(playerName, km.PlayerName) {
-				continue
-			}
+// FindLadderOpportunities finds +EV bets on the Kalshi strikes of fitted ladders
+func FindLadderOpportunities(
+	ladders []PropLadder,
+	gameDate, homeTeam, awayTeam string,
+	gameID int,
+	cfg Config,
+) []PlayerPropOpportunity {
+	var opportunities []PlayerPropOpportunity
 
-			// Sort BDL lines and extract probabilities
-			var bdlLines []float64
-			var bdlOverProbs []float64
-			var bdlUnderProbs []float64
-			var totalBooks int
-
-			for _, ld := range lines {
-				bdlLines = append(bdlLines, ld.Line)
-				bdlOverProbs = append(bdlOverProbs, ld.OverProb)
-				bdlUnderProbs = append(bdlUnderProbs, ld.UnderProb)
-				totalBooks += ld.BookCount
-			}
-			avgBooks := totalBooks / len(lines)
+	for _, ladder := range ladders {
+		avgBooks := ladder.BookCount
 
-			// Use distribution interpolation to estimate probability at Kalshi line
+		for _, km := range ladder.Markets {
 			kalshiLine := km.Line
 
-			// For OVER: estimate P(X >= kalshiLine)
-			var estimatedOverProb float64
-			if len(bdlLines) == 1 {
-				estimatedOverProb = EstimateProbabilityAtLine(bdlLines[0], bdlOverProbs[0], kalshiLine, ppKey.PropType)
-			} else {
-				estimatedOverProb = EstimateProbabilityFromMultipleLines(bdlLines, bdlOverProbs, kalshiLine, ppKey.PropType)
-			}
+			// For OVER: P(X >= kalshiLine) from the ladder's curve
+			estimatedOverProb := ladder.Curve.ProbAtLeast(kalshiLine)
 
 			// For UNDER: P(X < kalshiLine) = 1 - P(X >= kalshiLine)
 			estimatedUnderProb := 1 - estimatedOverProb
@@ -493,9 +422,9 @@ func FindPlayerPropOpportunitiesWithInterpolation(
 						GameDate:     gameDate,
 						HomeTeam:     homeTeam,
 						AwayTeam:     awayTeam,
-						PlayerID:     ppKey.PlayerID,
-						PlayerName:   playerName,
-						PropType:     ppKey.PropType,
+						PlayerID:     ladder.PlayerID,
+						PlayerName:   ladder.PlayerName,
+						PropType:     ladder.PropType,
 						Line:         kalshiLine,
 						Side:         "over",
 						TrueProb:     overProb,
@@ -518,9 +447,9 @@ func FindPlayerPropOpportunitiesWithInterpolation(
 						GameDate:     gameDate,
 						HomeTeam:     homeTeam,
 						AwayTeam:     awayTeam,
-						PlayerID:     ppKey.PlayerID,
-						PlayerName:   playerName,
-						PropType:     ppKey.PropType,
+						PlayerID:     ladder.PlayerID,
+						PlayerName:   ladder.PlayerName,
+						PropType:     ladder.PropType,
 						Line:         kalshiLine,
 						Side:         "under",
 						TrueProb:     underProb,
@@ -540,7 +469,8 @@ func FindPlayerPropOpportunitiesWithInterpolation(
 }
 
 // calculateBDLConsensus calculates consensus from Ball Don't Lie props only (no Kalshi)
-// Applies vendor weighting per api.VendorPropWeight (e.g. FanDuel 1.5x, BetMGM 0.7x)
+// Applies vendor weighting per api.VendorWeight for the prop type (fitted weights,
+// or defaults such as FanDuel 1.5x, BetMGM 0.7x)
 func calculateBDLConsensus(props []api.PlayerProp) *PlayerPropConsensus {
 	if len(props) == 0 {
 		return nil
@@ -572,7 +502,7 @@ func calculateBDLConsensus(props []api.PlayerProp) *PlayerPropConsensus {
 		// Remove vig using Power method (accounts for FLB bias)
 		overProb, underProb := odds.RemoveVigPowerFromAmerican(prop.Market.OverOdds, prop.Market.UnderOdds)
 		if overProb > 0 && underProb > 0 {
-			probs = append(probs, weightedProb{overProb, underProb, api.VendorPropWeight(prop.Vendor)})
+			probs = append(probs, weightedProb{overProb, underProb, api.VendorWeight("prop_"+prop.PropType, prop.Vendor)})
 		}
 	}
 
@@ -2,10 +2,13 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"time"
+
+	"sports-betting-bot/internal/metrics"
 )
 
 const (
@@ -28,24 +31,28 @@ type BallDontLieClient struct {
 
 	// Cache for player names (persists for session)
 	playerCache map[int]string // player_id -> "FirstName LastName"
+
+	// Endpoints and list pagination limits; see pagination.go
+	baseURL    string
+	baseURLV2  string
+	maxPages   int
+	pageBudget time.Duration
 }
 
 // NewBallDontLieClient creates a new API client
 func NewBallDontLieClient(apiKey string) *BallDontLieClient {
 	return &BallDontLieClient{
 		apiKey:      apiKey,
-		client:      NewRateLimitedClient(requestsPerMinute, requestTimeout, maxRetries),
+		client:      NewRateLimitedClient(metrics.UpstreamBallDontLie, requestsPerMinute, requestTimeout, maxRetries),
 		gamesCache:  make(map[int]GameInfo),
 		playerCache: make(map[int]string),
+		baseURL:     baseURL,
+		baseURLV2:   baseURLV2,
+		maxPages:    maxPages,
+		pageBudget:  pageRateBudget,
 	}
 }
 
-// OddsResponseV2 represents the v2 API response (flat format, one record per vendor)
-type OddsResponseV2 struct {
-	Data []OddsRecordV2 `json:"data"`
-	Meta Meta           `json:"meta"`
-}
-
 // OddsRecordV2 represents a single odds record from v2 API (flat format)
 type OddsRecordV2 struct {
 	ID               int     `json:"id"`
@@ -94,6 +101,11 @@ type Game struct {
 // GameStartsWithin checks if the game starts within the given duration
 // Returns true if game is about to start (within duration) or has already started
 func (g *Game) StartsWithin(d time.Duration) bool {
+	return g.StartsWithinAt(time.Now(), d)
+}
+
+// StartsWithinAt is StartsWithin evaluated as of now rather than the wall clock
+func (g *Game) StartsWithinAt(now time.Time, d time.Duration) bool {
 	if g.DateTime == "" {
 		return false // Can't determine, assume safe
 	}
@@ -107,7 +119,7 @@ func (g *Game) StartsWithin(d time.Duration) bool {
 		}
 	}
 
-	timeUntilStart := time.Until(startTime)
+	timeUntilStart := startTime.Sub(now)
 	return timeUntilStart <= d
 }
 
@@ -150,12 +162,6 @@ type Total struct {
 	UnderOdds int    `json:"under_odds"`
 }
 
-// GamesResponse represents the API response for games
-type GamesResponse struct {
-	Data []GameInfo `json:"data"`
-	Meta Meta       `json:"meta"`
-}
-
 // GameInfo represents game details from the games endpoint
 type GameInfo struct {
 	ID              int    `json:"id"`
@@ -168,27 +174,19 @@ type GameInfo struct {
 	VisitorTeamScore int   `json:"visitor_team_score"`
 }
 
-// GetGames fetches NBA games for a specific date
+// GetGames fetches NBA games for a specific date, handling pagination
 func (c *BallDontLieClient) GetGames(date time.Time) (map[int]GameInfo, error) {
 	dateStr := date.Format("2006-01-02")
-	headers := map[string]string{
-		"Authorization": c.apiKey,
-	}
 
-	url := fmt.Sprintf("%s/games?dates[]=%s", baseURL, dateStr)
-	body, err := c.client.Get(url, headers)
+	url := fmt.Sprintf("%s/games?dates[]=%s", c.baseURL, dateStr)
+	data, err := listAll[GameInfo](c, url, "games")
 	if err != nil {
-		return nil, fmt.Errorf("fetching games: %w", err)
-	}
-
-	var resp GamesResponse
-	if err := json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("parsing games response: %w", err)
+		return nil, err
 	}
 
 	// Build map by game ID for quick lookup
 	gameMap := make(map[int]GameInfo)
-	for _, g := range resp.Data {
+	for _, g := range data {
 		gameMap[g.ID] = g
 	}
 
@@ -199,9 +197,6 @@ func (c *BallDontLieClient) GetGames(date time.Time) (map[int]GameInfo, error) {
 // Converts v2 flat format (one record per vendor) to grouped format (one record per game)
 func (c *BallDontLieClient) GetOdds(date time.Time) ([]GameOdds, error) {
 	dateStr := date.Format("2006-01-02")
-	headers := map[string]string{
-		"Authorization": c.apiKey,
-	}
 
 	// Use cached game info if available and fresh (within 5 minutes for same date)
 	games := c.gamesCache
@@ -222,32 +217,10 @@ func (c *BallDontLieClient) GetOdds(date time.Time) ([]GameOdds, error) {
 		}
 	}
 
-	var allRecords []OddsRecordV2
-	cursor := 0
-
-	for {
-		url := fmt.Sprintf("%s/odds?dates[]=%s&per_page=100", baseURLV2, dateStr)
-		if cursor > 0 {
-			url = fmt.Sprintf("%s&cursor=%d", url, cursor)
-		}
-
-		body, err := c.client.Get(url, headers)
-		if err != nil {
-			return nil, fmt.Errorf("fetching odds: %w", err)
-		}
-
-		var resp OddsResponseV2
-		if err := json.Unmarshal(body, &resp); err != nil {
-			return nil, fmt.Errorf("parsing odds response: %w", err)
-		}
-
-		allRecords = append(allRecords, resp.Data...)
-
-		// Check if there are more pages
-		if resp.Meta.NextCursor == 0 {
-			break
-		}
-		cursor = resp.Meta.NextCursor
+	url := fmt.Sprintf("%s/odds?dates[]=%s", c.baseURLV2, dateStr)
+	allRecords, err := listAll[OddsRecordV2](c, url, "odds")
+	if err != nil {
+		return nil, err
 	}
 
 	// Group records by game_id and convert to GameOdds format
@@ -297,25 +270,33 @@ func groupOddsByGame(records []OddsRecordV2, games map[int]GameInfo) []GameOdds
 			}
 		}
 
-		// Parse spread (if available)
+		// Parse spread (if available); a value that doesn't parse drops
+		// the vendor's spread rather than reading as a pick'em
 		if rec.SpreadHomeValue != "" && rec.SpreadHomeOdds != 0 {
-			homeSpread, _ := strconv.ParseFloat(rec.SpreadHomeValue, 64)
-			awaySpread, _ := strconv.ParseFloat(rec.SpreadAwayValue, 64)
-			vendor.Spread = &Spread{
-				HomeSpread: homeSpread,
-				HomeOdds:   rec.SpreadHomeOdds,
-				AwaySpread: awaySpread,
-				AwayOdds:   rec.SpreadAwayOdds,
+			homeSpread, homeErr := parseNumber("odds", rec.ID, "spread_home_value", rec.SpreadHomeValue)
+			awaySpread, awayErr := parseNumber("odds", rec.ID, "spread_away_value", rec.SpreadAwayValue)
+			if err := errors.Join(homeErr, awayErr); err != nil {
+				reportInvalid(err)
+			} else {
+				vendor.Spread = &Spread{
+					HomeSpread: homeSpread,
+					HomeOdds:   rec.SpreadHomeOdds,
+					AwaySpread: awaySpread,
+					AwayOdds:   rec.SpreadAwayOdds,
+				}
 			}
 		}
 
 		// Parse total (if available)
 		if rec.TotalValue != "" && rec.TotalOverOdds != nil && rec.TotalUnderOdds != nil {
-			line, _ := strconv.ParseFloat(rec.TotalValue, 64)
-			vendor.Total = &Total{
-				Line:      line,
-				OverOdds:  *rec.TotalOverOdds,
-				UnderOdds: *rec.TotalUnderOdds,
+			if line, err := parseNumber("odds", rec.ID, "total_value", rec.TotalValue); err != nil {
+				reportInvalid(err)
+			} else {
+				vendor.Total = &Total{
+					Line:      line,
+					OverOdds:  *rec.TotalOverOdds,
+					UnderOdds: *rec.TotalUnderOdds,
+				}
 			}
 		}
 
@@ -355,6 +336,8 @@ func IsKalshi(vendorName string) bool {
 
 // vendorGameWeights maps BDL vendor names to consensus weights for game markets
 // (moneyline, spread, totals). Based on Pikkit 2025 data and Data Golf studies.
+// These are the defaults; a weights file fitted from our own lines overrides
+// them (see VendorWeight).
 var vendorGameWeights = map[string]float64{
 	"DraftKings": 1.5, // Pikkit: NBA mains #3, MLB mains #4
 	"Bet365":     1.3, // Data Golf Tier 2, best blind betting ROI
@@ -362,7 +345,7 @@ var vendorGameWeights = map[string]float64{
 }
 
 // vendorPropWeights maps BDL vendor names to consensus weights for player prop markets.
-// Based on Pikkit 2025 data.
+// Based on Pikkit 2025 data. Defaults, like vendorGameWeights.
 var vendorPropWeights = map[string]float64{
 	"FanDuel":    1.5, // Pikkit: MLB props #1, NBA props #4
 	"DraftKings": 1.2, // Mid-pack for props
@@ -391,12 +374,6 @@ func VendorPropWeight(vendorName string) float64 {
 // Player Props API (V2 endpoint)
 // ===============================
 
-// PlayerPropsResponse represents the API response for player props
-type PlayerPropsResponse struct {
-	Data []PlayerProp `json:"data"`
-	Meta Meta         `json:"meta"`
-}
-
 // PlayerProp represents a single player prop bet
 type PlayerProp struct {
 	ID        int              `json:"id"`
@@ -415,6 +392,13 @@ func (p *PlayerProp) Line() float64 {
 	return val
 }
 
+// Validate reports a line that doesn't parse, which Line reads as 0.
+// The list calls drop such props.
+func (p *PlayerProp) Validate() error {
+	_, err := parseNumber("player_prop", p.ID, "line_value", p.LineStr)
+	return err
+}
+
 // Player represents a player in the API
 type Player struct {
 	ID        int    `json:"id"`
@@ -447,7 +431,7 @@ func (c *BallDontLieClient) GetPlayerName(playerID int) (string, error) {
 		"Authorization": c.apiKey,
 	}
 
-	url := fmt.Sprintf("%s/players/%d", baseURL, playerID)
+	url := fmt.Sprintf("%s/players/%d", c.baseURL, playerID)
 	body, err := c.client.Get(url, headers)
 	if err != nil {
 		return "", fmt.Errorf("fetching player %d: %w", playerID, err)
@@ -491,34 +475,20 @@ type PlayerPropMarket struct {
 // BallDontLie V2 endpoint: GET /v2/odds/player_props?game_id=X
 // Note: Player prop data is LIVE and not stored historically
 func (c *BallDontLieClient) GetPlayerProps(gameID int) ([]PlayerProp, error) {
-	headers := map[string]string{
-		"Authorization": c.apiKey,
-	}
-
-	url := fmt.Sprintf("%s/odds/player_props?game_id=%d", baseURLV2, gameID)
+	url := fmt.Sprintf("%s/odds/player_props?game_id=%d", c.baseURLV2, gameID)
 
-	body, err := c.client.Get(url, headers)
+	props, err := listAll[PlayerProp](c, url, "player props")
 	if err != nil {
-		return nil, fmt.Errorf("fetching player props: %w", err)
+		return nil, err
 	}
-
-	var resp PlayerPropsResponse
-	if err := json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("parsing player props response: %w", err)
-	}
-
-	return resp.Data, nil
+	return validProps(props), nil
 }
 
 // GetPlayerPropsFiltered fetches player props with optional filters
 // propType: "points", "rebounds", "assists", "threes", etc.
 // vendors: filter by specific sportsbooks
 func (c *BallDontLieClient) GetPlayerPropsFiltered(gameID int, propType string, vendors []string) ([]PlayerProp, error) {
-	headers := map[string]string{
-		"Authorization": c.apiKey,
-	}
-
-	url := fmt.Sprintf("%s/odds/player_props?game_id=%d", baseURLV2, gameID)
+	url := fmt.Sprintf("%s/odds/player_props?game_id=%d", c.baseURLV2, gameID)
 	if propType != "" {
 		url = fmt.Sprintf("%s&prop_type=%s", url, propType)
 	}
@@ -526,17 +496,11 @@ func (c *BallDontLieClient) GetPlayerPropsFiltered(gameID int, propType string,
 		url = fmt.Sprintf("%s&vendors[]=%s", url, v)
 	}
 
-	body, err := c.client.Get(url, headers)
+	props, err := listAll[PlayerProp](c, url, "player props")
 	if err != nil {
-		return nil, fmt.Errorf("fetching player props: %w", err)
-	}
-
-	var resp PlayerPropsResponse
-	if err := json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("parsing player props response: %w", err)
+		return nil, err
 	}
-
-	return resp.Data, nil
+	return validProps(props), nil
 }
 
 // GetTodaysPlayerProps fetches player props for all of today's games
@@ -0,0 +1,199 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/metrics"
+)
+
+// pagedServer serves pages[cursor] for every path, each page linking to
+// the next cursor, and records the queries it was sent.
+type pagedServer struct {
+	mu      sync.Mutex
+	pages   map[string]string // cursor ("" for the first page) -> JSON body
+	queries []string
+}
+
+func (s *pagedServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.queries = append(s.queries, r.URL.Path+"?"+r.URL.RawQuery)
+	s.mu.Unlock()
+	body, ok := s.pages[r.URL.Query().Get("cursor")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	io.WriteString(w, body)
+}
+
+func newTestClient(t *testing.T, h http.Handler) *BallDontLieClient {
+	t.Helper()
+	prev := log.Writer()
+	log.SetOutput(io.Discard)
+	t.Cleanup(func() { log.SetOutput(prev) })
+
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	c := NewBallDontLieClient("key")
+	c.baseURL = srv.URL + "/v1"
+	c.baseURLV2 = srv.URL + "/v2"
+	return c
+}
+
+func propJSON(id int, vendor, line string) string {
+	return fmt.Sprintf(`{"id":%d,"game_id":9,"player_id":1,"vendor":%q,"prop_type":"points","line_value":%q,`+
+		`"market":{"type":"over_under","over_odds":-110,"under_odds":-110}}`, id, vendor, line)
+}
+
+func TestGetPlayerPropsFollowsCursor(t *testing.T) {
+	srv := &pagedServer{pages: map[string]string{
+		"":   `{"data":[` + propJSON(1, "fanduel", "19.5") + `,` + propJSON(2, "draftkings", "n/a") + `],"meta":{"next_cursor":7}}`,
+		"7":  `{"data":[` + propJSON(3, "betmgm", "20.5") + `],"meta":{"next_cursor":12}}`,
+		"12": `{"data":[` + propJSON(4, "caesars", " ") + `,` + propJSON(5, "bet365", "21.5") + `],"meta":{}}`,
+	}}
+	c := newTestClient(t, srv)
+
+	before := metrics.APIInvalidRecords.Value("player_prop")
+	props, err := c.GetPlayerProps(9)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []int
+	for _, p := range props {
+		ids = append(ids, p.ID)
+	}
+	if fmt.Sprint(ids) != "[1 3 5]" {
+		t.Errorf("props = %v, want [1 3 5] from three pages without the unparseable lines", ids)
+	}
+	if got := metrics.APIInvalidRecords.Value("player_prop") - before; got != 2 {
+		t.Errorf("invalid records = %v, want 2", got)
+	}
+	if len(srv.queries) != 3 || !strings.Contains(srv.queries[1], "cursor=7") || !strings.Contains(srv.queries[0], "per_page=100") {
+		t.Errorf("queries = %v, want three pages following the cursor", srv.queries)
+	}
+}
+
+func TestGetPlayerPropsFilteredKeepsFilters(t *testing.T) {
+	srv := &pagedServer{pages: map[string]string{
+		"":  `{"data":[` + propJSON(1, "fanduel", "19.5") + `],"meta":{"next_cursor":3}}`,
+		"3": `{"data":[` + propJSON(2, "fanduel", "24.5") + `],"meta":{"next_cursor":0}}`,
+	}}
+	c := newTestClient(t, srv)
+
+	props, err := c.GetPlayerPropsFiltered(9, "points", []string{"fanduel"})
+	if err != nil || len(props) != 2 {
+		t.Fatalf("GetPlayerPropsFiltered = %v, %v, want both pages", props, err)
+	}
+	for _, q := range srv.queries {
+		if !strings.Contains(q, "prop_type=points") || !strings.Contains(q, "vendors[]=fanduel") {
+			t.Errorf("query %q lost the filters", q)
+		}
+	}
+}
+
+func TestGetGamesPaginates(t *testing.T) {
+	srv := &pagedServer{pages: map[string]string{
+		"":  `{"data":[{"id":1,"status":"scheduled"}],"meta":{"next_cursor":2}}`,
+		"2": `{"data":[{"id":2,"status":"Final"}],"meta":{"next_cursor":0}}`,
+	}}
+	c := newTestClient(t, srv)
+
+	games, err := c.GetGames(time.Date(2026, 2, 5, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(games) != 2 || games[2].Status != "Final" {
+		t.Fatalf("GetGames = %+v, %v, want both pages", games, err)
+	}
+	if !strings.HasPrefix(srv.queries[0], "/v1/games?dates[]=2026-02-05") {
+		t.Errorf("query = %q, want the games endpoint for the date", srv.queries[0])
+	}
+}
+
+func TestGetOddsDropsUnparseableLines(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/games", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":[{"id":9,"status":"scheduled"}],"meta":{}}`)
+	})
+	mux.HandleFunc("/v2/odds", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":[
+			{"id":1,"game_id":9,"vendor":"fanduel","spread_home_value":"-4.5","spread_home_odds":-110,
+			 "spread_away_value":"4.5","spread_away_odds":-110,"total_value":"221.5","total_over_odds":-110,"total_under_odds":-110},
+			{"id":2,"game_id":9,"vendor":"draftkings","spread_home_value":"PK?","spread_home_odds":-110,
+			 "spread_away_value":"4.5","spread_away_odds":-110,"total_value":"","total_over_odds":-110,"total_under_odds":-110},
+			{"id":3,"game_id":9,"vendor":"betmgm","spread_home_value":"-5","spread_home_odds":-110,
+			 "spread_away_value":"5","spread_away_odds":-110,"total_value":"o221","total_over_odds":-110,"total_under_odds":-110}
+		],"meta":{}}`)
+	})
+	c := newTestClient(t, mux)
+
+	games, err := c.GetOdds(time.Date(2026, 2, 5, 0, 0, 0, 0, time.UTC))
+	if err != nil || len(games) != 1 {
+		t.Fatalf("GetOdds = %+v, %v, want one game", games, err)
+	}
+	vendors := make(map[string]Vendor)
+	for _, v := range games[0].Vendors {
+		vendors[v.Name] = v
+	}
+	if v := vendors["fanduel"]; v.Spread == nil || v.Spread.HomeSpread != -4.5 || v.Total == nil || v.Total.Line != 221.5 {
+		t.Errorf("fanduel = %+v, want spread and total parsed", v)
+	}
+	if v := vendors["draftkings"]; v.Spread != nil || v.Total != nil {
+		t.Errorf("draftkings = %+v, want the unparseable spread dropped, not read as 0", v)
+	}
+	if v := vendors["betmgm"]; v.Spread == nil || v.Total != nil {
+		t.Errorf("betmgm = %+v, want the spread kept and the unparseable total dropped", v)
+	}
+}
+
+func TestListStopsAtPageCap(t *testing.T) {
+	srv := &pagedServer{pages: map[string]string{
+		"":  `{"data":[` + propJSON(1, "fanduel", "19.5") + `],"meta":{"next_cursor":1}}`,
+		"1": `{"data":[` + propJSON(2, "fanduel", "19.5") + `],"meta":{"next_cursor":1}}`,
+	}}
+	c := newTestClient(t, srv)
+	c.maxPages = 3
+
+	before := metrics.APIListTruncated.Value(metrics.UpstreamBallDontLie, "page_cap")
+	props, err := c.GetPlayerProps(9)
+	if err != nil || len(props) != 3 || len(srv.queries) != 3 {
+		t.Fatalf("GetPlayerProps = %d props, %v after %d requests, want 3 pages", len(props), err, len(srv.queries))
+	}
+	if got := metrics.APIListTruncated.Value(metrics.UpstreamBallDontLie, "page_cap") - before; got != 1 {
+		t.Errorf("truncated lists = %v, want 1", got)
+	}
+}
+
+func TestListStopsPastRateBudget(t *testing.T) {
+	srv := &pagedServer{pages: map[string]string{
+		"":  `{"data":[` + propJSON(1, "fanduel", "19.5") + `],"meta":{"next_cursor":4}}`,
+		"4": `{"data":[` + propJSON(2, "fanduel", "20.5") + `],"meta":{"next_cursor":0}}`,
+	}}
+	c := newTestClient(t, srv)
+	// One token, refilled every 10s: the second page can't get one in time
+	c.client = NewRateLimitedClient(metrics.UpstreamBallDontLie, 6, time.Second, 0)
+	c.pageBudget = 10 * time.Millisecond
+
+	props, err := c.GetPlayerProps(9)
+	if err != nil || len(props) != 1 || len(srv.queries) != 1 {
+		t.Fatalf("GetPlayerProps = %d props, %v after %d requests, want the first page only", len(props), err, len(srv.queries))
+	}
+}
+
+func TestListMalformedPage(t *testing.T) {
+	srv := &pagedServer{pages: map[string]string{
+		"":  `{"data":[` + propJSON(1, "fanduel", "19.5") + `],"meta":{"next_cursor":5}}`,
+		"5": `{"data":[{"id":"two"}]`,
+	}}
+	c := newTestClient(t, srv)
+
+	if props, err := c.GetPlayerProps(9); err == nil || !strings.Contains(err.Error(), "parsing player props response") {
+		t.Errorf("GetPlayerProps = %v, %v, want a parse error", props, err)
+	}
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NBA game clock lengths in seconds.
+const (
+	PeriodSeconds     = 12 * 60
+	OvertimeSeconds   = 5 * 60
+	RegulationSeconds = 4 * PeriodSeconds
+)
+
+// LiveBoxScore is a game's score and clock from the live box scores
+// endpoint. Box scores carry no game ID; match them on date and teams.
+type LiveBoxScore struct {
+	Date             string `json:"date"`
+	Status           string `json:"status"`
+	Period           int    `json:"period"`
+	Time             string `json:"time"` // Game clock, e.g. "5:32", "Q3 5:32", "45.2", "Half" or "Final"
+	HomeTeamScore    int    `json:"home_team_score"`
+	VisitorTeamScore int    `json:"visitor_team_score"`
+	HomeTeam         Team   `json:"home_team"`
+	VisitorTeam      Team   `json:"visitor_team"`
+}
+
+// GetLiveBoxScores fetches the score and clock of every game being played
+// today. The endpoint returns a single unpaginated list.
+func (c *BallDontLieClient) GetLiveBoxScores() ([]LiveBoxScore, error) {
+	headers := map[string]string{
+		"Authorization": c.apiKey,
+	}
+
+	body, err := c.client.Get(c.baseURL+"/box_scores/live", headers)
+	if err != nil {
+		return nil, fmt.Errorf("fetching live box scores: %w", err)
+	}
+
+	var resp listResponse[LiveBoxScore]
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing live box scores response: %w", err)
+	}
+	return resp.Data, nil
+}
+
+// IsFinal reports whether the game is over.
+func (b LiveBoxScore) IsFinal() bool {
+	return b.Status == "Final" || strings.TrimSpace(b.Time) == "Final"
+}
+
+// periodLength is the clock length of period p.
+func periodLength(p int) float64 {
+	if p > 4 {
+		return OvertimeSeconds
+	}
+	return PeriodSeconds
+}
+
+// clock parses the game clock into seconds left in the current period.
+func (b LiveBoxScore) clock() (float64, bool) {
+	t := strings.TrimSpace(b.Time)
+	if strings.HasPrefix(t, "Q") || strings.HasPrefix(t, "OT") {
+		// "Q3 5:32" or "OT1 2:10"
+		if _, rest, ok := strings.Cut(t, " "); ok {
+			t = strings.TrimSpace(rest)
+		}
+	}
+	if mins, secs, ok := strings.Cut(t, ":"); ok {
+		m, err := strconv.Atoi(mins)
+		if err != nil {
+			return 0, false
+		}
+		s, err := strconv.ParseFloat(secs, 64)
+		if err != nil {
+			return 0, false
+		}
+		return float64(m)*60 + s, true
+	}
+	// Under a minute the clock shows tenths only, e.g. "45.2"
+	s, err := strconv.ParseFloat(t, 64)
+	if err != nil {
+		return 0, false
+	}
+	return s, true
+}
+
+// SecondsLeft returns the game time remaining, counting the rest of
+// regulation or, in overtime, the rest of the current overtime. It
+// returns false before tip-off or when the clock can't be read.
+func (b LiveBoxScore) SecondsLeft() (float64, bool) {
+	switch {
+	case b.IsFinal():
+		return 0, true
+	case b.Status == "Halftime" || strings.TrimSpace(b.Time) == "Half":
+		return RegulationSeconds / 2, true
+	case b.Period < 1:
+		return 0, false
+	}
+	clock, ok := b.clock()
+	if !ok || clock < 0 || clock > periodLength(b.Period) {
+		return 0, false
+	}
+	if b.Period >= 4 {
+		return clock, true
+	}
+	return float64(4-b.Period)*PeriodSeconds + clock, true
+}
+
+// ClockRunning reports whether the game clock is mid-period, when the
+// score and clock should keep changing. Between periods and at halftime
+// the box score legitimately stands still.
+func (b LiveBoxScore) ClockRunning() bool {
+	if b.IsFinal() || b.Period < 1 {
+		return false
+	}
+	clock, ok := b.clock()
+	return ok && clock > 0 && clock < periodLength(b.Period)
+}
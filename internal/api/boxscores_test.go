@@ -0,0 +1,62 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestGetLiveBoxScores(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/box_scores/live", func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"data":[{"date":"2026-02-05","status":"3rd Qtr","period":3,"time":"5:32",
+			"home_team_score":71,"visitor_team_score":64,
+			"home_team":{"id":24,"abbreviation":"PHX"},"visitor_team":{"id":10,"abbreviation":"GSW"}}]}`)
+	})
+	c := newTestClient(t, mux)
+
+	boxes, err := c.GetLiveBoxScores()
+	if err != nil || len(boxes) != 1 {
+		t.Fatalf("GetLiveBoxScores = %+v, %v, want one game", boxes, err)
+	}
+	b := boxes[0]
+	if b.HomeTeam.Abbreviation != "PHX" || b.HomeTeamScore != 71 || b.VisitorTeamScore != 64 || b.Period != 3 {
+		t.Errorf("box score = %+v, want PHX up 71-64 in the 3rd", b)
+	}
+	if left, ok := b.SecondsLeft(); !ok || left != PeriodSeconds+5*60+32 {
+		t.Errorf("SecondsLeft = %v, %v, want the 4th plus 5:32", left, ok)
+	}
+}
+
+func TestLiveBoxScoreClock(t *testing.T) {
+	tests := []struct {
+		name    string
+		box     LiveBoxScore
+		left    float64
+		ok      bool
+		running bool
+	}{
+		{"first quarter", LiveBoxScore{Status: "1st Qtr", Period: 1, Time: "10:00"}, 3*PeriodSeconds + 600, true, true},
+		{"prefixed clock", LiveBoxScore{Status: "2nd Qtr", Period: 2, Time: "Q2 0:30"}, 2*PeriodSeconds + 30, true, true},
+		{"tenths", LiveBoxScore{Status: "4th Qtr", Period: 4, Time: "45.2"}, 45.2, true, true},
+		{"end of period", LiveBoxScore{Status: "3rd Qtr", Period: 3, Time: "0:00"}, PeriodSeconds, true, false},
+		{"start of period", LiveBoxScore{Status: "2nd Qtr", Period: 2, Time: "12:00"}, 2*PeriodSeconds + 720, true, false},
+		{"halftime", LiveBoxScore{Status: "Halftime", Period: 2, Time: "Half"}, RegulationSeconds / 2, true, false},
+		{"overtime", LiveBoxScore{Status: "OT", Period: 5, Time: "2:10"}, 130, true, true},
+		{"final", LiveBoxScore{Status: "Final", Period: 4, Time: "Final"}, 0, true, false},
+		{"not started", LiveBoxScore{Status: "7:00 pm ET"}, 0, false, false},
+		{"unreadable clock", LiveBoxScore{Status: "3rd Qtr", Period: 3, Time: "TBD"}, 0, false, false},
+		{"clock past period length", LiveBoxScore{Status: "OT", Period: 5, Time: "7:00"}, 0, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, ok := tt.box.SecondsLeft()
+			if ok != tt.ok || (ok && left != tt.left) {
+				t.Errorf("SecondsLeft = %v, %v, want %v, %v", left, ok, tt.left, tt.ok)
+			}
+			if running := tt.box.ClockRunning(); running != tt.running {
+				t.Errorf("ClockRunning = %v, want %v", running, tt.running)
+			}
+		})
+	}
+}
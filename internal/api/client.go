@@ -1,26 +1,30 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"sync"
 	"time"
+
+	"sports-betting-bot/internal/metrics"
 )
 
 // RateLimitedClient wraps http.Client with rate limiting
 type RateLimitedClient struct {
+	upstream    string // Metrics label, e.g. metrics.UpstreamKalshi
 	client      *http.Client
 	rateLimiter *rateLimiter
 	maxRetries  int
 }
 
 type rateLimiter struct {
-	mu          sync.Mutex
-	tokens      int
-	maxTokens   int
-	refillRate  time.Duration
-	lastRefill  time.Time
+	mu         sync.Mutex
+	tokens     int
+	maxTokens  int
+	refillRate time.Duration
+	lastRefill time.Time
 }
 
 func newRateLimiter(requestsPerMinute int) *rateLimiter {
@@ -63,9 +67,45 @@ func (rl *rateLimiter) wait() {
 	}
 }
 
-// NewRateLimitedClient creates a client limited to requestsPerMinute
-func NewRateLimitedClient(requestsPerMinute int, timeout time.Duration, maxRetries int) *RateLimitedClient {
+// waitWithin is wait, but gives up without taking a token when none will
+// refill within budget.
+func (rl *rateLimiter) waitWithin(budget time.Duration) bool {
+	deadline := time.Now().Add(budget)
+	for {
+		rl.mu.Lock()
+
+		now := time.Now()
+		elapsed := now.Sub(rl.lastRefill)
+		tokensToAdd := int(elapsed / rl.refillRate)
+		if tokensToAdd > 0 {
+			rl.tokens = min(rl.tokens+tokensToAdd, rl.maxTokens)
+			rl.lastRefill = now
+		}
+
+		if rl.tokens > 0 {
+			rl.tokens--
+			rl.mu.Unlock()
+			return true
+		}
+
+		waitTime := rl.refillRate - elapsed
+		rl.mu.Unlock()
+		if now.Add(waitTime).After(deadline) {
+			return false
+		}
+		time.Sleep(waitTime)
+	}
+}
+
+// ErrRateBudget is returned by GetWithin when the rate limiter can't grant
+// a request within its budget.
+var ErrRateBudget = errors.New("rate limit budget exceeded")
+
+// NewRateLimitedClient creates a client limited to requestsPerMinute.
+// upstream labels its latency, 429 and limiter wait metrics.
+func NewRateLimitedClient(upstream string, requestsPerMinute int, timeout time.Duration, maxRetries int) *RateLimitedClient {
 	return &RateLimitedClient{
+		upstream: upstream,
 		client: &http.Client{
 			Timeout: timeout,
 		},
@@ -76,12 +116,29 @@ func NewRateLimitedClient(requestsPerMinute int, timeout time.Duration, maxRetri
 
 // Do executes an HTTP request with rate limiting and retries
 func (c *RateLimitedClient) Do(req *http.Request) (*http.Response, error) {
+	return c.do(req, 0)
+}
+
+// do is Do; a positive budget bounds the rate limiter wait before the
+// first attempt, failing with ErrRateBudget past it.
+func (c *RateLimitedClient) do(req *http.Request, budget time.Duration) (*http.Response, error) {
 	var lastErr error
 
 	for attempt := 0; attempt <= c.maxRetries; attempt++ {
-		c.rateLimiter.wait()
+		waitStart := time.Now()
+		if attempt == 0 && budget > 0 {
+			if !c.rateLimiter.waitWithin(budget) {
+				metrics.RateLimiterWait.Observe(time.Since(waitStart).Seconds(), c.upstream)
+				return nil, ErrRateBudget
+			}
+		} else {
+			c.rateLimiter.wait()
+		}
+		metrics.RateLimiterWait.Observe(time.Since(waitStart).Seconds(), c.upstream)
 
+		reqStart := time.Now()
 		resp, err := c.client.Do(req)
+		metrics.APILatency.Observe(time.Since(reqStart).Seconds(), c.upstream)
 		if err != nil {
 			lastErr = err
 			backoff := time.Duration(1<<attempt) * 100 * time.Millisecond
@@ -91,6 +148,7 @@ func (c *RateLimitedClient) Do(req *http.Request) (*http.Response, error) {
 
 		// Handle rate limit responses (429)
 		if resp.StatusCode == http.StatusTooManyRequests {
+			metrics.APIRateLimited.Inc(c.upstream)
 			resp.Body.Close()
 			lastErr = fmt.Errorf("rate limited (429)")
 			backoff := time.Duration(1<<attempt) * time.Second
@@ -115,6 +173,13 @@ func (c *RateLimitedClient) Do(req *http.Request) (*http.Response, error) {
 
 // Get performs a rate-limited GET request
 func (c *RateLimitedClient) Get(url string, headers map[string]string) ([]byte, error) {
+	return c.GetWithin(url, headers, 0)
+}
+
+// GetWithin is Get, but fails with ErrRateBudget rather than wait longer
+// than budget for the rate limiter (0 = wait as long as it takes).
+// Retries after a failed attempt wait as usual.
+func (c *RateLimitedClient) GetWithin(url string, headers map[string]string, budget time.Duration) ([]byte, error) {
 	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
@@ -124,7 +189,7 @@ func (c *RateLimitedClient) Get(url string, headers map[string]string) ([]byte,
 		req.Header.Set(k, v)
 	}
 
-	resp, err := c.Do(req)
+	resp, err := c.do(req, budget)
 	if err != nil {
 		return nil, err
 	}
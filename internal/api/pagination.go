@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"sports-betting-bot/internal/metrics"
+)
+
+const (
+	pageSize       = 100
+	maxPages       = 50              // Pages one list call follows at most
+	pageRateBudget = 5 * time.Second // Longest a later page waits for the rate limiter
+)
+
+// listResponse is the envelope of every balldontlie list endpoint.
+type listResponse[T any] struct {
+	Data []T  `json:"data"`
+	Meta Meta `json:"meta"`
+}
+
+// cursorPages iterates a balldontlie list endpoint page by page, following
+// meta.next_cursor. It stops early at the client's page cap, or when a page
+// after the first can't get a rate limiter token within the client's page
+// budget, so a busy night can't stall a scan; truncated reports why.
+type cursorPages[T any] struct {
+	c      *BallDontLieClient
+	url    string
+	what   string // Endpoint name for errors and logs, e.g. "player props"
+	cursor int
+	pages  int
+	done   bool
+	err    error
+
+	truncated string // "page_cap" or "rate_budget" when stopped early
+}
+
+func newCursorPages[T any](c *BallDontLieClient, url, what string) *cursorPages[T] {
+	return &cursorPages[T]{c: c, url: url, what: what}
+}
+
+// Next fetches the next page. It returns false once the last page has
+// been read, the iterator stopped early, or a request failed; see Err.
+func (p *cursorPages[T]) Next() ([]T, bool) {
+	if p.done {
+		return nil, false
+	}
+	if p.pages >= p.c.maxPages {
+		p.done = true
+		p.truncated = "page_cap"
+		return nil, false
+	}
+
+	url := fmt.Sprintf("%s&per_page=%d", p.url, pageSize)
+	if p.cursor > 0 {
+		url = fmt.Sprintf("%s&cursor=%d", url, p.cursor)
+	}
+	var budget time.Duration
+	if p.pages > 0 {
+		budget = p.c.pageBudget
+	}
+	headers := map[string]string{
+		"Authorization": p.c.apiKey,
+	}
+
+	body, err := p.c.client.GetWithin(url, headers, budget)
+	if errors.Is(err, ErrRateBudget) {
+		p.done = true
+		p.truncated = "rate_budget"
+		return nil, false
+	}
+	if err != nil {
+		p.done, p.err = true, fmt.Errorf("fetching %s: %w", p.what, err)
+		return nil, false
+	}
+	var resp listResponse[T]
+	if err := json.Unmarshal(body, &resp); err != nil {
+		p.done, p.err = true, fmt.Errorf("parsing %s response: %w", p.what, err)
+		return nil, false
+	}
+
+	p.pages++
+	p.cursor = resp.Meta.NextCursor
+	p.done = p.cursor == 0
+	return resp.Data, true
+}
+
+// Err returns the error that ended iteration, if any.
+func (p *cursorPages[T]) Err() error {
+	return p.err
+}
+
+// listAll reads every page of url. A list cut short by the page cap or
+// rate budget is logged and counted, and what was read is returned.
+func listAll[T any](c *BallDontLieClient, url, what string) ([]T, error) {
+	pages := newCursorPages[T](c, url, what)
+	var all []T
+	for {
+		data, ok := pages.Next()
+		if !ok {
+			break
+		}
+		all = append(all, data...)
+	}
+	if err := pages.Err(); err != nil {
+		return nil, err
+	}
+	if pages.truncated != "" {
+		metrics.APIListTruncated.Inc(metrics.UpstreamBallDontLie, pages.truncated)
+		log.Printf("WARN %s: stopped after %d pages (%s), %d records read", what, pages.pages, pages.truncated, len(all))
+	}
+	return all, nil
+}
+
+// InvalidRecord is a balldontlie record with a numeric field sent as a
+// string that doesn't parse.
+type InvalidRecord struct {
+	Kind  string // "player_prop" or "odds"
+	ID    int
+	Field string
+	Value string
+}
+
+func (e *InvalidRecord) Error() string {
+	return fmt.Sprintf("%s %d: unparseable %s %q", e.Kind, e.ID, e.Field, e.Value)
+}
+
+// parseNumber parses a numeric string field, reporting it as an
+// InvalidRecord when it doesn't parse.
+func parseNumber(kind string, id int, field, value string) (float64, error) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, &InvalidRecord{Kind: kind, ID: id, Field: field, Value: value}
+	}
+	return f, nil
+}
+
+// reportInvalid logs and counts a record or market dropped by validation.
+func reportInvalid(err error) {
+	var invalid *InvalidRecord
+	if errors.As(err, &invalid) {
+		metrics.APIInvalidRecords.Inc(invalid.Kind)
+	}
+	log.Printf("WARN invalid balldontlie record: %v", err)
+}
+
+// validProps returns the props whose line parses, reporting the rest.
+func validProps(props []PlayerProp) []PlayerProp {
+	valid := props[:0]
+	for _, p := range props {
+		if err := p.Validate(); err != nil {
+			reportInvalid(err)
+			continue
+		}
+		valid = append(valid, p)
+	}
+	return valid
+}
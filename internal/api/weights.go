@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// VendorWeights maps a market type ("moneyline", "spread", "total",
+// "prop_points", ...) to per-vendor consensus weights. It is the format of
+// the weights file written by cmd/fitweights.
+type VendorWeights map[string]map[string]float64
+
+// fittedWeights replaces the compiled-in defaults for the markets and
+// vendors it lists. Set once at startup, before any scan.
+var fittedWeights VendorWeights
+
+// LoadVendorWeights reads and validates a weights file.
+func LoadVendorWeights(path string) (VendorWeights, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading vendor weights: %w", err)
+	}
+	var weights VendorWeights
+	if err := json.Unmarshal(data, &weights); err != nil {
+		return nil, fmt.Errorf("parsing vendor weights %s: %w", path, err)
+	}
+	for market, vendors := range weights {
+		for vendor, w := range vendors {
+			if w <= 0 || math.IsInf(w, 0) || math.IsNaN(w) {
+				return nil, fmt.Errorf("vendor weight %s/%s must be positive, got %v", market, vendor, w)
+			}
+		}
+	}
+	return weights, nil
+}
+
+// SetVendorWeights installs fitted weights in place of the compiled-in
+// defaults; nil restores the defaults. Not safe to call during a scan.
+func SetVendorWeights(weights VendorWeights) {
+	fittedWeights = weights
+}
+
+// VendorWeight returns a vendor's consensus weight for a market type. A
+// market or vendor missing from the fitted weights falls back to
+// DefaultVendorWeight.
+func VendorWeight(marketType, vendorName string) float64 {
+	if w, ok := fittedWeights[marketType][vendorName]; ok {
+		return w
+	}
+	return DefaultVendorWeight(marketType, vendorName)
+}
+
+// DefaultVendorWeight returns the compiled-in weight for a market type:
+// VendorPropWeight for "prop_" markets, VendorGameWeight for the rest.
+func DefaultVendorWeight(marketType, vendorName string) float64 {
+	if strings.HasPrefix(marketType, "prop_") {
+		return VendorPropWeight(vendorName)
+	}
+	return VendorGameWeight(vendorName)
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeWeights(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vendor_weights.json")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVendorWeightFallsBackToDefaults(t *testing.T) {
+	weights, err := LoadVendorWeights(writeWeights(t, `{
+		"moneyline": {"BetMGM": 1.8},
+		"prop_points": {"FanDuel": 0.9}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetVendorWeights(weights)
+	t.Cleanup(func() { SetVendorWeights(nil) })
+
+	tests := []struct {
+		market, vendor string
+		want           float64
+	}{
+		{"moneyline", "BetMGM", 1.8},      // Fitted
+		{"moneyline", "DraftKings", 1.5},  // Vendor not fitted: game default
+		{"spread", "BetMGM", 0.7},         // Market not fitted: game default
+		{"prop_points", "FanDuel", 0.9},   // Fitted
+		{"prop_rebounds", "FanDuel", 1.5}, // Market not fitted: prop default
+		{"prop_points", "Caesars", 1.0},   // Unlisted everywhere
+	}
+	for _, tt := range tests {
+		if got := VendorWeight(tt.market, tt.vendor); got != tt.want {
+			t.Errorf("VendorWeight(%s, %s) = %v, want %v", tt.market, tt.vendor, got, tt.want)
+		}
+	}
+
+	SetVendorWeights(nil)
+	if got := VendorWeight("moneyline", "BetMGM"); got != 0.7 {
+		t.Errorf("after reset, VendorWeight(moneyline, BetMGM) = %v, want default 0.7", got)
+	}
+}
+
+func TestLoadVendorWeightsRejectsBadFiles(t *testing.T) {
+	for name, body := range map[string]string{
+		"malformed": `{"moneyline": `,
+		"zero":      `{"moneyline": {"BetMGM": 0}}`,
+		"negative":  `{"total": {"FanDuel": -1.2}}`,
+	} {
+		if _, err := LoadVendorWeights(writeWeights(t, body)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+	if _, err := LoadVendorWeights(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("missing file: expected an error")
+	}
+}
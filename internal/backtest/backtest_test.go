@@ -0,0 +1,271 @@
+package backtest
+
+import (
+	"io"
+	"log"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/analysis"
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/snapshot"
+)
+
+func mustRecord(t *testing.T, at time.Time, kind snapshot.Kind, v any) snapshot.Record {
+	t.Helper()
+	rec, err := snapshot.NewRecord(at, kind, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+// testGameOdds returns a game where six books price PHX at ~65% and
+// Kalshi lists it at 50¢.
+func testGameOdds(scanTime time.Time) []api.GameOdds {
+	updated := scanTime.Add(-time.Minute).Format(time.RFC3339)
+	vendors := []api.Vendor{{
+		Name:      "Kalshi",
+		Moneyline: &api.Moneyline{Home: 50, Away: 50},
+		UpdatedAt: updated,
+	}}
+	for _, name := range []string{"DraftKings", "FanDuel", "Bet365", "Caesars", "BetRivers", "PointsBet"} {
+		vendors = append(vendors, api.Vendor{
+			Name:      name,
+			Moneyline: &api.Moneyline{Home: -200, Away: 170},
+			UpdatedAt: updated,
+		})
+	}
+	return []api.GameOdds{{
+		GameID: 1,
+		Game: api.Game{
+			ID:          1,
+			Date:        "2026-02-05",
+			DateTime:    scanTime.Add(3 * time.Hour).Format(time.RFC3339),
+			Status:      "scheduled",
+			HomeTeam:    api.Team{Abbreviation: "PHX"},
+			VisitorTeam: api.Team{Abbreviation: "GSW"},
+		},
+		Vendors: vendors,
+	}}
+}
+
+func testConfig() Config {
+	cfg := config.Config{
+		EVThreshold:   0.03,
+		KellyFraction: 0.25,
+		PollInterval:  config.DefaultPollInterval,
+		MaxOddsAgeSec: config.DefaultMaxOddsAgeSec,
+	}
+	return Config{
+		Engine: cfg,
+		Analysis: analysis.Config{
+			EVThreshold:   cfg.EVThreshold,
+			KellyFraction: cfg.KellyFraction,
+			MinBookCount:  config.DefaultMinBookCount,
+		},
+		Exec: kalshi.OrderConfig{
+			MaxSlippagePct:        0.02,
+			MinLiquidityContracts: 10,
+		},
+		Bankroll: 1000,
+	}
+}
+
+func quietLogs(t *testing.T) {
+	t.Helper()
+	prevLog, prevSlog := log.Writer(), slog.Default()
+	log.SetOutput(io.Discard)
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() {
+		log.SetOutput(prevLog)
+		slog.SetDefault(prevSlog)
+	})
+}
+
+func TestRunFillsAndSettles(t *testing.T) {
+	quietLogs(t)
+
+	// Tuesday 18:00 UTC, outside the Thursday maintenance window
+	scanTime := time.Date(2026, 2, 3, 18, 0, 0, 0, time.UTC)
+	ticker := "KXNBAGAME-26FEB05GSWPHX"
+	book := kalshi.OrderBookResponse{OrderBook: kalshi.OrderBookInner{
+		Yes: [][2]int{{48, 500}},
+		No:  [][2]int{{50, 500}}, // YES asks at 50¢
+	}}
+	closeBook := kalshi.OrderBookResponse{OrderBook: kalshi.OrderBookInner{
+		Yes: [][2]int{{58, 500}},
+		No:  [][2]int{{40, 500}}, // YES asks at 60¢ by close
+	}}
+
+	bookRec := mustRecord(t, scanTime.Add(time.Second), snapshot.KindOrderBook, book)
+	bookRec.Ticker = ticker
+	closeRec := mustRecord(t, scanTime.Add(2*time.Hour), snapshot.KindOrderBook, closeBook)
+	closeRec.Ticker = ticker
+	resultRec := mustRecord(t, scanTime.Add(6*time.Hour), snapshot.KindResult, snapshot.MarketResult{Result: "yes"})
+	resultRec.Ticker = ticker
+
+	secondScan := scanTime.Add(2*time.Hour - time.Second)
+	records := []snapshot.Record{
+		mustRecord(t, scanTime, snapshot.KindOdds, testGameOdds(scanTime)),
+		bookRec,
+		mustRecord(t, secondScan, snapshot.KindOdds, testGameOdds(secondScan)),
+		closeRec,
+		resultRec,
+	}
+
+	report, err := Run(records, testConfig())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if report.Scans != 2 {
+		t.Errorf("Scans = %d, want 2", report.Scans)
+	}
+	if len(report.ByMarket) != 1 || report.ByMarket[0].MarketType != "moneyline" {
+		t.Fatalf("ByMarket = %+v, want one moneyline row", report.ByMarket)
+	}
+
+	ml := report.ByMarket[0]
+	if ml.Fills != 1 || ml.Settled != 1 || ml.Wins != 1 {
+		t.Errorf("fills/settled/wins = %d/%d/%d, want 1/1/1", ml.Fills, ml.Settled, ml.Wins)
+	}
+	if ml.PnL <= 0 {
+		t.Errorf("PnL = %.2f, want positive for a winning 50¢ YES", ml.PnL)
+	}
+	if math.Abs(ml.AvgCLV()-10) > 1e-9 {
+		t.Errorf("AvgCLV = %.2f, want 10 (closed at 60¢, entered at 50¢)", ml.AvgCLV())
+	}
+	if math.Abs(report.FinalEquity-(report.StartingBankroll+ml.PnL)) > 0.01 {
+		t.Errorf("FinalEquity = %.2f, want bankroll + PnL = %.2f",
+			report.FinalEquity, report.StartingBankroll+ml.PnL)
+	}
+}
+
+func TestRunRefundsVoidedMarket(t *testing.T) {
+	quietLogs(t)
+
+	scanTime := time.Date(2026, 2, 3, 18, 0, 0, 0, time.UTC)
+	// A different game date from the other replays, so the executor's
+	// duplicate guard doesn't hold back this ticker
+	games := testGameOdds(scanTime)
+	games[0].Game.Date = "2026-02-06"
+	ticker := "KXNBAGAME-26FEB06GSWPHX"
+	book := kalshi.OrderBookResponse{OrderBook: kalshi.OrderBookInner{
+		Yes: [][2]int{{48, 500}},
+		No:  [][2]int{{50, 500}}, // YES asks at 50¢
+	}}
+	bookRec := mustRecord(t, scanTime.Add(time.Second), snapshot.KindOrderBook, book)
+	bookRec.Ticker = ticker
+	resultRec := mustRecord(t, scanTime.Add(6*time.Hour), snapshot.KindResult, snapshot.MarketResult{Result: "void"})
+	resultRec.Ticker = ticker
+
+	records := []snapshot.Record{
+		mustRecord(t, scanTime, snapshot.KindOdds, games),
+		bookRec,
+		resultRec,
+	}
+
+	report, err := Run(records, testConfig())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Total.Fills != 1 || report.Total.Voided != 1 || report.Total.Settled != 0 || report.Total.PnL != 0 {
+		t.Errorf("Total = %+v, want one voided fill and nothing settled", report.Total)
+	}
+	if math.Abs(report.FinalEquity-report.StartingBankroll) > 1e-9 {
+		t.Errorf("FinalEquity = %.4f, want the bankroll %.2f back after the refund",
+			report.FinalEquity, report.StartingBankroll)
+	}
+}
+
+func TestRunSkipsTickersWithoutBook(t *testing.T) {
+	quietLogs(t)
+
+	scanTime := time.Date(2026, 2, 3, 18, 0, 0, 0, time.UTC)
+	records := []snapshot.Record{
+		mustRecord(t, scanTime, snapshot.KindOdds, testGameOdds(scanTime)),
+	}
+
+	report, err := Run(records, testConfig())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if report.Total.Fills != 0 {
+		t.Errorf("Fills = %d, want 0 with no recorded order book", report.Total.Fills)
+	}
+	if report.FinalEquity != report.StartingBankroll {
+		t.Errorf("FinalEquity = %.2f, want unchanged %.2f", report.FinalEquity, report.StartingBankroll)
+	}
+}
+
+func TestSimExchangeNetsOppositeSide(t *testing.T) {
+	sim := NewSimExchange(100, time.Now)
+	sim.SetOrderBook(&kalshi.OrderBookResponse{
+		Ticker: "T",
+		OrderBook: kalshi.OrderBookInner{
+			Yes: [][2]int{{40, 100}}, // NO asks at 60¢
+			No:  [][2]int{{60, 100}}, // YES asks at 40¢
+		},
+	})
+	cfg := kalshi.OrderConfig{MaxSlippagePct: 0.02, MinLiquidityContracts: 1}
+
+	if _, err := sim.PlaceOrder("T", kalshi.SideYes, kalshi.ActionBuy, 10, cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sim.PlaceOrder("T", kalshi.SideNo, kalshi.ActionBuy, 10, cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	positions, _ := sim.GetPositions()
+	if len(positions) != 0 {
+		t.Errorf("positions = %+v, want flat after netting", positions)
+	}
+
+	// 10 pairs cost $10 plus fees and pay back $10 immediately
+	var fees float64
+	for _, f := range sim.Fills() {
+		fees += f.FeeCents / 100
+	}
+	balance, _ := sim.GetBalanceDollars()
+	if math.Abs(balance-(100-fees)) > 1e-9 {
+		t.Errorf("balance = %.4f, want %.4f", balance, 100-fees)
+	}
+}
+
+func TestMaxDrawdown(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   float64
+	}{
+		{nil, 0},
+		{[]float64{100, 110, 120}, 0},
+		{[]float64{100, 80, 120, 90, 130}, 30},
+		{[]float64{0, -5, 3, -10}, 13},
+	}
+	for _, tt := range tests {
+		if got := maxDrawdown(tt.values); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("maxDrawdown(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestMarketTypeForTicker(t *testing.T) {
+	tests := map[string]string{
+		"KXNBAGAME-26FEB05GSWPHX":               "moneyline",
+		"KXNBASPREAD-26FEB05GSWPHX":             "spread",
+		"KXNBATOTAL-26FEB05GSWPHX":              "total",
+		"KXNBAPTS-26FEB05GSWPHX-GSWSCURRY30-25": "prop_points",
+		"KXNBAREB-26FEB05GSWPHX-GSWDGREEN23-10": "prop_rebounds",
+		"UNKNOWN-1":                             "other",
+	}
+	for ticker, want := range tests {
+		if got := marketTypeForTicker(ticker); got != want {
+			t.Errorf("marketTypeForTicker(%q) = %q, want %q", ticker, got, want)
+		}
+	}
+}
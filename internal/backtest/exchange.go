@@ -0,0 +1,269 @@
+package backtest
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"sports-betting-bot/internal/kalshi"
+)
+
+// Fill is one simulated execution against a recorded order book.
+type Fill struct {
+	Time       time.Time
+	Ticker     string
+	Side       kalshi.Side
+	Contracts  int
+	PriceCents float64 // Average fill price in cents
+	FeeCents   float64 // Taker fee paid on the whole fill, in cents
+}
+
+// Cost returns the total cash spent on the fill in dollars, including fees.
+func (f Fill) Cost() float64 {
+	return (f.PriceCents*float64(f.Contracts) + f.FeeCents) / 100
+}
+
+// SimExchange implements engine.Exchange against recorded Kalshi data.
+// Orders fill immediately at the recorded book's prices using the same
+// PlanOrder safeguards as the live client; the book is not depleted, so
+// repeated orders see the same depth until the next snapshot replaces it.
+type SimExchange struct {
+	mu          sync.Mutex
+	cashCents   float64
+	books       map[string]*kalshi.OrderBookResponse
+	propMarkets map[string][]kalshi.PlayerPropMarket
+	positions   map[string]int // ticker -> contracts; positive = yes, negative = no
+	results     map[string]kalshi.Side
+	fills       []Fill
+	now         func() time.Time
+}
+
+// NewSimExchange creates a simulated exchange with the given starting balance.
+func NewSimExchange(bankroll float64, now func() time.Time) *SimExchange {
+	return &SimExchange{
+		cashCents:   bankroll * 100,
+		books:       make(map[string]*kalshi.OrderBookResponse),
+		propMarkets: make(map[string][]kalshi.PlayerPropMarket),
+		positions:   make(map[string]int),
+		results:     make(map[string]kalshi.Side),
+		now:         now,
+	}
+}
+
+// SetOrderBook replaces the current book for a ticker.
+func (s *SimExchange) SetOrderBook(book *kalshi.OrderBookResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.books[book.Ticker] = book
+}
+
+// SetPlayerPropMarkets replaces the current player prop market listing.
+func (s *SimExchange) SetPlayerPropMarkets(markets map[string][]kalshi.PlayerPropMarket) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.propMarkets = markets
+}
+
+// Settle pays out a finalized market: $1 per contract on the winning side.
+// A voided market refunds the cost and fees of every fill on it, less the
+// $1 already paid back for each netted YES/NO pair, so it realizes nothing.
+// Returns the payout in dollars.
+func (s *SimExchange) Settle(ticker string, result kalshi.Side) float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pos := s.positions[ticker]
+	delete(s.positions, ticker)
+	s.results[ticker] = result
+
+	if result == resultVoid {
+		var costCents float64
+		var bought int
+		for _, f := range s.fills {
+			if f.Ticker == ticker {
+				costCents += f.Cost() * 100
+				bought += f.Contracts
+			}
+		}
+		netted := (bought - abs(pos)) / 2
+		refund := costCents - float64(netted)*100
+		s.cashCents += refund
+		return refund / 100
+	}
+
+	won := (pos > 0 && result == kalshi.SideYes) || (pos < 0 && result == kalshi.SideNo)
+	if !won {
+		return 0
+	}
+	payout := float64(abs(pos))
+	s.cashCents += payout * 100
+	return payout
+}
+
+// Equity returns cash plus open positions marked at the best recorded bid
+// for their side. Positions on tickers with no bid are marked at zero.
+func (s *SimExchange) Equity() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	equity := s.cashCents
+	for ticker, pos := range s.positions {
+		book, ok := s.books[ticker]
+		if !ok || pos == 0 {
+			continue
+		}
+		side := kalshi.SideYes
+		if pos < 0 {
+			side = kalshi.SideNo
+		}
+		bid := kalshi.CheckLiquidity(book, side, kalshi.ActionSell, 1).BestPrice
+		equity += float64(bid * abs(pos))
+	}
+	return equity / 100
+}
+
+// Fills returns every simulated execution so far.
+func (s *SimExchange) Fills() []Fill {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Fill(nil), s.fills...)
+}
+
+// GetBalanceDollars returns the simulated cash balance.
+func (s *SimExchange) GetBalanceDollars() (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cashCents / 100, nil
+}
+
+// GetPlayerPropMarkets returns the most recently recorded prop market listing.
+// The date is ignored; the recording already reflects the scan's date.
+func (s *SimExchange) GetPlayerPropMarkets(date time.Time) (map[string][]kalshi.PlayerPropMarket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.propMarkets, nil
+}
+
+// GetOrderBook returns the most recently recorded book for ticker.
+func (s *SimExchange) GetOrderBook(ticker string) (*kalshi.OrderBookResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	book, ok := s.books[ticker]
+	if !ok {
+		return nil, fmt.Errorf("no recorded order book for %s", ticker)
+	}
+	return book, nil
+}
+
+// GetMarket reports a ticker as finalized once Settle has been called for it.
+func (s *SimExchange) GetMarket(ticker string) (*kalshi.Market, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if result, ok := s.results[ticker]; ok {
+		return &kalshi.Market{Ticker: ticker, Status: "finalized", Result: string(result)}, nil
+	}
+	return &kalshi.Market{Ticker: ticker, Status: "active"}, nil
+}
+
+// GetPositions returns the simulated open positions.
+func (s *SimExchange) GetPositions() ([]kalshi.MarketPosition, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []kalshi.MarketPosition
+	for ticker, pos := range s.positions {
+		if pos != 0 {
+			out = append(out, kalshi.MarketPosition{Ticker: ticker, Position: pos})
+		}
+	}
+	return out, nil
+}
+
+// GetOrders returns nothing: simulated orders fill or fail within
+// PlaceOrder, so none is ever left in flight.
+func (s *SimExchange) GetOrders(ticker string) ([]kalshi.Order, error) {
+	return nil, nil
+}
+
+// PlaceOrder fills a buy against the recorded book. Sells are rejected;
+// the engine only opens positions.
+func (s *SimExchange) PlaceOrder(ticker string, side kalshi.Side, action kalshi.OrderAction, contracts int, config kalshi.OrderConfig) (*kalshi.ExecutionResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if action != kalshi.ActionBuy {
+		return &kalshi.ExecutionResult{
+			Success:            false,
+			RequestedContracts: contracts,
+			RejectionReason:    "simulated exchange only supports buys",
+		}, nil
+	}
+
+	book, ok := s.books[ticker]
+	if !ok {
+		return &kalshi.ExecutionResult{
+			Success:            false,
+			RequestedContracts: contracts,
+			RejectionReason:    fmt.Sprintf("failed to fetch order book: no recorded order book for %s", ticker),
+		}, nil
+	}
+
+	plan, reason := kalshi.PlanOrder(book, side, action, contracts, config)
+	if reason != "" {
+		return &kalshi.ExecutionResult{
+			Success:            false,
+			RequestedContracts: plan.Contracts,
+			RejectionReason:    reason,
+		}, nil
+	}
+
+	contracts = plan.Contracts
+	avgPrice := plan.Slippage.AverageFillPrice
+	fee := kalshi.TakerFee(avgPrice/100) * 100 * float64(contracts)
+	cost := avgPrice*float64(contracts) + fee
+	if cost > s.cashCents {
+		return &kalshi.ExecutionResult{
+			Success:            false,
+			RequestedContracts: contracts,
+			RejectionReason:    fmt.Sprintf("insufficient balance: need $%.2f, have $%.2f", cost/100, s.cashCents/100),
+		}, nil
+	}
+
+	s.cashCents -= cost
+
+	// Buying the side opposite an open position nets out on Kalshi:
+	// each matched YES/NO pair is worth $1 immediately.
+	signed := contracts
+	if side == kalshi.SideNo {
+		signed = -contracts
+	}
+	prev := s.positions[ticker]
+	if prev != 0 && (prev > 0) != (signed > 0) {
+		netted := min(abs(prev), contracts)
+		s.cashCents += float64(netted) * 100
+	}
+	s.positions[ticker] = prev + signed
+	s.fills = append(s.fills, Fill{
+		Time:       s.now(),
+		Ticker:     ticker,
+		Side:       side,
+		Contracts:  contracts,
+		PriceCents: avgPrice,
+		FeeCents:   fee,
+	})
+
+	return &kalshi.ExecutionResult{
+		Success:            true,
+		OrderID:            fmt.Sprintf("sim-%d", len(s.fills)),
+		RequestedContracts: contracts,
+		FilledContracts:    contracts,
+		AveragePrice:       avgPrice,
+		TotalCost:          int64(float64(contracts) * avgPrice),
+	}, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,169 @@
+package backtest
+
+import (
+	"fmt"
+	"time"
+
+	"sports-betting-bot/internal/alerts"
+	"sports-betting-bot/internal/analysis"
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/engine"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/snapshot"
+)
+
+// Config controls a replay. Engine, Analysis and Exec are passed to
+// engine.New unchanged, so the parameters under test (EV threshold, Kelly
+// fraction, slippage limits) behave exactly as they do live.
+type Config struct {
+	Engine   config.Config
+	Analysis analysis.Config
+	Exec     kalshi.OrderConfig
+	Bankroll float64 // Starting simulated balance in dollars
+}
+
+// replaySource implements engine.OddsProvider from the most recently
+// applied snapshot records.
+type replaySource struct {
+	odds  []api.GameOdds
+	props map[int][]api.PlayerProp
+	names map[int]string
+}
+
+func (r *replaySource) GetTodaysOdds() ([]api.GameOdds, error) {
+	return r.odds, nil
+}
+
+func (r *replaySource) GetPlayerProps(gameID int) ([]api.PlayerProp, error) {
+	return r.props[gameID], nil
+}
+
+func (r *replaySource) GetPlayerNames(playerIDs []int) map[int]string {
+	result := make(map[int]string)
+	for _, id := range playerIDs {
+		if name, ok := r.names[id]; ok {
+			result[id] = name
+		}
+	}
+	return result
+}
+
+// Run replays records through engine.Scan against a SimExchange.
+// Records must be in time order (snapshot.ReadPath returns them sorted).
+// Each odds record opens a scan cycle; the scan runs once every record up to
+// the next odds or result record has been applied, so props and books
+// captured during the live scan are visible to the replayed one.
+func Run(records []snapshot.Record, cfg Config) (*Report, error) {
+	var now time.Time
+	clock := func() time.Time { return now }
+
+	cfg.Exec.DryRun = false
+	sim := NewSimExchange(cfg.Bankroll, clock)
+	src := &replaySource{
+		props: make(map[int][]api.PlayerProp),
+		names: make(map[int]string),
+	}
+
+	eng := engine.New(src, sim, alerts.NewNotifier(config.DefaultAlertCooldown), nil,
+		cfg.Engine, cfg.Analysis, cfg.Exec)
+	eng.SetClock(clock)
+
+	rep := newReport(cfg.Bankroll)
+	closing := make(map[string]*kalshi.OrderBookResponse)
+	results := make(map[string]kalshi.Side)
+
+	pending := false
+	var scanTime time.Time
+	flush := func() {
+		if !pending {
+			return
+		}
+		now = scanTime
+		eng.Scan()
+		rep.Scans++
+		rep.addEquity(now, sim.Equity())
+		pending = false
+	}
+
+	for _, rec := range records {
+		switch rec.Kind {
+		case snapshot.KindOdds:
+			flush()
+			var odds []api.GameOdds
+			if err := rec.Decode(&odds); err != nil {
+				return nil, err
+			}
+			src.odds = odds
+			src.props = make(map[int][]api.PlayerProp)
+			pending = true
+			scanTime = rec.Time
+
+		case snapshot.KindPlayerProps:
+			var props []api.PlayerProp
+			if err := rec.Decode(&props); err != nil {
+				return nil, err
+			}
+			src.props[rec.GameID] = props
+
+		case snapshot.KindPlayerNames:
+			var names map[int]string
+			if err := rec.Decode(&names); err != nil {
+				return nil, err
+			}
+			for id, name := range names {
+				src.names[id] = name
+			}
+
+		case snapshot.KindPropMarkets:
+			var markets map[string][]kalshi.PlayerPropMarket
+			if err := rec.Decode(&markets); err != nil {
+				return nil, err
+			}
+			sim.SetPlayerPropMarkets(markets)
+
+		case snapshot.KindOrderBook:
+			var book kalshi.OrderBookResponse
+			if err := rec.Decode(&book); err != nil {
+				return nil, err
+			}
+			book.Ticker = rec.Ticker
+			sim.SetOrderBook(&book)
+			if _, settled := results[rec.Ticker]; !settled {
+				closing[rec.Ticker] = &book
+			}
+
+		case snapshot.KindResult:
+			flush()
+			var res snapshot.MarketResult
+			if err := rec.Decode(&res); err != nil {
+				return nil, err
+			}
+			side, err := parseResult(res.Result)
+			if err != nil {
+				return nil, fmt.Errorf("result for %s: %w", rec.Ticker, err)
+			}
+			results[rec.Ticker] = side
+			now = rec.Time
+			sim.Settle(rec.Ticker, side)
+			rep.addEquity(now, sim.Equity())
+		}
+	}
+	flush()
+
+	rep.finish(sim.Fills(), results, closing, sim.Equity())
+	return rep, nil
+}
+
+// resultVoid is a voided market's result. It is not a side, so no fill
+// wins; SimExchange.Settle refunds the fills instead.
+const resultVoid kalshi.Side = "void"
+
+func parseResult(s string) (kalshi.Side, error) {
+	switch kalshi.Side(s) {
+	case kalshi.SideYes, kalshi.SideNo, resultVoid:
+		return kalshi.Side(s), nil
+	default:
+		return "", fmt.Errorf("unknown market result %q", s)
+	}
+}
@@ -0,0 +1,217 @@
+package backtest
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"sports-betting-bot/internal/kalshi"
+)
+
+// MarketStats summarizes simulated fills for one market type.
+type MarketStats struct {
+	MarketType  string
+	Fills       int     // All fills, settled or not
+	Settled     int     // Fills whose market has a recorded yes or no result
+	Voided      int     // Fills refunded by a voided market
+	Wins        int     // Settled fills on the winning side
+	Staked      float64 // Dollars spent on settled fills, including fees
+	PnL         float64 // Realized P&L on settled fills, in dollars
+	CLVCents    float64 // Sum of closing price minus entry price, per fill
+	CLVCount    int     // Fills with a recorded closing book
+	MaxDrawdown float64 // Largest peak-to-trough drop in cumulative realized P&L
+}
+
+// HitRate returns the fraction of settled fills that won.
+func (m MarketStats) HitRate() float64 {
+	if m.Settled == 0 {
+		return 0
+	}
+	return float64(m.Wins) / float64(m.Settled)
+}
+
+// ROI returns realized P&L as a fraction of the amount staked.
+func (m MarketStats) ROI() float64 {
+	if m.Staked == 0 {
+		return 0
+	}
+	return m.PnL / m.Staked
+}
+
+// AvgCLV returns the mean closing-line value in cents per contract.
+// Positive means entries beat the last recorded price before close.
+func (m MarketStats) AvgCLV() float64 {
+	if m.CLVCount == 0 {
+		return 0
+	}
+	return m.CLVCents / float64(m.CLVCount)
+}
+
+// EquityPoint is the simulated account value after a scan or settlement.
+type EquityPoint struct {
+	Time   time.Time
+	Equity float64
+}
+
+// Report is the outcome of a replay.
+type Report struct {
+	StartingBankroll float64
+	FinalEquity      float64
+	Scans            int
+	Equity           []EquityPoint
+	MaxDrawdown      float64 // Peak-to-trough drop in marked equity, in dollars
+	ByMarket         []MarketStats
+	Total            MarketStats
+}
+
+func newReport(bankroll float64) *Report {
+	return &Report{StartingBankroll: bankroll}
+}
+
+func (r *Report) addEquity(t time.Time, equity float64) {
+	r.Equity = append(r.Equity, EquityPoint{Time: t, Equity: equity})
+}
+
+// finish attributes each fill to its market type and computes the summary.
+// Fills are scored independently: a fill wins if its side matches the result.
+// Fills on a voided market were refunded and are left out of the settled stats.
+func (r *Report) finish(fills []Fill, results map[string]kalshi.Side, closing map[string]*kalshi.OrderBookResponse, finalEquity float64) {
+	r.FinalEquity = finalEquity
+	r.MaxDrawdown = maxDrawdown(equityValues(r.Equity, r.StartingBankroll))
+
+	byType := make(map[string]*MarketStats)
+	cumPnL := make(map[string][]float64)
+	total := &MarketStats{MarketType: "all"}
+	var totalCum []float64
+
+	for _, f := range fills {
+		mt := marketTypeForTicker(f.Ticker)
+		stats, ok := byType[mt]
+		if !ok {
+			stats = &MarketStats{MarketType: mt}
+			byType[mt] = stats
+		}
+
+		for _, s := range []*MarketStats{stats, total} {
+			s.Fills++
+			if book, ok := closing[f.Ticker]; ok {
+				closePrice := kalshi.CheckLiquidity(book, f.Side, kalshi.ActionBuy, 1).BestPrice
+				if closePrice > 0 {
+					s.CLVCents += float64(closePrice) - f.PriceCents
+					s.CLVCount++
+				}
+			}
+		}
+
+		result, settled := results[f.Ticker]
+		if !settled {
+			continue
+		}
+		if result == resultVoid {
+			stats.Voided++
+			total.Voided++
+			continue
+		}
+		pnl := -f.Cost()
+		won := result == f.Side
+		if won {
+			pnl += float64(f.Contracts)
+		}
+		for _, s := range []*MarketStats{stats, total} {
+			s.Settled++
+			s.Staked += f.Cost()
+			s.PnL += pnl
+			if won {
+				s.Wins++
+			}
+		}
+		cumPnL[mt] = append(cumPnL[mt], last(cumPnL[mt])+pnl)
+		totalCum = append(totalCum, last(totalCum)+pnl)
+	}
+
+	for mt, stats := range byType {
+		stats.MaxDrawdown = maxDrawdown(append([]float64{0}, cumPnL[mt]...))
+		r.ByMarket = append(r.ByMarket, *stats)
+	}
+	sort.Slice(r.ByMarket, func(i, j int) bool {
+		return r.ByMarket[i].MarketType < r.ByMarket[j].MarketType
+	})
+
+	total.MaxDrawdown = maxDrawdown(append([]float64{0}, totalCum...))
+	r.Total = *total
+}
+
+// WriteText writes a human-readable summary table.
+func (r *Report) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "Scans: %d  Bankroll: $%.2f -> $%.2f  Max drawdown: $%.2f\n\n",
+		r.Scans, r.StartingBankroll, r.FinalEquity, r.MaxDrawdown)
+
+	fmt.Fprintf(w, "%-16s %6s %7s %6s %8s %10s %10s %8s %9s %9s\n",
+		"MARKET", "FILLS", "SETTLED", "VOIDED", "HIT%", "STAKED", "P&L", "ROI%", "CLV(¢)", "MAXDD")
+	fmt.Fprintln(w, strings.Repeat("-", 99))
+	for _, m := range slices.Concat(r.ByMarket, []MarketStats{r.Total}) {
+		fmt.Fprintf(w, "%-16s %6d %7d %6d %7.1f%% %10.2f %10.2f %7.1f%% %9.2f %9.2f\n",
+			m.MarketType, m.Fills, m.Settled, m.Voided, m.HitRate()*100,
+			m.Staked, m.PnL, m.ROI()*100, m.AvgCLV(), m.MaxDrawdown)
+	}
+}
+
+// marketTypeForTicker maps a Kalshi ticker to the engine's market type names
+// ("moneyline", "spread", "total", "prop_points", ...).
+func marketTypeForTicker(ticker string) string {
+	series, _, _ := strings.Cut(ticker, "-")
+	switch kalshi.KalshiSeries(series) {
+	case kalshi.SeriesMoneyline:
+		return string(kalshi.MarketMoneyline)
+	case kalshi.SeriesSpread:
+		return string(kalshi.MarketSpread)
+	case kalshi.SeriesTotal:
+		return string(kalshi.MarketTotal)
+	}
+	for _, pt := range []kalshi.PropType{
+		kalshi.PropPoints, kalshi.PropRebounds, kalshi.PropAssists,
+		kalshi.PropThrees, kalshi.PropSteals, kalshi.PropBlocks,
+	} {
+		if kalshi.GetSeriesForPropType(pt) == kalshi.KalshiSeries(series) {
+			return "prop_" + string(pt)
+		}
+	}
+	return "other"
+}
+
+func equityValues(points []EquityPoint, start float64) []float64 {
+	values := make([]float64, 0, len(points)+1)
+	values = append(values, start)
+	for _, p := range points {
+		values = append(values, p.Equity)
+	}
+	return values
+}
+
+// maxDrawdown returns the largest peak-to-trough decline in a series.
+func maxDrawdown(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	peak := values[0]
+	worst := 0.0
+	for _, v := range values {
+		if v > peak {
+			peak = v
+		}
+		if dd := peak - v; dd > worst {
+			worst = dd
+		}
+	}
+	return worst
+}
+
+func last(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	return values[len(values)-1]
+}
@@ -0,0 +1,181 @@
+// Package calibration checks consensus probabilities against settled
+// outcomes: does a 60% call win 60% of the time?
+package calibration
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"sports-betting-bot/internal/positions"
+)
+
+// Breakdowns.
+const (
+	ByMarket = "market" // Market type: "moneyline", "prop_points", ...
+	ByProp   = "prop"   // Prop type, with every game market as "game"
+	ByBooks  = "books"  // Book count bucket; see positions.BookBucket
+)
+
+// Dimensions lists the breakdowns Build accepts.
+var Dimensions = []string{ByMarket, ByProp, ByBooks}
+
+// probFloor keeps log loss finite for a prediction of exactly 0 or 1.
+const probFloor = 1e-6
+
+// Bin is one point of a reliability curve: the predictions whose
+// probability fell in [Lo, Hi), their mean and how often they came true.
+type Bin struct {
+	Lo       float64 `json:"lo"`
+	Hi       float64 `json:"hi"`
+	Count    int     `json:"count"`
+	MeanProb float64 `json:"mean_prob"`
+	HitRate  float64 `json:"hit_rate"`
+}
+
+// Group scores the predictions of one group. A well-calibrated group has
+// each bin's HitRate close to its MeanProb.
+type Group struct {
+	Name     string  `json:"name"`
+	Count    int     `json:"count"`
+	Brier    float64 `json:"brier"`    // Mean squared error; 0.25 for a coin flip
+	LogLoss  float64 `json:"log_loss"` // Mean negative log likelihood; ln 2 for a coin flip
+	BaseRate float64 `json:"base_rate"`
+	Bins     []Bin   `json:"bins"` // Non-empty bins only
+}
+
+// Report is a calibration breakdown along one dimension, sorted by group
+// and ending with an "all" group.
+type Report struct {
+	By     string  `json:"by"`
+	Groups []Group `json:"groups"`
+}
+
+// Build scores resolved predictions along by with bins equal-width
+// probability bins. Unresolved and voided predictions are skipped.
+func Build(preds []positions.Prediction, by string, bins int) (Report, error) {
+	var group func(positions.Prediction) string
+	switch by {
+	case ByMarket:
+		group = func(p positions.Prediction) string { return p.MarketType }
+	case ByProp:
+		group = func(p positions.Prediction) string { return positions.PropGroup(p.MarketType) }
+	case ByBooks:
+		group = func(p positions.Prediction) string { return positions.BookBucket(p.BookCount) }
+	default:
+		return Report{}, fmt.Errorf("unknown breakdown %q (want one of %s)", by, strings.Join(Dimensions, ", "))
+	}
+	if bins < 1 {
+		return Report{}, fmt.Errorf("bins must be at least 1, got %d", bins)
+	}
+
+	grouped := make(map[string][]positions.Prediction)
+	var all []positions.Prediction
+	for _, p := range preds {
+		if _, ok := p.Outcome(); !ok {
+			continue
+		}
+		key := group(p)
+		grouped[key] = append(grouped[key], p)
+		all = append(all, p)
+	}
+
+	report := Report{By: by}
+	for name, ps := range grouped {
+		report.Groups = append(report.Groups, score(name, ps, bins))
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		return report.Groups[i].Name < report.Groups[j].Name
+	})
+	report.Groups = append(report.Groups, score("all", all, bins))
+	return report, nil
+}
+
+// score computes a group's Brier score, log loss and reliability curve.
+func score(name string, preds []positions.Prediction, bins int) Group {
+	g := Group{Name: name, Count: len(preds)}
+	counts := make([]int, bins)
+	probSums := make([]float64, bins)
+	hits := make([]float64, bins)
+
+	var brier, logLoss, wins float64
+	for _, p := range preds {
+		y, _ := p.Outcome()
+		brier += (p.Prob - y) * (p.Prob - y)
+		prob := math.Min(math.Max(p.Prob, probFloor), 1-probFloor)
+		logLoss -= y*math.Log(prob) + (1-y)*math.Log(1-prob)
+		wins += y
+
+		i := min(int(p.Prob*float64(bins)), bins-1)
+		counts[i]++
+		probSums[i] += p.Prob
+		hits[i] += y
+	}
+	if g.Count == 0 {
+		return g
+	}
+	n := float64(g.Count)
+	g.Brier, g.LogLoss, g.BaseRate = brier/n, logLoss/n, wins/n
+
+	for i, c := range counts {
+		if c == 0 {
+			continue
+		}
+		g.Bins = append(g.Bins, Bin{
+			Lo:       float64(i) / float64(bins),
+			Hi:       float64(i+1) / float64(bins),
+			Count:    c,
+			MeanProb: probSums[i] / float64(c),
+			HitRate:  hits[i] / float64(c),
+		})
+	}
+	return g
+}
+
+// WriteText writes a summary table followed by each group's reliability
+// curve.
+func (r Report) WriteText(w io.Writer) {
+	fmt.Fprintf(w, "%-16s %7s %8s %9s %7s\n", "BY "+strings.ToUpper(r.By), "N", "BRIER", "LOGLOSS", "BASE%")
+	fmt.Fprintln(w, strings.Repeat("-", 51))
+	for _, g := range r.Groups {
+		fmt.Fprintf(w, "%-16s %7d %8.4f %9.4f %6.1f%%\n", g.Name, g.Count, g.Brier, g.LogLoss, g.BaseRate*100)
+	}
+
+	for _, g := range r.Groups {
+		fmt.Fprintf(w, "\n%s\n", g.Name)
+		fmt.Fprintf(w, "  %-11s %7s %8s %8s %8s\n", "BIN", "N", "PRED%", "HIT%", "GAP")
+		for _, b := range g.Bins {
+			fmt.Fprintf(w, "  %4.0f-%-4.0f%% %7d %7.1f%% %7.1f%% %+8.1f\n",
+				b.Lo*100, b.Hi*100, b.Count, b.MeanProb*100, b.HitRate*100, (b.HitRate-b.MeanProb)*100)
+		}
+	}
+}
+
+// WriteCSV writes one row per group and bin, with the group's scores
+// repeated on each row.
+func (r Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"by", "group", "count", "brier", "log_loss", "base_rate",
+		"bin_lo", "bin_hi", "bin_count", "mean_prob", "hit_rate"})
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', 6, 64) }
+	for _, g := range r.Groups {
+		for _, b := range g.Bins {
+			cw.Write([]string{r.By, g.Name, strconv.Itoa(g.Count), f(g.Brier), f(g.LogLoss), f(g.BaseRate),
+				f(b.Lo), f(b.Hi), strconv.Itoa(b.Count), f(b.MeanProb), f(b.HitRate)})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
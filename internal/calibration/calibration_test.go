@@ -0,0 +1,111 @@
+package calibration
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"sports-betting-bot/internal/positions"
+)
+
+func predictions() []positions.Prediction {
+	var preds []positions.Prediction
+	// Ten moneylines at 70% from 6 books, seven of which came true
+	for i := range 10 {
+		result := positions.ResultYes
+		if i >= 7 {
+			result = positions.ResultNo
+		}
+		preds = append(preds, positions.Prediction{MarketType: "moneyline", Prob: 0.7, BookCount: 6, Result: result})
+	}
+	// Four points props at 20% from 4 books, all missed, and a void
+	for range 4 {
+		preds = append(preds, positions.Prediction{MarketType: "prop_points", Prob: 0.2, BookCount: 4, Result: positions.ResultNo})
+	}
+	preds = append(preds, positions.Prediction{MarketType: "prop_points", Prob: 0.5, BookCount: 4, Result: positions.ResultVoid})
+	return preds
+}
+
+func TestBuildScoresGroups(t *testing.T) {
+	r, err := Build(predictions(), ByMarket, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Groups) != 3 || r.Groups[0].Name != "moneyline" || r.Groups[1].Name != "prop_points" || r.Groups[2].Name != "all" {
+		t.Fatalf("groups = %+v, want moneyline, prop_points, all", r.Groups)
+	}
+
+	ml := r.Groups[0]
+	// 7 × 0.3² + 3 × 0.7² over 10
+	if ml.Count != 10 || math.Abs(ml.Brier-0.21) > 1e-9 || math.Abs(ml.BaseRate-0.7) > 1e-9 {
+		t.Errorf("moneyline = %+v, want 10 predictions with Brier 0.21", ml)
+	}
+	wantLogLoss := -(0.7*math.Log(0.7) + 0.3*math.Log(0.3))
+	if math.Abs(ml.LogLoss-wantLogLoss) > 1e-9 {
+		t.Errorf("moneyline log loss = %.4f, want %.4f", ml.LogLoss, wantLogLoss)
+	}
+	if len(ml.Bins) != 1 || ml.Bins[0].Lo != 0.7 || ml.Bins[0].HitRate != 0.7 {
+		t.Errorf("moneyline bins = %+v, want one calibrated 70-80%% bin", ml.Bins)
+	}
+
+	all := r.Groups[2]
+	if all.Count != 14 || len(all.Bins) != 2 {
+		t.Errorf("all = %+v, want 14 resolved predictions in two bins, the void left out", all)
+	}
+}
+
+func TestBuildBreakdowns(t *testing.T) {
+	byProp, err := Build(predictions(), ByProp, 10)
+	if err != nil || byProp.Groups[0].Name != "game" || byProp.Groups[1].Name != "points" {
+		t.Errorf("by prop = %+v, %v, want game then points", byProp.Groups, err)
+	}
+	byBooks, err := Build(predictions(), ByBooks, 10)
+	if err != nil || byBooks.Groups[0].Name != "4-5" || byBooks.Groups[1].Name != "6+" {
+		t.Errorf("by books = %+v, %v, want 4-5 then 6+", byBooks.Groups, err)
+	}
+	if _, err := Build(predictions(), "vendor", 10); err == nil {
+		t.Error("Build accepted an unknown breakdown")
+	}
+	if _, err := Build(predictions(), ByMarket, 0); err == nil {
+		t.Error("Build accepted zero bins")
+	}
+
+	// A certain call that missed still has a finite log loss
+	sure := []positions.Prediction{{MarketType: "total", Prob: 1, Result: positions.ResultNo}}
+	r, _ := Build(sure, ByMarket, 10)
+	if g := r.Groups[0]; math.IsInf(g.LogLoss, 0) || g.Bins[0].Lo != 0.9 {
+		t.Errorf("certain miss = %+v, want a finite log loss in the top bin", g)
+	}
+}
+
+func TestReportOutputs(t *testing.T) {
+	r, _ := Build(predictions(), ByMarket, 10)
+
+	var text bytes.Buffer
+	r.WriteText(&text)
+	if !strings.Contains(text.String(), "BY MARKET") || !strings.Contains(text.String(), "prop_points") {
+		t.Errorf("text report missing groups:\n%s", text.String())
+	}
+
+	var csvOut bytes.Buffer
+	if err := r.WriteCSV(&csvOut); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&csvOut).ReadAll()
+	// Header, one bin each for moneyline and prop_points, two for all
+	if err != nil || len(rows) != 5 || rows[1][1] != "moneyline" || rows[1][10] != "0.700000" {
+		t.Errorf("CSV rows = %v, %v, want a header and four bin rows", rows, err)
+	}
+
+	var jsonOut bytes.Buffer
+	if err := r.WriteJSON(&jsonOut); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil || decoded.By != ByMarket || len(decoded.Groups) != 3 {
+		t.Errorf("JSON = %+v, %v, want the report back", decoded, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -28,8 +29,60 @@ const (
 	DefaultMaxOddsAgeSec          = 1800 // 30 minutes
 	DefaultTakerFeeCoeff          = 0.07
 	DefaultTakerFeeCap            = 0.0175
+	DefaultSnapshotMaxFileMB      = 100
+	DefaultSnapshotMaxTotalMB     = 2048
+	DefaultSettlementInterval     = 5 * time.Minute
+	DefaultReconcileInterval      = 15 * time.Minute
+	DefaultBreakerInterval        = 1 * time.Minute
+	DefaultSMTPPort               = 587
+	DefaultCorrMarginTotal        = 0.0
+	DefaultCorrPlayerTotal        = 0.35
+	DefaultCorrPlayerMargin       = 0.15
+	DefaultCorrPlayerPlayer       = 0.10
+	DefaultFullScanInterval       = 1 * time.Minute
+	DefaultEventDebounce          = 250 * time.Millisecond
+	DefaultMakerRepriceThreshold  = 0.01
+	DefaultMakerCancelBeforeStart = 10 * time.Minute
+	DefaultOrderSyncInterval      = 10 * time.Second
+	DefaultHedgeMinProfit         = 1.00
+	DefaultArbRetryBandCents      = 2
+	DefaultInPlayMaxScoreAge      = 30 * time.Second
+	DefaultInPlayMaxLatency       = 500 * time.Millisecond
+	DefaultInPlayPregameMaxAge    = 6 * time.Hour // Oldest saved pre-game consensus reloaded at startup
 )
 
+// Reconciliation modes for RECONCILE_MODE.
+const (
+	ReconcileOff    = "off"    // Don't compare DB and Kalshi positions
+	ReconcileReport = "report" // Log discrepancies only (default)
+	ReconcileFix    = "fix"    // Import missing positions and correct counts
+)
+
+// Bet sizing modes for SIZING_MODE.
+const (
+	SizingIndependent  = "independent"  // Kelly-size each bet on its own (default)
+	SizingSimultaneous = "simultaneous" // Size each game's bets jointly with its open positions
+)
+
+// Scan modes for SCAN_MODE.
+const (
+	ScanPoll  = "poll"  // Rescan every game on every poll (default)
+	ScanEvent = "event" // Re-evaluate only games whose lines or books changed
+)
+
+// Execution modes for EXECUTION_MODE.
+const (
+	ExecTaker = "taker" // Take liquidity with IOC orders (default)
+	ExecMaker = "maker" // Post resting limit bids below the ask
+)
+
+// AlertSinkConfig configures one external alert destination.
+type AlertSinkConfig struct {
+	Target string  // Webhook URL, or comma-separated recipients for email (empty = off)
+	MinEV  float64 // Minimum AdjustedEV for game and prop alerts
+	Types  string  // Comma-separated alert types: game, prop, hedge, error (empty = all)
+}
+
 // Config holds all application configuration.
 type Config struct {
 	APIKey        string
@@ -44,6 +97,7 @@ type Config struct {
 	KalshiAPIKeyPath string // For local dev (file path)
 	KalshiPrivateKey string // For cloud deployment (key content directly)
 	KalshiDemo       bool
+	KalshiWebSocket  bool // Live order books over WebSocket instead of REST
 
 	// Execution settings
 	AutoExecute           bool
@@ -53,6 +107,90 @@ type Config struct {
 	MaxOddsAgeSec         int     // Max age of vendor odds to include in consensus (0 = no filter)
 	TakerFeeCoeff         float64 // Kalshi taker fee coefficient (default 0.07)
 	TakerFeeCap           float64 // Kalshi taker fee cap in dollars (default 0.0175)
+
+	// Maker mode: rest limit bids instead of taking, repricing when the
+	// consensus moves by MakerRepriceThreshold and cancelling
+	// MakerCancelBeforeStart before tip-off
+	ExecutionMode          string
+	MakerRepriceThreshold  float64
+	MakerCancelBeforeStart time.Duration
+
+	// Automated exits for open positions (0 = rule off): sell once the bid
+	// reaches ExitTakeProfit, once the consensus falls ExitStopEdge below
+	// what a sale nets, and ExitCloseBefore before tip-off
+	ExitTakeProfit  float64
+	ExitStopEdge    float64
+	ExitCloseBefore time.Duration
+
+	// Auto-hedge: buy the opposite side of a position once doing so locks
+	// in at least HedgeMinProfit dollars after fees
+	AutoHedge      bool
+	HedgeMinProfit float64
+
+	// Cross-market arbs: buy sets of related markets on one game (both
+	// teams, or two strikes of a ladder) that pay $1 for less
+	CrossArb bool
+
+	// Leg risk: after an uneven arb fill, retry the missing leg up to
+	// ArbRetryBandCents over its quoted price before selling the excess
+	// back (0 = always sell back)
+	ArbRetryBandCents int
+
+	// In-play mode: price games in progress from the live score and clock
+	// against the last pre-game consensus. A score that has stood still on
+	// a running clock for InPlayMaxScoreAge, or a box score or Kalshi book
+	// read slower than InPlayMaxLatency, keeps the game out of the scan
+	InPlay            bool
+	InPlayMaxScoreAge time.Duration
+	InPlayMaxLatency  time.Duration
+
+	// Portfolio exposure caps in dollars of open cost (0 = no cap)
+	MaxGameExposure       float64
+	MaxTeamExposure       float64
+	MaxPlayerExposure     float64
+	MaxMarketTypeExposure float64
+	MaxTotalExposure      float64
+
+	// Circuit breaker: halt execution past these losses (0 = off)
+	MaxDailyLoss    float64 // Dollars below start-of-day equity
+	MaxDailyLossPct float64 // Fraction of start-of-day balance
+	MaxDrawdownPct  float64 // Fraction below peak equity
+
+	// Bet sizing: independent or simultaneous Kelly. Simultaneous sizing
+	// models each game's outcomes with these factor correlations.
+	SizingMode       string
+	CorrMarginTotal  float64 // Home margin vs game total
+	CorrPlayerTotal  float64 // Player stat vs game total
+	CorrPlayerMargin float64 // Player stat vs their team's margin
+	CorrPlayerPlayer float64 // Two players' stats
+
+	// Event-driven scanning: in event mode each poll only re-evaluates
+	// games whose vendor lines changed, and Kalshi book updates re-evaluate
+	// their game between polls. A full scan still runs every FullScanInterval.
+	ScanMode         string
+	FullScanInterval time.Duration
+
+	// Snapshot recording (empty dir = disabled)
+	SnapshotDir        string
+	SnapshotMaxFileMB  int // Rotate to a new file past this size
+	SnapshotMaxTotalMB int // Delete oldest files past this total (0 = no cap)
+
+	// Consensus vendor weights fitted by cmd/fitweights (empty = compiled-in defaults)
+	VendorWeightsFile string
+
+	// Position reconciliation against Kalshi: off, report or fix
+	ReconcileMode string
+
+	// External alert sinks, in addition to the log
+	SlackAlerts   AlertSinkConfig
+	DiscordAlerts AlertSinkConfig
+	WebhookAlerts AlertSinkConfig // Generic JSON POST
+	EmailAlerts   AlertSinkConfig
+	SMTPHost      string
+	SMTPPort      int
+	SMTPUsername  string
+	SMTPPassword  string
+	SMTPFrom      string
 }
 
 // Load reads configuration from environment variables (and .env file if present).
@@ -72,6 +210,7 @@ func Load() Config {
 		KalshiAPIKeyPath: os.Getenv("KALSHI_API_KEY_PATH"), // Local dev: file path
 		KalshiPrivateKey: os.Getenv("KALSHI_PRIVATE_KEY"),  // Cloud: key content directly
 		KalshiDemo:       os.Getenv("KALSHI_DEMO") == "true",
+		KalshiWebSocket:  os.Getenv("KALSHI_WEBSOCKET") != "false",
 
 		// Execution defaults
 		AutoExecute:           false,
@@ -81,6 +220,43 @@ func Load() Config {
 		MaxOddsAgeSec:         DefaultMaxOddsAgeSec,
 		TakerFeeCoeff:         DefaultTakerFeeCoeff,
 		TakerFeeCap:           DefaultTakerFeeCap,
+
+		ExecutionMode:          ExecTaker,
+		MakerRepriceThreshold:  DefaultMakerRepriceThreshold,
+		MakerCancelBeforeStart: DefaultMakerCancelBeforeStart,
+
+		HedgeMinProfit:    DefaultHedgeMinProfit,
+		ArbRetryBandCents: DefaultArbRetryBandCents,
+
+		InPlayMaxScoreAge: DefaultInPlayMaxScoreAge,
+		InPlayMaxLatency:  DefaultInPlayMaxLatency,
+
+		ScanMode:         ScanPoll,
+		FullScanInterval: DefaultFullScanInterval,
+
+		SizingMode:       SizingIndependent,
+		CorrMarginTotal:  DefaultCorrMarginTotal,
+		CorrPlayerTotal:  DefaultCorrPlayerTotal,
+		CorrPlayerMargin: DefaultCorrPlayerMargin,
+		CorrPlayerPlayer: DefaultCorrPlayerPlayer,
+
+		SnapshotDir:        os.Getenv("SNAPSHOT_DIR"),
+		SnapshotMaxFileMB:  DefaultSnapshotMaxFileMB,
+		SnapshotMaxTotalMB: DefaultSnapshotMaxTotalMB,
+
+		VendorWeightsFile: os.Getenv("VENDOR_WEIGHTS_FILE"),
+
+		ReconcileMode: ReconcileReport,
+
+		SlackAlerts:   loadAlertSink("ALERT_SLACK_WEBHOOK_URL", "ALERT_SLACK"),
+		DiscordAlerts: loadAlertSink("ALERT_DISCORD_WEBHOOK_URL", "ALERT_DISCORD"),
+		WebhookAlerts: loadAlertSink("ALERT_WEBHOOK_URL", "ALERT_WEBHOOK"),
+		EmailAlerts:   loadAlertSink("ALERT_EMAIL_TO", "ALERT_EMAIL"),
+		SMTPHost:      os.Getenv("SMTP_HOST"),
+		SMTPPort:      DefaultSMTPPort,
+		SMTPUsername:  os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:  os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:      os.Getenv("SMTP_FROM"),
 	}
 
 	if v := os.Getenv("EV_THRESHOLD"); v != "" {
@@ -150,9 +326,202 @@ func Load() Config {
 		}
 	}
 
+	if v := os.Getenv("MAX_GAME_EXPOSURE"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.MaxGameExposure = f
+		}
+	}
+
+	if v := os.Getenv("MAX_TEAM_EXPOSURE"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.MaxTeamExposure = f
+		}
+	}
+
+	if v := os.Getenv("MAX_PLAYER_EXPOSURE"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.MaxPlayerExposure = f
+		}
+	}
+
+	if v := os.Getenv("MAX_MARKET_TYPE_EXPOSURE"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.MaxMarketTypeExposure = f
+		}
+	}
+
+	if v := os.Getenv("MAX_TOTAL_EXPOSURE"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.MaxTotalExposure = f
+		}
+	}
+
+	if v := os.Getenv("MAX_DAILY_LOSS"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.MaxDailyLoss = f
+		}
+	}
+
+	if v := os.Getenv("MAX_DAILY_LOSS_PCT"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.MaxDailyLossPct = f
+		}
+	}
+
+	if v := os.Getenv("MAX_DRAWDOWN_PCT"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.MaxDrawdownPct = f
+		}
+	}
+
+	if v := os.Getenv("EXECUTION_MODE"); v != "" {
+		cfg.ExecutionMode = v
+	}
+
+	if v := os.Getenv("MAKER_REPRICE_THRESHOLD"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.MakerRepriceThreshold = f
+		}
+	}
+
+	if v := os.Getenv("MAKER_CANCEL_BEFORE_START_MIN"); v != "" {
+		if minutes, err := strconv.Atoi(v); err == nil {
+			cfg.MakerCancelBeforeStart = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if v := os.Getenv("EXIT_TAKE_PROFIT"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.ExitTakeProfit = f
+		}
+	}
+
+	if v := os.Getenv("EXIT_STOP_EDGE"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.ExitStopEdge = f
+		}
+	}
+
+	if v := os.Getenv("EXIT_CLOSE_BEFORE_START_MIN"); v != "" {
+		if minutes, err := strconv.Atoi(v); err == nil {
+			cfg.ExitCloseBefore = time.Duration(minutes) * time.Minute
+		}
+	}
+
+	if os.Getenv("AUTO_HEDGE") == "true" {
+		cfg.AutoHedge = true
+	}
+
+	if v := os.Getenv("HEDGE_MIN_PROFIT"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.HedgeMinProfit = f
+		}
+	}
+
+	if os.Getenv("CROSS_ARB") == "true" {
+		cfg.CrossArb = true
+	}
+
+	if v := os.Getenv("ARB_RETRY_BAND_CENTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.ArbRetryBandCents = n
+		}
+	}
+
+	if os.Getenv("IN_PLAY") == "true" {
+		cfg.InPlay = true
+	}
+
+	if v := os.Getenv("IN_PLAY_MAX_SCORE_AGE_SEC"); v != "" {
+		if sec, err := strconv.Atoi(v); err == nil {
+			cfg.InPlayMaxScoreAge = time.Duration(sec) * time.Second
+		}
+	}
+
+	if v := os.Getenv("IN_PLAY_MAX_LATENCY_MS"); v != "" {
+		if ms, err := strconv.Atoi(v); err == nil {
+			cfg.InPlayMaxLatency = time.Duration(ms) * time.Millisecond
+		}
+	}
+
+	if v := os.Getenv("SCAN_MODE"); v != "" {
+		cfg.ScanMode = v
+	}
+
+	if v := os.Getenv("FULL_SCAN_INTERVAL_SEC"); v != "" {
+		if sec, err := strconv.Atoi(v); err == nil {
+			cfg.FullScanInterval = time.Duration(sec) * time.Second
+		}
+	}
+
+	if v := os.Getenv("SIZING_MODE"); v != "" {
+		cfg.SizingMode = v
+	}
+
+	if v := os.Getenv("CORR_MARGIN_TOTAL"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.CorrMarginTotal = f
+		}
+	}
+
+	if v := os.Getenv("CORR_PLAYER_TOTAL"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.CorrPlayerTotal = f
+		}
+	}
+
+	if v := os.Getenv("CORR_PLAYER_MARGIN"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.CorrPlayerMargin = f
+		}
+	}
+
+	if v := os.Getenv("CORR_PLAYER_PLAYER"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			cfg.CorrPlayerPlayer = f
+		}
+	}
+
+	if v := os.Getenv("SNAPSHOT_MAX_FILE_MB"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.SnapshotMaxFileMB = n
+		}
+	}
+
+	if v := os.Getenv("SNAPSHOT_MAX_TOTAL_MB"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.SnapshotMaxTotalMB = n
+		}
+	}
+
+	if v := os.Getenv("RECONCILE_MODE"); v != "" {
+		cfg.ReconcileMode = v
+	}
+
+	if v := os.Getenv("SMTP_PORT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			cfg.SMTPPort = n
+		}
+	}
+
 	return cfg
 }
 
+// loadAlertSink reads a sink's target from targetVar and its filters from
+// <prefix>_MIN_EV and <prefix>_TYPES.
+func loadAlertSink(targetVar, prefix string) AlertSinkConfig {
+	sink := AlertSinkConfig{
+		Target: os.Getenv(targetVar),
+		Types:  os.Getenv(prefix + "_TYPES"),
+	}
+	if v := os.Getenv(prefix + "_MIN_EV"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			sink.MinEV = f
+		}
+	}
+	return sink
+}
+
 // Validate checks that configuration values are within acceptable ranges.
 func Validate(cfg Config) error {
 	if cfg.EVThreshold < 0 || cfg.EVThreshold > 1 {
@@ -170,9 +539,93 @@ func Validate(cfg Config) error {
 	if cfg.MaxBetDollars < 0 {
 		return fmt.Errorf("MAX_BET_DOLLARS must be non-negative, got %f", cfg.MaxBetDollars)
 	}
+	if cfg.MaxGameExposure < 0 || cfg.MaxTeamExposure < 0 || cfg.MaxPlayerExposure < 0 ||
+		cfg.MaxMarketTypeExposure < 0 || cfg.MaxTotalExposure < 0 {
+		return fmt.Errorf("MAX_*_EXPOSURE caps must be non-negative")
+	}
+	if cfg.MaxDailyLoss < 0 {
+		return fmt.Errorf("MAX_DAILY_LOSS must be non-negative, got %f", cfg.MaxDailyLoss)
+	}
+	if cfg.MaxDailyLossPct < 0 || cfg.MaxDailyLossPct > 1 || cfg.MaxDrawdownPct < 0 || cfg.MaxDrawdownPct > 1 {
+		return fmt.Errorf("MAX_DAILY_LOSS_PCT and MAX_DRAWDOWN_PCT must be between 0 and 1")
+	}
+	switch cfg.ExecutionMode {
+	case ExecTaker, ExecMaker, "":
+	default:
+		return fmt.Errorf("EXECUTION_MODE must be taker or maker, got %q", cfg.ExecutionMode)
+	}
+	if cfg.MakerRepriceThreshold < 0 || cfg.MakerRepriceThreshold > 1 {
+		return fmt.Errorf("MAKER_REPRICE_THRESHOLD must be between 0 and 1, got %f", cfg.MakerRepriceThreshold)
+	}
+	if cfg.MakerCancelBeforeStart < 0 {
+		return fmt.Errorf("MAKER_CANCEL_BEFORE_START_MIN must be non-negative, got %v", cfg.MakerCancelBeforeStart)
+	}
+	if cfg.ExitTakeProfit < 0 || cfg.ExitTakeProfit >= 1 {
+		return fmt.Errorf("EXIT_TAKE_PROFIT must be at least 0 and below 1, got %f", cfg.ExitTakeProfit)
+	}
+	if cfg.ExitStopEdge < 0 || cfg.ExitStopEdge > 1 {
+		return fmt.Errorf("EXIT_STOP_EDGE must be between 0 and 1, got %f", cfg.ExitStopEdge)
+	}
+	if cfg.ExitCloseBefore < 0 {
+		return fmt.Errorf("EXIT_CLOSE_BEFORE_START_MIN must be non-negative, got %v", cfg.ExitCloseBefore)
+	}
+	if cfg.HedgeMinProfit < 0 {
+		return fmt.Errorf("HEDGE_MIN_PROFIT must be non-negative, got %f", cfg.HedgeMinProfit)
+	}
+	if cfg.ArbRetryBandCents < 0 {
+		return fmt.Errorf("ARB_RETRY_BAND_CENTS must be non-negative, got %d", cfg.ArbRetryBandCents)
+	}
+	if cfg.InPlay && (cfg.InPlayMaxScoreAge <= 0 || cfg.InPlayMaxLatency <= 0) {
+		return fmt.Errorf("IN_PLAY_MAX_SCORE_AGE_SEC and IN_PLAY_MAX_LATENCY_MS must be positive with IN_PLAY=true")
+	}
+	switch cfg.SizingMode {
+	case SizingIndependent, SizingSimultaneous, "":
+	default:
+		return fmt.Errorf("SIZING_MODE must be independent or simultaneous, got %q", cfg.SizingMode)
+	}
+	for _, c := range []float64{cfg.CorrMarginTotal, cfg.CorrPlayerTotal, cfg.CorrPlayerMargin, cfg.CorrPlayerPlayer} {
+		if c < -1 || c > 1 {
+			return fmt.Errorf("CORR_* correlations must be between -1 and 1, got %f", c)
+		}
+	}
+	if cfg.SnapshotMaxFileMB < 0 || cfg.SnapshotMaxTotalMB < 0 {
+		return fmt.Errorf("SNAPSHOT_MAX_FILE_MB and SNAPSHOT_MAX_TOTAL_MB must be non-negative")
+	}
+	switch cfg.ReconcileMode {
+	case ReconcileOff, ReconcileReport, ReconcileFix, "":
+	default:
+		return fmt.Errorf("RECONCILE_MODE must be off, report or fix, got %q", cfg.ReconcileMode)
+	}
+	for name, sink := range map[string]AlertSinkConfig{
+		"ALERT_SLACK": cfg.SlackAlerts, "ALERT_DISCORD": cfg.DiscordAlerts,
+		"ALERT_WEBHOOK": cfg.WebhookAlerts, "ALERT_EMAIL": cfg.EmailAlerts,
+	} {
+		if sink.MinEV < 0 || sink.MinEV > 1 {
+			return fmt.Errorf("%s_MIN_EV must be between 0 and 1, got %f", name, sink.MinEV)
+		}
+		for _, t := range strings.Split(sink.Types, ",") {
+			switch strings.TrimSpace(strings.ToLower(t)) {
+			case "", "game", "prop", "hedge", "error":
+			default:
+				return fmt.Errorf("%s_TYPES has unknown alert type %q", name, t)
+			}
+		}
+	}
+	if cfg.EmailAlerts.Target != "" && (cfg.SMTPHost == "" || cfg.SMTPFrom == "") {
+		return fmt.Errorf("ALERT_EMAIL_TO requires SMTP_HOST and SMTP_FROM")
+	}
 	if cfg.PollInterval < 10*time.Millisecond {
 		return fmt.Errorf("POLL_INTERVAL_MS must be at least 10ms, got %v", cfg.PollInterval)
 	}
+	switch cfg.ScanMode {
+	case ScanPoll, "":
+	case ScanEvent:
+		if cfg.FullScanInterval < cfg.PollInterval {
+			return fmt.Errorf("FULL_SCAN_INTERVAL_SEC must be at least the poll interval, got %v", cfg.FullScanInterval)
+		}
+	default:
+		return fmt.Errorf("SCAN_MODE must be poll or event, got %q", cfg.ScanMode)
+	}
 	return nil
 }
 
@@ -106,7 +106,21 @@ func TestValidate(t *testing.T) {
 		{"negative slippage", func(c *Config) { c.MaxSlippagePct = -0.1 }},
 		{"negative liquidity", func(c *Config) { c.MinLiquidityContracts = -1 }},
 		{"negative max bet", func(c *Config) { c.MaxBetDollars = -10 }},
+		{"drawdown > 1", func(c *Config) { c.MaxDrawdownPct = 1.5 }},
+		{"unknown alert type", func(c *Config) { c.SlackAlerts.Types = "game,trades" }},
+		{"email without SMTP", func(c *Config) { c.EmailAlerts.Target = "me@example.com" }},
+		{"unknown sizing mode", func(c *Config) { c.SizingMode = "martingale" }},
+		{"correlation > 1", func(c *Config) { c.CorrPlayerTotal = 1.2 }},
 		{"poll too fast", func(c *Config) { c.PollInterval = time.Millisecond }},
+		{"unknown scan mode", func(c *Config) { c.ScanMode = "push" }},
+		{"full scan faster than poll", func(c *Config) { c.ScanMode = ScanEvent; c.FullScanInterval = time.Second }},
+		{"unknown execution mode", func(c *Config) { c.ExecutionMode = "market" }},
+		{"negative reprice threshold", func(c *Config) { c.MakerRepriceThreshold = -0.01 }},
+		{"take profit at $1", func(c *Config) { c.ExitTakeProfit = 1 }},
+		{"negative close before start", func(c *Config) { c.ExitCloseBefore = -time.Minute }},
+		{"negative hedge profit floor", func(c *Config) { c.HedgeMinProfit = -1 }},
+		{"negative arb retry band", func(c *Config) { c.ArbRetryBandCents = -1 }},
+		{"in-play without a latency guard", func(c *Config) { c.InPlay, c.InPlayMaxLatency = true, 0 }},
 	}
 
 	for _, tt := range tests {
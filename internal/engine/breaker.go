@@ -0,0 +1,224 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/positions"
+)
+
+// BreakerLimits halt auto-execution once losses pass a threshold.
+// A zero limit is disabled.
+type BreakerLimits struct {
+	MaxDailyLoss    float64 // Dollars below start-of-day equity
+	MaxDailyLossPct float64 // Fraction of start-of-day balance
+	MaxDrawdownPct  float64 // Fraction below peak equity since the last reset
+}
+
+// BreakerLimitsFromConfig reads the circuit breaker limits from cfg.
+func BreakerLimitsFromConfig(cfg config.Config) BreakerLimits {
+	return BreakerLimits{
+		MaxDailyLoss:    cfg.MaxDailyLoss,
+		MaxDailyLossPct: cfg.MaxDailyLossPct,
+		MaxDrawdownPct:  cfg.MaxDrawdownPct,
+	}
+}
+
+// Enabled reports whether any limit is set.
+func (l BreakerLimits) Enabled() bool {
+	return l != BreakerLimits{}
+}
+
+// Breach returns a description of the first limit equity breaches against
+// state's start-of-day and peak values, or "" if none is.
+func (l BreakerLimits) Breach(state positions.BreakerState, equity float64) string {
+	dayLoss := state.StartEquity - equity
+	if l.MaxDailyLoss > 0 && dayLoss >= l.MaxDailyLoss {
+		return fmt.Sprintf("daily loss $%.2f reached limit $%.2f", dayLoss, l.MaxDailyLoss)
+	}
+	if l.MaxDailyLossPct > 0 && state.StartBalance > 0 && dayLoss >= l.MaxDailyLossPct*state.StartBalance {
+		return fmt.Sprintf("daily loss $%.2f reached %.1f%% of start-of-day balance $%.2f",
+			dayLoss, l.MaxDailyLossPct*100, state.StartBalance)
+	}
+	if l.MaxDrawdownPct > 0 && state.PeakEquity > 0 {
+		if dd := (state.PeakEquity - equity) / state.PeakEquity; dd >= l.MaxDrawdownPct {
+			return fmt.Sprintf("drawdown %.1f%% from peak $%.2f reached limit %.1f%%",
+				dd*100, state.PeakEquity, l.MaxDrawdownPct*100)
+		}
+	}
+	return ""
+}
+
+// Equity is the account marked to market.
+type Equity struct {
+	Balance    float64 // Available cash
+	Resting    float64 // Cash held by open maker bids: unfilled contracts at their limit
+	OpenValue  float64 // Open positions at the best bid
+	Unrealized float64 // OpenValue minus open cost and fees
+	Realized   float64 // Settled P&L since the start of the day
+}
+
+// Total returns cash, including what resting bids hold, plus the value of
+// open positions.
+func (q Equity) Total() float64 {
+	return q.Balance + q.Resting + q.OpenValue
+}
+
+// MarkToMarket values the open positions at what they could be sold for
+// now: the best bid on their side, or their entry price if the book has
+// no bids or can't be fetched. Arb rows pay $1 per contract whichever way
+// the market settles. Kalshi's balance excludes the cash resting maker bids
+// hold, so that is added back from the order table.
+func MarkToMarket(kalshiClient Exchange, db *positions.DB, balance float64, dayStart time.Time) (Equity, error) {
+	q := Equity{Balance: balance}
+
+	open, err := db.GetAllPositions()
+	if err != nil {
+		return q, fmt.Errorf("loading open positions: %w", err)
+	}
+	q.Realized, err = db.RealizedPnLSince(dayStart)
+	if err != nil {
+		return q, err
+	}
+	orders, err := db.GetOpenOrders()
+	if err != nil {
+		return q, fmt.Errorf("loading open orders: %w", err)
+	}
+	for _, o := range orders {
+		q.Resting += float64(o.LimitPrice*o.Remaining()) / 100
+	}
+
+	books := make(map[string]*kalshi.OrderBookResponse)
+	for _, pos := range open {
+		value := pos.Cost()
+		switch {
+		case pos.Side == "arb":
+			value = float64(pos.Contracts)
+		case pos.Ticker != "":
+			book, ok := books[pos.Ticker]
+			if !ok {
+				book, err = kalshiClient.GetOrderBook(pos.Ticker)
+				if err != nil {
+					// One bad book shouldn't blind the breaker to the rest
+					slog.Warn("Orderbook fetch failed, marking position at cost", "ticker", pos.Ticker, "err", err)
+					book = nil
+				}
+				books[pos.Ticker] = book
+			}
+			if book == nil {
+				break
+			}
+			if bid := bestBid(book, kalshi.Side(pos.BetSide)); bid > 0 {
+				value = float64(bid) / 100 * float64(pos.Contracts)
+			}
+		}
+		q.OpenValue += value
+		q.Unrealized += value - pos.Cost() - pos.Fees
+	}
+	return q, nil
+}
+
+// bestBid returns the highest bid in cents for side, or 0 if there is none.
+func bestBid(book *kalshi.OrderBookResponse, side kalshi.Side) int {
+	levels := book.OrderBook.Yes
+	if side == kalshi.SideNo {
+		levels = book.OrderBook.No
+	}
+	best := 0
+	for _, level := range levels {
+		if level[1] > 0 && level[0] > best {
+			best = level[0]
+		}
+	}
+	return best
+}
+
+// CheckBreaker marks the account to market, rolls the start-of-day values
+// over on a new ET trading day, tracks peak equity and trips the breaker if
+// a limit is breached. The updated state is persisted and returned.
+func CheckBreaker(kalshiClient Exchange, db *positions.DB, limits BreakerLimits, now time.Time) (positions.BreakerState, Equity, error) {
+	state, err := db.GetBreakerState()
+	if err != nil {
+		return state, Equity{}, err
+	}
+
+	balance, err := kalshiClient.GetBalanceDollars()
+	if err != nil {
+		return state, Equity{}, fmt.Errorf("fetching balance: %w", err)
+	}
+	day, dayStart := tradingDay(now)
+	equity, err := MarkToMarket(kalshiClient, db, balance, dayStart)
+	if err != nil {
+		return state, equity, err
+	}
+
+	total := equity.Total()
+	if state.Day != day {
+		state.Day = day
+		state.StartBalance = balance
+		state.StartEquity = total
+	}
+	if total > state.PeakEquity {
+		state.PeakEquity = total
+	}
+	if !state.Tripped {
+		if reason := limits.Breach(state, total); reason != "" {
+			state.Tripped = true
+			state.Reason = reason
+			state.TrippedAt = now
+		}
+	}
+
+	return state, equity, db.SaveBreakerState(state)
+}
+
+// tradingDay returns now's ET date and the instant that day began.
+func tradingDay(now time.Time) (string, time.Time) {
+	et, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		et = time.FixedZone("ET", -5*60*60)
+	}
+	local := now.In(et)
+	start := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, et)
+	return local.Format("2006-01-02"), start
+}
+
+// checkBreaker runs one circuit breaker check and alerts when it trips.
+func (e *Engine) checkBreaker() {
+	limits := BreakerLimitsFromConfig(e.cfg)
+	if e.kalshiClient == nil || e.db == nil || !limits.Enabled() || kalshi.IsMaintenanceWindow(e.now()) {
+		return
+	}
+
+	wasTripped := e.executionHalted()
+	state, equity, err := CheckBreaker(e.kalshiClient, e.db, limits, e.now())
+	if err != nil {
+		e.notifier.LogError("checking circuit breaker", err)
+		return
+	}
+
+	slog.Debug("Circuit breaker check",
+		"equity", equity.Total(), "dayPnL", equity.Total()-state.StartEquity,
+		"realized", equity.Realized, "unrealized", equity.Unrealized, "peak", state.PeakEquity)
+	if state.Tripped && !wasTripped {
+		e.notifier.AlertCircuitBreaker(state.Reason)
+	}
+}
+
+// executionHalted reports whether the persisted circuit breaker is tripped.
+// It reads the DB each time so a reset from cmd/breaker applies immediately.
+func (e *Engine) executionHalted() bool {
+	if e.db == nil {
+		return false
+	}
+	state, err := e.db.GetBreakerState()
+	if err != nil {
+		// Fail closed: a breaker we can't read may be tripped
+		slog.Error("Reading breaker state failed", "err", err)
+		return true
+	}
+	return state.Tripped
+}
@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+	"sports-betting-bot/internal/positions"
+)
+
+func TestBreakerLimitsBreach(t *testing.T) {
+	state := positions.BreakerState{StartBalance: 800, StartEquity: 1000, PeakEquity: 1200}
+
+	tests := []struct {
+		name     string
+		limits   BreakerLimits
+		equity   float64
+		breached bool
+	}{
+		{"no limits", BreakerLimits{}, 100, false},
+		{"daily loss under limit", BreakerLimits{MaxDailyLoss: 100}, 950, false},
+		{"daily loss at limit", BreakerLimits{MaxDailyLoss: 100}, 900, true},
+		{"daily loss pct of start balance", BreakerLimits{MaxDailyLossPct: 0.10}, 920, true},
+		{"daily gain", BreakerLimits{MaxDailyLoss: 100}, 1100, false},
+		{"drawdown from peak", BreakerLimits{MaxDrawdownPct: 0.15}, 1010, true},
+		{"drawdown under limit", BreakerLimits{MaxDrawdownPct: 0.20}, 1010, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := tt.limits.Breach(state, tt.equity)
+			if (reason != "") != tt.breached {
+				t.Errorf("Breach(%v) = %q, want breached=%v", tt.equity, reason, tt.breached)
+			}
+		})
+	}
+}
+
+func TestCircuitBreakerHaltsExecution(t *testing.T) {
+	losing := "KXNBAGAME-26FEB05SACMEM"
+	ticker := "KXNBAGAME-26FEB05NOPMIL"
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(losing, kalshi.SideNo, 50, 100)
+	x.AddLiquidity(losing, kalshi.SideYes, 10, 100) // YES now only sells for 10¢
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 500)
+
+	eng, db := newTestEngine(t, []api.GameOdds{favoriteOdds(21, "MIL", "NOP")}, x)
+	eng.cfg.MaxDailyLoss = 25
+
+	// Start of day: $1000, nothing open
+	eng.checkBreaker()
+	if state, _ := db.GetBreakerState(); state.StartEquity != 1000 || state.Tripped {
+		t.Fatalf("start state = %+v, want $1000 and not tripped", state)
+	}
+
+	// Buy 100 YES at 50¢ that mark at 10¢: about $42 down on the day
+	if _, err := x.SubmitOrder(kalshi.CreateOrderRequest{Ticker: losing, Side: kalshi.SideYes,
+		Action: kalshi.ActionBuy, Count: 100, YesPrice: 50, TimeInForce: kalshi.TimeInForceIOC}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddPosition(positions.Position{MarketType: "moneyline", Side: "home", Ticker: losing,
+		BetSide: "yes", EntryPrice: 0.50, Contracts: 100, Fees: kalshi.OrderFeeDollars(50, 100)}); err != nil {
+		t.Fatal(err)
+	}
+
+	eng.checkBreaker()
+	state, _ := db.GetBreakerState()
+	if !state.Tripped {
+		t.Fatalf("state = %+v, want tripped on daily loss", state)
+	}
+
+	eng.Scan()
+	if has, _ := db.HasPositionOnTicker(ticker, "yes"); has {
+		t.Fatal("traded with the breaker tripped")
+	}
+
+	// Still tripped after a restart, even on a later day
+	restarted := New(eng.client, x, eng.notifier, db, eng.cfg, eng.analysisCfg, eng.execConfig)
+	restarted.SetClock(func() time.Time { return scanTime.Add(24 * time.Hour) })
+	restarted.checkBreaker()
+	restarted.SetClock(func() time.Time { return scanTime })
+	restarted.Scan()
+	if has, _ := db.HasPositionOnTicker(ticker, "yes"); has {
+		t.Fatal("traded after restart with the breaker tripped")
+	}
+
+	if err := db.ResetBreaker(); err != nil {
+		t.Fatal(err)
+	}
+	restarted.Scan()
+	if has, _ := db.HasPositionOnTicker(ticker, "yes"); !has {
+		t.Error("no trade after reset")
+	}
+}
+
+func TestMarkToMarketSurvivesFailedBook(t *testing.T) {
+	live := "KXNBAGAME-26FEB05SACMEM"
+	closed := "KXNBAGAME-26FEB05NOPMIL"
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(live, kalshi.SideYes, 30, 100)
+	x.CloseMarket(closed) // GetOrderBook now errors
+
+	_, db := newTestEngine(t, nil, x)
+	for _, pos := range []positions.Position{
+		{MarketType: "moneyline", Side: "home", Ticker: live, BetSide: "yes", EntryPrice: 0.50, Contracts: 10},
+		{MarketType: "moneyline", Side: "home", Ticker: closed, BetSide: "yes", EntryPrice: 0.40, Contracts: 10},
+	} {
+		if _, err := db.AddPosition(pos); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	q, err := MarkToMarket(x, db, 1000, scanTime.Add(-time.Hour))
+	if err != nil {
+		t.Fatalf("MarkToMarket: %v", err)
+	}
+	// Live leg at its 30¢ bid, the unpriceable one at its $4 cost
+	if want := 3.0 + 4.0; math.Abs(q.OpenValue-want) > 1e-9 {
+		t.Errorf("OpenValue = %v, want %v", q.OpenValue, want)
+	}
+	if want := -2.0; math.Abs(q.Unrealized-want) > 1e-9 {
+		t.Errorf("Unrealized = %v, want %v", q.Unrealized, want)
+	}
+}
+
+func TestBreakerCountsRestingMakerBids(t *testing.T) {
+	game := favoriteOdds(33, "ATL", "CHI")
+	ticker := moneylineTicker(game)
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 500) // YES offered at 50¢
+	x.AddLiquidity(ticker, kalshi.SideYes, 45, 100)
+
+	eng, db := makerEngine(t, []api.GameOdds{game}, x)
+	eng.cfg.MaxDailyLoss = 1
+	eng.cfg.MaxDrawdownPct = 0.001
+
+	eng.checkBreaker()
+	eng.Scan()
+
+	open := mustOpenOrders(t, db)
+	if len(open) != 1 {
+		t.Fatalf("open orders = %+v, want one resting bid", open)
+	}
+	held := float64(open[0].LimitPrice*open[0].Contracts) / 100
+	if balance, _ := x.GetBalanceDollars(); math.Abs(balance-(1000-held)) > 1e-9 {
+		t.Fatalf("balance = %v, want $%.2f held back for the bid", balance, held)
+	}
+
+	state, equity, err := CheckBreaker(x, db, BreakerLimitsFromConfig(eng.cfg), scanTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(equity.Resting-held) > 1e-9 || math.Abs(equity.Total()-1000) > 1e-9 {
+		t.Errorf("equity = %+v, want the $%.2f resting bid counted toward $1000", equity, held)
+	}
+	if state.Tripped {
+		t.Errorf("state = %+v, resting a bid tripped the breaker", state)
+	}
+}
@@ -0,0 +1,144 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"sports-betting-bot/internal/analysis"
+	"sports-betting-bot/internal/odds"
+	"sports-betting-bot/internal/positions"
+)
+
+// pregameScan is a pre-game game's consensus and prop ladders from the
+// current scan: the candidate closing line for everything on it, and the
+// predictions recorded for calibration.
+type pregameScan struct {
+	consensus odds.ConsensusOdds
+	ladders   []analysis.PropLadder
+}
+
+// recordEntry stores an alert as the entry for its ticker+side. Only the
+// first alert on a ticker+side is kept.
+func (e *Engine) recordEntry(tp TradeParams, bookCount int) {
+	if e.db == nil || tp.Ticker == "" {
+		return
+	}
+	err := e.db.RecordEntry(positions.ClosingLine{
+		Ticker:     tp.Ticker,
+		BetSide:    tp.BetSide,
+		GameID:     fmt.Sprintf("%d", tp.GameID),
+		MarketType: tp.MarketType,
+		Side:       tp.PositionSide,
+		EntryProb:  tp.TrueProb,
+		EntryPrice: tp.KalshiPrice,
+		EntryEV:    tp.AdjustedEV,
+		BookCount:  bookCount,
+	})
+	if err != nil {
+		slog.Warn("Failed to record alert entry", "ticker", tp.Ticker, "err", err)
+	}
+}
+
+// recordClosingLines prices every alerted or traded ticker of the scanned
+// games at this scan's consensus. Pre-game scans stop at
+// DefaultPreGameSkipWindow, so the last one written is the closing line.
+// Positions without an alert, such as ones opened before alerts were
+// recorded, enter at their fill price.
+func (e *Engine) recordClosingLines(scans []pregameScan) {
+	if e.db == nil {
+		return
+	}
+	at := e.now()
+	for _, scan := range scans {
+		gameID := fmt.Sprintf("%d", scan.consensus.GameID)
+		open, err := e.db.GetPositionsByGame(gameID)
+		if err != nil {
+			slog.Warn("Failed to load positions for closing lines", "gameID", gameID, "err", err)
+			continue
+		}
+		for _, pos := range open {
+			if pos.Locked || pos.HedgeOf != 0 || strings.HasPrefix(pos.MarketType, "arb_") {
+				continue
+			}
+			err := e.db.RecordEntry(positions.ClosingLine{
+				Ticker:     pos.Ticker,
+				BetSide:    pos.BetSide,
+				GameID:     pos.GameID,
+				MarketType: pos.MarketType,
+				Side:       pos.Side,
+				EntryPrice: pos.EntryPrice,
+			})
+			if err != nil {
+				slog.Warn("Failed to record position entry", "ticker", pos.Ticker, "err", err)
+			}
+		}
+
+		lines, err := e.db.GetClosingLinesByGame(gameID)
+		if err != nil {
+			slog.Warn("Failed to load closing lines", "gameID", gameID, "err", err)
+			continue
+		}
+		for _, l := range lines {
+			prob, price, ok := closingQuote(scan, l)
+			if !ok {
+				continue
+			}
+			if err := e.db.RecordClose(l.Ticker, l.BetSide, prob, price, at); err != nil {
+				slog.Warn("Failed to record closing line", "ticker", l.Ticker, "err", err)
+			}
+		}
+	}
+}
+
+// closingQuote returns the consensus probability and Kalshi ask for a
+// line's side. Game markets read the game consensus; props read the fitted
+// ladder curve at the Kalshi strike, unshrunk.
+func closingQuote(scan pregameScan, l positions.ClosingLine) (float64, float64, bool) {
+	if strings.HasPrefix(l.MarketType, "prop_") {
+		for _, ladder := range scan.ladders {
+			for _, km := range ladder.Markets {
+				if km.Ticker != l.Ticker {
+					continue
+				}
+				over := ladder.Curve.ProbAtLeast(km.Line)
+				if over <= 0 || over >= 1 {
+					return 0, 0, false
+				}
+				if l.BetSide == "no" {
+					return 1 - over, float64(km.NoAsk) / 100.0, km.NoAsk > 0
+				}
+				return over, float64(km.YesAsk) / 100.0, km.YesAsk > 0
+			}
+		}
+		return 0, 0, false
+	}
+
+	c := scan.consensus
+	if c.KalshiOdds == nil {
+		return 0, 0, false
+	}
+	var prob float64
+	var price int
+	switch {
+	case l.MarketType == string(odds.MarketMoneyline) && c.Moneyline != nil && c.KalshiOdds.Moneyline != nil:
+		prob, price = c.Moneyline.HomeTrueProb, c.KalshiOdds.Moneyline.Home
+		if l.Side == "away" {
+			prob, price = c.Moneyline.AwayTrueProb, c.KalshiOdds.Moneyline.Away
+		}
+	case l.MarketType == string(odds.MarketSpread) && c.Spread != nil && c.KalshiOdds.Spread != nil:
+		prob, price = c.Spread.HomeCoverProb, c.KalshiOdds.Spread.HomeOdds
+		if l.Side == "away" {
+			prob, price = c.Spread.AwayCoverProb, c.KalshiOdds.Spread.AwayOdds
+		}
+	case l.MarketType == string(odds.MarketTotal) && c.Total != nil && c.KalshiOdds.Total != nil:
+		prob, price = c.Total.OverProb, c.KalshiOdds.Total.OverOdds
+		if l.Side == "under" {
+			prob, price = c.Total.UnderProb, c.KalshiOdds.Total.UnderOdds
+		}
+	default:
+		return 0, 0, false
+	}
+	implied := odds.OddsToImplied(price)
+	return prob, implied, prob > 0 && implied > 0
+}
@@ -0,0 +1,45 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+)
+
+func TestScanRecordsClosingLine(t *testing.T) {
+	ticker := "KXNBAGAME-26FEB05GSWPHX"
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 500) // YES offered at 50¢
+
+	eng, db := newTestEngine(t, []api.GameOdds{favoriteOdds(1, "PHX", "GSW")}, x)
+	eng.Scan()
+
+	// Kalshi moves toward the books before the last scan ahead of tip-off
+	kalshiVendor := &eng.client.(*fakeOdds).games[0].Vendors[0]
+	kalshiVendor.Moneyline = &api.Moneyline{Home: 58, Away: 44}
+	eng.Scan()
+
+	// Inside the pre-game skip window the close is already fixed
+	kalshiVendor.Moneyline = &api.Moneyline{Home: 62, Away: 40}
+	eng.SetClock(func() time.Time { return scanTime.Add(3*time.Hour - 30*time.Second) })
+	eng.Scan()
+
+	lines, err := db.GetClosingLines()
+	if err != nil || len(lines) != 1 {
+		t.Fatalf("GetClosingLines = %+v, %v, want the home moneyline", lines, err)
+	}
+	l := lines[0]
+	if l.Ticker != ticker || l.BetSide != "yes" || l.Side != "home" || l.BookCount != 6 {
+		t.Errorf("line = %+v, want YES home on %s from 6 books", l, ticker)
+	}
+	if l.EntryPrice != 0.50 || l.EntryEV <= 0 || l.FillPrice != 0.50 {
+		t.Errorf("entry = %.2f (EV %.3f), fill %.2f, want the 50¢ alert and fill", l.EntryPrice, l.EntryEV, l.FillPrice)
+	}
+	if l.ClosingPrice != 0.58 || l.ClosingProb < 0.6 || !l.ClosedAt.Equal(scanTime) {
+		t.Errorf("close = %.2f at %.3f (%s), want 58¢ against the ~65%% consensus at the last pre-game scan",
+			l.ClosingPrice, l.ClosingProb, l.ClosedAt)
+	}
+}
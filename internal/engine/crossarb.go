@@ -0,0 +1,203 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/positions"
+)
+
+// SetMarketLister lets CROSS_ARB=true scan the game-level series (team
+// moneylines, spread and total ladders) as well as the player prop
+// ladders every scan already lists.
+func (e *Engine) SetMarketLister(l MarketLister) {
+	e.markets = l
+}
+
+// crossArbConfig is the profit floor for cross-market arbs, shared with
+// same-ticker arbs.
+func crossArbConfig() kalshi.ArbConfig {
+	return kalshi.ArbConfig{
+		MinProfitCents: config.DefaultMinArbProfitCents,
+		MinProfitPct:   config.DefaultMinArbProfitPct,
+	}
+}
+
+// scanCrossArbs screens the listed prices of the markets on games for
+// arbs across related markets and executes the ones the books confirm.
+// Returns the dollar amount spent.
+func (e *Engine) scanCrossArbs(games []api.GameOdds, propMarkets map[string][]kalshi.PlayerPropMarket, bankroll float64) float64 {
+	if !e.cfg.CrossArb || e.kalshiClient == nil {
+		return 0
+	}
+	byKey := make(map[string]api.GameOdds, len(games))
+	for _, g := range games {
+		if key := gameKey(g); key != "" {
+			byKey[key] = g
+		}
+	}
+
+	var quotes []kalshi.MarketQuote
+	add := func(ticker string, yesAsk, noAsk int) {
+		if info, ok := kalshi.ParseNBATicker(ticker); ok {
+			if _, ok := byKey[info.Game()]; ok {
+				quotes = append(quotes, kalshi.MarketQuote{Ticker: ticker, YesAsk: yesAsk, NoAsk: noAsk})
+			}
+		}
+	}
+	if e.markets != nil {
+		listing, err := e.markets.GetOpenNBAMarkets()
+		if err != nil {
+			e.notifier.LogError("fetching Kalshi game markets", err)
+		}
+		for _, markets := range listing {
+			for _, m := range markets {
+				add(m.Ticker, m.YesAsk, m.NoAsk)
+			}
+		}
+	}
+	for _, markets := range propMarkets {
+		for _, m := range markets {
+			add(m.Ticker, m.YesAsk, m.NoAsk)
+		}
+	}
+
+	spent := 0.0
+	for _, opp := range kalshi.FindCrossArbs(quotes, crossArbConfig()) {
+		if bankroll-spent <= 0 {
+			break
+		}
+		info, _ := kalshi.ParseNBATicker(opp.Legs[0].Ticker)
+		spent += e.executeCrossArb(opp, byKey[info.Game()], bankroll-spent)
+	}
+	return spent
+}
+
+// executeCrossArb confirms a screened arb against the legs' books and buys
+// every leg at once. Contracts filled on every leg are stored locked
+// together; an uneven fill is squared by the leg manager. Returns the
+// dollar amount spent.
+func (e *Engine) executeCrossArb(screened kalshi.CrossArbOpportunity, game api.GameOdds, bankroll float64) float64 {
+	// Listed prices only screen; the arb and its depth must hold on the books
+	quotes := make([]kalshi.MarketQuote, 0, len(screened.Legs))
+	for _, leg := range screened.Legs {
+		if tickerLocked(e.db, leg.Ticker) {
+			return 0
+		}
+		book, err := e.orderBook(leg.Ticker)
+		if err != nil {
+			slog.Error("Orderbook fetch failed", "ticker", leg.Ticker, "err", err)
+			return 0
+		}
+		quotes = append(quotes, kalshi.QuoteFromBook(leg.Ticker, book))
+	}
+	confirmed := kalshi.FindCrossArbs(quotes, crossArbConfig())
+	if len(confirmed) == 0 {
+		slog.Info("Cross arb not on the books", "event", screened.Event, "description", screened.Description)
+		return 0
+	}
+	arb := confirmed[0]
+	for _, leg := range arb.Legs {
+		if orderInFlight(e.db, leg.Ticker, string(leg.Side)) {
+			return 0
+		}
+	}
+
+	contracts := min(arb.MaxContracts, int(bankroll*100/float64(arb.TotalCost)))
+	if e.cfg.MaxBetDollars > 0 {
+		contracts = min(contracts, int(e.cfg.MaxBetDollars*100/float64(arb.TotalCost)))
+	}
+	if contracts < e.execConfig.MinLiquidityContracts {
+		slog.Info("Cross arb size below minimum, skipping", "event", arb.Event, "contracts", contracts)
+		return 0
+	}
+	slog.Info("Cross arb detected", "event", arb.Event, "description", arb.Description)
+
+	if e.execConfig.DryRun {
+		slog.Info("Dry run cross arb", "event", arb.Event, "contracts", contracts,
+			"cost", arb.TotalCost, "profit", arb.GuaranteedProfit*float64(contracts)/100)
+		return 0
+	}
+
+	// Journal every leg before sending; ExecuteCrossArb derives each leg's
+	// client order ID from the base ID the same way
+	base := uuid.New().String()
+	legs := make([]positions.JournalEntry, len(arb.Legs))
+	for i, leg := range arb.Legs {
+		legs[i] = crossArbEntry(game, leg, contracts)
+		legs[i].ClientOrderID = kalshi.CrossArbLegClientOrderID(base, i)
+		if e.db == nil {
+			markAttempted(leg.Ticker, string(leg.Side))
+			continue
+		}
+		if err := e.db.RecordIntent(legs[i]); err != nil {
+			slog.Error("Journaling cross arb leg failed, skipping arb", "ticker", leg.Ticker, "err", err)
+			return 0
+		}
+	}
+	cfg := e.execConfig
+	cfg.TrueProb = 0 // The legs only have value together
+	cfg.ClientOrderID = base
+
+	slog.Info("Executing cross arb", "event", arb.Event, "legs", len(arb.Legs), "contracts", contracts)
+	results, err := kalshi.ExecuteCrossArb(e.kalshiClient, &arb, contracts, cfg)
+	if err != nil {
+		// With concurrent execution, other legs may have filled
+		slog.Error("Cross arb execution error", "event", arb.Event, "err", err)
+	}
+
+	spent := 0.0
+	for i, result := range results {
+		recordOrder("arb_"+legs[i].MarketType, float64(arb.Legs[i].Price), result, nil)
+		if result != nil {
+			spent += float64(result.TotalCost) / 100
+		}
+	}
+	if e.db == nil {
+		return spent
+	}
+
+	quoted := make([]int, len(arb.Legs))
+	for i, leg := range arb.Legs {
+		quoted[i] = leg.Price
+	}
+	lm := e.legManager()
+	if fill, ok := lm.settle(arb.Event, legs, quoted, results); ok && fill.Pairs+fill.Sold+fill.Open > 0 {
+		e.notifier.AlertArb(fill)
+	}
+	return spent + lm.spent
+}
+
+// crossArbEntry describes a buy of one cross arb leg. Team markets back
+// the team in their ticker on YES; totals and props back the over.
+func crossArbEntry(game api.GameOdds, leg kalshi.ArbLeg, contracts int) positions.JournalEntry {
+	info, _ := kalshi.ParseNBATicker(leg.Ticker)
+	yes := leg.Side == kalshi.SideYes
+	side := "under"
+	switch team := kalshi.MarketTeam(leg.Ticker); {
+	case team != "" && yes == (team == info.HomeTeam):
+		side = "home"
+	case team != "":
+		side = "away"
+	case yes:
+		side = "over"
+	}
+	return positions.JournalEntry{
+		Kind:       positions.OrderKindArb,
+		GameID:     fmt.Sprintf("%d", game.GameID),
+		HomeTeam:   game.Game.HomeTeam.Abbreviation,
+		AwayTeam:   game.Game.VisitorTeam.Abbreviation,
+		MarketType: kalshi.MarketTypeForSeries(info.Series),
+		Side:       side,
+		Ticker:     leg.Ticker,
+		BetSide:    string(leg.Side),
+		Action:     string(kalshi.ActionBuy),
+		Contracts:  contracts,
+		StartsAt:   game.Game.DateTime,
+	}
+}
@@ -0,0 +1,135 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+	"sports-betting-bot/internal/positions"
+)
+
+// listing serves a fixed game market listing.
+type listing map[kalshi.KalshiSeries][]kalshi.KalshiMarket
+
+func (l listing) GetOpenNBAMarkets() (map[kalshi.KalshiSeries][]kalshi.KalshiMarket, error) {
+	return l, nil
+}
+
+// shortFill fills at most max contracts of each order on ticker.
+type shortFill struct {
+	*kalshitest.Exchange
+	ticker string
+	max    int
+}
+
+func (s shortFill) PlaceOrder(ticker string, side kalshi.Side, action kalshi.OrderAction, contracts int, cfg kalshi.OrderConfig) (*kalshi.ExecutionResult, error) {
+	if ticker == s.ticker && action == kalshi.ActionBuy {
+		contracts = min(contracts, s.max)
+	}
+	return s.Exchange.PlaceOrder(ticker, side, action, contracts, cfg)
+}
+
+func heldContracts(t *testing.T, x *kalshitest.Exchange) map[string]int {
+	t.Helper()
+	held, err := x.GetPositions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]int, len(held))
+	for _, p := range held {
+		out[p.Ticker] = p.Position
+	}
+	return out
+}
+
+func TestCrossArbBuysBothTeams(t *testing.T) {
+	x := kalshitest.NewExchange(1000)
+	game := favoriteOdds(5, "SAC", "MEM")
+	eng, db := newTestEngine(t, []api.GameOdds{game}, x)
+	eng.cfg.CrossArb = true
+
+	// MEM YES at 40 and SAC YES at 50: one of them pays $1 for 90¢
+	mem, sac := moneylineTicker(game)+"-MEM", moneylineTicker(game)+"-SAC"
+	x.AddLiquidity(mem, kalshi.SideNo, 60, 30)
+	x.AddLiquidity(sac, kalshi.SideNo, 50, 20)
+	eng.SetMarketLister(listing{kalshi.SeriesMoneyline: {
+		{Ticker: mem, YesAsk: 40, NoAsk: 62},
+		{Ticker: sac, YesAsk: 50, NoAsk: 52},
+	}})
+
+	if spent := eng.scanCrossArbs([]api.GameOdds{game}, nil, 1000); spent < 17.9 || spent > 18.5 {
+		t.Errorf("spent = %.2f, want ~$18 for 20 pairs", spent)
+	}
+
+	stored := mustPositions(t, db)
+	if len(stored) != 2 {
+		t.Fatalf("open = %+v, want two locked legs", stored)
+	}
+	for _, p := range stored {
+		if !p.Locked || p.Contracts != 20 || p.MarketType != "moneyline" {
+			t.Errorf("leg = %+v, want 20 locked moneyline contracts", p)
+		}
+		if want := map[string]string{mem: "away", sac: "home"}[p.Ticker]; p.Side != want {
+			t.Errorf("leg %s side = %s, want %s", p.Ticker, p.Side, want)
+		}
+	}
+	if held := heldContracts(t, x); held[mem] != 20 || held[sac] != 20 {
+		t.Errorf("exchange positions = %v, want 20 YES on each team", held)
+	}
+
+	// Each leg is on its own ticker, so Kalshi holds it as stored and a
+	// fix-mode reconcile has nothing to import
+	if diffs, err := ReconcilePositions(x, db, true); err != nil || len(diffs) != 0 {
+		t.Errorf("reconcile = %v, %v; want the locked legs to match Kalshi", diffs, err)
+	}
+	if n := len(mustPositions(t, db)); n != 2 {
+		t.Errorf("open rows after reconcile = %d, want the two legs", n)
+	}
+
+	// Locked tickers take no second round
+	x.AddLiquidity(sac, kalshi.SideNo, 50, 20)
+	if spent := eng.scanCrossArbs([]api.GameOdds{game}, nil, 1000); spent != 0 {
+		t.Errorf("second scan spent %.2f, want nothing on locked tickers", spent)
+	}
+}
+
+func TestCrossArbUnwindsUnevenFills(t *testing.T) {
+	x := kalshitest.NewExchange(1000)
+	game := favoriteOdds(6, "MIL", "NOP")
+	eng, db := newTestEngine(t, []api.GameOdds{game}, x)
+	eng.cfg.CrossArb = true
+
+	// Over 220 at 40 and under 225 at 45, but under 225 only fills 12
+	event := strings.Replace(moneylineTicker(game), string(kalshi.SeriesMoneyline), string(kalshi.SeriesTotal), 1)
+	low, high := event+"-220", event+"-225"
+	x.AddLiquidity(low, kalshi.SideNo, 60, 20)
+	x.AddLiquidity(high, kalshi.SideYes, 55, 20)
+	x.AddLiquidity(low, kalshi.SideYes, 38, 50)
+	eng.kalshiClient = shortFill{Exchange: x, ticker: high, max: 12}
+	eng.SetMarketLister(listing{kalshi.SeriesTotal: {
+		{Ticker: low, YesAsk: 40, NoAsk: 61},
+		{Ticker: high, YesAsk: 56, NoAsk: 45},
+	}})
+
+	eng.scanCrossArbs([]api.GameOdds{game}, nil, 1000)
+
+	// 12 pairs lock; the 8 extra overs are sold back into the 38¢ bid
+	stored := mustPositions(t, db)
+	if len(stored) != 2 {
+		t.Fatalf("open = %+v, want two locked legs", stored)
+	}
+	for _, p := range stored {
+		if !p.Locked || p.Contracts != 12 || p.MarketType != "total" {
+			t.Errorf("leg = %+v, want 12 locked total contracts", p)
+		}
+	}
+	closes := mustSettlements(t, db)
+	if len(closes) != 1 || closes[0].Result != positions.ResultClosed || closes[0].Payout != 8*0.38 {
+		t.Fatalf("settlements = %+v, want the 8 extra contracts closed at 38¢", closes)
+	}
+	if held := heldContracts(t, x); held[low] != 12 || held[high] != -12 {
+		t.Errorf("exchange positions = %v, want 12 over 220 and 12 under 225", held)
+	}
+}
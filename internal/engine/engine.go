@@ -12,28 +12,65 @@ import (
 	"sports-betting-bot/internal/api"
 	"sports-betting-bot/internal/config"
 	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/metrics"
 	"sports-betting-bot/internal/odds"
 	"sports-betting-bot/internal/positions"
+	"sports-betting-bot/internal/risk"
 )
 
 // Engine is the main orchestrator that polls for odds, detects +EV opportunities,
 // and executes trades.
 type Engine struct {
-	client       *api.BallDontLieClient
-	kalshiClient *kalshi.KalshiClient
+	client       OddsProvider
+	kalshiClient Exchange
 	notifier     *alerts.Notifier
 	db           *positions.DB
 	cfg          config.Config
 	analysisCfg  analysis.Config
 	execConfig   kalshi.OrderConfig
 
+	// now is the engine's clock; time.Now unless replaced for replay.
+	now func() time.Time
+
 	lastMaintenanceLog time.Time
+	lastBreakerLog     time.Time
+
+	// orders manages maker mode's resting orders; see maker.go
+	orders OrderManager
+
+	// markets lists game markets for cross-market arbs; see crossarb.go
+	markets MarketLister
+
+	// ladderFlags holds the prop ladder flags already logged, by ticker and kind
+	ladderFlags map[string]bool
+
+	// In-play mode state; see inplay.go
+	scores      LiveScoreProvider
+	pregame     map[int]odds.ConsensusOdds // Game ID -> last consensus before tip-off
+	liveScores  map[string]liveScore       // Kalshi game key -> latest box score
+	liveFetched time.Time
+
+	// Event-driven scanning state; see events.go
+	feed         MarketFeed
+	oddsVersions map[int]string                       // Game ID -> vendor line version
+	games        map[string]api.GameOdds              // Kalshi game key -> latest odds
+	propMarkets  map[string][]kalshi.PlayerPropMarket // From the last full scan
+	playerProps  map[int][]api.PlayerProp             // Game ID -> sportsbook props
+	dirty        map[string]bool                      // Game keys with book updates to evaluate
+	lastFullScan time.Time
+
+	// Account as of the last refresh, reused by book updates; see account
+	openPositions []positions.Position
+	bankroll      float64 // Less what scans have spent since
+	bankrollOK    bool    // False when the balance fetch failed
+	accountAt     time.Time
 }
 
 // New creates a new Engine with all dependencies.
+// kalshiClient may be nil to run in alerts-only mode.
 func New(
-	client *api.BallDontLieClient,
-	kalshiClient *kalshi.KalshiClient,
+	client OddsProvider,
+	kalshiClient Exchange,
 	notifier *alerts.Notifier,
 	db *positions.DB,
 	cfg config.Config,
@@ -48,9 +85,23 @@ func New(
 		cfg:          cfg,
 		analysisCfg:  analysisCfg,
 		execConfig:   execConfig,
+		now:          time.Now,
+		oddsVersions: make(map[int]string),
+		games:        make(map[string]api.GameOdds),
+		playerProps:  make(map[int][]api.PlayerProp),
+		dirty:        make(map[string]bool),
+		ladderFlags:  make(map[string]bool),
+		pregame:      make(map[int]odds.ConsensusOdds),
+		liveScores:   make(map[string]liveScore),
 	}
 }
 
+// SetClock replaces the engine's time source. Backtests use it to evaluate
+// start-time, staleness and maintenance checks as of the recorded snapshot.
+func (e *Engine) SetClock(now func() time.Time) {
+	e.now = now
+}
+
 // Run starts the main polling loop. It blocks until ctx is cancelled.
 func (e *Engine) Run(ctx context.Context) {
 	ticker := time.NewTicker(e.cfg.PollInterval)
@@ -59,8 +110,41 @@ func (e *Engine) Run(ctx context.Context) {
 	cleanupTicker := time.NewTicker(config.DefaultCleanupInterval)
 	defer cleanupTicker.Stop()
 
+	settleTicker := time.NewTicker(config.DefaultSettlementInterval)
+	defer settleTicker.Stop()
+
+	reconcileTicker := time.NewTicker(config.DefaultReconcileInterval)
+	defer reconcileTicker.Stop()
+
+	breakerTicker := time.NewTicker(config.DefaultBreakerInterval)
+	defer breakerTicker.Stop()
+
+	orderTicker := time.NewTicker(config.DefaultOrderSyncInterval)
+	defer orderTicker.Stop()
+
+	// Book updates are batched so a burst of deltas costs one evaluation
+	eventTicker := time.NewTicker(config.DefaultEventDebounce)
+	defer eventTicker.Stop()
+	eventMode := e.cfg.ScanMode == config.ScanEvent
+	var bookUpdates <-chan string
+	if eventMode && e.feed != nil {
+		bookUpdates = e.feed.Updates()
+	}
+
 	slog.Info("Starting polling loop")
 
+	// Resolve orders a previous run sent without recording the outcome,
+	// recover resting orders and resolve anything that finished while the
+	// bot was down, then catch fills or manual trades the DB missed
+	e.reconcileJournal()
+	if e.makerMode() {
+		e.syncOrders()
+	}
+	e.settle()
+	e.reconcile()
+	e.checkBreaker()
+	e.loadPregame()
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -70,14 +154,46 @@ func (e *Engine) Run(ctx context.Context) {
 		case <-cleanupTicker.C:
 			e.notifier.CleanupOldAlerts()
 
+		case <-settleTicker.C:
+			e.settle()
+
+		case <-reconcileTicker.C:
+			e.reconcile()
+
+		case <-breakerTicker.C:
+			e.checkBreaker()
+
+		case <-orderTicker.C:
+			e.reconcileJournal()
+			if e.makerMode() {
+				e.syncOrders()
+			}
+
+		case updated := <-bookUpdates:
+			e.markBookUpdate(updated)
+
+		case <-eventTicker.C:
+			if eventMode {
+				e.evaluateBookUpdates()
+			}
+
 		case <-ticker.C:
-			e.Scan()
+			if eventMode {
+				e.pollChanges()
+			} else {
+				e.Scan()
+			}
 		}
 	}
 }
 
-// Scan performs a single scan cycle: fetch odds, find opportunities, execute trades.
+// Scan performs a full scan cycle: fetch odds, find opportunities across
+// every game, execute trades.
 func (e *Engine) Scan() {
+	defer func(start time.Time) {
+		metrics.ScanDuration.Observe(time.Since(start).Seconds())
+	}(time.Now())
+
 	gameOdds, err := e.client.GetTodaysOdds()
 	if err != nil {
 		e.notifier.LogError("fetching odds", err)
@@ -88,64 +204,104 @@ func (e *Engine) Scan() {
 		return
 	}
 
-	var allPositions []positions.Position
-	if e.db != nil {
-		allPositions, _ = e.db.GetAllPositions()
+	kalshiPlayerProps := e.fetchPropMarkets()
+	e.rememberScan(gameOdds, kalshiPlayerProps)
+
+	gameOpps, propOpps := e.evaluate(gameOdds, kalshiPlayerProps, true)
+	e.notifier.LogScanWithProps(len(gameOdds), gameOpps, propOpps)
+}
+
+// fetchPropMarkets lists today's Kalshi player prop markets, or nil
+// without an exchange.
+func (e *Engine) fetchPropMarkets() map[string][]kalshi.PlayerPropMarket {
+	if e.kalshiClient == nil {
+		return nil
+	}
+	et, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		et = time.FixedZone("ET", -5*60*60)
+	}
+	markets, err := e.kalshiClient.GetPlayerPropMarkets(e.now().In(et))
+	if err != nil {
+		e.notifier.LogError("fetching Kalshi player props", err)
 	}
+	return markets
+}
 
-	// Fetch current balance if Kalshi client available
-	var bankroll float64
-	var kalshiAvailable bool
-	if e.kalshiClient != nil {
-		if kalshi.IsMaintenanceWindowNow() {
-			if time.Since(e.lastMaintenanceLog) > config.DefaultMaintenanceLogCooldown {
-				slog.Warn("Kalshi maintenance window - skipping execution", "window", "Thu 3-5am ET")
-				e.lastMaintenanceLog = time.Now()
-			}
-		} else {
-			bankroll, err = e.kalshiClient.GetBalanceDollars()
-			if err != nil {
-				e.notifier.LogError("fetching Kalshi balance", err)
-			} else {
-				kalshiAvailable = true
-			}
+// evaluate finds and acts on opportunities in the given games, pricing
+// props against kalshiPlayerProps. When refresh is set sportsbook props,
+// open positions and the Kalshi balance are fetched anew; otherwise they
+// come from the last refresh, so a book update makes no upstream calls.
+// It returns the game and prop opportunity counts.
+func (e *Engine) evaluate(gameOdds []api.GameOdds, kalshiPlayerProps map[string][]kalshi.PlayerPropMarket, refresh bool) (int, int) {
+	var err error
+	allPositions, bankroll, kalshiAvailable := e.account(refresh)
+
+	// Exits and hedges run even with the breaker tripped: they only
+	// reduce risk
+	canReduce := kalshiAvailable
+	if canReduce && e.manageExits(gameOdds) > 0 {
+		allPositions = e.reloadPositions()
+	}
+
+	// A tripped circuit breaker halts execution; alerts still go out
+	if kalshiAvailable && e.executionHalted() {
+		kalshiAvailable = false
+		if e.now().Sub(e.lastBreakerLog) > config.DefaultMaintenanceLogCooldown {
+			slog.Warn("Circuit breaker tripped - skipping execution", "reset", "go run ./cmd/breaker -reset")
+			e.lastBreakerLog = e.now()
 		}
 	}
 
 	var allGameOpps []analysis.Opportunity
 	var allPropOpps []analysis.PlayerPropOpportunity
 
-	// Fetch Kalshi player props once for all games
-	var kalshiPlayerProps map[string][]kalshi.PlayerPropMarket
-	if e.kalshiClient != nil {
-		et, err := time.LoadLocation("America/New_York")
-		if err != nil {
-			et = time.FixedZone("ET", -5*60*60)
-		}
-		nowET := time.Now().In(et)
-		kalshiPlayerProps, err = e.kalshiClient.GetPlayerPropMarkets(nowET)
-		if err != nil {
-			e.notifier.LogError("fetching Kalshi player props", err)
-		}
-	}
+	// Live books, when streaming, replace listed Kalshi prices
+	kalshiPlayerProps = e.withLivePropQuotes(kalshiPlayerProps)
 
+	var gamesScanned, propsScanned int
+	evaluated := make(map[int]bool)
+	startsAt := make(map[int]string)
+	var started []api.GameOdds
+	var closing []pregameScan
 	for _, game := range gameOdds {
 		status := game.Game.Status
-		if status == "Final" || strings.Contains(status, "Qtr") || status == "Halftime" || status == "OT" {
+		if status == "Final" {
+			delete(e.pregame, game.GameID)
+			continue
+		}
+		if inProgress(status) {
+			if e.inPlayMode() {
+				started = append(started, game)
+			}
 			continue
 		}
 
-		if game.Game.StartsWithin(config.DefaultPreGameSkipWindow) {
+		if game.Game.StartsWithinAt(e.now(), config.DefaultPreGameSkipWindow) {
 			continue
 		}
 
-		consensus := odds.CalculateConsensus(game, e.cfg.MaxOddsAgeSec)
+		gamesScanned++
+		evaluated[game.GameID] = true
+		startsAt[game.GameID] = game.Game.DateTime
+		game = e.withLiveMoneyline(game)
+		consensus := odds.CalculateConsensusAt(game, e.now(), e.cfg.MaxOddsAgeSec)
+		e.pregame[game.GameID] = consensus
 
 		opportunities := analysis.FindAllOpportunities(consensus, e.analysisCfg)
 		allGameOpps = append(allGameOpps, opportunities...)
 
-		playerProps, err := e.client.GetPlayerProps(game.GameID)
-		if err == nil && len(playerProps) > 0 {
+		scan := pregameScan{consensus: consensus}
+		playerProps, cached := e.playerProps[game.GameID]
+		if refresh || !cached {
+			playerProps, err = e.client.GetPlayerProps(game.GameID)
+			if err != nil {
+				playerProps = nil
+			}
+			e.playerProps[game.GameID] = playerProps
+		}
+		propsScanned += len(playerProps)
+		if len(playerProps) > 0 {
 			if len(kalshiPlayerProps) > 0 {
 				playerIDSet := make(map[int]bool)
 				for _, prop := range playerProps {
@@ -157,10 +313,11 @@ func (e *Engine) Scan() {
 				}
 				playerNames := e.client.GetPlayerNames(playerIDs)
 
-				propOpportunities := analysis.FindPlayerPropOpportunitiesWithInterpolation(
-					playerProps,
-					kalshiPlayerProps,
-					playerNames,
+				ladders := analysis.FitPropLadders(playerProps, kalshiPlayerProps, playerNames, e.analysisCfg)
+				e.flagLadders(ladders)
+				scan.ladders = ladders
+				propOpportunities := analysis.FindLadderOpportunities(
+					ladders,
 					game.Game.Date,
 					game.Game.HomeTeam.Abbreviation,
 					game.Game.VisitorTeam.Abbreviation,
@@ -170,15 +327,41 @@ func (e *Engine) Scan() {
 				allPropOpps = append(allPropOpps, propOpportunities...)
 			}
 		}
+		closing = append(closing, scan)
 
 		if e.db != nil && len(allPositions) > 0 {
 			hedges := positions.FindHedgeOpportunities(allPositions, consensus)
 			for _, hedge := range hedges {
-				e.notifier.AlertHedge(hedge)
+				sent := e.notifier.AlertHedge(hedge)
+				e.recordHedgeAlert(hedge, !sent)
+				if canReduce {
+					e.autoHedge(hedge)
+				}
 			}
 		}
 	}
 
+	// Games in progress are priced from the score and clock instead
+	inPlay := make(map[int]bool)
+	if len(started) > 0 {
+		liveOpps := e.inPlayOpportunities(started, refresh)
+		for _, opp := range liveOpps {
+			inPlay[opp.GameID] = true
+		}
+		allGameOpps = append(allGameOpps, liveOpps...)
+	}
+
+	metrics.GamesScanned.Set(float64(gamesScanned))
+	metrics.PropsScanned.Set(float64(propsScanned))
+	for _, opp := range allGameOpps {
+		metrics.Opportunities.Inc(string(opp.MarketType))
+	}
+	for _, opp := range allPropOpps {
+		metrics.Opportunities.Inc("prop_" + opp.PropType)
+	}
+
+	e.trackOpportunities(allGameOpps, allPropOpps)
+
 	sort.Slice(allGameOpps, func(i, j int) bool {
 		return allGameOpps[i].AdjustedEV > allGameOpps[j].AdjustedEV
 	})
@@ -187,10 +370,47 @@ func (e *Engine) Scan() {
 		return allPropOpps[i].AdjustedEV > allPropOpps[j].AdjustedEV
 	})
 
+	// Maker mode: pull resting orders the new consensus no longer supports
+	// before posting fresh ones
+	maker := e.makerMode()
+	if maker && kalshiAvailable {
+		e.repriceOrders(evaluated, allGameOpps, allPropOpps)
+	}
+
+	// Simultaneous sizing replaces each opportunity's stake with one chosen
+	// jointly across its game, as a fraction of the scan's starting bankroll
+	var stakes map[string]float64
+	startBankroll := bankroll
+	if kalshiAvailable && bankroll > 0 && e.cfg.SizingMode == config.SizingSimultaneous {
+		stakes = jointStakes(allGameOpps, allPropOpps, allPositions, bankroll, e.cfg)
+	}
+	jointStake := func(tp TradeParams) float64 {
+		return stakes[stakeKey(tp.Ticker, tp.BetSide)]
+	}
+
 	for _, opp := range allGameOpps {
-		e.notifier.AlertOpportunity(opp)
+		if stakes != nil {
+			opp.KellyStake = jointStake(TradeParamsFromOpportunity(opp))
+		}
+		tp := TradeParamsFromOpportunity(opp)
+		sent := e.notifier.AlertOpportunity(opp)
+		e.recordAlert(positions.AlertKindGame, tp, opp.BookCount, !sent)
+		if !inPlay[opp.GameID] {
+			e.recordEntry(tp, opp.BookCount)
+		}
 		if kalshiAvailable && bankroll > 0 {
-			spent := ExecuteOpportunity(e.kalshiClient, opp, bankroll, e.execConfig, e.cfg, e.db)
+			if stakes != nil {
+				// Keep the dollar stake fixed as earlier trades spend cash
+				opp.KellyStake *= startBankroll / bankroll
+			}
+			var spent float64
+			if maker && !inPlay[opp.GameID] {
+				spent = e.postMakerOrder(TradeParamsFromOpportunity(opp), startsAt[opp.GameID], bankroll)
+			} else if inPlay[opp.GameID] {
+				spent = ExecuteOpportunity(inPlayExchange{e.kalshiClient, e}, opp, bankroll, e.execConfig, e.cfg, e.db, e.notifier)
+			} else {
+				spent = ExecuteOpportunity(e.kalshiClient, opp, bankroll, e.execConfig, e.cfg, e.db, e.notifier)
+			}
 			if spent > 0 {
 				bankroll -= spent
 			}
@@ -198,14 +418,121 @@ func (e *Engine) Scan() {
 	}
 
 	for _, propOpp := range allPropOpps {
-		e.notifier.AlertPlayerProp(propOpp)
+		if stakes != nil {
+			propOpp.KellyStake = jointStake(TradeParamsFromPropOpportunity(propOpp))
+		}
+		tp := TradeParamsFromPropOpportunity(propOpp)
+		sent := e.notifier.AlertPlayerProp(propOpp)
+		e.recordAlert(positions.AlertKindProp, tp, propOpp.BookCount, !sent)
+		e.recordEntry(tp, propOpp.BookCount)
 		if kalshiAvailable && bankroll > 0 {
-			spent := ExecutePropOpportunity(e.kalshiClient, propOpp, bankroll, e.execConfig, e.cfg, e.db)
+			if stakes != nil {
+				propOpp.KellyStake *= startBankroll / bankroll
+			}
+			var spent float64
+			if maker {
+				spent = e.postMakerOrder(TradeParamsFromPropOpportunity(propOpp), startsAt[propOpp.GameID], bankroll)
+			} else {
+				spent = ExecutePropOpportunity(e.kalshiClient, propOpp, bankroll, e.execConfig, e.cfg, e.db)
+			}
 			if spent > 0 {
 				bankroll -= spent
 			}
 		}
 	}
 
-	e.notifier.LogScanWithProps(len(gameOdds), len(allGameOpps), len(allPropOpps))
+	// After this scan's fills, so a trade on the last scan before tip-off
+	// still gets its closing line
+	e.recordClosingLines(closing)
+	e.recordPredictions(closing)
+	e.savePregame(closing)
+
+	// Cross-market arbs need no consensus, only the games scanned above
+	if e.cfg.CrossArb && kalshiAvailable && bankroll > 0 {
+		var scanned []api.GameOdds
+		for _, game := range gameOdds {
+			if evaluated[game.GameID] {
+				scanned = append(scanned, game)
+			}
+		}
+		bankroll -= e.scanCrossArbs(scanned, kalshiPlayerProps, bankroll)
+	}
+
+	// Carry this scan's trades over to book updates until the next refresh
+	if bankroll < startBankroll {
+		e.bankroll = bankroll
+		e.reloadPositions()
+	}
+
+	return len(allGameOpps), len(allPropOpps)
+}
+
+// account returns the open positions and Kalshi balance to evaluate
+// against, and whether the exchange can be traded on. Without refresh the
+// values cached by the last refresh are reused.
+func (e *Engine) account(refresh bool) ([]positions.Position, float64, bool) {
+	stale := refresh || e.accountAt.IsZero()
+	if stale {
+		e.reloadPositions()
+		e.accountAt = e.now()
+	}
+	if e.kalshiClient == nil {
+		return e.openPositions, 0, false
+	}
+	if kalshi.IsMaintenanceWindow(e.now()) {
+		if e.now().Sub(e.lastMaintenanceLog) > config.DefaultMaintenanceLogCooldown {
+			slog.Warn("Kalshi maintenance window - skipping execution", "window", "Thu 3-5am ET")
+			e.lastMaintenanceLog = e.now()
+		}
+		e.bankrollOK = false
+		return e.openPositions, 0, false
+	}
+	if stale {
+		bankroll, err := e.kalshiClient.GetBalanceDollars()
+		if err != nil {
+			e.notifier.LogError("fetching Kalshi balance", err)
+		} else {
+			metrics.Bankroll.Set(bankroll)
+		}
+		e.bankroll, e.bankrollOK = bankroll, err == nil
+	}
+	return e.openPositions, e.bankroll, e.bankrollOK
+}
+
+// reloadPositions refreshes the cached open positions from the DB.
+func (e *Engine) reloadPositions() []positions.Position {
+	if e.db != nil {
+		e.openPositions, _ = e.db.GetAllPositions()
+		metrics.OpenExposure.Set(risk.ExposureOf(e.openPositions).Total)
+	}
+	return e.openPositions
+}
+
+// flagLadders logs Kalshi prop rungs priced out of line with their
+// ladder: above a lower strike, or far off the curve fitted to the
+// sportsbook lines. Each flag is logged the first time it is seen.
+func (e *Engine) flagLadders(ladders []analysis.PropLadder) {
+	for _, ladder := range ladders {
+		for _, flag := range ladder.Flags(analysis.LadderTolerance) {
+			metrics.LadderFlags.Inc(flag.Kind)
+			key := flag.Ticker + "|" + flag.Kind
+			if e.ladderFlags[key] {
+				continue
+			}
+			e.ladderFlags[key] = true
+			slog.Warn("Prop ladder mispriced", "ticker", flag.Ticker, "kind", flag.Kind,
+				"price", flag.Price, "fairProb", flag.FairProb, "description", flag.Description)
+		}
+	}
+}
+
+// pruneLadderFlags forgets the logged flags on games that aren't live, so
+// the set doesn't grow for as long as the bot runs.
+func (e *Engine) pruneLadderFlags(live map[string]bool) {
+	for key := range e.ladderFlags {
+		ticker, _, _ := strings.Cut(key, "|")
+		if finished(ticker, live) {
+			delete(e.ladderFlags, key)
+		}
+	}
 }
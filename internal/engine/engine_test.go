@@ -0,0 +1,242 @@
+package engine
+
+import (
+	"io"
+	"log"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/alerts"
+	"sports-betting-bot/internal/analysis"
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+	"sports-betting-bot/internal/metrics"
+	"sports-betting-bot/internal/positions"
+)
+
+var _ Exchange = (*kalshitest.Exchange)(nil)
+
+// fakeOdds serves fixed odds with no player props.
+type fakeOdds struct {
+	games []api.GameOdds
+}
+
+func (f *fakeOdds) GetTodaysOdds() ([]api.GameOdds, error)              { return f.games, nil }
+func (f *fakeOdds) GetPlayerProps(gameID int) ([]api.PlayerProp, error) { return nil, nil }
+func (f *fakeOdds) GetPlayerNames(playerIDs []int) map[int]string       { return nil }
+
+// scanTime is a Tuesday afternoon, outside the Thursday maintenance window.
+var scanTime = time.Date(2026, 2, 3, 18, 0, 0, 0, time.UTC)
+
+// favoriteOdds returns a game where six books price the home team at ~65%
+// and Kalshi lists it at 50¢.
+func favoriteOdds(gameID int, home, away string) api.GameOdds {
+	updated := scanTime.Add(-time.Minute).Format(time.RFC3339)
+	vendors := []api.Vendor{{
+		Name:      "Kalshi",
+		Moneyline: &api.Moneyline{Home: 50, Away: 50},
+		UpdatedAt: updated,
+	}}
+	for _, name := range []string{"DraftKings", "FanDuel", "Bet365", "Caesars", "BetRivers", "PointsBet"} {
+		vendors = append(vendors, api.Vendor{
+			Name:      name,
+			Moneyline: &api.Moneyline{Home: -200, Away: 170},
+			UpdatedAt: updated,
+		})
+	}
+	return api.GameOdds{
+		GameID: gameID,
+		Game: api.Game{
+			ID:          gameID,
+			Date:        "2026-02-05",
+			DateTime:    scanTime.Add(3 * time.Hour).Format(time.RFC3339),
+			Status:      "scheduled",
+			HomeTeam:    api.Team{Abbreviation: home},
+			VisitorTeam: api.Team{Abbreviation: away},
+		},
+		Vendors: vendors,
+	}
+}
+
+func newTestEngine(t *testing.T, games []api.GameOdds, x *kalshitest.Exchange) (*Engine, *positions.DB) {
+	t.Helper()
+
+	prevLog, prevSlog := log.Writer(), slog.Default()
+	log.SetOutput(io.Discard)
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() {
+		log.SetOutput(prevLog)
+		slog.SetDefault(prevSlog)
+	})
+
+	db, err := positions.NewDB(filepath.Join(t.TempDir(), "positions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	cfg := config.Config{
+		EVThreshold:   0.03,
+		KellyFraction: 0.25,
+		MaxOddsAgeSec: config.DefaultMaxOddsAgeSec,
+	}
+	eng := New(
+		&fakeOdds{games: games},
+		x,
+		alerts.NewNotifier(time.Minute),
+		db,
+		cfg,
+		analysis.Config{
+			EVThreshold:   cfg.EVThreshold,
+			KellyFraction: cfg.KellyFraction,
+			MinBookCount:  config.DefaultMinBookCount,
+		},
+		kalshi.OrderConfig{MaxSlippagePct: 0.02, MinLiquidityContracts: 10},
+	)
+	eng.SetClock(func() time.Time { return scanTime })
+	return eng, db
+}
+
+func TestScanFillsAgainstFakeExchange(t *testing.T) {
+	ticker := "KXNBAGAME-26FEB05GSWPHX"
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 500) // YES offered at 50¢
+	x.AddLiquidity(ticker, kalshi.SideYes, 48, 500)
+
+	eng, db := newTestEngine(t, []api.GameOdds{favoriteOdds(1, "PHX", "GSW")}, x)
+	eng.Scan()
+
+	held, _ := x.GetPositions()
+	if len(held) != 1 || held[0].Ticker != ticker || held[0].Position <= 0 {
+		t.Fatalf("exchange positions = %+v, want long YES on %s", held, ticker)
+	}
+
+	stored, err := db.GetAllPositions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stored) != 1 {
+		t.Fatalf("stored %d positions, want 1", len(stored))
+	}
+	p := stored[0]
+	if p.MarketType != "moneyline" || p.Side != "home" || p.Contracts != held[0].Position {
+		t.Errorf("stored = %+v, want %d home moneyline contracts", p, held[0].Position)
+	}
+	if has, _ := db.HasPositionOnTicker(ticker, "yes"); !has {
+		t.Errorf("HasPositionOnTicker(%s, yes) = false, want true", ticker)
+	}
+	if p.EntryPrice != 0.50 {
+		t.Errorf("EntryPrice = %v, want 0.50", p.EntryPrice)
+	}
+
+	balance, _ := x.GetBalanceDollars()
+	if balance >= 1000 {
+		t.Errorf("balance = %.2f, want cost and fees deducted", balance)
+	}
+
+	// A second scan must not add to the position
+	eng.Scan()
+	again, _ := x.GetPositions()
+	if again[0].Position != held[0].Position {
+		t.Errorf("position after rescan = %d, want unchanged %d", again[0].Position, held[0].Position)
+	}
+}
+
+func TestScanSkipsThinBook(t *testing.T) {
+	ticker := "KXNBAGAME-26FEB05LALBOS"
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 5) // below MinLiquidityContracts
+
+	eng, db := newTestEngine(t, []api.GameOdds{favoriteOdds(2, "BOS", "LAL")}, x)
+	eng.Scan()
+
+	if held, _ := x.GetPositions(); len(held) != 0 {
+		t.Errorf("exchange positions = %+v, want none", held)
+	}
+	if stored, _ := db.GetAllPositions(); len(stored) != 0 {
+		t.Errorf("stored %d positions, want none", len(stored))
+	}
+}
+
+func TestScanHaltedExchange(t *testing.T) {
+	ticker := "KXNBAGAME-26FEB05MIANYK"
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 500)
+	x.SetHalted(true)
+
+	eng, db := newTestEngine(t, []api.GameOdds{favoriteOdds(3, "NYK", "MIA")}, x)
+	eng.Scan()
+
+	if stored, _ := db.GetAllPositions(); len(stored) != 0 {
+		t.Errorf("stored %d positions on halted exchange, want none", len(stored))
+	}
+	if balance, _ := x.GetBalanceDollars(); balance != 1000 {
+		t.Errorf("balance = %.2f, want untouched 1000", balance)
+	}
+}
+
+func TestScanStopsAtTotalExposureCap(t *testing.T) {
+	x := kalshitest.NewExchange(1000)
+	tickers := []string{"KXNBAGAME-26FEB05ATLORL", "KXNBAGAME-26FEB05SASUTA"}
+	for _, ticker := range tickers {
+		x.AddLiquidity(ticker, kalshi.SideNo, 50, 500)
+	}
+
+	eng, db := newTestEngine(t, []api.GameOdds{
+		favoriteOdds(6, "ORL", "ATL"),
+		favoriteOdds(7, "UTA", "SAS"),
+	}, x)
+	eng.cfg.MaxTotalExposure = 40
+	eng.Scan()
+
+	stored := mustPositions(t, db)
+	if len(stored) != 1 {
+		t.Fatalf("stored %d positions, want 1 before the cap blocks the second game", len(stored))
+	}
+	if cost := stored[0].Cost(); cost > 40 {
+		t.Errorf("first position cost = %.2f, want it shrunk to the $40 cap", cost)
+	}
+}
+
+func TestScanRecordsMetrics(t *testing.T) {
+	ticker := "KXNBAGAME-26FEB05BKNCLE"
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 500)
+
+	attempted := metrics.OrdersAttempted.Value("moneyline")
+	filled := metrics.OrdersFilled.Value("moneyline")
+	found := metrics.Opportunities.Value("moneyline")
+	scans := metrics.ScanDuration.Count()
+
+	eng, _ := newTestEngine(t, []api.GameOdds{favoriteOdds(12, "CLE", "BKN")}, x)
+	eng.Scan()
+
+	if got := metrics.OrdersAttempted.Value("moneyline") - attempted; got != 1 {
+		t.Errorf("orders attempted += %v, want 1", got)
+	}
+	if got := metrics.OrdersFilled.Value("moneyline") - filled; got != 1 {
+		t.Errorf("orders filled += %v, want 1", got)
+	}
+	if got := metrics.Opportunities.Value("moneyline") - found; got < 1 {
+		t.Errorf("moneyline opportunities += %v, want at least 1", got)
+	}
+	if got := metrics.ScanDuration.Count() - scans; got != 1 {
+		t.Errorf("scan duration observations += %d, want 1", got)
+	}
+	if got := metrics.Bankroll.Value(); got != 1000 {
+		t.Errorf("bankroll = %v, want 1000 at scan start", got)
+	}
+	if got := metrics.GamesScanned.Value(); got != 1 {
+		t.Errorf("games scanned = %v, want 1", got)
+	}
+
+	// Exposure is read from the DB at the start of the next scan
+	eng.Scan()
+	if got := metrics.OpenExposure.Value(); got <= 0 {
+		t.Errorf("open exposure = %v, want the filled position's cost", got)
+	}
+}
@@ -0,0 +1,321 @@
+package engine
+
+import (
+	"log/slog"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"sports-betting-bot/internal/analysis"
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/metrics"
+)
+
+// SetMarketFeed streams live Kalshi books into the engine. Scans then price
+// game moneylines and player props from the feed's books, and in event
+// mode every book update re-evaluates its game.
+func (e *Engine) SetMarketFeed(feed MarketFeed) {
+	e.feed = feed
+}
+
+// pollChanges is the event-mode poll: it re-evaluates only games whose
+// vendor lines changed since the last poll, plus in-play games, or runs a
+// full scan when one is due as a consistency backstop.
+func (e *Engine) pollChanges() {
+	if e.now().Sub(e.lastFullScan) >= e.cfg.FullScanInterval {
+		e.Scan()
+		return
+	}
+
+	gameOdds, err := e.client.GetTodaysOdds()
+	if err != nil {
+		e.notifier.LogError("fetching odds", err)
+		return
+	}
+
+	changed := e.changedGames(gameOdds)
+	if e.inPlayMode() {
+		changed = withStartedGames(changed, gameOdds)
+	}
+	if len(changed) == 0 {
+		return
+	}
+	// Re-evaluating a game covers any book updates waiting on it
+	for _, game := range changed {
+		delete(e.dirty, gameKey(game))
+	}
+
+	metrics.EventScans.Inc("odds")
+	gameOpps, propOpps := e.evaluate(changed, e.propMarkets, true)
+	slog.Debug("Event scan", "trigger", "odds", "games", len(changed), "gameOpps", gameOpps, "propOpps", propOpps)
+}
+
+// withStartedGames adds the games in progress to changed: their score
+// and clock move every poll even when the vendor lines don't.
+func withStartedGames(changed, gameOdds []api.GameOdds) []api.GameOdds {
+	seen := make(map[int]bool, len(changed))
+	for _, game := range changed {
+		seen[game.GameID] = true
+	}
+	for _, game := range gameOdds {
+		if inProgress(game.Game.Status) && !seen[game.GameID] {
+			changed = append(changed, game)
+		}
+	}
+	return changed
+}
+
+// markBookUpdate queues ticker's game for the next evaluateBookUpdates.
+func (e *Engine) markBookUpdate(ticker string) {
+	if info, ok := kalshi.ParseNBATicker(ticker); ok {
+		e.dirty[info.Game()] = true
+	}
+}
+
+// evaluateBookUpdates re-evaluates every game with a changed Kalshi book
+// against its last known odds and sportsbook props, pricing only those
+// games' prop markets. The balance and open positions come from the last
+// refresh; no upstream calls are made besides the live book reads.
+func (e *Engine) evaluateBookUpdates() {
+	if len(e.dirty) == 0 {
+		return
+	}
+	keys := make([]string, 0, len(e.dirty))
+	for key := range e.dirty {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	clear(e.dirty)
+
+	var games []api.GameOdds
+	inGame := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if game, ok := e.games[key]; ok {
+			games = append(games, game)
+			inGame[key] = true
+		}
+	}
+	if len(games) == 0 {
+		return
+	}
+
+	metrics.EventScans.Inc("book")
+	gameOpps, propOpps := e.evaluate(games, propMarketsOn(e.propMarkets, inGame), false)
+	slog.Debug("Event scan", "trigger", "book", "games", len(games), "gameOpps", gameOpps, "propOpps", propOpps)
+}
+
+// propMarketsOn returns the markets on the games with the given keys.
+func propMarketsOn(markets map[string][]kalshi.PlayerPropMarket, keys map[string]bool) map[string][]kalshi.PlayerPropMarket {
+	scoped := make(map[string][]kalshi.PlayerPropMarket)
+	for propType, list := range markets {
+		for _, m := range list {
+			if info, ok := kalshi.ParseNBATicker(m.Ticker); ok && keys[info.Game()] {
+				scoped[propType] = append(scoped[propType], m)
+			}
+		}
+	}
+	return scoped
+}
+
+// rememberScan resets the event-mode baseline after a full scan,
+// subscribes the feed to every game's moneyline and forgets the tickers
+// of games that are final or no longer listed.
+func (e *Engine) rememberScan(gameOdds []api.GameOdds, propMarkets map[string][]kalshi.PlayerPropMarket) {
+	e.lastFullScan = e.now()
+	e.propMarkets = propMarkets
+	e.oddsVersions = make(map[int]string)
+	e.games = make(map[string]api.GameOdds)
+	e.playerProps = make(map[int][]api.PlayerProp)
+	clear(e.dirty)
+	e.changedGames(gameOdds)
+
+	live := liveGameKeys(gameOdds)
+	e.pruneLadderFlags(live)
+	e.prunePregame(gameOdds)
+	if e.feed != nil {
+		var tickers []string
+		for _, game := range gameOdds {
+			if t := moneylineTicker(game); t != "" {
+				tickers = append(tickers, t)
+			}
+		}
+		e.feed.Track(tickers...)
+		e.untrackFinishedGames(live)
+	}
+}
+
+// liveGameKeys returns the game keys of the listed games that aren't final.
+func liveGameKeys(gameOdds []api.GameOdds) map[string]bool {
+	live := make(map[string]bool, len(gameOdds))
+	for _, game := range gameOdds {
+		if game.Game.Status != "Final" {
+			live[gameKey(game)] = true
+		}
+	}
+	return live
+}
+
+// finished reports whether ticker names a game outside live. Tickers that
+// don't name a game are never finished.
+func finished(ticker string, live map[string]bool) bool {
+	info, ok := kalshi.ParseNBATicker(ticker)
+	return ok && !live[info.Game()]
+}
+
+// untrackFinishedGames unsubscribes the feed from every game ticker whose
+// game isn't live.
+func (e *Engine) untrackFinishedGames(live map[string]bool) {
+	var done []string
+	for _, t := range e.feed.Tracked() {
+		if finished(t, live) {
+			done = append(done, t)
+		}
+	}
+	if len(done) > 0 {
+		slog.Debug("Untracking finished games", "tickers", len(done))
+		e.feed.Untrack(done...)
+	}
+}
+
+// changedGames records gameOdds as the latest odds and returns the games
+// whose vendor lines differ from the previous call.
+func (e *Engine) changedGames(gameOdds []api.GameOdds) []api.GameOdds {
+	var changed []api.GameOdds
+	for _, game := range gameOdds {
+		version := oddsVersion(game)
+		if e.oddsVersions[game.GameID] != version {
+			changed = append(changed, game)
+			e.oddsVersions[game.GameID] = version
+		}
+		if key := gameKey(game); key != "" {
+			e.games[key] = game
+		}
+	}
+	return changed
+}
+
+// oddsVersion fingerprints a game's lines by each vendor's UpdatedAt, so
+// any vendor moving a line (or the game changing status) changes it.
+func oddsVersion(game api.GameOdds) string {
+	stamps := make([]string, 0, len(game.Vendors))
+	for _, v := range game.Vendors {
+		stamps = append(stamps, v.Name+"@"+v.UpdatedAt)
+	}
+	sort.Strings(stamps)
+	return game.Game.Status + "|" + strings.Join(stamps, "|")
+}
+
+// moneylineTicker returns the game's KXNBAGAME ticker, or "" if it can't
+// be built.
+func moneylineTicker(game api.GameOdds) string {
+	date, err := time.Parse("2006-01-02", game.Game.Date)
+	if err != nil {
+		return ""
+	}
+	return kalshi.BuildNBATicker(kalshi.SeriesMoneyline, date,
+		game.Game.VisitorTeam.Abbreviation, game.Game.HomeTeam.Abbreviation)
+}
+
+// gameKey returns the Kalshi game segment for a game, e.g. "26FEB05GSWPHX",
+// which book updates are matched on.
+func gameKey(game api.GameOdds) string {
+	info, ok := kalshi.ParseNBATicker(moneylineTicker(game))
+	if !ok {
+		return ""
+	}
+	return info.Game()
+}
+
+// trackOpportunities subscribes the feed to every ticker an opportunity
+// was found on, so later book moves re-evaluate it.
+func (e *Engine) trackOpportunities(gameOpps []analysis.Opportunity, propOpps []analysis.PlayerPropOpportunity) {
+	if e.feed == nil {
+		return
+	}
+	var tickers []string
+	for _, opp := range gameOpps {
+		if t := MapToKalshiTicker(opp); t != "" {
+			tickers = append(tickers, t)
+		}
+	}
+	for _, opp := range propOpps {
+		if opp.KalshiTicker != "" {
+			tickers = append(tickers, opp.KalshiTicker)
+		}
+	}
+	if len(tickers) > 0 {
+		e.feed.Track(tickers...)
+	}
+}
+
+// withLiveMoneyline replaces the Kalshi vendor's moneyline with the asks
+// on the feed's live book, adding a Kalshi vendor if BallDontLie has none.
+func (e *Engine) withLiveMoneyline(game api.GameOdds) api.GameOdds {
+	if e.feed == nil {
+		return game
+	}
+	book, ok := e.feed.OrderBook(moneylineTicker(game))
+	if !ok {
+		return game
+	}
+	_, yesAsk, _, noAsk := bookQuotes(book)
+	live := api.Vendor{
+		Name:      "Kalshi",
+		Moneyline: &api.Moneyline{Home: yesAsk, Away: noAsk},
+		UpdatedAt: e.now().Format(time.RFC3339),
+	}
+
+	game.Vendors = slices.Clone(game.Vendors)
+	for i, v := range game.Vendors {
+		if api.IsKalshi(v.Name) {
+			v.Moneyline = live.Moneyline
+			game.Vendors[i] = v
+			return game
+		}
+	}
+	game.Vendors = append(game.Vendors, live)
+	return game
+}
+
+// withLivePropQuotes returns markets with bids and asks taken from the
+// feed's live books wherever it has one.
+func (e *Engine) withLivePropQuotes(markets map[string][]kalshi.PlayerPropMarket) map[string][]kalshi.PlayerPropMarket {
+	if e.feed == nil || len(markets) == 0 {
+		return markets
+	}
+	live := make(map[string][]kalshi.PlayerPropMarket, len(markets))
+	for propType, list := range markets {
+		list = slices.Clone(list)
+		for i := range list {
+			if book, ok := e.feed.OrderBook(list[i].Ticker); ok {
+				list[i].YesBid, list[i].YesAsk, list[i].NoBid, list[i].NoAsk = bookQuotes(book)
+			}
+		}
+		live[propType] = list
+	}
+	return live
+}
+
+// bookQuotes returns the best bids and the asks they imply, in cents. A
+// YES bid at P is a NO offer at 100-P. Missing sides are 0.
+func bookQuotes(book *kalshi.OrderBookResponse) (yesBid, yesAsk, noBid, noAsk int) {
+	for _, l := range book.OrderBook.Yes {
+		if l[1] > 0 {
+			yesBid = max(yesBid, l[0])
+		}
+	}
+	for _, l := range book.OrderBook.No {
+		if l[1] > 0 {
+			noBid = max(noBid, l[0])
+		}
+	}
+	if noBid > 0 {
+		yesAsk = 100 - noBid
+	}
+	if yesBid > 0 {
+		noAsk = 100 - yesBid
+	}
+	return yesBid, yesAsk, noBid, noAsk
+}
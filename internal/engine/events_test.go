@@ -0,0 +1,209 @@
+package engine
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+)
+
+// countingOdds serves fixed odds and counts player prop fetches per game.
+type countingOdds struct {
+	fakeOdds
+	propCalls map[int]int
+}
+
+func (c *countingOdds) GetPlayerProps(gameID int) ([]api.PlayerProp, error) {
+	c.propCalls[gameID]++
+	return nil, nil
+}
+
+// countingExchange counts balance fetches.
+type countingExchange struct {
+	*kalshitest.Exchange
+	balanceCalls int
+}
+
+func (c *countingExchange) GetBalanceDollars() (float64, error) {
+	c.balanceCalls++
+	return c.Exchange.GetBalanceDollars()
+}
+
+// fakeFeed serves fixed order books and records tracked tickers.
+type fakeFeed struct {
+	books   map[string]*kalshi.OrderBookResponse
+	tracked []string
+	updates chan string
+}
+
+func (f *fakeFeed) Track(tickers ...string) {
+	for _, t := range tickers {
+		if !slices.Contains(f.tracked, t) {
+			f.tracked = append(f.tracked, t)
+		}
+	}
+}
+func (f *fakeFeed) Untrack(tickers ...string) {
+	f.tracked = slices.DeleteFunc(f.tracked, func(t string) bool { return slices.Contains(tickers, t) })
+}
+func (f *fakeFeed) Tracked() []string      { return slices.Clone(f.tracked) }
+func (f *fakeFeed) Updates() <-chan string { return f.updates }
+func (f *fakeFeed) OrderBook(ticker string) (*kalshi.OrderBookResponse, bool) {
+	b, ok := f.books[ticker]
+	return b, ok
+}
+
+func TestPollChangesReevaluatesChangedGames(t *testing.T) {
+	x := kalshitest.NewExchange(1000)
+	odds := &countingOdds{
+		fakeOdds:  fakeOdds{games: []api.GameOdds{favoriteOdds(13, "DEN", "MIN"), favoriteOdds(14, "TOR", "WAS")}},
+		propCalls: make(map[int]int),
+	}
+	eng, _ := newTestEngine(t, nil, x)
+	eng.client = odds
+	eng.cfg.ScanMode = config.ScanEvent
+	eng.cfg.FullScanInterval = time.Minute
+
+	eng.Scan()
+	if odds.propCalls[13] != 1 || odds.propCalls[14] != 1 {
+		t.Fatalf("prop fetches after full scan = %v, want one per game", odds.propCalls)
+	}
+
+	// Nothing moved: the poll evaluates nothing
+	eng.pollChanges()
+	if odds.propCalls[13] != 1 || odds.propCalls[14] != 1 {
+		t.Errorf("prop fetches after idle poll = %v, want unchanged", odds.propCalls)
+	}
+
+	// One book moves a line on game 13 only
+	odds.games[0].Vendors[1].UpdatedAt = scanTime.Format(time.RFC3339)
+	eng.pollChanges()
+	if odds.propCalls[13] != 2 || odds.propCalls[14] != 1 {
+		t.Errorf("prop fetches after game 13 moved = %v, want only game 13 refetched", odds.propCalls)
+	}
+
+	// Once the full scan interval passes, every game is rescanned
+	eng.SetClock(func() time.Time { return scanTime.Add(2 * time.Minute) })
+	eng.pollChanges()
+	if odds.propCalls[13] != 3 || odds.propCalls[14] != 2 {
+		t.Errorf("prop fetches after backstop scan = %v, want every game refetched", odds.propCalls)
+	}
+}
+
+func TestBookUpdateUsesLiveMoneyline(t *testing.T) {
+	ticker := "KXNBAGAME-26FEB05CHAIND"
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 500)
+
+	// BallDontLie's Kalshi quote is stale at 70¢, where the home side has no edge
+	game := favoriteOdds(15, "IND", "CHA")
+	game.Vendors[0].Moneyline = &api.Moneyline{Home: 70, Away: 40}
+
+	feed := &fakeFeed{books: make(map[string]*kalshi.OrderBookResponse), updates: make(chan string, 1)}
+	eng, db := newTestEngine(t, []api.GameOdds{game}, x)
+	counting := &countingExchange{Exchange: x}
+	eng.kalshiClient = counting
+	eng.cfg.ScanMode = config.ScanEvent
+	eng.cfg.FullScanInterval = time.Minute
+	eng.SetMarketFeed(feed)
+
+	eng.Scan()
+	if !slices.Contains(feed.tracked, ticker) {
+		t.Errorf("tracked = %v, want the moneyline %s", feed.tracked, ticker)
+	}
+	if stored := mustPositions(t, db); len(stored) != 0 {
+		t.Fatalf("stored %d positions at the stale price, want none", len(stored))
+	}
+
+	// The live book now offers YES at 50¢
+	book := &kalshi.OrderBookResponse{}
+	book.OrderBook.No = [][2]int{{50, 500}}
+	book.OrderBook.Yes = [][2]int{{48, 500}}
+	feed.books[ticker] = book
+
+	eng.markBookUpdate(ticker)
+	eng.evaluateBookUpdates()
+
+	stored := mustPositions(t, db)
+	if len(stored) != 1 || stored[0].Side != "home" {
+		t.Fatalf("stored = %+v, want a home position from the book update", stored)
+	}
+	if len(eng.dirty) != 0 {
+		t.Errorf("dirty = %v, want cleared after evaluation", eng.dirty)
+	}
+
+	// The book update traded on the full scan's balance, less what it spent
+	if counting.balanceCalls != 1 {
+		t.Errorf("balance fetched %d times, want only by the full scan", counting.balanceCalls)
+	}
+	if spent := stored[0].Cost(); eng.bankroll > 1000-spent+1e-9 {
+		t.Errorf("cached bankroll = %.2f, want 1000 less the $%.2f spent", eng.bankroll, spent)
+	}
+	if len(eng.openPositions) != 1 {
+		t.Errorf("cached positions = %d, want the new fill", len(eng.openPositions))
+	}
+}
+
+func TestPropMarketsOn(t *testing.T) {
+	markets := map[string][]kalshi.PlayerPropMarket{
+		"points": {
+			{Ticker: "KXNBAPTS-26FEB05CHAIND-INDTHALIBURTON0-20"},
+			{Ticker: "KXNBAPTS-26FEB05MINDEN-DENNJOKIC15-25"},
+		},
+		"rebounds": {{Ticker: "KXNBAREB-26FEB05MINDEN-DENNJOKIC15-10"}},
+	}
+	got := propMarketsOn(markets, map[string]bool{"26FEB05CHAIND": true})
+	if len(got) != 1 || len(got["points"]) != 1 || got["points"][0].Ticker != markets["points"][0].Ticker {
+		t.Errorf("propMarketsOn = %+v, want only the CHA-IND points market", got)
+	}
+}
+
+func TestFullScanUntracksFinishedGames(t *testing.T) {
+	x := kalshitest.NewExchange(1000)
+	den, tor := favoriteOdds(13, "DEN", "MIN"), favoriteOdds(14, "TOR", "WAS")
+	odds := &fakeOdds{games: []api.GameOdds{den, tor}}
+	feed := &fakeFeed{books: make(map[string]*kalshi.OrderBookResponse), updates: make(chan string, 1)}
+	eng, _ := newTestEngine(t, nil, x)
+	eng.client = odds
+	eng.SetMarketFeed(feed)
+
+	eng.Scan()
+	feed.Track("KXNBAPTS-26FEB05MINDEN-DENNJOKIC15-25") // A prop on DEN-MIN
+	if len(feed.tracked) != 3 {
+		t.Fatalf("tracked = %v, want both moneylines and the prop", feed.tracked)
+	}
+
+	den.Game.Status = "Final"
+	odds.games = []api.GameOdds{den, tor}
+	eng.Scan()
+	if !slices.Equal(feed.tracked, []string{moneylineTicker(tor)}) {
+		t.Errorf("tracked = %v, want only %s once DEN-MIN is final", feed.tracked, moneylineTicker(tor))
+	}
+
+	bos := favoriteOdds(15, "BOS", "LAL")
+	odds.games = []api.GameOdds{bos}
+	eng.Scan()
+	if !slices.Equal(feed.tracked, []string{moneylineTicker(bos)}) {
+		t.Errorf("tracked = %v, want only %s once TOR-WAS is no longer listed", feed.tracked, moneylineTicker(bos))
+	}
+}
+
+func TestFullScanPrunesLadderFlags(t *testing.T) {
+	den, tor := favoriteOdds(13, "DEN", "MIN"), favoriteOdds(14, "TOR", "WAS")
+	den.Game.Status = "Final"
+	eng, _ := newTestEngine(t, []api.GameOdds{den, tor}, kalshitest.NewExchange(1000))
+	eng.ladderFlags = map[string]bool{
+		"KXNBAPTS-26FEB05MINDEN-DENNJOKIC15-25|non_monotone": true,
+		"KXNBAPTS-26FEB05WASTOR-TORSBARNES4-20|off_curve":    true,
+		"KXNBAPTS-26FEB04BOSLAL-LALLJAMES23-25|off_curve":    true, // Yesterday's, no longer listed
+	}
+
+	eng.Scan()
+	if len(eng.ladderFlags) != 1 || !eng.ladderFlags["KXNBAPTS-26FEB05WASTOR-TORSBARNES4-20|off_curve"] {
+		t.Errorf("ladderFlags = %v, want only the TOR-WAS flag", eng.ladderFlags)
+	}
+}
@@ -6,14 +6,20 @@ import (
 	"sync"
 	"time"
 
+	"github.com/google/uuid"
+
+	"sports-betting-bot/internal/alerts"
 	"sports-betting-bot/internal/analysis"
 	"sports-betting-bot/internal/config"
 	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/metrics"
 	"sports-betting-bot/internal/positions"
+	"sports-betting-bot/internal/risk"
 )
 
 // recentAttempts tracks recently-attempted ticker+side combos to prevent
-// race conditions between poll cycles while the order is in-flight.
+// race conditions between poll cycles while the order is in-flight. With a
+// DB the order journal does this durably; see orderInFlight.
 var (
 	recentAttempts   = make(map[string]time.Time)
 	recentAttemptsMu sync.Mutex
@@ -49,6 +55,21 @@ func clearAttempt(ticker, side string) {
 	delete(recentAttempts, ticker+":"+side)
 }
 
+// recordOrder updates the order metrics for one PlaceOrder call. quotedCents
+// is the price the opportunity was found at, for slippage.
+func recordOrder(marketType string, quotedCents float64, result *kalshi.ExecutionResult, err error) {
+	metrics.OrdersAttempted.Inc(marketType)
+	switch {
+	case err != nil || result == nil:
+		metrics.OrdersRejected.Inc(marketType, "error")
+	case result.FilledContracts > 0:
+		metrics.OrdersFilled.Inc(marketType)
+		metrics.FillSlippage.Observe(result.AveragePrice-quotedCents, marketType)
+	default:
+		metrics.OrdersRejected.Inc(marketType, metrics.RejectionReason(result.RejectionReason))
+	}
+}
+
 // TradeParams captures the common fields needed for trade execution,
 // regardless of whether the opportunity is a game market or player prop.
 type TradeParams struct {
@@ -64,9 +85,30 @@ type TradeParams struct {
 	AwayTeam     string
 	MarketType   string
 	PositionSide string // e.g. "home", "away", "over", "under", or player-specific
+	Player       string // Player props only
 	LogPrefix    string // "GAME" or "PROP"
 }
 
+// riskTrade describes tp for the portfolio exposure caps.
+func (tp TradeParams) riskTrade() risk.Trade {
+	side := tp.PositionSide
+	if tp.Player != "" {
+		side = "over"
+		if tp.Side == kalshi.SideNo {
+			side = "under"
+		}
+	}
+	return risk.Trade{
+		Ticker:     tp.Ticker,
+		GameID:     fmt.Sprintf("%d", tp.GameID),
+		HomeTeam:   tp.HomeTeam,
+		AwayTeam:   tp.AwayTeam,
+		MarketType: tp.MarketType,
+		Side:       side,
+		Player:     tp.Player,
+	}
+}
+
 // TradeParamsFromOpportunity builds TradeParams from a game opportunity.
 func TradeParamsFromOpportunity(opp analysis.Opportunity) TradeParams {
 	ticker := MapToKalshiTicker(opp)
@@ -113,6 +155,7 @@ func TradeParamsFromPropOpportunity(opp analysis.PlayerPropOpportunity) TradePar
 		AwayTeam:     opp.AwayTeam,
 		MarketType:   fmt.Sprintf("prop_%s", opp.PropType),
 		PositionSide: fmt.Sprintf("%s_%s_%.1f", opp.PlayerName, opp.Side, opp.Line),
+		Player:       opp.PlayerName,
 		LogPrefix:    "PROP",
 	}
 }
@@ -121,12 +164,13 @@ func TradeParamsFromPropOpportunity(opp analysis.PlayerPropOpportunity) TradePar
 // ticker mapping, duplicate check, arb detection, and trade execution.
 // Returns the dollar amount spent.
 func ExecuteOpportunity(
-	kalshiClient *kalshi.KalshiClient,
+	kalshiClient Exchange,
 	opp analysis.Opportunity,
 	bankroll float64,
 	execConfig kalshi.OrderConfig,
 	cfg config.Config,
 	db *positions.DB,
+	notifier *alerts.Notifier,
 ) float64 {
 	tp := TradeParamsFromOpportunity(opp)
 	if tp.Ticker == "" {
@@ -134,8 +178,9 @@ func ExecuteOpportunity(
 		return 0
 	}
 
-	// Check in-flight lock (prevents race between poll cycles)
-	if isRecentlyAttempted(tp.Ticker, tp.BetSide) {
+	// Skip while an earlier order's outcome is unknown, even across
+	// restarts, and on tickers a hedge has locked
+	if orderInFlight(db, tp.Ticker, tp.BetSide) || tickerLocked(db, tp.Ticker) {
 		return 0
 	}
 
@@ -155,7 +200,7 @@ func ExecuteOpportunity(
 		MinProfitPct:   config.DefaultMinArbProfitPct,
 	}
 
-	canAdd, isArb, arbOpp, err := kalshiClient.CheckCanAddToPosition(tp.Ticker, tp.Side, arbConfig)
+	canAdd, isArb, arbOpp, err := kalshi.CheckCanAddToPosition(kalshiClient, tp.Ticker, tp.Side, arbConfig)
 	if err != nil {
 		slog.Error("Checking position failed", "ticker", tp.Ticker, "err", err)
 		return 0
@@ -167,7 +212,7 @@ func ExecuteOpportunity(
 	// If this is an arb opportunity, execute the arb instead of the +EV trade
 	if isArb && arbOpp != nil {
 		slog.Info("Arb detected", "ticker", tp.Ticker, "description", arbOpp.Description)
-		return ExecuteArbitrage(kalshiClient, arbOpp, bankroll, execConfig, cfg, db, tp)
+		return ExecuteArbitrage(kalshiClient, arbOpp, bankroll, execConfig, cfg, db, notifier, tp)
 	}
 
 	return ExecuteTrade(kalshiClient, tp, bankroll, execConfig, cfg, db)
@@ -176,7 +221,7 @@ func ExecuteOpportunity(
 // ExecutePropOpportunity handles the full lifecycle of executing a player prop opportunity.
 // Returns the dollar amount spent.
 func ExecutePropOpportunity(
-	kalshiClient *kalshi.KalshiClient,
+	kalshiClient Exchange,
 	opp analysis.PlayerPropOpportunity,
 	bankroll float64,
 	execConfig kalshi.OrderConfig,
@@ -189,8 +234,9 @@ func ExecutePropOpportunity(
 		return 0
 	}
 
-	// Check in-flight lock (prevents race between poll cycles)
-	if isRecentlyAttempted(tp.Ticker, tp.BetSide) {
+	// Skip while an earlier order's outcome is unknown, even across
+	// restarts, and on tickers a hedge has locked
+	if orderInFlight(db, tp.Ticker, tp.BetSide) || tickerLocked(db, tp.Ticker) {
 		return 0
 	}
 
@@ -210,7 +256,7 @@ func ExecutePropOpportunity(
 		MinProfitPct:   config.DefaultMinArbProfitPct,
 	}
 
-	canAdd, _, _, err := kalshiClient.CheckCanAddToPosition(tp.Ticker, tp.Side, arbConfig)
+	canAdd, _, _, err := kalshi.CheckCanAddToPosition(kalshiClient, tp.Ticker, tp.Side, arbConfig)
 	if err != nil {
 		slog.Error("Checking position for prop failed", "ticker", tp.Ticker, "err", err)
 		return 0
@@ -225,7 +271,7 @@ func ExecutePropOpportunity(
 // ExecuteTrade is the unified trade execution path for both game and prop opportunities.
 // Returns the dollar amount spent.
 func ExecuteTrade(
-	kalshiClient *kalshi.KalshiClient,
+	kalshiClient Exchange,
 	tp TradeParams,
 	bankroll float64,
 	execConfig kalshi.OrderConfig,
@@ -233,15 +279,10 @@ func ExecuteTrade(
 	db *positions.DB,
 ) float64 {
 	// Calculate bet size using real bankroll
-	priceInCents := int(tp.KalshiPrice * 100)
-	contracts := analysis.CalculateKellyContracts(
-		tp.TrueProb,
-		tp.KalshiPrice,
-		cfg.KellyFraction,
-		bankroll,
-		cfg.MaxBetDollars,
-		priceInCents,
-	)
+	size := func(price float64, priceInCents int) int {
+		return stakeContracts(tp, cfg, bankroll, price, priceInCents)
+	}
+	contracts := size(tp.KalshiPrice, int(tp.KalshiPrice*100))
 
 	if contracts < execConfig.MinLiquidityContracts {
 		return 0
@@ -254,16 +295,14 @@ func ExecuteTrade(
 		return 0
 	}
 
-	slippage := kalshiClient.CalculateSlippage(book, tp.Side, kalshi.ActionBuy, contracts)
+	slippage := kalshi.CalculateSlippage(book, tp.Side, kalshi.ActionBuy, contracts)
 	if !slippage.Acceptable {
 		return 0
 	}
 
 	// Recompute Kelly at actual fill price and take the smaller size
 	actualFillPrice := slippage.AverageFillPrice / 100.0
-	adjustedContracts := analysis.CalculateKellyContracts(
-		tp.TrueProb, actualFillPrice, cfg.KellyFraction,
-		bankroll, cfg.MaxBetDollars, int(slippage.AverageFillPrice))
+	adjustedContracts := size(actualFillPrice, int(slippage.AverageFillPrice))
 	if adjustedContracts < contracts {
 		contracts = adjustedContracts
 	}
@@ -280,25 +319,67 @@ func ExecuteTrade(
 		return 0
 	}
 
+	// Portfolio caps: shrink to the remaining headroom, or skip the trade
+	// if that leaves less than the minimum size
+	headroom, binding, err := risk.NewManager(risk.LimitsFromConfig(cfg), db).Headroom(tp.riskTrade())
+	if err != nil {
+		slog.Error("Risk check failed", "ticker", tp.Ticker, "err", err)
+		return 0
+	}
+	if binding != "" {
+		maxContracts := int(headroom * 100 / slippage.AverageFillPrice)
+		if maxContracts < execConfig.MinLiquidityContracts {
+			slog.Warn("Risk cap blocked trade",
+				"ticker", tp.Ticker, "cap", binding, "headroom", headroom, "contracts", contracts)
+			return 0
+		}
+		if maxContracts < contracts {
+			slog.Info("Risk cap reduced trade",
+				"ticker", tp.Ticker, "cap", binding, "from", contracts, "to", maxContracts)
+			contracts = maxContracts
+		}
+	}
+
 	slog.Info("Executing trade",
 		"type", tp.LogPrefix, "ticker", tp.Ticker, "side", tp.Side,
 		"contracts", contracts, "price", slippage.AverageFillPrice,
 		"ev", adjustedEV*100, "kelly", tp.KellyStake*100)
 
-	// Mark as in-flight to prevent duplicate attempts from concurrent poll cycles
-	markAttempted(tp.Ticker, tp.BetSide)
-
 	// Add EV verification to execution config
 	execConfigWithEV := execConfig
 	execConfigWithEV.TrueProb = tp.TrueProb
 	execConfigWithEV.EVThreshold = cfg.EVThreshold
 
+	// Journal the order before sending it, so a crash mid-submit leaves a
+	// record to reconcile rather than an order nobody knows about. Without a
+	// DB, mark it in-flight in memory instead.
+	var entry *positions.JournalEntry
+	if db != nil && !execConfig.DryRun {
+		j := tp.journalEntry(positions.OrderKindTaker, contracts)
+		if err := db.RecordIntent(j); err != nil {
+			slog.Error("Journaling order failed, skipping trade", "ticker", tp.Ticker, "err", err)
+			return 0
+		}
+		entry = &j
+		execConfigWithEV.ClientOrderID = j.ClientOrderID
+	} else {
+		markAttempted(tp.Ticker, tp.BetSide)
+	}
+
 	result, err := kalshiClient.PlaceOrder(tp.Ticker, tp.Side, kalshi.ActionBuy, contracts, execConfigWithEV)
+	recordOrder(tp.MarketType, tp.KalshiPrice*100, result, err)
+	// A journaled order whose outcome is unknown stays in flight until
+	// reconcileJournal finds it on Kalshi
 	if err != nil {
 		slog.Error("Order failed", "ticker", tp.Ticker, "err", err)
 		clearAttempt(tp.Ticker, tp.BetSide)
 		return 0
 	}
+	if entry != nil && outcomeUnknown(result) {
+		slog.Warn("Order outcome unknown, left for reconciliation",
+			"ticker", tp.Ticker, "clientOrderID", entry.ClientOrderID, "reason", result.RejectionReason)
+		return 0
+	}
 
 	if result.Success {
 		slog.Info("Order filled",
@@ -318,8 +399,15 @@ func ExecuteTrade(
 				BetSide:    tp.BetSide,
 				EntryPrice: result.AveragePrice / 100,
 				Contracts:  result.FilledContracts,
+				Fees:       kalshi.OrderFeeDollars(result.AveragePrice, result.FilledContracts),
+			}
+			var id int64
+			var dbErr error
+			if entry != nil {
+				id, dbErr = db.RecordOrderResult(takerUpdate(*entry, result), &pos)
+			} else {
+				id, dbErr = db.AddPosition(pos)
 			}
-			id, dbErr := db.AddPosition(pos)
 			if dbErr != nil {
 				slog.Error("Storing position failed", "err", dbErr)
 			} else {
@@ -332,19 +420,47 @@ func ExecuteTrade(
 
 	slog.Warn("Order rejected",
 		"type", tp.LogPrefix, "ticker", tp.Ticker, "reason", result.RejectionReason)
+	if entry != nil {
+		if _, err := db.RecordOrderResult(takerUpdate(*entry, result), nil); err != nil {
+			slog.Error("Recording order outcome failed", "ticker", tp.Ticker, "err", err)
+		}
+	}
 	clearAttempt(tp.Ticker, tp.BetSide)
 	return 0
 }
 
-// ExecuteArbitrage executes an arbitrage opportunity.
+// stakeContracts sizes tp at a price (0-1, and in cents) from the real
+// bankroll. Simultaneous sizing has already set the stake jointly with the
+// rest of the game.
+func stakeContracts(tp TradeParams, cfg config.Config, bankroll, price float64, priceInCents int) int {
+	if cfg.SizingMode == config.SizingSimultaneous {
+		betSize := tp.KellyStake * bankroll
+		if cfg.MaxBetDollars > 0 && betSize > cfg.MaxBetDollars {
+			betSize = cfg.MaxBetDollars
+		}
+		return analysis.StakeContracts(betSize, priceInCents)
+	}
+	return analysis.CalculateKellyContracts(
+		tp.TrueProb,
+		price,
+		cfg.KellyFraction,
+		bankroll,
+		cfg.MaxBetDollars,
+		priceInCents,
+	)
+}
+
+// ExecuteArbitrage executes an arbitrage opportunity, squares an uneven
+// fill through the leg manager and alerts with the arb's P&L.
 // Returns the dollar amount spent.
 func ExecuteArbitrage(
-	kalshiClient *kalshi.KalshiClient,
+	kalshiClient Exchange,
 	arb *kalshi.ArbOpportunity,
 	bankroll float64,
 	execConfig kalshi.OrderConfig,
 	cfg config.Config,
 	db *positions.DB,
+	notifier *alerts.Notifier,
 	tp TradeParams,
 ) float64 {
 	costPerContractCents := arb.TotalCost
@@ -369,14 +485,35 @@ func ExecuteArbitrage(
 		return 0
 	}
 
-	// Mark as in-flight
-	markAttempted(tp.Ticker, tp.BetSide)
+	// Journal both legs before sending; ExecuteArb derives each leg's
+	// client order ID from the base ID the same way
+	legs := make(map[kalshi.Side]positions.JournalEntry)
+	if db != nil {
+		base := uuid.New().String()
+		for _, side := range []kalshi.Side{kalshi.SideYes, kalshi.SideNo} {
+			j := tp.journalEntry(positions.OrderKindArb, contracts)
+			j.ClientOrderID = kalshi.ArbLegClientOrderID(base, side)
+			j.Side = "arb"
+			j.BetSide = string(side)
+			if err := db.RecordIntent(j); err != nil {
+				slog.Error("Journaling arb leg failed, skipping arb", "ticker", tp.Ticker, "err", err)
+				return 0
+			}
+			legs[side] = j
+		}
+		execConfig.ClientOrderID = base
+	} else {
+		markAttempted(tp.Ticker, tp.BetSide)
+	}
 
 	slog.Info("Executing arb",
 		"ticker", arb.Ticker, "contracts", contracts,
 		"yesPrice", arb.YesPrice, "noPrice", arb.NoPrice)
 
-	yesResult, noResult, err := kalshiClient.ExecuteArb(arb, contracts, execConfig)
+	yesResult, noResult, err := kalshi.ExecuteArb(kalshiClient, arb, contracts, execConfig)
+	// A failed leg comes back nil; the other leg's result still counts
+	recordOrder("arb_"+tp.MarketType, float64(arb.YesPrice), yesResult, nil)
+	recordOrder("arb_"+tp.MarketType, float64(arb.NoPrice), noResult, nil)
 	if err != nil {
 		// With concurrent execution, one leg may have filled even on error
 		slog.Error("Arb execution error", "err", err)
@@ -384,51 +521,27 @@ func ExecuteArbitrage(
 			clearAttempt(tp.Ticker, tp.BetSide)
 			return 0
 		}
-		slog.Warn("Partial arb fill",
-			"yesFilled", yesResult != nil && yesResult.FilledContracts > 0,
-			"noFilled", noResult != nil && noResult.FilledContracts > 0)
 	}
 
 	totalSpent := 0.0
-	if yesResult != nil && yesResult.FilledContracts > 0 {
-		totalSpent += float64(yesResult.TotalCost) / 100
-	}
-	if noResult != nil && noResult.FilledContracts > 0 {
-		totalSpent += float64(noResult.TotalCost) / 100
-	}
-
-	if yesResult != nil && noResult != nil && yesResult.FilledContracts > 0 && noResult.FilledContracts > 0 {
-		matchedContracts := min(yesResult.FilledContracts, noResult.FilledContracts)
-		profit := kalshi.CalculateArbProfit(
-			int(yesResult.AveragePrice),
-			int(noResult.AveragePrice),
-			matchedContracts,
-		)
-		slog.Info("Arb filled",
-			"matched", matchedContracts, "yesAvg", yesResult.AveragePrice,
-			"noAvg", noResult.AveragePrice, "profit", profit/100)
-	}
-
-	// Store arb position AFTER successful fill
-	if totalSpent > 0 && db != nil {
-		pos := positions.Position{
-			GameID:     fmt.Sprintf("%d", tp.GameID),
-			HomeTeam:   tp.HomeTeam,
-			AwayTeam:   tp.AwayTeam,
-			MarketType: "arb_" + tp.MarketType,
-			Side:       "arb",
-			Ticker:     tp.Ticker,
-			BetSide:    tp.BetSide,
-			EntryPrice: totalSpent,
-			Contracts:  contracts,
-		}
-		id, dbErr := db.AddPosition(pos)
-		if dbErr != nil {
-			slog.Error("Storing arb position failed", "err", dbErr)
-		} else {
-			slog.Info("Stored arb position", "id", id, "ticker", tp.Ticker, "side", tp.BetSide)
+	results := []*kalshi.ExecutionResult{yesResult, noResult}
+	for _, result := range results {
+		if result != nil {
+			totalSpent += float64(result.TotalCost) / 100
 		}
 	}
+	if db == nil {
+		return totalSpent
+	}
 
-	return totalSpent
+	// Each side is stored as its own leg; an uneven fill is retried or
+	// sold back rather than left naked
+	lm := newLegManager(kalshiClient, db, execConfig, cfg, kalshiClient.GetOrderBook, time.Now())
+	fill, ok := lm.settle(arb.Ticker,
+		[]positions.JournalEntry{legs[kalshi.SideYes], legs[kalshi.SideNo]},
+		[]int{arb.YesPrice, arb.NoPrice}, results)
+	if ok && fill.Pairs+fill.Sold+fill.Open > 0 {
+		notifier.AlertArb(fill)
+	}
+	return totalSpent + lm.spent
 }
@@ -0,0 +1,246 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/odds"
+	"sports-betting-bot/internal/positions"
+)
+
+// exitRules returns the configured exit thresholds.
+func (e *Engine) exitRules() positions.ExitRules {
+	return positions.ExitRules{
+		TakeProfit:  e.cfg.ExitTakeProfit,
+		StopEdge:    e.cfg.ExitStopEdge,
+		CloseBefore: e.cfg.ExitCloseBefore,
+	}
+}
+
+// manageExits checks every holding in gameOdds against the exit rules and
+// sells the ones that trip. Games in the pre-game window and in progress
+// are included, since that is when the tip-off rule fires; finished games
+// are left to settlement. Returns the number of exits sent.
+func (e *Engine) manageExits(gameOdds []api.GameOdds) int {
+	rules := e.exitRules()
+	if !rules.Enabled() || e.db == nil || e.kalshiClient == nil {
+		return 0
+	}
+	open, err := e.db.GetAllPositions()
+	if err != nil {
+		slog.Error("Loading positions for exits failed", "err", err)
+		return 0
+	}
+	holdings := positions.Holdings(open)
+	if len(holdings) == 0 {
+		return 0
+	}
+
+	games := make(map[string]api.GameOdds, len(gameOdds))
+	for _, g := range gameOdds {
+		games[fmt.Sprintf("%d", g.GameID)] = g
+	}
+
+	// Never sell more than Kalshi says we hold
+	remote, err := e.kalshiClient.GetPositions()
+	if err != nil {
+		slog.Error("Fetching Kalshi positions for exits failed", "err", err)
+		return 0
+	}
+	held := make(map[string]int, len(remote))
+	for _, p := range remote {
+		held[p.Ticker] = p.Position
+	}
+
+	sent := 0
+	consensus := make(map[int]odds.ConsensusOdds)
+	for _, h := range holdings {
+		game, ok := games[h.GameID]
+		if !ok || game.Game.Status == "Final" {
+			continue
+		}
+		if orderInFlight(e.db, h.Ticker, h.BetSide) {
+			continue
+		}
+
+		side := kalshi.Side(h.BetSide)
+		contracts := held[h.Ticker]
+		if side == kalshi.SideNo {
+			contracts = -contracts
+		}
+		contracts = min(contracts, h.Contracts)
+		if contracts <= 0 {
+			continue
+		}
+
+		book, err := e.orderBook(h.Ticker)
+		if err != nil {
+			slog.Error("Orderbook fetch failed", "ticker", h.Ticker, "err", err)
+			continue
+		}
+		// Sell what the bids can absorb now; the rest goes on a later scan
+		contracts = min(contracts, kalshi.CheckLiquidity(book, side, kalshi.ActionSell, contracts).Available)
+		if contracts == 0 {
+			continue
+		}
+		yesBid, _, noBid, _ := bookQuotes(book)
+		bid := yesBid
+		if side == kalshi.SideNo {
+			bid = noBid
+		}
+
+		c, ok := consensus[game.GameID]
+		if !ok {
+			c = odds.CalculateConsensusAt(e.withLiveMoneyline(game), e.now(), e.cfg.MaxOddsAgeSec)
+			consensus[game.GameID] = c
+		}
+		nearTip := game.Game.StartsWithinAt(e.now(), rules.CloseBefore)
+		reason := rules.ExitReason(float64(bid)/100, holdingProb(h, c), nearTip)
+		if reason == "" {
+			continue
+		}
+		e.exitHolding(h, contracts, float64(bid)/100, reason)
+		sent++
+	}
+	return sent
+}
+
+// orderBook returns the live book for ticker when streaming, else fetches it.
+func (e *Engine) orderBook(ticker string) (*kalshi.OrderBookResponse, error) {
+	if e.feed != nil {
+		if book, ok := e.feed.OrderBook(ticker); ok {
+			return book, nil
+		}
+	}
+	return e.kalshiClient.GetOrderBook(ticker)
+}
+
+// holdingProb returns the consensus probability that h wins, or 0 where
+// the game consensus doesn't price it (props, or a missing market).
+func holdingProb(h positions.Holding, c odds.ConsensusOdds) float64 {
+	switch h.MarketType {
+	case string(odds.MarketMoneyline):
+		if c.Moneyline == nil {
+			return 0
+		}
+		if h.Side == "home" {
+			return c.Moneyline.HomeTrueProb
+		}
+		return c.Moneyline.AwayTrueProb
+	case string(odds.MarketSpread):
+		if c.Spread == nil {
+			return 0
+		}
+		if h.Side == "home" {
+			return c.Spread.HomeCoverProb
+		}
+		return c.Spread.AwayCoverProb
+	case string(odds.MarketTotal):
+		if c.Total == nil {
+			return 0
+		}
+		if h.Side == "over" {
+			return c.Total.OverProb
+		}
+		return c.Total.UnderProb
+	}
+	return 0
+}
+
+// exitHolding sells contracts of h and logs the closes.
+func (e *Engine) exitHolding(h positions.Holding, contracts int, bid float64, reason string) {
+	_, closes := sellHolding(e.kalshiClient, e.db, e.execConfig, h, contracts, bid, reason, e.now())
+	for _, s := range closes {
+		e.notifier.LogSettlement(s)
+	}
+}
+
+// sellHolding sells contracts of h through PlaceOrder, which applies the
+// same liquidity and slippage guards as buys and may shrink the order, and
+// closes the sold contracts out of the DB. Returns the contracts sold and
+// the closes.
+func sellHolding(
+	kalshiClient Exchange,
+	db *positions.DB,
+	execConfig kalshi.OrderConfig,
+	h positions.Holding,
+	contracts int,
+	bid float64,
+	reason string,
+	now time.Time,
+) (int, []positions.Settlement) {
+	side := kalshi.Side(h.BetSide)
+	if execConfig.DryRun {
+		slog.Info("Dry run exit", "ticker", h.Ticker, "side", side, "contracts", contracts, "bid", bid*100, "reason", reason)
+		return 0, nil
+	}
+
+	// The EV re-check is for buys; a small holding only needs its own depth
+	cfg := execConfig
+	cfg.TrueProb = 0
+	cfg.MinLiquidityContracts = min(cfg.MinLiquidityContracts, contracts)
+
+	j := positions.JournalEntry{
+		ClientOrderID: uuid.New().String(),
+		Kind:          positions.OrderKindExit,
+		GameID:        h.GameID,
+		HomeTeam:      h.HomeTeam,
+		AwayTeam:      h.AwayTeam,
+		MarketType:    h.MarketType,
+		Side:          h.Side,
+		Ticker:        h.Ticker,
+		BetSide:       h.BetSide,
+		Action:        string(kalshi.ActionSell),
+		Contracts:     contracts,
+	}
+	if err := db.RecordIntent(j); err != nil {
+		slog.Error("Journaling exit failed", "ticker", h.Ticker, "err", err)
+		return 0, nil
+	}
+	cfg.ClientOrderID = j.ClientOrderID
+
+	slog.Info("Exiting position", "ticker", h.Ticker, "side", side, "contracts", contracts, "bid", bid*100, "reason", reason)
+	result, err := kalshiClient.PlaceOrder(h.Ticker, side, kalshi.ActionSell, contracts, cfg)
+	recordOrder("exit", bid*100, result, err)
+	if err != nil {
+		slog.Error("Exit order failed", "ticker", h.Ticker, "err", err)
+		return 0, nil
+	}
+	if outcomeUnknown(result) {
+		slog.Warn("Exit outcome unknown, left for reconciliation",
+			"ticker", h.Ticker, "clientOrderID", j.ClientOrderID, "reason", result.RejectionReason)
+		return 0, nil
+	}
+
+	u := takerUpdate(j, result)
+	if u.Detail == "" {
+		u.Detail = reason
+	}
+	return u.Filled, closeSold(db, j, u, now)
+}
+
+// recordExit closes the contracts an exit sold and logs each close.
+func (e *Engine) recordExit(j positions.JournalEntry, u positions.OrderUpdate) {
+	for _, s := range closeSold(e.db, j, u, e.now()) {
+		e.notifier.LogSettlement(s)
+	}
+}
+
+// closeSold closes the contracts an exit sold out of the DB.
+func closeSold(db *positions.DB, j positions.JournalEntry, u positions.OrderUpdate, now time.Time) []positions.Settlement {
+	closes, err := db.RecordExit(j, u, now)
+	if err != nil {
+		slog.Error("Recording exit failed", "ticker", j.Ticker, "err", err)
+		return nil
+	}
+	if u.Filled == 0 {
+		slog.Warn("Exit not filled", "ticker", j.Ticker, "reason", u.Detail)
+		return nil
+	}
+	return closes
+}
@@ -0,0 +1,148 @@
+package engine
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+	"sports-betting-bot/internal/positions"
+)
+
+// hold buys contracts of side on the exchange and stores them as one
+// position row per entry in rows.
+func hold(t *testing.T, x *kalshitest.Exchange, db *positions.DB, game api.GameOdds, side kalshi.Side, rows ...int) string {
+	t.Helper()
+	ticker := moneylineTicker(game)
+	total := 0
+	for _, n := range rows {
+		total += n
+	}
+	x.AddLiquidity(ticker, opposite(side), 50, total)
+	req := kalshi.CreateOrderRequest{
+		Ticker: ticker, Side: side, Action: kalshi.ActionBuy, Count: total,
+		Type: kalshi.OrderTypeLimit, TimeInForce: kalshi.TimeInForceIOC,
+	}
+	posSide := "home"
+	if side == kalshi.SideYes {
+		req.YesPrice = 50
+	} else {
+		req.NoPrice = 50
+		posSide = "away"
+	}
+	if _, err := x.SubmitOrder(req); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range rows {
+		_, err := db.AddPosition(positions.Position{
+			GameID: strconv.Itoa(game.GameID), HomeTeam: game.Game.HomeTeam.Abbreviation, AwayTeam: game.Game.VisitorTeam.Abbreviation,
+			MarketType: "moneyline", Side: posSide, Ticker: ticker, BetSide: string(side),
+			EntryPrice: 0.50, Contracts: n,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return ticker
+}
+
+func opposite(side kalshi.Side) kalshi.Side {
+	if side == kalshi.SideYes {
+		return kalshi.SideNo
+	}
+	return kalshi.SideYes
+}
+
+func mustSettlements(t *testing.T, db *positions.DB) []positions.Settlement {
+	t.Helper()
+	all, err := db.GetSettlements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return all
+}
+
+func TestExitTakesProfitWithPartialClose(t *testing.T) {
+	x := kalshitest.NewExchange(1000)
+	game := favoriteOdds(1, "LAL", "PHX")
+	eng, db := newTestEngine(t, []api.GameOdds{game}, x)
+	eng.cfg.ExitTakeProfit = 0.85
+
+	ticker := hold(t, x, db, game, kalshi.SideYes, 15, 5)
+	x.AddLiquidity(ticker, kalshi.SideYes, 90, 10)
+	eng.manageExits([]api.GameOdds{game})
+
+	// Only 10 of 20 could be sold: the older row closes in part
+	closes := mustSettlements(t, db)
+	if len(closes) != 1 || closes[0].Result != positions.ResultClosed || closes[0].Payout != 9 {
+		t.Fatalf("settlements = %+v, want one close of 10 contracts paying $9", closes)
+	}
+	if pnl := closes[0].RealizedPnL; pnl < 3.5 || pnl > 4 {
+		t.Errorf("realized P&L = %.2f, want $4 less fees", pnl)
+	}
+	stored := mustPositions(t, db)
+	if len(stored) != 2 || stored[0].Contracts != 5 || stored[1].Contracts != 5 {
+		t.Errorf("open = %+v, want the 5 left of the first row and the second row", stored)
+	}
+	if held, _ := x.GetPositions(); len(held) != 1 || held[0].Position != 10 {
+		t.Errorf("exchange positions = %+v, want 10 YES left", held)
+	}
+}
+
+func TestExitCutsWhenEdgeFlips(t *testing.T) {
+	x := kalshitest.NewExchange(1000)
+	game := favoriteOdds(2, "PHX", "LAL")
+	eng, db := newTestEngine(t, []api.GameOdds{game}, x)
+
+	// The books give the away side ~35%; a NO bid at 45 nets ~43
+	ticker := hold(t, x, db, game, kalshi.SideNo, 10)
+	x.AddLiquidity(ticker, kalshi.SideNo, 45, 50)
+
+	eng.cfg.ExitStopEdge = 0.20
+	eng.manageExits([]api.GameOdds{game})
+	if closes := mustSettlements(t, db); len(closes) != 0 {
+		t.Fatalf("settlements = %+v, want none inside the stop", closes)
+	}
+
+	eng.cfg.ExitStopEdge = 0.05
+	eng.manageExits([]api.GameOdds{game})
+	closes := mustSettlements(t, db)
+	if len(closes) != 1 || closes[0].Payout != 4.5 || closes[0].RealizedPnL >= -0.5 {
+		t.Fatalf("settlements = %+v, want 10 contracts closed at 45¢ for a loss", closes)
+	}
+	if stored := mustPositions(t, db); len(stored) != 0 {
+		t.Errorf("open = %+v, want none", stored)
+	}
+}
+
+func TestExitClosesBeforeTipOff(t *testing.T) {
+	x := kalshitest.NewExchange(1000)
+	game := favoriteOdds(3, "DEN", "UTA")
+	eng, db := newTestEngine(t, []api.GameOdds{game}, x)
+	eng.cfg.ExitCloseBefore = 30 * time.Minute
+
+	ticker := hold(t, x, db, game, kalshi.SideYes, 10)
+	x.AddLiquidity(ticker, kalshi.SideYes, 60, 10)
+
+	// Tip-off is 3h out
+	eng.manageExits([]api.GameOdds{game})
+	if closes := mustSettlements(t, db); len(closes) != 0 {
+		t.Fatalf("settlements = %+v, want none 3h before tip-off", closes)
+	}
+
+	eng.SetClock(func() time.Time { return scanTime.Add(2*time.Hour + 45*time.Minute) })
+	eng.manageExits([]api.GameOdds{game})
+	if closes := mustSettlements(t, db); len(closes) != 1 || closes[0].Payout != 6 {
+		t.Fatalf("settlements = %+v, want 10 contracts closed at 60¢", closes)
+	}
+
+	orders := mustOrders(t, x, ticker)
+	exit := orders[len(orders)-1]
+	j, _ := db.GetJournalEntry(exit.ClientOrderID)
+	if j == nil || j.Kind != positions.OrderKindExit || j.Status != positions.JournalExecuted || !strings.Contains(j.Detail, "tip-off") {
+		t.Errorf("journal entry = %+v, want an executed tip-off exit", j)
+	}
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/positions"
+)
+
+// tickerLocked reports whether a hedged pair holds ticker. Locked tickers
+// take no further bets on either side.
+func tickerLocked(db *positions.DB, ticker string) bool {
+	if db == nil {
+		return false
+	}
+	locked, err := db.IsTickerLocked(ticker)
+	if err != nil {
+		slog.Error("Checking locked tickers failed", "ticker", ticker, "err", err)
+		return true
+	}
+	return locked
+}
+
+// autoHedge buys the other side of a hedge's position when that locks in
+// at least HedgeMinProfit after fees. Hedges are found on listed prices, so
+// the depth and price are checked again against the book before buying;
+// thin books hedge only part of the position.
+func (e *Engine) autoHedge(hedge positions.HedgeOpportunity) {
+	pos := hedge.Position
+	if !e.cfg.AutoHedge || hedge.Action != "hedge" || e.db == nil || e.kalshiClient == nil || pos.Ticker == "" {
+		return
+	}
+	j := positions.HedgeEntry(pos, uuid.New().String(), pos.Contracts)
+	side := kalshi.Side(j.BetSide)
+	if orderInFlight(e.db, j.Ticker, j.BetSide) {
+		return
+	}
+
+	book, err := e.orderBook(j.Ticker)
+	if err != nil {
+		slog.Error("Orderbook fetch failed", "ticker", j.Ticker, "err", err)
+		return
+	}
+	liquidity := kalshi.CheckLiquidity(book, side, kalshi.ActionBuy, pos.Contracts)
+	contracts := min(pos.Contracts, liquidity.Available)
+	if contracts == 0 {
+		return
+	}
+	slippage := kalshi.CalculateSlippage(book, side, kalshi.ActionBuy, contracts)
+	price := slippage.AverageFillPrice / 100
+	_, profit := positions.CalculateHedgeSize(pos.EntryPrice, price, contracts)
+	profit -= pos.Fees * float64(contracts) / float64(pos.Contracts)
+	if profit < e.cfg.HedgeMinProfit {
+		return
+	}
+
+	if e.execConfig.DryRun {
+		slog.Info("Dry run hedge", "ticker", j.Ticker, "side", side, "contracts", contracts, "price", price*100, "profit", profit)
+		return
+	}
+
+	// The hedge's value is the lock, not an edge on its own side
+	cfg := e.execConfig
+	cfg.TrueProb = 0
+	cfg.MinLiquidityContracts = min(cfg.MinLiquidityContracts, contracts)
+
+	j.Contracts = contracts
+	if err := e.db.RecordIntent(j); err != nil {
+		slog.Error("Journaling hedge failed", "ticker", j.Ticker, "err", err)
+		return
+	}
+	cfg.ClientOrderID = j.ClientOrderID
+
+	slog.Info("Hedging position", "positionID", pos.ID, "ticker", j.Ticker, "side", side,
+		"contracts", contracts, "price", price*100, "profit", profit)
+	result, err := e.kalshiClient.PlaceOrder(j.Ticker, side, kalshi.ActionBuy, contracts, cfg)
+	recordOrder("hedge", price*100, result, err)
+	if err != nil {
+		slog.Error("Hedge order failed", "ticker", j.Ticker, "err", err)
+		return
+	}
+	if outcomeUnknown(result) {
+		slog.Warn("Hedge outcome unknown, left for reconciliation",
+			"ticker", j.Ticker, "clientOrderID", j.ClientOrderID, "reason", result.RejectionReason)
+		return
+	}
+	e.recordHedge(j, takerUpdate(j, result))
+}
+
+// recordHedge stores a hedge's fills as a leg locked with its position.
+func (e *Engine) recordHedge(j positions.JournalEntry, u positions.OrderUpdate) {
+	id, err := e.db.RecordHedge(j, u)
+	if err != nil {
+		slog.Error("Recording hedge failed", "ticker", j.Ticker, "err", err)
+		return
+	}
+	if u.Filled == 0 {
+		slog.Warn("Hedge not filled", "ticker", j.Ticker, "reason", u.Detail)
+		return
+	}
+	slog.Info("Hedge locked", "ticker", j.Ticker, "hedgeOf", j.HedgeOf, "positionID", id,
+		"filled", u.Filled, "avgPrice", u.AvgPrice)
+}
@@ -0,0 +1,53 @@
+package engine
+
+import (
+	"testing"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+)
+
+func TestAutoHedgeLocksTicker(t *testing.T) {
+	x := kalshitest.NewExchange(1000)
+	game := favoriteOdds(4, "BOS", "NYK")
+	game.Vendors[0].Moneyline = &api.Moneyline{Home: 60, Away: 40}
+	eng, db := newTestEngine(t, []api.GameOdds{game}, x)
+	eng.cfg.AutoHedge = true
+
+	// 20 YES at 50¢ plus NO at 40¢ locks about $1.66 after the hedge fee
+	ticker := hold(t, x, db, game, kalshi.SideYes, 20)
+	x.AddLiquidity(ticker, kalshi.SideYes, 60, 20)
+
+	eng.cfg.HedgeMinProfit = 2
+	eng.Scan()
+	if tickerLocked(db, ticker) || len(mustPositions(t, db)) != 1 {
+		t.Fatalf("open = %+v, want no hedge below the floor", mustPositions(t, db))
+	}
+
+	eng.cfg.HedgeMinProfit = 1
+	eng.Scan()
+	stored := mustPositions(t, db)
+	if len(stored) != 2 || !tickerLocked(db, ticker) {
+		t.Fatalf("open = %+v, want the position and its hedge leg, locked", stored)
+	}
+	leg, held := stored[0], stored[1]
+	if leg.HedgeOf == 0 {
+		leg, held = held, leg
+	}
+	if leg.BetSide != "no" || leg.Contracts != 20 || leg.HedgeOf != held.ID || !held.Locked {
+		t.Errorf("leg = %+v, held = %+v, want 20 NO hedging the YES row", leg, held)
+	}
+	if pos, _ := x.GetPositions(); len(pos) != 0 {
+		t.Errorf("exchange positions = %+v, want the pair netted out", pos)
+	}
+
+	// No further bets on either side of the locked ticker
+	eng.client.(*fakeOdds).games[0].Vendors[0].Moneyline = &api.Moneyline{Home: 75, Away: 25}
+	x.AddLiquidity(ticker, kalshi.SideYes, 75, 500)
+	before := len(mustOrders(t, x, ticker))
+	eng.Scan()
+	if after := len(mustOrders(t, x, ticker)); after != before {
+		t.Errorf("orders on %s = %d after locking, want %d", ticker, after, before)
+	}
+}
@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+
+	"sports-betting-bot/internal/positions"
+)
+
+// recordAlert stores a game or prop alert with the inputs it was priced
+// from. suppressed marks a repeat the notifier's cooldown held back.
+func (e *Engine) recordAlert(kind string, tp TradeParams, bookCount int, suppressed bool) {
+	if e.db == nil {
+		return
+	}
+	_, err := e.db.RecordAlert(positions.AlertRecord{
+		Kind:        kind,
+		GameID:      fmt.Sprintf("%d", tp.GameID),
+		Ticker:      tp.Ticker,
+		BetSide:     tp.BetSide,
+		MarketType:  tp.MarketType,
+		Side:        tp.PositionSide,
+		TrueProb:    tp.TrueProb,
+		KalshiPrice: tp.KalshiPrice,
+		BookCount:   bookCount,
+		AdjustedEV:  tp.AdjustedEV,
+		KellyStake:  tp.KellyStake,
+		Suppressed:  suppressed,
+		AlertedAt:   e.now(),
+	})
+	if err != nil {
+		slog.Warn("Failed to record alert", "ticker", tp.Ticker, "err", err)
+	}
+}
+
+// recordHedgeAlert stores a hedge alert as a buy of the position's other
+// side at the hedge price.
+func (e *Engine) recordHedgeAlert(hedge positions.HedgeOpportunity, suppressed bool) {
+	if e.db == nil {
+		return
+	}
+	j := positions.HedgeEntry(hedge.Position, "", hedge.Position.Contracts)
+	_, err := e.db.RecordAlert(positions.AlertRecord{
+		Kind:             positions.AlertKindHedge,
+		GameID:           j.GameID,
+		Ticker:           j.Ticker,
+		BetSide:          j.BetSide,
+		MarketType:       j.MarketType,
+		Side:             j.Side,
+		KalshiPrice:      hedge.CurrentPrice,
+		PositionID:       hedge.Position.ID,
+		GuaranteedProfit: hedge.GuaranteedProfit,
+		Suppressed:       suppressed,
+		AlertedAt:        e.now(),
+	})
+	if err != nil {
+		slog.Warn("Failed to record hedge alert", "ticker", j.Ticker, "err", err)
+	}
+}
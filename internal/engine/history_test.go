@@ -0,0 +1,66 @@
+package engine
+
+import (
+	"testing"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+	"sports-betting-bot/internal/positions"
+)
+
+func TestScanRecordsAlertsWithOutcomes(t *testing.T) {
+	traded := favoriteOdds(1, "PHX", "GSW")
+	passed := favoriteOdds(2, "HOU", "DAL")
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(moneylineTicker(traded), kalshi.SideNo, 50, 500) // YES offered at 50¢
+	// No book on the second game: alerted, never filled
+
+	eng, db := newTestEngine(t, []api.GameOdds{traded, passed}, x)
+	eng.Scan()
+	eng.Scan() // Inside the cooldown: stored as suppressed
+
+	x.Settle(moneylineTicker(traded), "yes")
+	x.Settle(moneylineTicker(passed), "no")
+	eng.settle()
+
+	outcomes, err := db.GetAlertOutcomes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(outcomes) != 4 {
+		t.Fatalf("stored %d alerts, want two per game: %+v", len(outcomes), outcomes)
+	}
+	byTicker := make(map[string]positions.AlertOutcome)
+	for _, o := range outcomes {
+		if o.Suppressed {
+			if byTicker[o.Ticker].Ticker == "" {
+				t.Errorf("alert %+v suppressed before its first send", o.AlertRecord)
+			}
+			continue
+		}
+		if byTicker[o.Ticker].Ticker != "" {
+			t.Errorf("alert %+v sent again inside the cooldown", o.AlertRecord)
+		}
+		byTicker[o.Ticker] = o
+	}
+
+	won := byTicker[moneylineTicker(traded)]
+	if won.Kind != positions.AlertKindGame || won.BetSide != "yes" || won.KalshiPrice != 0.50 ||
+		won.BookCount != 6 || won.TrueProb <= 0.5 || won.KellyStake <= 0 {
+		t.Errorf("traded alert = %+v, want the YES home alert with its inputs", won.AlertRecord)
+	}
+	if !won.Traded() || !won.Settled || won.Settlement != positions.ResultYes || won.RealizedPnL <= 0 || !won.Won() {
+		t.Errorf("traded outcome = %+v, want a winning settled fill", won)
+	}
+
+	lost := byTicker[moneylineTicker(passed)]
+	if lost.Traded() || lost.Result != positions.ResultNo || lost.Won() {
+		t.Errorf("untraded outcome = %+v, want a losing alert with no fill", lost)
+	}
+
+	precision := positions.SummarizeAlertPrecision(outcomes)
+	if len(precision) != 2 || precision[0].Traded || precision[0].Won != 0 || precision[1].Won != 1 {
+		t.Errorf("precision = %+v, want the untraded miss then the traded hit", precision)
+	}
+}
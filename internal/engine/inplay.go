@@ -0,0 +1,284 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"sports-betting-bot/internal/analysis"
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/metrics"
+	"sports-betting-bot/internal/odds"
+)
+
+// SetLiveScores lets IN_PLAY=true price games in progress from the
+// provider's live box scores.
+func (e *Engine) SetLiveScores(p LiveScoreProvider) {
+	e.scores = p
+}
+
+// liveScore is a game's latest box score and when its score or clock
+// last moved.
+type liveScore struct {
+	box   api.LiveBoxScore
+	moved time.Time
+}
+
+// inPlayMode reports whether games in progress are priced. In-play
+// prices come from the live Kalshi books, so it needs an exchange.
+func (e *Engine) inPlayMode() bool {
+	return e.cfg.InPlay && e.scores != nil && e.kalshiClient != nil
+}
+
+// inProgress reports whether a balldontlie game status is a game being
+// played, e.g. "3rd Qtr" or "Halftime".
+func inProgress(status string) bool {
+	return strings.Contains(status, "Qtr") || status == "Halftime" || status == "OT"
+}
+
+// boxScoreKey returns the Kalshi game segment for a box score, the same
+// key gameKey gives its game.
+func boxScoreKey(box api.LiveBoxScore) string {
+	return gameKey(api.GameOdds{Game: api.Game{
+		Date:        box.Date,
+		HomeTeam:    box.HomeTeam,
+		VisitorTeam: box.VisitorTeam,
+	}})
+}
+
+// refreshLiveScores fetches the live box scores. A fetch slower than
+// InPlayMaxLatency is discarded: by the time it arrives the score may
+// already have moved.
+func (e *Engine) refreshLiveScores() {
+	start := time.Now()
+	boxes, err := e.scores.GetLiveBoxScores()
+	if err != nil {
+		e.notifier.LogError("fetching live box scores", err)
+		return
+	}
+	if took := time.Since(start); took > e.cfg.InPlayMaxLatency {
+		metrics.InPlaySkips.Inc("score_latency")
+		slog.Warn("Live box scores too slow, discarding", "took", took, "max", e.cfg.InPlayMaxLatency)
+		return
+	}
+
+	now := e.now()
+	scores := make(map[string]liveScore, len(boxes))
+	for _, box := range boxes {
+		key := boxScoreKey(box)
+		if key == "" {
+			continue
+		}
+		score := liveScore{box: box, moved: now}
+		if prev, ok := e.liveScores[key]; ok && sameScoreAndClock(prev.box, box) {
+			score.moved = prev.moved
+		}
+		scores[key] = score
+	}
+	e.liveScores = scores
+	e.liveFetched = now
+}
+
+// savePregame persists the scans' consensus, so a restart during a game
+// can still price it in play.
+func (e *Engine) savePregame(scans []pregameScan) {
+	if e.db == nil || !e.inPlayMode() || len(scans) == 0 {
+		return
+	}
+	consensus := make([]odds.ConsensusOdds, len(scans))
+	for i, scan := range scans {
+		consensus[i] = scan.consensus
+	}
+	if err := e.db.SavePregame(consensus, e.now()); err != nil {
+		slog.Warn("Failed to save pre-game consensus", "err", err)
+	}
+}
+
+// loadPregame restores the pre-game consensus saved by an earlier run
+// within DefaultInPlayPregameMaxAge. Games scanned since keep theirs.
+func (e *Engine) loadPregame() {
+	if e.db == nil || !e.inPlayMode() {
+		return
+	}
+	saved, err := e.db.GetPregame(e.now().Add(-config.DefaultInPlayPregameMaxAge))
+	if err != nil {
+		slog.Warn("Failed to load pre-game consensus", "err", err)
+		return
+	}
+	for id, c := range saved {
+		if _, ok := e.pregame[id]; !ok {
+			e.pregame[id] = c
+		}
+	}
+	if len(saved) > 0 {
+		slog.Info("Loaded pre-game consensus", "games", len(saved))
+	}
+}
+
+// prunePregame forgets the pre-game consensus of games that are final or
+// no longer in gameOdds.
+func (e *Engine) prunePregame(gameOdds []api.GameOdds) {
+	listed := make(map[int]bool, len(gameOdds))
+	for _, game := range gameOdds {
+		if game.Game.Status != "Final" {
+			listed[game.GameID] = true
+		}
+	}
+	for id := range e.pregame {
+		if !listed[id] {
+			delete(e.pregame, id)
+		}
+	}
+}
+
+// sameScoreAndClock reports whether two box scores of a game show the
+// same score and clock.
+func sameScoreAndClock(a, b api.LiveBoxScore) bool {
+	return a.Period == b.Period && a.Time == b.Time &&
+		a.HomeTeamScore == b.HomeTeamScore && a.VisitorTeamScore == b.VisitorTeamScore
+}
+
+// inPlayOpportunities prices games in progress: each game's last pre-game
+// consensus is carried forward to its score and clock and compared with
+// the live Kalshi books. Box scores are re-fetched when refresh is set and
+// otherwise come from the last fetch. Games are left out when that fetch
+// is older than InPlayMaxScoreAge, when their score has stood still on a
+// running clock for as long, or when the bot never saw them before
+// tip-off.
+func (e *Engine) inPlayOpportunities(games []api.GameOdds, refresh bool) []analysis.Opportunity {
+	if refresh {
+		e.refreshLiveScores()
+	}
+	if age := e.now().Sub(e.liveFetched); age > e.cfg.InPlayMaxScoreAge {
+		metrics.InPlaySkips.Add(float64(len(games)), "stale_scores")
+		slog.Debug("Live box scores stale, skipping games in progress", "age", age)
+		return nil
+	}
+
+	var opps []analysis.Opportunity
+	priced := 0
+	for _, game := range games {
+		pregame, ok := e.pregame[game.GameID]
+		if !ok {
+			metrics.InPlaySkips.Inc("no_pregame")
+			continue
+		}
+		score, ok := e.liveScores[gameKey(game)]
+		if !ok || score.box.IsFinal() {
+			metrics.InPlaySkips.Inc("no_score")
+			continue
+		}
+		if score.box.ClockRunning() && e.now().Sub(score.moved) > e.cfg.InPlayMaxScoreAge {
+			metrics.InPlaySkips.Inc("frozen_clock")
+			slog.Debug("Live score frozen on a running clock", "gameID", game.GameID, "since", score.moved)
+			continue
+		}
+		secondsLeft, ok := score.box.SecondsLeft()
+		if !ok {
+			metrics.InPlaySkips.Inc("no_score")
+			continue
+		}
+		kalshiOdds := e.liveKalshiOdds(game)
+		if kalshiOdds == nil {
+			metrics.InPlaySkips.Inc("no_book")
+			continue
+		}
+
+		state := odds.GameState{
+			HomeScore:   score.box.HomeTeamScore,
+			AwayScore:   score.box.VisitorTeamScore,
+			SecondsLeft: secondsLeft,
+		}
+		consensus := odds.LiveConsensus(pregame, state, kalshiOdds)
+		priced++
+		opps = append(opps, analysis.FindAllOpportunities(consensus, e.analysisCfg)...)
+	}
+	metrics.InPlayGames.Set(float64(priced))
+	return opps
+}
+
+// liveKalshiOdds reads the game's moneyline book and, where the Kalshi
+// vendor lists a line, its spread and total books, as Kalshi odds in
+// cents. Returns nil when no market could be read.
+func (e *Engine) liveKalshiOdds(game api.GameOdds) *odds.KalshiOdds {
+	date, err := time.Parse("2006-01-02", game.Game.Date)
+	if err != nil {
+		return nil
+	}
+	var listed api.Vendor
+	for _, v := range game.Vendors {
+		if api.IsKalshi(v.Name) {
+			listed = v
+			break
+		}
+	}
+	asks := func(series kalshi.KalshiSeries) (int, int, bool) {
+		ticker := kalshi.BuildNBATicker(series, date, game.Game.VisitorTeam.Abbreviation, game.Game.HomeTeam.Abbreviation)
+		book, ok := e.inPlayBook(ticker)
+		if !ok {
+			return 0, 0, false
+		}
+		_, yesAsk, _, noAsk := bookQuotes(book)
+		return yesAsk, noAsk, yesAsk > 0 || noAsk > 0
+	}
+
+	var live odds.KalshiOdds
+	if yes, no, ok := asks(kalshi.SeriesMoneyline); ok {
+		live.Moneyline = &api.Moneyline{Home: yes, Away: no}
+	}
+	if s := listed.Spread; s != nil {
+		if yes, no, ok := asks(kalshi.SeriesSpread); ok {
+			live.Spread = &api.Spread{HomeSpread: s.HomeSpread, HomeOdds: yes, AwaySpread: s.AwaySpread, AwayOdds: no}
+		}
+	}
+	if t := listed.Total; t != nil {
+		if yes, no, ok := asks(kalshi.SeriesTotal); ok {
+			live.Total = &api.Total{Line: t.Line, OverOdds: yes, UnderOdds: no}
+		}
+	}
+	if live.Moneyline == nil && live.Spread == nil && live.Total == nil {
+		return nil
+	}
+	return &live
+}
+
+// inPlayExchange is the exchange in-play trades execute on: its order
+// books come from inPlayBook, so the book a trade is sized against is held
+// to the same latency bound as the one it was priced from.
+type inPlayExchange struct {
+	Exchange
+	e *Engine
+}
+
+func (x inPlayExchange) GetOrderBook(ticker string) (*kalshi.OrderBookResponse, error) {
+	book, ok := x.e.inPlayBook(ticker)
+	if !ok {
+		return nil, fmt.Errorf("no %s book within %v", ticker, x.e.cfg.InPlayMaxLatency)
+	}
+	return book, nil
+}
+
+// inPlayBook returns ticker's book from the feed, or from a REST read
+// that answered within InPlayMaxLatency.
+func (e *Engine) inPlayBook(ticker string) (*kalshi.OrderBookResponse, bool) {
+	if e.feed != nil {
+		if book, ok := e.feed.OrderBook(ticker); ok {
+			return book, true
+		}
+	}
+	start := time.Now()
+	book, err := e.kalshiClient.GetOrderBook(ticker)
+	if err != nil {
+		slog.Debug("In-play orderbook fetch failed", "ticker", ticker, "err", err)
+		return nil, false
+	}
+	if took := time.Since(start); took > e.cfg.InPlayMaxLatency {
+		metrics.InPlaySkips.Inc("book_latency")
+		slog.Warn("In-play orderbook too slow, skipping market", "ticker", ticker, "took", took, "max", e.cfg.InPlayMaxLatency)
+		return nil, false
+	}
+	return book, true
+}
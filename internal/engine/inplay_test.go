@@ -0,0 +1,202 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+	"sports-betting-bot/internal/metrics"
+	"sports-betting-bot/internal/positions"
+)
+
+// fakeScores serves fixed box scores, taking delay to answer.
+type fakeScores struct {
+	boxes []api.LiveBoxScore
+	delay time.Duration
+}
+
+func (f *fakeScores) GetLiveBoxScores() ([]api.LiveBoxScore, error) {
+	time.Sleep(f.delay)
+	return f.boxes, nil
+}
+
+// slowBooks answers GetOrderBook after delay once fast calls have been
+// answered promptly.
+type slowBooks struct {
+	*kalshitest.Exchange
+	fast  int
+	delay time.Duration
+}
+
+func (s *slowBooks) GetOrderBook(ticker string) (*kalshi.OrderBookResponse, error) {
+	if s.fast > 0 {
+		s.fast--
+	} else {
+		time.Sleep(s.delay)
+	}
+	return s.Exchange.GetOrderBook(ticker)
+}
+
+// inPlayGame scans favoriteOdds pre-game with an empty book, then puts
+// the 65% home favorite down 10 with 6:00 left in the 3rd while the book
+// still offers the away side at 52¢.
+func inPlayGame(t *testing.T, id int) (*Engine, *positions.DB, *kalshitest.Exchange, *fakeScores, string) {
+	t.Helper()
+	x := kalshitest.NewExchange(1000)
+	game := favoriteOdds(id, "PHX", "GSW")
+	eng, db := newTestEngine(t, []api.GameOdds{game}, x)
+	eng.cfg.InPlay = true
+	eng.cfg.InPlayMaxScoreAge = 30 * time.Second
+	eng.cfg.InPlayMaxLatency = time.Second
+	scores := &fakeScores{boxes: []api.LiveBoxScore{{
+		Date:             game.Game.Date,
+		Status:           "3rd Qtr",
+		Period:           3,
+		Time:             "6:00",
+		HomeTeamScore:    60,
+		VisitorTeamScore: 70,
+		HomeTeam:         game.Game.HomeTeam,
+		VisitorTeam:      game.Game.VisitorTeam,
+	}}}
+	eng.SetLiveScores(scores)
+
+	eng.Scan()
+	if held := heldContracts(t, x); len(held) != 0 {
+		t.Fatalf("pre-game scan filled %v on an empty book", held)
+	}
+
+	eng.client.(*fakeOdds).games[0].Game.Status = "3rd Qtr"
+	ticker := moneylineTicker(game)
+	x.AddLiquidity(ticker, kalshi.SideYes, 48, 500) // NO offered at 52¢
+	return eng, db, x, scores, ticker
+}
+
+func TestInPlayPricesFromScore(t *testing.T) {
+	eng, db, x, _, ticker := inPlayGame(t, 1)
+
+	// Down 10 with 18 minutes left the favorite wins ~12% of the time
+	eng.Scan()
+
+	if held := heldContracts(t, x); held[ticker] >= 0 {
+		t.Fatalf("exchange positions = %v, want NO on %s", held, ticker)
+	}
+	stored := mustPositions(t, db)
+	if len(stored) != 1 || stored[0].Side != "away" || stored[0].MarketType != "moneyline" {
+		t.Fatalf("stored = %+v, want one away moneyline position", stored)
+	}
+	if stored[0].EntryPrice != 0.52 {
+		t.Errorf("EntryPrice = %v, want the 52¢ NO the pre-game 35%% would never buy", stored[0].EntryPrice)
+	}
+}
+
+func TestInPlayGuards(t *testing.T) {
+	t.Run("frozen clock", func(t *testing.T) {
+		eng, _, x, _, _ := inPlayGame(t, 2)
+		eng.refreshLiveScores()
+		// The same score and clock half a minute later: the feed is stuck
+		eng.SetClock(func() time.Time { return scanTime.Add(31 * time.Second) })
+
+		before := metrics.InPlaySkips.Value("frozen_clock")
+		eng.Scan()
+		if held := heldContracts(t, x); len(held) != 0 {
+			t.Errorf("exchange positions = %v, want nothing on a frozen score", held)
+		}
+		if metrics.InPlaySkips.Value("frozen_clock")-before != 1 {
+			t.Error("frozen score not counted")
+		}
+	})
+
+	t.Run("slow box scores", func(t *testing.T) {
+		eng, _, x, scores, _ := inPlayGame(t, 3)
+		eng.cfg.InPlayMaxLatency = time.Millisecond
+		scores.delay = 20 * time.Millisecond
+
+		before := metrics.InPlaySkips.Value("score_latency")
+		eng.Scan()
+		if held := heldContracts(t, x); len(held) != 0 {
+			t.Errorf("exchange positions = %v, want nothing on a slow box score", held)
+		}
+		if metrics.InPlaySkips.Value("score_latency")-before != 1 {
+			t.Error("slow box score not counted")
+		}
+	})
+
+	t.Run("slow book at execution", func(t *testing.T) {
+		eng, _, x, _, _ := inPlayGame(t, 8)
+		eng.cfg.InPlayMaxLatency = 5 * time.Millisecond
+		// Priced from a prompt read; the re-read before ordering is slow
+		eng.kalshiClient = &slowBooks{Exchange: x, fast: 1, delay: 20 * time.Millisecond}
+
+		before := metrics.InPlaySkips.Value("book_latency")
+		eng.Scan()
+		if held := heldContracts(t, x); len(held) != 0 {
+			t.Errorf("exchange positions = %v, want nothing on a slow execution book", held)
+		}
+		if metrics.InPlaySkips.Value("book_latency")-before != 1 {
+			t.Error("slow execution book not counted")
+		}
+	})
+
+	t.Run("stale between fetches", func(t *testing.T) {
+		eng, _, x, _, _ := inPlayGame(t, 4)
+		eng.refreshLiveScores()
+		eng.SetClock(func() time.Time { return scanTime.Add(31 * time.Second) })
+
+		// A book-triggered evaluation reuses the last box scores
+		before := metrics.InPlaySkips.Value("stale_scores")
+		eng.evaluate(eng.client.(*fakeOdds).games, nil, false)
+		if held := heldContracts(t, x); len(held) != 0 {
+			t.Errorf("exchange positions = %v, want nothing on stale box scores", held)
+		}
+		if metrics.InPlaySkips.Value("stale_scores")-before != 1 {
+			t.Error("stale box scores not counted")
+		}
+	})
+
+	t.Run("never seen before tip-off", func(t *testing.T) {
+		eng, _, x, _, _ := inPlayGame(t, 5)
+		clear(eng.pregame)
+
+		eng.Scan()
+		if held := heldContracts(t, x); len(held) != 0 {
+			t.Errorf("exchange positions = %v, want nothing without a pre-game consensus", held)
+		}
+	})
+}
+
+func TestFullScanPrunesPregame(t *testing.T) {
+	eng, _, _, _, _ := inPlayGame(t, 6)
+	games := eng.client.(*fakeOdds).games
+	if _, ok := eng.pregame[6]; !ok {
+		t.Fatal("no pre-game consensus kept for the game in progress")
+	}
+	eng.pregame[99] = eng.pregame[6] // A game no longer listed
+
+	eng.Scan()
+	if _, ok := eng.pregame[99]; ok || len(eng.pregame) != 1 {
+		t.Errorf("pregame = %v, want only the listed game", eng.pregame)
+	}
+
+	games[0].Game.Status = "Final"
+	eng.Scan()
+	if len(eng.pregame) != 0 {
+		t.Errorf("pregame = %v, want nothing once the game is final", eng.pregame)
+	}
+}
+
+func TestInPlaySurvivesRestart(t *testing.T) {
+	eng, db, x, scores, ticker := inPlayGame(t, 7)
+
+	// A new run sees the game only after tip-off
+	restarted := New(eng.client, x, eng.notifier, db, eng.cfg, eng.analysisCfg, eng.execConfig)
+	restarted.SetClock(func() time.Time { return scanTime.Add(2 * time.Hour) })
+	restarted.SetLiveScores(scores)
+	restarted.loadPregame()
+	restarted.Scan()
+
+	if held := heldContracts(t, x); held[ticker] >= 0 {
+		t.Errorf("exchange positions = %v, want NO on %s from the saved pre-game consensus", held, ticker)
+	}
+}
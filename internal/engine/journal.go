@@ -0,0 +1,194 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/positions"
+)
+
+// unconfirmedGrace is how long an in-flight order may go unseen on Kalshi
+// before it is presumed never placed: a submit that timed out on our side
+// can still be accepted moments later.
+var unconfirmedGrace = time.Minute
+
+// orderInFlight reports whether an order on ticker+side may have been sent
+// without its outcome being recorded. The journal survives restarts;
+// without a DB (backtests) only the in-memory lock is available.
+func orderInFlight(db *positions.DB, ticker, betSide string) bool {
+	if db == nil {
+		return isRecentlyAttempted(ticker, betSide)
+	}
+	inFlight, err := db.HasInFlightOrder(ticker, betSide)
+	if err != nil {
+		slog.Error("Checking order journal failed", "ticker", ticker, "err", err)
+		return true
+	}
+	return inFlight
+}
+
+// journalEntry describes a buy of contracts on tp under a fresh client
+// order ID.
+func (tp TradeParams) journalEntry(kind string, contracts int) positions.JournalEntry {
+	return positions.JournalEntry{
+		ClientOrderID: uuid.New().String(),
+		Kind:          kind,
+		GameID:        fmt.Sprintf("%d", tp.GameID),
+		HomeTeam:      tp.HomeTeam,
+		AwayTeam:      tp.AwayTeam,
+		MarketType:    tp.MarketType,
+		Side:          tp.PositionSide,
+		Ticker:        tp.Ticker,
+		BetSide:       tp.BetSide,
+		Action:        string(kalshi.ActionBuy),
+		Contracts:     contracts,
+		TrueProb:      tp.TrueProb,
+	}
+}
+
+// outcomeUnknown reports whether a PlaceOrder result leaves it unclear if
+// Kalshi took the order: the submit itself failed, possibly after Kalshi
+// accepted it. Rejections from the pre-submit checks are definite.
+func outcomeUnknown(result *kalshi.ExecutionResult) bool {
+	return result == nil || strings.HasPrefix(result.RejectionReason, "order submission failed")
+}
+
+// takerUpdate is the journal update for a known PlaceOrder result.
+func takerUpdate(j positions.JournalEntry, result *kalshi.ExecutionResult) positions.OrderUpdate {
+	u := positions.OrderUpdate{
+		ClientOrderID: j.ClientOrderID,
+		OrderID:       result.OrderID,
+		Filled:        result.FilledContracts,
+		Detail:        result.RejectionReason,
+	}
+	switch {
+	case result.OrderID == "":
+		u.Status = positions.JournalFailed
+	case result.FilledContracts > 0 && result.FilledContracts >= result.RequestedContracts:
+		u.Status = positions.JournalExecuted
+	default:
+		u.Status = positions.JournalCanceled // IOC remainder
+	}
+	if result.FilledContracts > 0 {
+		u.AvgPrice = result.AveragePrice
+		u.Fees = kalshi.OrderFeeDollars(result.AveragePrice, result.FilledContracts)
+	}
+	return u
+}
+
+// reconcileJournal resolves orders that were journaled but whose outcome
+// was never recorded, typically because the bot died mid-submit. Each is
+// looked up on Kalshi by client order ID: fills are stored as positions,
+// maker orders are handed to the orders table, and orders Kalshi never
+// saw are marked failed so the opportunity can be traded again. Until
+// then the ticker stays blocked, so a restart never submits twice.
+func (e *Engine) reconcileJournal() {
+	if e.db == nil || e.kalshiClient == nil {
+		return
+	}
+	inFlight, err := e.db.InFlightOrders()
+	if err != nil {
+		slog.Error("Loading in-flight orders failed", "err", err)
+		return
+	}
+
+	byTicker := make(map[string][]kalshi.Order)
+	for _, j := range inFlight {
+		orders, ok := byTicker[j.Ticker]
+		if !ok {
+			orders, err = e.kalshiClient.GetOrders(j.Ticker)
+			if err != nil {
+				slog.Error("Fetching orders failed", "ticker", j.Ticker, "err", err)
+				continue
+			}
+			byTicker[j.Ticker] = orders
+		}
+
+		found := false
+		for _, o := range orders {
+			if o.ClientOrderID == j.ClientOrderID {
+				e.recoverOrder(j, o)
+				found = true
+				break
+			}
+		}
+		if found || time.Since(j.CreatedAt) < unconfirmedGrace {
+			continue
+		}
+
+		u := positions.OrderUpdate{
+			ClientOrderID: j.ClientOrderID,
+			Status:        positions.JournalFailed,
+			Detail:        "not found on exchange",
+		}
+		if _, err := e.db.RecordOrderResult(u, nil); err != nil {
+			slog.Error("Recording order outcome failed", "clientOrderID", j.ClientOrderID, "err", err)
+			continue
+		}
+		slog.Info("In-flight order never reached Kalshi", "ticker", j.Ticker, "clientOrderID", j.ClientOrderID)
+	}
+}
+
+// recoverOrder records the exchange's state for a journaled order.
+func (e *Engine) recoverOrder(j positions.JournalEntry, o kalshi.Order) {
+	if j.Kind == positions.OrderKindMaker {
+		order := j.Order(o.OrderID)
+		if _, err := e.db.AddOrder(order); err != nil {
+			slog.Error("Storing recovered order failed", "orderID", o.OrderID, "err", err)
+			return
+		}
+		u := positions.OrderUpdate{
+			ClientOrderID: j.ClientOrderID,
+			OrderID:       o.OrderID,
+			Status:        positions.JournalResting,
+			Detail:        "recovered",
+		}
+		if _, err := e.db.RecordOrderResult(u, nil); err != nil {
+			slog.Error("Recording order outcome failed", "orderID", o.OrderID, "err", err)
+			return
+		}
+		slog.Info("Recovered maker order", "ticker", j.Ticker, "orderID", o.OrderID)
+		e.refreshOrder(order, &o)
+		return
+	}
+
+	u := positions.OrderUpdate{
+		ClientOrderID: j.ClientOrderID,
+		OrderID:       o.OrderID,
+		Status:        string(o.Status),
+		Filled:        o.FillCount,
+		Detail:        "recovered",
+	}
+	if o.FillCount > 0 {
+		u.AvgPrice = o.AvgFillPrice()
+		u.Fees = float64(o.TakerFees+o.MakerFees) / 100
+	}
+	switch j.Kind {
+	case positions.OrderKindExit:
+		slog.Info("Recovered exit order", "ticker", j.Ticker, "orderID", o.OrderID, "filled", o.FillCount)
+		e.recordExit(j, u)
+		return
+	case positions.OrderKindHedge:
+		slog.Info("Recovered hedge order", "ticker", j.Ticker, "orderID", o.OrderID, "filled", o.FillCount)
+		e.recordHedge(j, u)
+		return
+	}
+
+	var pos *positions.Position
+	if o.FillCount > 0 {
+		p := j.Position(o.FillCount, u.AvgPrice, u.Fees)
+		pos = &p
+	}
+	id, err := e.db.RecordOrderResult(u, pos)
+	if err != nil {
+		slog.Error("Recording order outcome failed", "orderID", o.OrderID, "err", err)
+		return
+	}
+	slog.Info("Recovered in-flight order",
+		"ticker", j.Ticker, "orderID", o.OrderID, "status", o.Status, "filled", o.FillCount, "positionID", id)
+}
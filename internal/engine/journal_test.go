@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/alerts"
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+	"sports-betting-bot/internal/positions"
+)
+
+func mustOrders(t *testing.T, x *kalshitest.Exchange, ticker string) []kalshi.Order {
+	t.Helper()
+	orders, err := x.GetOrders(ticker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return orders
+}
+
+func TestExecuteTradeJournalsOrder(t *testing.T) {
+	ticker := "KXNBAGAME-26FEB05OKCPOR"
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 500)
+
+	eng, db := newTestEngine(t, []api.GameOdds{favoriteOdds(18, "POR", "OKC")}, x)
+	eng.Scan()
+
+	orders := mustOrders(t, x, ticker)
+	if len(orders) != 1 || orders[0].ClientOrderID == "" {
+		t.Fatalf("exchange orders = %+v, want one with a client order ID", orders)
+	}
+	j, err := db.GetJournalEntry(orders[0].ClientOrderID)
+	if err != nil || j == nil {
+		t.Fatalf("journal entry = %v, %v, want the submitted order", j, err)
+	}
+	stored := mustPositions(t, db)
+	if j.OrderID != orders[0].OrderID || j.Status != positions.JournalExecuted || j.Filled != orders[0].FillCount ||
+		len(stored) != 1 || j.PositionID != stored[0].ID {
+		t.Errorf("entry = %+v, want %s executed and linked to the stored position", j, orders[0].OrderID)
+	}
+	if events, _ := db.OrderEvents(j.ClientOrderID); len(events) != 2 || events[0].Status != positions.JournalIntent {
+		t.Errorf("events = %+v, want intent then executed", events)
+	}
+}
+
+func TestRestartReconcilesInFlightOrder(t *testing.T) {
+	prevGrace := unconfirmedGrace
+	unconfirmedGrace = 0
+	t.Cleanup(func() { unconfirmedGrace = prevGrace })
+
+	sent, lost := "KXNBAGAME-26FEB05PHIBOS", "KXNBAGAME-26FEB05MEMDAL"
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(sent, kalshi.SideNo, 50, 500)
+	x.AddLiquidity(lost, kalshi.SideNo, 50, 500)
+
+	games := []api.GameOdds{favoriteOdds(19, "BOS", "PHI"), favoriteOdds(20, "DAL", "MEM")}
+	eng, db := newTestEngine(t, games, x)
+
+	// The previous run journaled two orders and died: Kalshi took the
+	// first, the second never left the process
+	intent := func(clientOrderID, ticker, gameID, home, away string) {
+		t.Helper()
+		err := db.RecordIntent(positions.JournalEntry{
+			ClientOrderID: clientOrderID, Kind: positions.OrderKindTaker, GameID: gameID,
+			HomeTeam: home, AwayTeam: away, MarketType: "moneyline", Side: "home",
+			Ticker: ticker, BetSide: "yes", Action: "buy", Contracts: 10, TrueProb: 0.65,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	intent("crash-sent", sent, "19", "BOS", "PHI")
+	intent("crash-lost", lost, "20", "DAL", "MEM")
+	_, err := x.SubmitOrder(kalshi.CreateOrderRequest{
+		Ticker: sent, ClientOrderID: "crash-sent", Side: kalshi.SideYes, Action: kalshi.ActionBuy,
+		Count: 10, Type: kalshi.OrderTypeLimit, YesPrice: 50, TimeInForce: kalshi.TimeInForceIOC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unresolved, both tickers are off limits
+	eng.Scan()
+	if orders := mustOrders(t, x, sent); len(orders) != 1 {
+		t.Fatalf("orders on %s = %d, want only the crashed one", sent, len(orders))
+	}
+	if orders := mustOrders(t, x, lost); len(orders) != 0 {
+		t.Fatalf("orders on %s = %d, want none while in flight", lost, len(orders))
+	}
+
+	restarted := New(&fakeOdds{games: games}, x, alerts.NewNotifier(time.Minute), db, eng.cfg, eng.analysisCfg, eng.execConfig)
+	restarted.SetClock(eng.now)
+	restarted.reconcileJournal()
+
+	stored := mustPositions(t, db)
+	if len(stored) != 1 || stored[0].Ticker != sent || stored[0].Contracts != 10 || stored[0].EntryPrice != 0.50 {
+		t.Fatalf("stored = %+v, want the recovered 10 contracts at 0.50 on %s", stored, sent)
+	}
+	if j, _ := db.GetJournalEntry("crash-sent"); j == nil || j.Status != positions.JournalExecuted || j.PositionID != stored[0].ID {
+		t.Errorf("sent entry = %+v, want executed and linked to the position", j)
+	}
+	if j, _ := db.GetJournalEntry("crash-lost"); j == nil || j.Status != positions.JournalFailed {
+		t.Errorf("lost entry = %+v, want failed", j)
+	}
+
+	// The recovered fill blocks a second buy; the lost order is retried
+	restarted.Scan()
+	if orders := mustOrders(t, x, sent); len(orders) != 1 {
+		t.Errorf("orders on %s after restart = %d, want no resubmit", sent, len(orders))
+	}
+	if orders := mustOrders(t, x, lost); len(orders) != 1 {
+		t.Errorf("orders on %s after restart = %d, want the opportunity traded", lost, len(orders))
+	}
+}
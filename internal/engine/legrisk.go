@@ -0,0 +1,239 @@
+package engine
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/positions"
+)
+
+// legManager squares up an arb whose legs filled unevenly. Contracts one
+// leg filled beyond the others are a naked bet, not an arb: for a two-leg
+// arb the missing leg is bought again if its book still offers it within
+// retryBand cents of the quoted price and the pair still pays after fees.
+// Whatever stays unmatched is sold back into the bids.
+type legManager struct {
+	kalshiClient Exchange
+	db           *positions.DB
+	execConfig   kalshi.OrderConfig
+	retryBand    int // Cents over its quote a retried leg may pay
+	orderBook    func(ticker string) (*kalshi.OrderBookResponse, error)
+	now          time.Time
+
+	spent float64 // Dollars paid for retried legs
+}
+
+func newLegManager(
+	kalshiClient Exchange,
+	db *positions.DB,
+	execConfig kalshi.OrderConfig,
+	cfg config.Config,
+	orderBook func(ticker string) (*kalshi.OrderBookResponse, error),
+	now time.Time,
+) *legManager {
+	return &legManager{
+		kalshiClient: kalshiClient,
+		db:           db,
+		execConfig:   execConfig,
+		retryBand:    cfg.ArbRetryBandCents,
+		orderBook:    orderBook,
+		now:          now,
+	}
+}
+
+// settle records the results of an arb's leg orders, journaled as legs and
+// quoted at quoted cents, and squares an uneven fill. A leg whose outcome
+// is unknown can't be matched or unwound: what is known is stored as plain
+// positions, the rest is left in flight for reconcileJournal, and ok is
+// false.
+func (m *legManager) settle(event string, legs []positions.JournalEntry, quoted []int, results []*kalshi.ExecutionResult) (fill positions.ArbFill, ok bool) {
+	fill.Event = event
+	known := true
+	updates := make([]positions.OrderUpdate, len(legs))
+	for i, result := range results {
+		if outcomeUnknown(result) {
+			known = false
+			continue
+		}
+		updates[i] = takerUpdate(legs[i], result)
+	}
+
+	if !known {
+		for i, u := range updates {
+			if u.ClientOrderID == "" {
+				continue
+			}
+			var pos *positions.Position
+			if u.Filled > 0 {
+				p := legs[i].Position(u.Filled, u.AvgPrice, u.Fees)
+				pos = &p
+			}
+			if _, err := m.db.RecordOrderResult(u, pos); err != nil {
+				slog.Error("Recording arb leg failed", "ticker", legs[i].Ticker, "err", err)
+			}
+		}
+		slog.Warn("Arb leg outcome unknown, filled legs left unhedged", "event", event)
+		return fill, false
+	}
+
+	pairs, excess, err := m.db.RecordArbLegs(legs, updates)
+	if err != nil {
+		slog.Error("Recording arb legs failed", "event", event, "err", err)
+		return fill, false
+	}
+	fill.Pairs = pairs
+	for _, u := range updates {
+		fill.Cost += unitCost(u) * float64(pairs)
+	}
+
+	for i, u := range updates {
+		extra := u.Filled - pairs
+		if extra <= 0 {
+			continue
+		}
+		slog.Warn("Uneven arb fill", "event", event, "ticker", legs[i].Ticker, "excess", extra)
+		if len(legs) == 2 && m.retryBand > 0 {
+			other := 1 - i
+			filled, cost, known := m.retryLeg(legs[other], quoted[other], excess[i], extra, unitCost(u))
+			fill.Pairs += filled
+			fill.Cost += cost
+			extra -= filled
+			if !known {
+				// Selling back could leave the retry's fills naked
+				fill.Open += extra
+				continue
+			}
+		}
+		if extra > 0 {
+			sold, pnl := m.sellBack(legs[i], extra)
+			fill.Sold += sold
+			fill.UnwindPnL += pnl
+			fill.Open += extra - sold
+		}
+	}
+	if fill.Pairs > 0 {
+		slog.Info("Arb filled", "event", event, "pairs", fill.Pairs, "locked", fill.LockedPnL(),
+			"unwind", fill.UnwindPnL, "open", fill.Open)
+	}
+	return fill, true
+}
+
+// retryLeg buys up to shortfall contracts of the missing leg j to pair with
+// the leftover row hedgeOf, which cost paid dollars per contract. The buy
+// is capped at quoted+retryBand cents and at the highest price where the
+// pair still pays after fees, and sized to the book's depth within that
+// cap. Fills are stored locked with the leftover through RecordHedge.
+// Returns the contracts paired, their cost including the leftover's, and
+// whether the retry's outcome is known.
+func (m *legManager) retryLeg(j positions.JournalEntry, quoted int, hedgeOf int64, shortfall int, paid float64) (int, float64, bool) {
+	ceiling := min(quoted+m.retryBand, 99)
+	for ceiling > 0 && paid*100+float64(ceiling)+kalshi.TakerFeeCents(ceiling) >= 100 {
+		ceiling--
+	}
+	if ceiling == 0 {
+		return 0, 0, true
+	}
+
+	side := kalshi.Side(j.BetSide)
+	book, err := m.orderBook(j.Ticker)
+	if err != nil {
+		slog.Error("Orderbook fetch failed", "ticker", j.Ticker, "err", err)
+		return 0, 0, true
+	}
+	contracts := min(shortfall, kalshi.DepthWithin(book, side, kalshi.ActionBuy, ceiling))
+	if contracts == 0 {
+		slog.Info("Missing arb leg outside the retry band", "ticker", j.Ticker, "side", side, "ceiling", ceiling)
+		return 0, 0, true
+	}
+
+	// The retry is journaled as a hedge of the leftover so recovery locks
+	// it the same way
+	j.Side = j.Position(0, 0, 0).Side
+	j.ClientOrderID = uuid.New().String()
+	j.Kind = positions.OrderKindHedge
+	j.Contracts = contracts
+	j.HedgeOf = hedgeOf
+	if err := m.db.RecordIntent(j); err != nil {
+		slog.Error("Journaling arb leg retry failed", "ticker", j.Ticker, "err", err)
+		return 0, 0, true
+	}
+
+	cfg := m.execConfig
+	cfg.TrueProb = 0
+	cfg.MinLiquidityContracts = min(cfg.MinLiquidityContracts, contracts)
+	cfg.ClientOrderID = j.ClientOrderID
+
+	slog.Info("Retrying missing arb leg", "ticker", j.Ticker, "side", side, "contracts", contracts, "ceiling", ceiling)
+	result, err := m.kalshiClient.PlaceOrder(j.Ticker, side, kalshi.ActionBuy, contracts, cfg)
+	recordOrder("arb_"+j.MarketType, float64(quoted), result, err)
+	if err != nil {
+		slog.Error("Arb leg retry failed", "ticker", j.Ticker, "err", err)
+		return 0, 0, false
+	}
+	if outcomeUnknown(result) {
+		slog.Warn("Arb leg retry outcome unknown, left for reconciliation",
+			"ticker", j.Ticker, "clientOrderID", j.ClientOrderID, "reason", result.RejectionReason)
+		return 0, 0, false
+	}
+
+	u := takerUpdate(j, result)
+	if _, err := m.db.RecordHedge(j, u); err != nil {
+		slog.Error("Recording arb leg retry failed", "ticker", j.Ticker, "err", err)
+	}
+	m.spent += float64(result.TotalCost) / 100
+	return u.Filled, (unitCost(u) + paid) * float64(u.Filled), true
+}
+
+// sellBack sells up to extra leftover contracts of leg j into its bids
+// through the exit path. Returns the contracts sold and the P&L realized.
+func (m *legManager) sellBack(j positions.JournalEntry, extra int) (int, float64) {
+	side := kalshi.Side(j.BetSide)
+	book, err := m.orderBook(j.Ticker)
+	if err != nil {
+		slog.Error("Orderbook fetch failed", "ticker", j.Ticker, "err", err)
+		return 0, 0
+	}
+	contracts := min(extra, kalshi.CheckLiquidity(book, side, kalshi.ActionSell, extra).Available)
+	if contracts == 0 {
+		slog.Warn("No bids to unwind arb leg", "ticker", j.Ticker, "excess", extra)
+		return 0, 0
+	}
+	yesBid, _, noBid, _ := bookQuotes(book)
+	bid := yesBid
+	if side == kalshi.SideNo {
+		bid = noBid
+	}
+	h := positions.Holding{
+		GameID:     j.GameID,
+		HomeTeam:   j.HomeTeam,
+		AwayTeam:   j.AwayTeam,
+		MarketType: j.MarketType,
+		Side:       j.Position(0, 0, 0).Side,
+		Ticker:     j.Ticker,
+		BetSide:    j.BetSide,
+		Contracts:  extra,
+	}
+	sold, closes := sellHolding(m.kalshiClient, m.db, m.execConfig, h, contracts, float64(bid)/100, "arb unwind", m.now)
+	pnl := 0.0
+	for _, s := range closes {
+		pnl += s.RealizedPnL
+	}
+	return sold, pnl
+}
+
+// unitCost is the dollars per contract an update's fill cost, fees included.
+func unitCost(u positions.OrderUpdate) float64 {
+	if u.Filled == 0 {
+		return 0
+	}
+	return u.AvgPrice/100 + u.Fees/float64(u.Filled)
+}
+
+// legManager returns a leg manager reading books through the feed.
+func (e *Engine) legManager() *legManager {
+	return newLegManager(e.kalshiClient, e.db, e.execConfig, e.cfg, e.orderBook, e.now())
+}
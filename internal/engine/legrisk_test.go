@@ -0,0 +1,138 @@
+package engine
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"sports-betting-bot/internal/alerts"
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+	"sports-betting-bot/internal/positions"
+)
+
+// arbSink keeps the arb alerts it is sent.
+type arbSink struct {
+	mu    sync.Mutex
+	fills []positions.ArbFill
+}
+
+func (s *arbSink) Name() string { return "arbs" }
+
+func (s *arbSink) Send(a alerts.Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if fill, ok := a.Data.(positions.ArbFill); ok {
+		s.fills = append(s.fills, fill)
+	}
+	return nil
+}
+
+// bookTaken has other traders sweep ticker's asks on side right after our
+// first buy there, leaving the missing leg to whatever rests behind them.
+type bookTaken struct {
+	shortFill
+	side  kalshi.Side
+	taken bool
+}
+
+func (b *bookTaken) PlaceOrder(ticker string, side kalshi.Side, action kalshi.OrderAction, contracts int, cfg kalshi.OrderConfig) (*kalshi.ExecutionResult, error) {
+	result, err := b.shortFill.PlaceOrder(ticker, side, action, contracts, cfg)
+	if ticker == b.ticker && !b.taken {
+		b.taken = true
+		b.Exchange.ExternalTake(ticker, b.side, 100, 47)
+	}
+	return result, err
+}
+
+// unevenLadder sets up the total ladder arb of TestCrossArbUnwindsUnevenFills:
+// over 220 at 40 and under 225 at 45, where under 225 fills only 12 of 20.
+func unevenLadder(t *testing.T, id int) (*Engine, *positions.DB, *kalshitest.Exchange, *arbSink, string, string) {
+	t.Helper()
+	x := kalshitest.NewExchange(1000)
+	game := favoriteOdds(id, "MIL", "NOP")
+	eng, db := newTestEngine(t, []api.GameOdds{game}, x)
+	eng.cfg.CrossArb = true
+	eng.cfg.ArbRetryBandCents = 2
+	sink := &arbSink{}
+	eng.notifier.AddSink(sink, alerts.Filter{})
+
+	event := strings.Replace(moneylineTicker(game), string(kalshi.SeriesMoneyline), string(kalshi.SeriesTotal), 1)
+	low, high := event+"-220", event+"-225"
+	x.AddLiquidity(low, kalshi.SideNo, 60, 20)
+	x.AddLiquidity(high, kalshi.SideYes, 55, 20)
+	x.AddLiquidity(low, kalshi.SideYes, 38, 50)
+	eng.kalshiClient = shortFill{Exchange: x, ticker: high, max: 12}
+	eng.SetMarketLister(listing{kalshi.SeriesTotal: {
+		{Ticker: low, YesAsk: 40, NoAsk: 61},
+		{Ticker: high, YesAsk: 56, NoAsk: 45},
+	}})
+	return eng, db, x, sink, low, high
+}
+
+func TestArbLegRetryLocksLeftover(t *testing.T) {
+	eng, db, x, sink, low, high := unevenLadder(t, 7)
+	game := favoriteOdds(7, "MIL", "NOP")
+
+	// The 8 unders still resting at 45 are inside the band: the retry
+	// pairs them with the extra overs instead of selling those back
+	eng.scanCrossArbs([]api.GameOdds{game}, nil, 1000)
+
+	stored := mustPositions(t, db)
+	total := map[string]int{}
+	for _, p := range stored {
+		if !p.Locked {
+			t.Errorf("position %+v left unlocked", p)
+		}
+		total[p.Ticker] += p.Contracts
+	}
+	if total[low] != 20 || total[high] != 20 {
+		t.Errorf("locked contracts = %v, want 20 on each strike", total)
+	}
+	if closes := mustSettlements(t, db); len(closes) != 0 {
+		t.Errorf("settlements = %+v, want nothing sold back", closes)
+	}
+	if held := heldContracts(t, x); held[low] != 20 || held[high] != -20 {
+		t.Errorf("exchange positions = %v, want 20 over 220 and 20 under 225", held)
+	}
+
+	eng.notifier.Flush()
+	if len(sink.fills) != 1 {
+		t.Fatalf("arb alerts = %+v, want one", sink.fills)
+	}
+	if fill := sink.fills[0]; fill.Pairs != 20 || fill.Sold != 0 || fill.Open != 0 || fill.PnL() <= 0 {
+		t.Errorf("fill = %+v, want 20 profitable pairs", fill)
+	}
+}
+
+func TestArbLegRetryOutsideBandSellsBack(t *testing.T) {
+	eng, db, x, sink, low, high := unevenLadder(t, 8)
+	game := favoriteOdds(8, "MIL", "NOP")
+
+	// The rest of the unders at 45 are taken; 50 is past the 2¢ band
+	x.AddLiquidity(high, kalshi.SideYes, 50, 20)
+	eng.kalshiClient = &bookTaken{shortFill: eng.kalshiClient.(shortFill), side: kalshi.SideNo}
+
+	eng.scanCrossArbs([]api.GameOdds{game}, nil, 1000)
+
+	closes := mustSettlements(t, db)
+	if len(closes) != 1 || closes[0].Payout != 8*0.38 {
+		t.Fatalf("settlements = %+v, want the 8 extra overs closed at 38¢", closes)
+	}
+	if held := heldContracts(t, x); held[low] != 12 || held[high] != -12 {
+		t.Errorf("exchange positions = %v, want 12 over 220 and 12 under 225", held)
+	}
+
+	eng.notifier.Flush()
+	if len(sink.fills) != 1 {
+		t.Fatalf("arb alerts = %+v, want one", sink.fills)
+	}
+	fill := sink.fills[0]
+	if fill.Pairs != 12 || fill.Sold != 8 || fill.Open != 0 {
+		t.Errorf("fill = %+v, want 12 pairs and 8 sold back", fill)
+	}
+	if fill.UnwindPnL != closes[0].RealizedPnL || fill.PnL() != fill.LockedPnL()+closes[0].RealizedPnL {
+		t.Errorf("fill P&L = %+.2f, want the locked pairs plus the %+.2f unwind", fill.PnL(), closes[0].RealizedPnL)
+	}
+}
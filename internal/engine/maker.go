@@ -0,0 +1,282 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+
+	"sports-betting-bot/internal/analysis"
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/metrics"
+	"sports-betting-bot/internal/positions"
+	"sports-betting-bot/internal/risk"
+)
+
+// SetOrderManager enables maker mode: with EXECUTION_MODE=maker,
+// opportunities are worked with resting limit bids through om instead of
+// taking liquidity.
+func (e *Engine) SetOrderManager(om OrderManager) {
+	e.orders = om
+}
+
+// makerMode reports whether opportunities are executed as resting orders.
+// Order state lives in the DB, so maker mode requires one.
+func (e *Engine) makerMode() bool {
+	return e.cfg.ExecutionMode == config.ExecMaker && e.orders != nil && e.db != nil
+}
+
+// makerPrice returns the limit for a resting bid on side: one cent above
+// the best bid, capped where the fee-free EV would fall below threshold and
+// kept under the ask so the order never takes. 0 means no price works.
+func makerPrice(trueProb, threshold float64, book *kalshi.OrderBookResponse, side kalshi.Side) int {
+	yesBid, yesAsk, noBid, noAsk := bookQuotes(book)
+	bid, ask := yesBid, yesAsk
+	if side == kalshi.SideNo {
+		bid, ask = noBid, noAsk
+	}
+
+	// Without fees, EV at price p is trueProb - p
+	price := int(math.Floor((trueProb-threshold)*100 + 1e-9))
+	price = min(price, bid+1, 99)
+	if ask > 0 {
+		price = min(price, ask-1)
+	}
+	if price < 1 || analysis.CalculateEV(trueProb, float64(price)/100) < threshold {
+		return 0
+	}
+	return price
+}
+
+// postMakerOrder rests a post-only bid for tp and records it. Returns the
+// dollars the order reserves.
+func (e *Engine) postMakerOrder(tp TradeParams, startsAt string, bankroll float64) float64 {
+	if tp.Ticker == "" {
+		return 0
+	}
+	game := api.Game{DateTime: startsAt}
+	if game.StartsWithinAt(e.now(), e.cfg.MakerCancelBeforeStart) {
+		return 0
+	}
+
+	// An open order on the ticker is repriced by repriceOrders instead
+	if orderInFlight(e.db, tp.Ticker, tp.BetSide) || tickerLocked(e.db, tp.Ticker) {
+		return 0
+	}
+	if has, err := e.db.HasOpenOrder(tp.Ticker, tp.BetSide); err != nil || has {
+		if err != nil {
+			slog.Error("Checking open orders failed", "ticker", tp.Ticker, "err", err)
+		}
+		return 0
+	}
+	if has, err := e.db.HasPositionOnTicker(tp.Ticker, tp.BetSide); err != nil || has {
+		if err != nil {
+			slog.Error("Checking DB failed", "ticker", tp.Ticker, "err", err)
+		}
+		return 0
+	}
+	arbConfig := kalshi.ArbConfig{
+		MinProfitCents: config.DefaultMinArbProfitCents,
+		MinProfitPct:   config.DefaultMinArbProfitPct,
+	}
+	canAdd, _, _, err := kalshi.CheckCanAddToPosition(e.kalshiClient, tp.Ticker, tp.Side, arbConfig)
+	if err != nil {
+		slog.Error("Checking position failed", "ticker", tp.Ticker, "err", err)
+		return 0
+	}
+	if !canAdd {
+		return 0
+	}
+
+	book, err := e.kalshiClient.GetOrderBook(tp.Ticker)
+	if err != nil {
+		slog.Error("Orderbook fetch failed", "ticker", tp.Ticker, "err", err)
+		return 0
+	}
+	price := makerPrice(tp.TrueProb, e.cfg.EVThreshold, book, tp.Side)
+	if price == 0 {
+		return 0
+	}
+
+	contracts := stakeContracts(tp, e.cfg, bankroll, float64(price)/100, price)
+	headroom, binding, err := risk.NewManager(risk.LimitsFromConfig(e.cfg), e.db).Headroom(tp.riskTrade())
+	if err != nil {
+		slog.Error("Risk check failed", "ticker", tp.Ticker, "err", err)
+		return 0
+	}
+	if binding != "" {
+		contracts = min(contracts, int(headroom*100/float64(price)))
+	}
+	if contracts < e.execConfig.MinLiquidityContracts || contracts <= 0 {
+		return 0
+	}
+
+	if e.execConfig.DryRun {
+		slog.Info("Dry run maker order",
+			"ticker", tp.Ticker, "side", tp.Side, "contracts", contracts, "price", price)
+		return 0
+	}
+
+	j := tp.journalEntry(positions.OrderKindMaker, contracts)
+	j.LimitPrice = price
+	j.StartsAt = startsAt
+	if err := e.db.RecordIntent(j); err != nil {
+		slog.Error("Journaling maker order failed", "ticker", tp.Ticker, "err", err)
+		return 0
+	}
+
+	req := kalshi.CreateOrderRequest{
+		Ticker:        tp.Ticker,
+		ClientOrderID: j.ClientOrderID,
+		Side:          tp.Side,
+		Action:        kalshi.ActionBuy,
+		Count:         contracts,
+		Type:          kalshi.OrderTypeLimit,
+		TimeInForce:   kalshi.TimeInForceGTC,
+		PostOnly:      true,
+	}
+	if tp.Side == kalshi.SideYes {
+		req.YesPrice = price
+	} else {
+		req.NoPrice = price
+	}
+
+	metrics.OrdersAttempted.Inc(tp.MarketType)
+	placed, err := e.orders.SubmitOrder(req)
+	if err != nil {
+		// The error may have come after Kalshi took the order, so the
+		// journal entry stays in flight until reconcileJournal looks
+		metrics.OrdersRejected.Inc(tp.MarketType, "submit_failed")
+		slog.Warn("Maker order rejected", "ticker", tp.Ticker, "price", price, "err", err)
+		return 0
+	}
+
+	o := j.Order(placed.OrderID)
+	if _, err := e.db.AddOrder(o); err != nil {
+		// Untracked, the order could fill unseen; take it down. Its journal
+		// entry stays in flight, so any fills are still recovered.
+		slog.Error("Storing maker order failed, cancelling", "orderID", placed.OrderID, "err", err)
+		if err := e.orders.CancelOrder(placed.OrderID); err != nil {
+			slog.Error("Cancelling untracked order failed", "orderID", placed.OrderID, "err", err)
+		}
+		return 0
+	}
+	u := positions.OrderUpdate{ClientOrderID: j.ClientOrderID, OrderID: placed.OrderID, Status: positions.JournalResting}
+	if _, err := e.db.RecordOrderResult(u, nil); err != nil {
+		slog.Error("Recording order outcome failed", "orderID", placed.OrderID, "err", err)
+	}
+	slog.Info("Maker order resting",
+		"type", tp.LogPrefix, "ticker", tp.Ticker, "side", tp.Side, "orderID", placed.OrderID,
+		"contracts", contracts, "price", price, "ev", analysis.CalculateEV(tp.TrueProb, float64(price)/100)*100)
+
+	e.refreshOrder(o, placed)
+	return float64(price*contracts) / 100
+}
+
+// repriceOrders cancels resting orders on the evaluated games whose edge is
+// gone or whose consensus moved by MakerRepriceThreshold. It runs before
+// execution, so an opportunity that still stands is reposted at a fresh
+// price in the same pass.
+func (e *Engine) repriceOrders(evaluated map[int]bool, gameOpps []analysis.Opportunity, propOpps []analysis.PlayerPropOpportunity) {
+	open, err := e.db.GetOpenOrders()
+	if err != nil {
+		slog.Error("Loading open orders failed", "err", err)
+		return
+	}
+	if len(open) == 0 {
+		return
+	}
+
+	current := make(map[string]TradeParams)
+	for _, opp := range gameOpps {
+		tp := TradeParamsFromOpportunity(opp)
+		current[stakeKey(tp.Ticker, tp.BetSide)] = tp
+	}
+	for _, opp := range propOpps {
+		tp := TradeParamsFromPropOpportunity(opp)
+		current[stakeKey(tp.Ticker, tp.BetSide)] = tp
+	}
+
+	for _, o := range open {
+		var gameID int
+		if _, err := fmt.Sscanf(o.GameID, "%d", &gameID); err != nil || !evaluated[gameID] {
+			continue
+		}
+		tp, ok := current[stakeKey(o.Ticker, o.BetSide)]
+		switch {
+		case !ok:
+			e.cancelOrder(o, "edge gone")
+		case math.Abs(tp.TrueProb-o.TrueProb) >= e.cfg.MakerRepriceThreshold:
+			e.cancelOrder(o, fmt.Sprintf("consensus moved %.1f%% to %.1f%%", o.TrueProb*100, tp.TrueProb*100))
+		}
+	}
+}
+
+// syncOrders records fills on every resting order and cancels orders near
+// tip-off, or all of them while the circuit breaker is tripped. At startup
+// it recovers orders left resting by a previous run.
+func (e *Engine) syncOrders() {
+	open, err := e.db.GetOpenOrders()
+	if err != nil {
+		slog.Error("Loading open orders failed", "err", err)
+		return
+	}
+	if len(open) == 0 {
+		return
+	}
+	halted := e.executionHalted()
+
+	for _, o := range open {
+		game := api.Game{DateTime: o.StartsAt}
+		switch {
+		case halted:
+			e.cancelOrder(o, "circuit breaker tripped")
+		case game.StartsWithinAt(e.now(), e.cfg.MakerCancelBeforeStart):
+			e.cancelOrder(o, "tip-off")
+		default:
+			latest, err := e.orders.GetOrder(o.OrderID)
+			if err != nil {
+				slog.Error("Fetching order failed", "orderID", o.OrderID, "err", err)
+				continue
+			}
+			e.refreshOrder(o, latest)
+		}
+	}
+}
+
+// cancelOrder cancels o and records its final state, including any fills
+// that landed before the cancel.
+func (e *Engine) cancelOrder(o positions.Order, reason string) {
+	if err := e.orders.CancelOrder(o.OrderID); err != nil {
+		// Usually filled or already cancelled; the refresh below tells
+		slog.Warn("Cancelling order failed", "orderID", o.OrderID, "err", err)
+	} else {
+		slog.Info("Maker order cancelled", "ticker", o.Ticker, "orderID", o.OrderID, "reason", reason)
+	}
+	latest, err := e.orders.GetOrder(o.OrderID)
+	if err != nil {
+		slog.Error("Fetching order failed", "orderID", o.OrderID, "err", err)
+		return
+	}
+	e.refreshOrder(o, latest)
+}
+
+// refreshOrder stores latest as o's state, turning new fills into positions.
+func (e *Engine) refreshOrder(o positions.Order, latest *kalshi.Order) {
+	if string(latest.Status) == o.Status && latest.FillCount == o.Filled {
+		return
+	}
+	fees := float64(latest.MakerFees+latest.TakerFees) / 100
+	id, err := e.db.RecordOrderState(o, string(latest.Status), latest.FillCount, fees)
+	if err != nil {
+		slog.Error("Recording order state failed", "orderID", o.OrderID, "err", err)
+		return
+	}
+	if id != 0 {
+		metrics.OrdersFilled.Inc(o.MarketType)
+		slog.Info("Maker order filled",
+			"ticker", o.Ticker, "orderID", o.OrderID, "positionID", id,
+			"filled", latest.FillCount, "requested", o.Contracts, "price", o.LimitPrice)
+	}
+}
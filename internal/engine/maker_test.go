@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/alerts"
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+	"sports-betting-bot/internal/positions"
+)
+
+var _ OrderManager = (*kalshitest.Exchange)(nil)
+
+// makerEngine is newTestEngine in maker mode against x.
+func makerEngine(t *testing.T, games []api.GameOdds, x *kalshitest.Exchange) (*Engine, *positions.DB) {
+	t.Helper()
+	eng, db := newTestEngine(t, games, x)
+	eng.cfg.ExecutionMode = config.ExecMaker
+	eng.cfg.MakerRepriceThreshold = config.DefaultMakerRepriceThreshold
+	eng.cfg.MakerCancelBeforeStart = config.DefaultMakerCancelBeforeStart
+	eng.SetOrderManager(x)
+	return eng, db
+}
+
+func mustOpenOrders(t *testing.T, db *positions.DB) []positions.Order {
+	t.Helper()
+	open, err := db.GetOpenOrders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return open
+}
+
+func TestMakerPrice(t *testing.T) {
+	book := &kalshi.OrderBookResponse{}
+	book.OrderBook.Yes = [][2]int{{45, 100}}
+	book.OrderBook.No = [][2]int{{50, 100}} // YES ask 50
+
+	tests := []struct {
+		name     string
+		trueProb float64
+		side     kalshi.Side
+		want     int
+	}{
+		{"improves the bid", 0.65, kalshi.SideYes, 46},
+		{"capped by fee-free EV", 0.48, kalshi.SideYes, 45},
+		{"no price clears threshold", 0.035, kalshi.SideYes, 0},
+		{"NO side stays under its ask", 0.70, kalshi.SideNo, 51},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := makerPrice(tt.trueProb, 0.03, book, tt.side); got != tt.want {
+				t.Errorf("makerPrice = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMakerOrderRestsFillsAndRecovers(t *testing.T) {
+	ticker := "KXNBAGAME-26FEB05LACSAC"
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 500) // YES offered at 50¢
+	x.AddLiquidity(ticker, kalshi.SideYes, 45, 100)
+
+	games := []api.GameOdds{favoriteOdds(16, "SAC", "LAC")}
+	eng, db := makerEngine(t, games, x)
+	eng.Scan()
+
+	open := mustOpenOrders(t, db)
+	if len(open) != 1 || open[0].Ticker != ticker || open[0].LimitPrice != 46 || open[0].BetSide != "yes" {
+		t.Fatalf("open orders = %+v, want one YES bid at 46¢ on %s", open, ticker)
+	}
+	if stored := mustPositions(t, db); len(stored) != 0 {
+		t.Fatalf("stored %d positions before any fill, want none", len(stored))
+	}
+	if held, _ := x.GetPositions(); len(held) != 0 {
+		t.Fatalf("exchange positions = %+v, want none: the bid must not take", held)
+	}
+
+	// Another trader sells into part of the bid while the bot is down
+	filled := x.ExternalTake(ticker, kalshi.SideNo, 5, 54)
+	if filled != 5 {
+		t.Fatalf("external take filled %d, want 5 against our bid", filled)
+	}
+
+	restarted := New(&fakeOdds{games: games}, x, alerts.NewNotifier(time.Minute), db, eng.cfg, eng.analysisCfg, eng.execConfig)
+	restarted.SetClock(eng.now)
+	restarted.SetOrderManager(x)
+	restarted.syncOrders()
+
+	stored := mustPositions(t, db)
+	if len(stored) != 1 || stored[0].Contracts != 5 || stored[0].EntryPrice != 0.46 {
+		t.Fatalf("stored = %+v, want 5 contracts at 0.46 from the maker fill", stored)
+	}
+	if open := mustOpenOrders(t, db); len(open) != 1 || open[0].Filled != 5 {
+		t.Errorf("open orders = %+v, want the remainder still resting", open)
+	}
+
+	// Near tip-off the remainder is cancelled
+	restarted.SetClock(func() time.Time { return scanTime.Add(3*time.Hour - 5*time.Minute) })
+	restarted.syncOrders()
+	if open := mustOpenOrders(t, db); len(open) != 0 {
+		t.Errorf("open orders near tip-off = %+v, want none", open)
+	}
+	order, err := x.GetOrder(open[0].OrderID)
+	if err != nil || order.Status != kalshi.OrderStatusCanceled {
+		t.Errorf("exchange order = %+v, %v, want canceled", order, err)
+	}
+}
+
+func TestMakerOrderRepricesAndCancels(t *testing.T) {
+	ticker := "KXNBAGAME-26FEB05DETIND"
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 500)
+	x.AddLiquidity(ticker, kalshi.SideYes, 45, 100)
+
+	game := favoriteOdds(17, "IND", "DET")
+	eng, db := makerEngine(t, []api.GameOdds{game}, x)
+	eng.Scan()
+	first := mustOpenOrders(t, db)
+	if len(first) != 1 {
+		t.Fatalf("open orders = %+v, want one", first)
+	}
+
+	// The books move further toward the home side: cancel and repost
+	for i := range game.Vendors[1:] {
+		game.Vendors[i+1].Moneyline = &api.Moneyline{Home: -300, Away: 250}
+	}
+	eng.client = &fakeOdds{games: []api.GameOdds{game}}
+	eng.Scan()
+
+	second := mustOpenOrders(t, db)
+	if len(second) != 1 || second[0].OrderID == first[0].OrderID || second[0].TrueProb <= first[0].TrueProb {
+		t.Fatalf("open orders = %+v, want a fresh order at the new consensus", second)
+	}
+	if o, _ := x.GetOrder(first[0].OrderID); o.Status != kalshi.OrderStatusCanceled {
+		t.Errorf("first order status = %s, want canceled", o.Status)
+	}
+
+	// The books come back to Kalshi's price: the edge is gone
+	for i := range game.Vendors[1:] {
+		game.Vendors[i+1].Moneyline = &api.Moneyline{Home: -100, Away: -100}
+	}
+	eng.client = &fakeOdds{games: []api.GameOdds{game}}
+	eng.Scan()
+
+	if open := mustOpenOrders(t, db); len(open) != 0 {
+		t.Errorf("open orders after the edge vanished = %+v, want none", open)
+	}
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+
+	"sports-betting-bot/internal/analysis"
+	"sports-betting-bot/internal/odds"
+	"sports-betting-bot/internal/positions"
+)
+
+// recordPredictions stores the consensus P(YES) of every Kalshi market the
+// scanned games list, alerted on or not, for calibration. Each scan
+// replaces the last, so what remains is the last pre-game consensus.
+func (e *Engine) recordPredictions(scans []pregameScan) {
+	if e.db == nil {
+		return
+	}
+	at := e.now()
+	var preds []positions.Prediction
+	for _, scan := range scans {
+		preds = append(preds, gamePredictions(scan.consensus)...)
+		gameID := fmt.Sprintf("%d", scan.consensus.GameID)
+		for _, ladder := range scan.ladders {
+			for _, km := range ladder.Markets {
+				over := ladder.Curve.ProbAtLeast(km.Line)
+				if over <= 0 || over >= 1 {
+					continue
+				}
+				preds = append(preds, positions.Prediction{
+					Ticker:     km.Ticker,
+					GameID:     gameID,
+					MarketType: "prop_" + ladder.PropType,
+					Line:       km.Line,
+					Prob:       over,
+					BookCount:  ladder.BookCount,
+				})
+			}
+		}
+	}
+	for i := range preds {
+		preds[i].PredictedAt = at
+	}
+	if err := e.db.RecordPredictions(preds); err != nil {
+		slog.Warn("Failed to record predictions", "err", err)
+	}
+}
+
+// gamePredictions returns the consensus P(YES) of a game's moneyline,
+// spread and total markets that Kalshi lists: the home win, the home
+// cover and the over.
+func gamePredictions(c odds.ConsensusOdds) []positions.Prediction {
+	if c.KalshiOdds == nil {
+		return nil
+	}
+	var preds []positions.Prediction
+	add := func(marketType odds.MarketType, line, prob float64, books int) {
+		ticker := MapToKalshiTicker(analysis.Opportunity{
+			MarketType: marketType,
+			GameDate:   c.GameDate,
+			HomeTeam:   c.HomeTeam,
+			AwayTeam:   c.AwayTeam,
+		})
+		if ticker == "" || prob <= 0 || prob >= 1 {
+			return
+		}
+		preds = append(preds, positions.Prediction{
+			Ticker:     ticker,
+			GameID:     fmt.Sprintf("%d", c.GameID),
+			MarketType: string(marketType),
+			Line:       line,
+			Prob:       prob,
+			BookCount:  books,
+		})
+	}
+	if c.Moneyline != nil && c.KalshiOdds.Moneyline != nil {
+		add(odds.MarketMoneyline, 0, c.Moneyline.HomeTrueProb, c.Moneyline.BookCount)
+	}
+	if c.Spread != nil && c.KalshiOdds.Spread != nil {
+		add(odds.MarketSpread, c.Spread.HomeSpread, c.Spread.HomeCoverProb, c.Spread.BookCount)
+	}
+	if c.Total != nil && c.KalshiOdds.Total != nil {
+		add(odds.MarketTotal, c.Total.Line, c.Total.OverProb, c.Total.BookCount)
+	}
+	return preds
+}
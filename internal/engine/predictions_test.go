@@ -0,0 +1,40 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+)
+
+func TestScanRecordsPredictionsForCalibration(t *testing.T) {
+	game := favoriteOdds(1, "PHX", "GSW")
+	ticker := moneylineTicker(game)
+	x := kalshitest.NewExchange(1000)
+	// No book: the market is predicted without being traded
+
+	eng, db := newTestEngine(t, []api.GameOdds{game}, x)
+	eng.Scan()
+	x.Settle(ticker, "yes")
+
+	// Not looked up while the game could still be in progress
+	eng.settle()
+	if preds, _ := db.GetResolvedPredictions(); len(preds) != 0 {
+		t.Fatalf("resolved %+v at tip-off, want nothing yet", preds)
+	}
+
+	eng.SetClock(func() time.Time { return scanTime.Add(predictionResolveDelay + time.Minute) })
+	eng.settle()
+	preds, err := db.GetResolvedPredictions()
+	if err != nil || len(preds) != 1 {
+		t.Fatalf("GetResolvedPredictions = %+v, %v, want the moneyline", preds, err)
+	}
+	p := preds[0]
+	if p.Ticker != ticker || p.MarketType != "moneyline" || p.BookCount != 6 || p.Prob < 0.6 || p.Prob > 0.7 {
+		t.Errorf("prediction = %+v, want the ~65%% home win from 6 books", p)
+	}
+	if y, ok := p.Outcome(); !ok || y != 1 {
+		t.Errorf("Outcome = %v, %v, want the YES result", y, ok)
+	}
+}
@@ -0,0 +1,97 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/positions"
+)
+
+// ReconcilePositions diffs open DB positions against Kalshi's positions.
+// With fix set it imports positions missing from the DB and corrects
+// contract counts, closing contracts Kalshi no longer holds. Orphans (DB rows Kalshi doesn't hold) are only reported:
+// they usually mean a manual close or an unsettled market and need a look.
+// Returns every discrepancy found, fixed or not.
+func ReconcilePositions(kalshiClient Exchange, db *positions.DB, fix bool) ([]positions.Discrepancy, error) {
+	remote, err := kalshiClient.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("fetching Kalshi positions: %w", err)
+	}
+	local, err := db.GetAllPositions()
+	if err != nil {
+		return nil, fmt.Errorf("loading open positions: %w", err)
+	}
+
+	diffs := positions.Reconcile(local, remote)
+	if !fix {
+		return diffs, nil
+	}
+
+	for _, d := range diffs {
+		switch d.Kind {
+		case positions.DiscrepancyMissing:
+			if _, err := db.AddPosition(positions.ImportedPosition(*d.Remote)); err != nil {
+				return diffs, fmt.Errorf("importing %s: %w", d.Ticker, err)
+			}
+		case positions.DiscrepancyCount:
+			if err := fixContractCount(kalshiClient, db, d); err != nil {
+				return diffs, fmt.Errorf("fixing %s: %w", d.Ticker, err)
+			}
+		}
+	}
+	return diffs, nil
+}
+
+// fixContractCount brings the DB rows for a ticker+side to Kalshi's count.
+// Extra contracts go on the newest row. A shortfall, usually a manual sale,
+// is closed out of the newest rows first at the current best bid, so it
+// stays in realized P&L and the rows keep their history.
+func fixContractCount(kalshiClient Exchange, db *positions.DB, d positions.Discrepancy) error {
+	delta := d.RemoteContracts - d.LocalContracts
+	if delta > 0 {
+		newest := d.Local[0]
+		return db.UpdateContracts(newest.ID, newest.Contracts+delta)
+	}
+
+	bid := 0
+	if book, err := kalshiClient.GetOrderBook(d.Ticker); err != nil {
+		slog.Warn("Orderbook fetch failed, closing missing contracts at entry price", "ticker", d.Ticker, "err", err)
+	} else {
+		bid = bestBid(book, kalshi.Side(d.BetSide))
+	}
+	_, err := db.CloseMissingContracts(d.Local, -delta, bid, time.Now())
+	return err
+}
+
+// reconcile runs one reconciliation pass in the configured mode and logs
+// each discrepancy.
+func (e *Engine) reconcile() {
+	mode := e.cfg.ReconcileMode
+	if e.kalshiClient == nil || e.db == nil || mode == config.ReconcileOff {
+		return
+	}
+
+	// Record in-flight and maker fills first so they aren't mistaken for
+	// missing positions
+	e.reconcileJournal()
+	if e.makerMode() {
+		e.syncOrders()
+	}
+
+	fix := mode == config.ReconcileFix
+	diffs, err := ReconcilePositions(e.kalshiClient, e.db, fix)
+	for _, d := range diffs {
+		action := "reported"
+		if fix && d.Kind != positions.DiscrepancyOrphan {
+			action = "fixed"
+		}
+		slog.Warn("Position mismatch", "kind", d.Kind, "ticker", d.Ticker, "side", d.BetSide,
+			"db", d.LocalContracts, "kalshi", d.RemoteContracts, "action", action)
+	}
+	if err != nil {
+		e.notifier.LogError("reconciling positions", err)
+	}
+}
@@ -0,0 +1,129 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+	"sports-betting-bot/internal/positions"
+)
+
+func TestReconcilePositions(t *testing.T) {
+	ticker := "KXNBAGAME-26FEB05CHIDET"
+	manual := "KXNBASPREAD-26FEB05CHIDET"
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 500)
+	x.AddLiquidity(manual, kalshi.SideYes, 60, 100)
+
+	eng, db := newTestEngine(t, []api.GameOdds{favoriteOdds(5, "DET", "CHI")}, x)
+	eng.Scan()
+
+	// Trades the DB never saw: more of the bot's ticker, and a manual NO buy
+	for _, req := range []kalshi.CreateOrderRequest{
+		{Ticker: ticker, Side: kalshi.SideYes, Action: kalshi.ActionBuy, Count: 5, YesPrice: 50, TimeInForce: kalshi.TimeInForceIOC},
+		{Ticker: manual, Side: kalshi.SideNo, Action: kalshi.ActionBuy, Count: 15, NoPrice: 40, TimeInForce: kalshi.TimeInForceIOC},
+	} {
+		if _, err := x.SubmitOrder(req); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// A DB row Kalshi never filled
+	if _, err := db.AddPosition(positions.Position{GameID: "5", HomeTeam: "DET", AwayTeam: "CHI",
+		MarketType: "total", Side: "over", Ticker: "KXNBATOTAL-26FEB05CHIDET", BetSide: "yes",
+		EntryPrice: 0.5, Contracts: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	before, _ := db.GetAllPositions()
+
+	// Report mode changes nothing
+	diffs, err := ReconcilePositions(x, db, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 3 {
+		t.Fatalf("diffs = %v, want count, missing and orphan", diffs)
+	}
+	if after, _ := db.GetAllPositions(); len(after) != len(before) {
+		t.Errorf("report mode changed the DB: %d rows, want %d", len(after), len(before))
+	}
+
+	if _, err := ReconcilePositions(x, db, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the orphan remains
+	diffs, err = ReconcilePositions(x, db, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diffs) != 1 || diffs[0].Kind != positions.DiscrepancyOrphan {
+		t.Errorf("after fix diffs = %v, want only the orphan", diffs)
+	}
+
+	imported := false
+	for _, p := range mustPositions(t, db) {
+		if p.Ticker == manual {
+			imported = p.MarketType == "spread" && p.BetSide == "no" && p.Contracts == 15
+		}
+	}
+	if !imported {
+		t.Errorf("manual %s trade was not imported as 15 NO spread contracts", manual)
+	}
+}
+
+func TestReconcileClosesManualSale(t *testing.T) {
+	ticker := "KXNBAGAME-26FEB05SASUTA"
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(ticker, kalshi.SideNo, 60, 100)  // YES offered at 40¢
+	x.AddLiquidity(ticker, kalshi.SideYes, 55, 100) // YES bid at 55¢
+	_, db := newTestEngine(t, nil, x)
+
+	if _, err := x.SubmitOrder(kalshi.CreateOrderRequest{Ticker: ticker, Side: kalshi.SideYes,
+		Action: kalshi.ActionBuy, Count: 20, YesPrice: 40, TimeInForce: kalshi.TimeInForceIOC}); err != nil {
+		t.Fatal(err)
+	}
+	id, err := db.AddPosition(positions.Position{GameID: "9", MarketType: "moneyline", Side: "home",
+		Ticker: ticker, BetSide: "yes", EntryPrice: 0.40, Contracts: 20, Fees: 0.20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 8 contracts sold by hand on Kalshi
+	if _, err := x.SubmitOrder(kalshi.CreateOrderRequest{Ticker: ticker, Side: kalshi.SideYes,
+		Action: kalshi.ActionSell, Count: 8, YesPrice: 55, TimeInForce: kalshi.TimeInForceIOC}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReconcilePositions(x, db, true); err != nil {
+		t.Fatal(err)
+	}
+
+	if open := mustPositions(t, db); len(open) != 1 || open[0].ID != id || open[0].Contracts != 12 {
+		t.Errorf("open = %+v, want position %d left with 12 contracts", open, id)
+	}
+	settlements, err := db.GetSettlements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(settlements) != 1 || settlements[0].Result != positions.ResultClosed || settlements[0].PositionID == id {
+		t.Fatalf("settlements = %+v, want one close on a row split off %d", settlements, id)
+	}
+	// 8 contracts at the 55¢ bid, bought at 40¢ with 8¢ of the entry fees
+	if s := settlements[0]; math.Abs(s.Payout-4.40) > 1e-9 || math.Abs(s.RealizedPnL-(4.40-3.20-0.08)) > 1e-9 {
+		t.Errorf("close = %+v, want $4.40 paid and $1.12 realized", s)
+	}
+	if diffs, _ := ReconcilePositions(x, db, false); len(diffs) != 0 {
+		t.Errorf("diffs after fix = %v, want none", diffs)
+	}
+}
+
+func mustPositions(t *testing.T, db *positions.DB) []positions.Position {
+	t.Helper()
+	all, err := db.GetAllPositions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return all
+}
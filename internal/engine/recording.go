@@ -0,0 +1,91 @@
+package engine
+
+import (
+	"log/slog"
+	"time"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/snapshot"
+)
+
+// recordingOdds wraps an OddsProvider and persists every successful response.
+type recordingOdds struct {
+	OddsProvider
+	rec *snapshot.Recorder
+}
+
+// RecordOdds returns an OddsProvider that writes GetTodaysOdds, GetPlayerProps
+// and GetPlayerNames results to rec before returning them.
+func RecordOdds(p OddsProvider, rec *snapshot.Recorder) OddsProvider {
+	return &recordingOdds{OddsProvider: p, rec: rec}
+}
+
+func (r *recordingOdds) GetTodaysOdds() ([]api.GameOdds, error) {
+	odds, err := r.OddsProvider.GetTodaysOdds()
+	if err == nil {
+		record(r.rec, snapshot.KindOdds, 0, "", odds)
+	}
+	return odds, err
+}
+
+func (r *recordingOdds) GetPlayerProps(gameID int) ([]api.PlayerProp, error) {
+	props, err := r.OddsProvider.GetPlayerProps(gameID)
+	if err == nil {
+		record(r.rec, snapshot.KindPlayerProps, gameID, "", props)
+	}
+	return props, err
+}
+
+func (r *recordingOdds) GetPlayerNames(playerIDs []int) map[int]string {
+	names := r.OddsProvider.GetPlayerNames(playerIDs)
+	record(r.rec, snapshot.KindPlayerNames, 0, "", names)
+	return names
+}
+
+// recordingExchange wraps an Exchange and persists market data reads.
+type recordingExchange struct {
+	Exchange
+	rec *snapshot.Recorder
+}
+
+// RecordExchange returns an Exchange that writes GetPlayerPropMarkets and
+// GetOrderBook results, and settled GetMarket results, to rec before
+// returning them. Orders pass through.
+func RecordExchange(x Exchange, rec *snapshot.Recorder) Exchange {
+	return &recordingExchange{Exchange: x, rec: rec}
+}
+
+func (r *recordingExchange) GetPlayerPropMarkets(date time.Time) (map[string][]kalshi.PlayerPropMarket, error) {
+	markets, err := r.Exchange.GetPlayerPropMarkets(date)
+	if err == nil {
+		record(r.rec, snapshot.KindPropMarkets, 0, "", markets)
+	}
+	return markets, err
+}
+
+func (r *recordingExchange) GetOrderBook(ticker string) (*kalshi.OrderBookResponse, error) {
+	book, err := r.Exchange.GetOrderBook(ticker)
+	if err == nil {
+		record(r.rec, snapshot.KindOrderBook, 0, ticker, book)
+	}
+	return book, err
+}
+
+// GetMarket records settled results so backtests can replay settlements.
+func (r *recordingExchange) GetMarket(ticker string) (*kalshi.Market, error) {
+	market, err := r.Exchange.GetMarket(ticker)
+	if err == nil {
+		if result, ok := market.SettledResult(); ok {
+			record(r.rec, snapshot.KindResult, 0, ticker, snapshot.MarketResult{Result: result})
+		}
+	}
+	return market, err
+}
+
+// record writes a snapshot, logging rather than failing the scan on error.
+func record(rec *snapshot.Recorder, kind snapshot.Kind, gameID int, ticker string, v any) {
+	if err := rec.Record(kind, gameID, ticker, v); err != nil {
+		slog.Warn("Snapshot write failed", "kind", kind, "err", err)
+	}
+}
@@ -0,0 +1,134 @@
+package engine
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"sports-betting-bot/internal/positions"
+)
+
+// SettlePositions looks up the market for every open position's ticker and
+// records a settlement for each position whose market has finalized.
+// Settled positions drop out of GetAllPositions. Positions stored before
+// tickers were tracked cannot be resolved and are left open.
+func SettlePositions(kalshiClient Exchange, db *positions.DB, now time.Time) ([]positions.Settlement, error) {
+	open, err := db.GetAllPositions()
+	if err != nil {
+		return nil, fmt.Errorf("loading open positions: %w", err)
+	}
+
+	// Several positions can share a ticker (e.g. an arb row beside the +EV
+	// row), so look each market up once.
+	results := make(map[string]string)
+	checked := make(map[string]bool)
+
+	var settled []positions.Settlement
+	for _, pos := range open {
+		if pos.Ticker == "" {
+			continue
+		}
+
+		if !checked[pos.Ticker] {
+			checked[pos.Ticker] = true
+			market, err := kalshiClient.GetMarket(pos.Ticker)
+			if err != nil {
+				slog.Warn("Market lookup failed", "ticker", pos.Ticker, "err", err)
+				continue
+			}
+			if result, ok := market.SettledResult(); ok {
+				results[pos.Ticker] = result
+			}
+		}
+
+		result, ok := results[pos.Ticker]
+		if !ok {
+			continue
+		}
+
+		s := positions.Settle(pos, result)
+		s.SettledAt = now
+		if err := db.RecordSettlement(s); err != nil {
+			return settled, err
+		}
+		settled = append(settled, s)
+	}
+
+	return settled, nil
+}
+
+// settle runs one settlement pass and logs each resolved position, then
+// records the results of alerted and predicted markets.
+func (e *Engine) settle() {
+	if e.kalshiClient == nil || e.db == nil {
+		return
+	}
+	defer e.resolveMarkets()
+
+	settled, err := SettlePositions(e.kalshiClient, e.db, e.now())
+	for _, s := range settled {
+		e.notifier.LogSettlement(s)
+	}
+	if err != nil {
+		e.notifier.LogError("settling positions", err)
+	}
+}
+
+// resolveWindow bounds how far back unresolved alerts and predictions are
+// looked up on Kalshi, so a ticker that never settles isn't polled forever.
+const resolveWindow = 7 * 24 * time.Hour
+
+// predictionResolveDelay is how long after its last pre-game prediction a
+// market is first looked up: about the length of a game, so markets
+// aren't polled while still being played.
+const predictionResolveDelay = 2 * time.Hour
+
+// resolveMarkets records the result of every settled market the bot
+// alerted on or predicted in the last resolveWindow, traded or not. Each
+// market is looked up once.
+func (e *Engine) resolveMarkets() {
+	now := e.now()
+	alerted, err := e.db.UnresolvedAlertTickers(now.Add(-resolveWindow))
+	if err != nil {
+		e.notifier.LogError("loading unresolved alerts", err)
+		return
+	}
+	predicted, err := e.db.UnresolvedPredictionTickers(now.Add(-resolveWindow), now.Add(-predictionResolveDelay))
+	if err != nil {
+		e.notifier.LogError("loading unresolved predictions", err)
+		return
+	}
+
+	results := make(map[string]string)
+	lookup := func(ticker string) (string, bool) {
+		if result, ok := results[ticker]; ok {
+			return result, result != ""
+		}
+		results[ticker] = ""
+		market, err := e.kalshiClient.GetMarket(ticker)
+		if err != nil {
+			slog.Warn("Market lookup failed", "ticker", ticker, "err", err)
+			return "", false
+		}
+		result, ok := market.SettledResult()
+		results[ticker] = result
+		return result, ok
+	}
+
+	for _, ticker := range alerted {
+		if result, ok := lookup(ticker); ok {
+			if err := e.db.ResolveAlerts(ticker, result); err != nil {
+				e.notifier.LogError("resolving alerts", err)
+				return
+			}
+		}
+	}
+	for _, ticker := range predicted {
+		if result, ok := lookup(ticker); ok {
+			if err := e.db.ResolvePrediction(ticker, result); err != nil {
+				e.notifier.LogError("resolving predictions", err)
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package engine
+
+import (
+	"testing"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+)
+
+func TestSettlePositionsAfterFill(t *testing.T) {
+	ticker := "KXNBAGAME-26FEB05DALHOU"
+	x := kalshitest.NewExchange(1000)
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 500)
+
+	eng, db := newTestEngine(t, []api.GameOdds{favoriteOdds(4, "HOU", "DAL")}, x)
+	eng.Scan()
+
+	open, _ := db.GetAllPositions()
+	if len(open) != 1 {
+		t.Fatalf("open positions = %d, want 1 after scan", len(open))
+	}
+
+	// Nothing settles while the market is live
+	settled, err := SettlePositions(x, db, scanTime)
+	if err != nil || len(settled) != 0 {
+		t.Fatalf("settled %d before result, err %v", len(settled), err)
+	}
+
+	x.Settle(ticker, "yes")
+	settled, err = SettlePositions(x, db, scanTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(settled) != 1 {
+		t.Fatalf("settled %d, want 1", len(settled))
+	}
+
+	s := settled[0]
+	pos := open[0]
+	wantPnL := float64(pos.Contracts) - pos.Cost() - pos.Fees
+	if s.Result != "yes" || s.Payout != float64(pos.Contracts) || s.RealizedPnL != wantPnL {
+		t.Errorf("settlement = %+v, want yes paying %d with P&L %.2f", s, pos.Contracts, wantPnL)
+	}
+	if pos.Fees <= 0 {
+		t.Errorf("Fees = %v, want entry fees recorded", pos.Fees)
+	}
+
+	if open, _ := db.GetAllPositions(); len(open) != 0 {
+		t.Errorf("open positions = %d, want 0 after settlement", len(open))
+	}
+	if again, _ := SettlePositions(x, db, scanTime); len(again) != 0 {
+		t.Errorf("second pass settled %d, want 0", len(again))
+	}
+}
@@ -0,0 +1,117 @@
+package engine
+
+import (
+	"strings"
+
+	"sports-betting-bot/internal/analysis"
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/positions"
+	"sports-betting-bot/internal/risk"
+)
+
+// jointBet is a scan opportunity awaiting a simultaneous Kelly stake.
+type jointBet struct {
+	key string
+	bet analysis.JointBet
+}
+
+// jointStakes sizes a scan's opportunities with simultaneous Kelly, one game
+// at a time, counting the open positions on each game. Games are treated as
+// independent of each other. Returns stakes as fractions of bankroll keyed
+// by stakeKey.
+func jointStakes(
+	games []analysis.Opportunity,
+	props []analysis.PlayerPropOpportunity,
+	open []positions.Position,
+	bankroll float64,
+	cfg config.Config,
+) map[string]float64 {
+	bets := make(map[string][]jointBet)
+	add := func(tp TradeParams) {
+		t := tp.riskTrade()
+		outcome, ok := outcomeOf(t, tp.TrueProb)
+		if !ok {
+			return
+		}
+		game := t.Game()
+		bets[game] = append(bets[game], jointBet{
+			key: stakeKey(tp.Ticker, tp.BetSide),
+			bet: analysis.JointBet{Outcome: outcome, Price: tp.KalshiPrice},
+		})
+	}
+	for _, opp := range games {
+		add(TradeParamsFromOpportunity(opp))
+	}
+	for _, opp := range props {
+		add(TradeParamsFromPropOpportunity(opp))
+	}
+
+	// Open positions have no current consensus here, so their entry price
+	// stands in for the win probability
+	held := make(map[string][]analysis.Holding)
+	for _, pos := range open {
+		if pos.Side == "arb" || pos.Locked {
+			continue
+		}
+		t := risk.TradeFromPosition(pos)
+		if outcome, ok := outcomeOf(t, pos.EntryPrice); ok {
+			held[t.Game()] = append(held[t.Game()], analysis.Holding{Outcome: outcome, Payout: float64(pos.Contracts)})
+		}
+	}
+
+	corr := analysis.Correlations{
+		MarginTotal:  cfg.CorrMarginTotal,
+		PlayerTotal:  cfg.CorrPlayerTotal,
+		PlayerMargin: cfg.CorrPlayerMargin,
+		PlayerPlayer: cfg.CorrPlayerPlayer,
+	}
+
+	stakes := make(map[string]float64)
+	for game, gameBets := range bets {
+		candidates := make([]analysis.JointBet, len(gameBets))
+		for i, b := range gameBets {
+			candidates[i] = b.bet
+		}
+		sized := analysis.SimultaneousKelly(candidates, held[game], bankroll, corr, cfg.KellyFraction)
+		for i, b := range gameBets {
+			stakes[b.key] = sized[i]
+		}
+	}
+	return stakes
+}
+
+// outcomeOf places a trade on its game's latent factors for joint sizing.
+// Returns false for markets the model doesn't cover.
+func outcomeOf(t risk.Trade, prob float64) (analysis.Outcome, bool) {
+	dir := 1
+	if t.Side == "away" || t.Side == "under" {
+		dir = -1
+	}
+	o := analysis.Outcome{Dir: dir, Prob: prob}
+
+	switch {
+	case t.MarketType == "moneyline" || t.MarketType == "spread":
+		o.Factor = analysis.FactorMargin
+	case t.MarketType == "total":
+		o.Factor = analysis.FactorTotal
+	case strings.HasPrefix(t.MarketType, "prop_") && t.Player != "":
+		o.Factor = analysis.PlayerFactor(t.Player)
+		if info, ok := kalshi.ParseNBATicker(t.Ticker); ok && info.PlayerTeam != "" {
+			switch info.PlayerTeam {
+			case t.HomeTeam:
+				o.Home = 1
+			case t.AwayTeam:
+				o.Home = -1
+			}
+		}
+	default:
+		return o, false
+	}
+	return o, true
+}
+
+// stakeKey identifies a bet by ticker and Kalshi side.
+func stakeKey(ticker, betSide string) string {
+	return ticker + ":" + betSide
+}
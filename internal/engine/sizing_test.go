@@ -0,0 +1,50 @@
+package engine
+
+import (
+	"testing"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/kalshi/kalshitest"
+	"sports-betting-bot/internal/positions"
+)
+
+func TestScanSimultaneousSizing(t *testing.T) {
+	// scan buys the home moneyline with an optional open home spread
+	// position on the same game, and returns the contracts bought
+	scan := func(gameID int, home, away string, mode string, spreadHeld int) int {
+		ticker := "KXNBAGAME-26FEB05" + away + home
+		x := kalshitest.NewExchange(1000)
+		x.AddLiquidity(ticker, kalshi.SideNo, 50, 1000)
+
+		eng, db := newTestEngine(t, []api.GameOdds{favoriteOdds(gameID, home, away)}, x)
+		eng.cfg.SizingMode = mode
+		if spreadHeld > 0 {
+			if _, err := db.AddPosition(positions.Position{HomeTeam: home, AwayTeam: away,
+				MarketType: "spread", Side: "home", Ticker: "KXNBASPREAD-26FEB05" + away + home,
+				BetSide: "yes", EntryPrice: 0.5, Contracts: spreadHeld}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		eng.Scan()
+
+		held, _ := x.GetPositions()
+		if len(held) != 1 {
+			t.Fatalf("%s: exchange positions = %+v, want one", ticker, held)
+		}
+		return held[0].Position
+	}
+
+	independent := scan(11, "PHX", "DAL", config.SizingIndependent, 0)
+	alone := scan(12, "DEN", "POR", config.SizingSimultaneous, 0)
+	if diff := alone - independent; diff < -1 || diff > 1 {
+		t.Errorf("simultaneous with nothing else on the game bought %d, want independent %d", alone, independent)
+	}
+
+	// $400 riding on the home spread already: the moneyline adds to the same risk
+	correlated := scan(13, "MEM", "HOU", config.SizingSimultaneous, 400)
+	if correlated >= alone*3/4 {
+		t.Errorf("with a home spread position bought %d, want well below %d", correlated, alone)
+	}
+}
@@ -0,0 +1,67 @@
+package engine
+
+import (
+	"time"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+)
+
+// OddsProvider is the sportsbook data the scan cycle consumes.
+// *api.BallDontLieClient implements it; backtests substitute recorded data.
+type OddsProvider interface {
+	GetTodaysOdds() ([]api.GameOdds, error)
+	GetPlayerProps(gameID int) ([]api.PlayerProp, error)
+	GetPlayerNames(playerIDs []int) map[int]string
+}
+
+// Exchange is the Kalshi surface the scan cycle and executor use.
+// *kalshi.KalshiClient implements it; backtests and tests substitute a simulator.
+type Exchange interface {
+	GetBalanceDollars() (float64, error)
+	GetPlayerPropMarkets(date time.Time) (map[string][]kalshi.PlayerPropMarket, error)
+	GetOrderBook(ticker string) (*kalshi.OrderBookResponse, error)
+	GetPositions() ([]kalshi.MarketPosition, error)
+	GetMarket(ticker string) (*kalshi.Market, error)
+	GetOrders(ticker string) ([]kalshi.Order, error)
+	PlaceOrder(ticker string, side kalshi.Side, action kalshi.OrderAction, contracts int, config kalshi.OrderConfig) (*kalshi.ExecutionResult, error)
+}
+
+// MarketFeed streams live Kalshi order books.
+// *kalshi.MarketFeed implements it.
+type MarketFeed interface {
+	Track(tickers ...string)
+	Untrack(tickers ...string)
+	Tracked() []string
+	OrderBook(ticker string) (*kalshi.OrderBookResponse, bool)
+	Updates() <-chan string
+}
+
+// OrderManager places and manages resting orders for maker mode.
+// *kalshi.KalshiClient and *kalshitest.Exchange implement it.
+type OrderManager interface {
+	SubmitOrder(req kalshi.CreateOrderRequest) (*kalshi.Order, error)
+	GetOrder(orderID string) (*kalshi.Order, error)
+	CancelOrder(orderID string) error
+}
+
+// MarketLister lists open Kalshi game markets for cross-market arbs.
+// *kalshi.KalshiClient implements it.
+type MarketLister interface {
+	GetOpenNBAMarkets() (map[kalshi.KalshiSeries][]kalshi.KalshiMarket, error)
+}
+
+// LiveScoreProvider is the live score and clock feed in-play mode prices
+// games from. *api.BallDontLieClient implements it.
+type LiveScoreProvider interface {
+	GetLiveBoxScores() ([]api.LiveBoxScore, error)
+}
+
+var (
+	_ OddsProvider      = (*api.BallDontLieClient)(nil)
+	_ LiveScoreProvider = (*api.BallDontLieClient)(nil)
+	_ Exchange          = (*kalshi.KalshiClient)(nil)
+	_ MarketFeed        = (*kalshi.MarketFeed)(nil)
+	_ OrderManager      = (*kalshi.KalshiClient)(nil)
+	_ MarketLister      = (*kalshi.KalshiClient)(nil)
+)
@@ -182,12 +182,42 @@ func (c *KalshiClient) CheckCanAddToPosition(
 	ticker string,
 	proposedSide Side,
 	config ArbConfig,
+) (canAdd bool, isArb bool, arbOpp *ArbOpportunity, err error) {
+	return CheckCanAddToPosition(c, ticker, proposedSide, config)
+}
+
+// PositionBookReader is the read side of an exchange needed to decide whether
+// a position can be added to. *KalshiClient and simulated exchanges implement it.
+type PositionBookReader interface {
+	GetPositions() ([]MarketPosition, error)
+	GetOrderBook(ticker string) (*OrderBookResponse, error)
+}
+
+// OrderPlacer places orders with the full PlaceOrder safeguard chain.
+type OrderPlacer interface {
+	PlaceOrder(ticker string, side Side, action OrderAction, contracts int, config OrderConfig) (*ExecutionResult, error)
+}
+
+// CheckCanAddToPosition applies the add-to-position rules against any
+// PositionBookReader. See (*KalshiClient).CheckCanAddToPosition.
+func CheckCanAddToPosition(
+	r PositionBookReader,
+	ticker string,
+	proposedSide Side,
+	config ArbConfig,
 ) (canAdd bool, isArb bool, arbOpp *ArbOpportunity, err error) {
 	// Check existing position
-	hasPosition, positionSize, err := c.HasPositionOnMarket(ticker)
+	positions, err := r.GetPositions()
 	if err != nil {
 		return false, false, nil, fmt.Errorf("checking position: %w", err)
 	}
+	hasPosition, positionSize := false, 0
+	for _, pos := range positions {
+		if pos.Ticker == ticker && pos.Position != 0 {
+			hasPosition, positionSize = true, pos.Position
+			break
+		}
+	}
 
 	// No existing position → can add normally
 	if !hasPosition || positionSize == 0 {
@@ -206,7 +236,7 @@ func (c *KalshiClient) CheckCanAddToPosition(
 	}
 
 	// Opposite side → check for arb
-	book, err := c.GetOrderBook(ticker)
+	book, err := r.GetOrderBook(ticker)
 	if err != nil {
 		return false, false, nil, fmt.Errorf("fetching order book: %w", err)
 	}
@@ -282,10 +312,29 @@ func CalculateArbProfit(yesPriceCents, noPriceCents, contracts int) float64 {
 // ExecuteArb executes an arbitrage opportunity by buying both sides concurrently.
 // Both legs are launched in parallel to minimize the window for price movement.
 func (c *KalshiClient) ExecuteArb(arb *ArbOpportunity, contracts int, config OrderConfig) (*ExecutionResult, *ExecutionResult, error) {
+	return ExecuteArb(c, arb, contracts, config)
+}
+
+// ArbLegClientOrderID derives the client order ID of one arb leg from the
+// ID set on the arb's OrderConfig.
+func ArbLegClientOrderID(base string, side Side) string {
+	return base + "-" + string(side)
+}
+
+// ExecuteArb executes an arbitrage opportunity through any OrderPlacer,
+// launching both legs concurrently. A ClientOrderID in config is split into
+// one ID per leg with ArbLegClientOrderID.
+func ExecuteArb(p OrderPlacer, arb *ArbOpportunity, contracts int, config OrderConfig) (*ExecutionResult, *ExecutionResult, error) {
 	if contracts > arb.MaxContracts {
 		contracts = arb.MaxContracts
 	}
 
+	yesConfig, noConfig := config, config
+	if config.ClientOrderID != "" {
+		yesConfig.ClientOrderID = ArbLegClientOrderID(config.ClientOrderID, SideYes)
+		noConfig.ClientOrderID = ArbLegClientOrderID(config.ClientOrderID, SideNo)
+	}
+
 	var (
 		yesResult *ExecutionResult
 		noResult  *ExecutionResult
@@ -298,12 +347,12 @@ func (c *KalshiClient) ExecuteArb(arb *ArbOpportunity, contracts int, config Ord
 
 	go func() {
 		defer wg.Done()
-		yesResult, yesErr = c.PlaceOrder(arb.Ticker, SideYes, ActionBuy, contracts, config)
+		yesResult, yesErr = p.PlaceOrder(arb.Ticker, SideYes, ActionBuy, contracts, yesConfig)
 	}()
 
 	go func() {
 		defer wg.Done()
-		noResult, noErr = c.PlaceOrder(arb.Ticker, SideNo, ActionBuy, contracts, config)
+		noResult, noErr = p.PlaceOrder(arb.Ticker, SideNo, ActionBuy, contracts, noConfig)
 	}()
 
 	wg.Wait()
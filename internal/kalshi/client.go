@@ -18,6 +18,7 @@ import (
 	"time"
 
 	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/metrics"
 )
 
 const (
@@ -45,6 +46,9 @@ type KalshiClient struct {
 
 	// Use demo mode
 	demo bool
+
+	// Live order books; nil reads every book over REST
+	feed *MarketFeed
 }
 
 // NewKalshiClient creates a client using API key authentication
@@ -95,7 +99,7 @@ func NewKalshiClientFromKey(keyID, privateKeyPEM string, demo bool) (*KalshiClie
 	}
 
 	return &KalshiClient{
-		client:        api.NewRateLimitedClient(requestsPerMinute, requestTimeout, maxRetries),
+		client:        api.NewRateLimitedClient(metrics.UpstreamKalshi, requestsPerMinute, requestTimeout, maxRetries),
 		baseURL:       base,
 		apiKeyID:      keyID,
 		apiKeyPrivate: privateKey,
@@ -112,9 +116,14 @@ func (c *KalshiClient) signRequest(method, path string, timestampMs int64) (stri
 		pathForSigning = path[:idx]
 	}
 
-	// Message format: timestamp (ms) + HTTP method + path (no query params)
 	// The path should include /trade-api/v2 prefix
-	fullPath := "/trade-api/v2" + pathForSigning
+	return c.signPath(method, "/trade-api/v2"+pathForSigning, timestampMs)
+}
+
+// signPath signs a full request path (no query params), as used by both
+// the REST API and the WebSocket upgrade.
+func (c *KalshiClient) signPath(method, fullPath string, timestampMs int64) (string, error) {
+	// Message format: timestamp (ms) + HTTP method + path (no query params)
 	message := fmt.Sprintf("%d%s%s", timestampMs, method, fullPath)
 
 	// Hash the message with SHA-256
@@ -0,0 +1,286 @@
+package kalshi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ArbLeg is one buy in a cross-market arb.
+type ArbLeg struct {
+	Ticker string
+	Side   Side
+	Price  int // Best ask in cents
+	Depth  int // Contracts offered at Price; 0 when priced from a listing
+}
+
+// CrossArbOpportunity is a set of buys across related markets of one game
+// that pays at least $1 per contract however the game ends.
+type CrossArbOpportunity struct {
+	Event            string // Ticker prefix the legs share, e.g. "KXNBATOTAL-26FEB04NOPMIL"
+	Legs             []ArbLeg
+	TotalCost        int     // Sum of leg prices in cents
+	GuaranteedProfit float64 // Profit per contract after fees (cents)
+	ProfitPct        float64 // Profit as percentage of cost
+	MaxContracts     int     // Limited by the thinnest leg
+	Type             string  // "complement_arb" or "ladder_arb"
+	Description      string
+}
+
+// MarketQuote is the best ask on each side of a market.
+type MarketQuote struct {
+	Ticker   string
+	YesAsk   int // Cents; 0 when nothing is offered
+	YesDepth int // Contracts at YesAsk; 0 when unknown
+	NoAsk    int
+	NoDepth  int
+}
+
+// QuoteFromBook reads the best asks and their depth from an order book.
+func QuoteFromBook(ticker string, book *OrderBookResponse) MarketQuote {
+	q := MarketQuote{Ticker: ticker}
+	q.YesAsk, q.YesDepth = getBestAskFromNoBids(ParseLevels(book.OrderBook.No))
+	q.NoAsk, q.NoDepth = getBestAskFromYesBids(ParseLevels(book.OrderBook.Yes))
+	return q
+}
+
+// ask returns the quote's price and depth for buying side.
+func (q MarketQuote) ask(side Side) (int, int) {
+	if side == SideYes {
+		return q.YesAsk, q.YesDepth
+	}
+	return q.NoAsk, q.NoDepth
+}
+
+// leg returns the buy of side at the quoted ask.
+func (q MarketQuote) leg(side Side) ArbLeg {
+	price, depth := q.ask(side)
+	return ArbLeg{Ticker: q.Ticker, Side: side, Price: price, Depth: depth}
+}
+
+// rung is one strike of a ladder.
+type rung struct {
+	strike float64
+	quote  MarketQuote
+}
+
+// FindCrossArbs looks for arbs across related markets in quotes:
+//   - Complementary team markets of one game (KXNBAGAME-...-MEM and
+//     KXNBAGAME-...-SAC): exactly one team wins, so YES on both, or NO on
+//     both, pays exactly $1.
+//   - Strike ladders (KXNBASPREAD-...-SAC5, KXNBATOTAL-...-220,
+//     KXNBAPTS-...-HOUATHOMPSON1-25): YES at a strike implies YES at every
+//     lower strike, so YES at the lower strike plus NO at the higher pays
+//     $1, or $2 when the result lands between them. It only costs less than
+//     $1 when the ladder's prices are not monotone.
+//
+// At most one opportunity, the most profitable, is returned per game or
+// ladder, best first. Legs priced from listings have no depth, so their
+// MaxContracts is 0 until confirmed against the books.
+func FindCrossArbs(quotes []MarketQuote, config ArbConfig) []CrossArbOpportunity {
+	teams := make(map[string][]MarketQuote)
+	ladders := make(map[string][]rung)
+	for _, q := range quotes {
+		if key, strike, ok := ladderStrike(q.Ticker); ok {
+			ladders[key] = append(ladders[key], rung{strike, q})
+			continue
+		}
+		parts := strings.Split(q.Ticker, "-")
+		if len(parts) == 3 && KalshiSeries(parts[0]) == SeriesMoneyline {
+			event := parts[0] + "-" + parts[1]
+			teams[event] = append(teams[event], q)
+		}
+	}
+
+	var opps []CrossArbOpportunity
+	for event, qs := range teams {
+		if len(qs) != 2 {
+			continue // Not a two-team game listing
+		}
+		if opp := analyzeComplement(event, qs[0], qs[1], config); opp != nil {
+			opps = append(opps, *opp)
+		}
+	}
+	for key, rungs := range ladders {
+		if opp := analyzeLadder(strings.TrimSuffix(key, "-"), rungs, config); opp != nil {
+			opps = append(opps, *opp)
+		}
+	}
+
+	sort.Slice(opps, func(i, j int) bool {
+		if opps[i].GuaranteedProfit != opps[j].GuaranteedProfit {
+			return opps[i].GuaranteedProfit > opps[j].GuaranteedProfit
+		}
+		return opps[i].Event < opps[j].Event
+	})
+	return opps
+}
+
+// ladderStrike splits a strike market's ticker into its ladder key and
+// strike: "KXNBASPREAD-26FEB04MEMSAC-SAC5" is strike 5 of
+// "KXNBASPREAD-26FEB04MEMSAC-SAC". Tickers without a market segment ending
+// in a number are not on a ladder.
+func ladderStrike(ticker string) (string, float64, bool) {
+	parts := strings.Split(ticker, "-")
+	if len(parts) < 3 {
+		return "", 0, false
+	}
+	last := parts[len(parts)-1]
+	prefix := strings.TrimRight(last, "0123456789.")
+	if prefix == last {
+		return "", 0, false
+	}
+	strike, err := strconv.ParseFloat(last[len(prefix):], 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return ticker[:len(ticker)-len(last)+len(prefix)], strike, true
+}
+
+// analyzeComplement prices both ways of covering a two-team game.
+func analyzeComplement(event string, a, b MarketQuote, config ArbConfig) *CrossArbOpportunity {
+	var best *CrossArbOpportunity
+	for _, side := range []Side{SideYes, SideNo} {
+		opp := newCrossArb("complement_arb", event, []ArbLeg{a.leg(side), b.leg(side)}, config)
+		if opp != nil && (best == nil || opp.GuaranteedProfit > best.GuaranteedProfit) {
+			best = opp
+		}
+	}
+	return best
+}
+
+// analyzeLadder prices YES at each strike against NO at every higher one.
+func analyzeLadder(event string, rungs []rung, config ArbConfig) *CrossArbOpportunity {
+	sort.Slice(rungs, func(i, j int) bool { return rungs[i].strike < rungs[j].strike })
+
+	var best *CrossArbOpportunity
+	for i, low := range rungs {
+		for _, high := range rungs[i+1:] {
+			if high.strike == low.strike {
+				continue
+			}
+			opp := newCrossArb("ladder_arb", event, []ArbLeg{low.quote.leg(SideYes), high.quote.leg(SideNo)}, config)
+			if opp != nil && (best == nil || opp.GuaranteedProfit > best.GuaranteedProfit) {
+				best = opp
+			}
+		}
+	}
+	return best
+}
+
+// newCrossArb prices a set of legs paying at least $1, returning nil when
+// a leg has no offer or the profit after fees misses config.
+func newCrossArb(arbType, event string, legs []ArbLeg, config ArbConfig) *CrossArbOpportunity {
+	totalCost, totalFees := 0, 0.0
+	maxContracts := -1
+	desc := make([]string, len(legs))
+	for i, leg := range legs {
+		if leg.Price <= 0 {
+			return nil
+		}
+		totalCost += leg.Price
+		totalFees += TakerFeeCents(leg.Price)
+		if maxContracts < 0 || leg.Depth < maxContracts {
+			maxContracts = leg.Depth
+		}
+		desc[i] = fmt.Sprintf("%s %s@%d¢", leg.Ticker, strings.ToUpper(string(leg.Side)), leg.Price)
+	}
+
+	profitCents := 100.0 - float64(totalCost) - totalFees
+	if profitCents < config.MinProfitCents {
+		return nil
+	}
+	profitPct := profitCents / float64(totalCost)
+	if profitPct < config.MinProfitPct {
+		return nil
+	}
+
+	return &CrossArbOpportunity{
+		Event:            event,
+		Legs:             legs,
+		TotalCost:        totalCost,
+		GuaranteedProfit: profitCents,
+		ProfitPct:        profitPct,
+		MaxContracts:     maxContracts,
+		Type:             arbType,
+		Description: fmt.Sprintf(
+			"CROSS ARB: Buy %s = %d¢ cost. Return>=$1. Profit=%.1f¢ (%.2f%%). Max %d contracts.",
+			strings.Join(desc, " + "), totalCost, profitCents, profitPct*100, maxContracts,
+		),
+	}
+}
+
+// MarketTeam returns the team a game market's YES side backs, e.g. "SAC"
+// for KXNBAGAME-26FEB04MEMSAC-SAC or KXNBASPREAD-26FEB04MEMSAC-SAC5, or ""
+// for markets not tied to one team.
+func MarketTeam(ticker string) string {
+	parts := strings.Split(ticker, "-")
+	if len(parts) != 3 {
+		return ""
+	}
+	switch KalshiSeries(parts[0]) {
+	case SeriesMoneyline, SeriesSpread:
+		return strings.TrimRight(parts[2], "0123456789.")
+	}
+	return ""
+}
+
+// CrossArbLegClientOrderID derives the client order ID of leg i of a cross
+// arb from the ID set on the arb's OrderConfig.
+func CrossArbLegClientOrderID(base string, leg int) string {
+	return fmt.Sprintf("%s-%d", base, leg)
+}
+
+// ExecuteCrossArb buys every leg of a cross arb through any OrderPlacer,
+// launching the legs concurrently like ExecuteArb. A ClientOrderID in
+// config is split into one ID per leg with CrossArbLegClientOrderID.
+//
+// Results are in leg order, nil for legs whose order failed. Legs can fill
+// unevenly; MatchedContracts gives the contracts every leg holds, and the
+// caller unwinds the excess.
+func ExecuteCrossArb(p OrderPlacer, arb *CrossArbOpportunity, contracts int, config OrderConfig) ([]*ExecutionResult, error) {
+	if contracts > arb.MaxContracts {
+		contracts = arb.MaxContracts
+	}
+
+	results := make([]*ExecutionResult, len(arb.Legs))
+	errs := make([]error, len(arb.Legs))
+	var wg sync.WaitGroup
+	for i, leg := range arb.Legs {
+		legConfig := config
+		if config.ClientOrderID != "" {
+			legConfig.ClientOrderID = CrossArbLegClientOrderID(config.ClientOrderID, i)
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = p.PlaceOrder(leg.Ticker, leg.Side, ActionBuy, contracts, legConfig)
+			if errs[i] != nil {
+				results[i] = nil
+				errs[i] = fmt.Errorf("leg %d (%s %s): %w", i, leg.Ticker, leg.Side, errs[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results, errors.Join(errs...)
+}
+
+// MatchedContracts returns the contracts filled on every leg: the part of a
+// cross arb that is actually hedged.
+func MatchedContracts(results []*ExecutionResult) int {
+	matched := -1
+	for _, r := range results {
+		filled := 0
+		if r != nil {
+			filled = r.FilledContracts
+		}
+		if matched < 0 || filled < matched {
+			matched = filled
+		}
+	}
+	return max(matched, 0)
+}
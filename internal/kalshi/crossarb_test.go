@@ -0,0 +1,198 @@
+package kalshi
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestFindCrossArbs(t *testing.T) {
+	config := DefaultArbConfig()
+
+	tests := []struct {
+		name       string
+		quotes     []MarketQuote
+		wantType   string // "" for no arb
+		wantLegs   []string
+		wantProfit float64 // approximate cents per contract
+	}{
+		{
+			name: "both teams underpriced",
+			quotes: []MarketQuote{
+				{Ticker: "KXNBAGAME-26FEB04MEMSAC-MEM", YesAsk: 40, YesDepth: 30, NoAsk: 62, NoDepth: 30},
+				{Ticker: "KXNBAGAME-26FEB04MEMSAC-SAC", YesAsk: 50, YesDepth: 20, NoAsk: 52, NoDepth: 20},
+			},
+			wantType:   "complement_arb",
+			wantLegs:   []string{"KXNBAGAME-26FEB04MEMSAC-MEM yes", "KXNBAGAME-26FEB04MEMSAC-SAC yes"},
+			wantProfit: 6.6, // 100 - 40 - 50 - TakerFee(40) - TakerFee(50)
+		},
+		{
+			name: "both NOs underpriced",
+			quotes: []MarketQuote{
+				{Ticker: "KXNBAGAME-26FEB04MEMSAC-MEM", YesAsk: 62, NoAsk: 45},
+				{Ticker: "KXNBAGAME-26FEB04MEMSAC-SAC", YesAsk: 58, NoAsk: 45},
+			},
+			wantType:   "complement_arb",
+			wantLegs:   []string{"KXNBAGAME-26FEB04MEMSAC-MEM no", "KXNBAGAME-26FEB04MEMSAC-SAC no"},
+			wantProfit: 6.5,
+		},
+		{
+			name: "fair moneyline",
+			quotes: []MarketQuote{
+				{Ticker: "KXNBAGAME-26FEB04MEMSAC-MEM", YesAsk: 45, NoAsk: 56},
+				{Ticker: "KXNBAGAME-26FEB04MEMSAC-SAC", YesAsk: 56, NoAsk: 45},
+			},
+		},
+		{
+			name: "one team listed",
+			quotes: []MarketQuote{
+				{Ticker: "KXNBAGAME-26FEB04MEMSAC-MEM", YesAsk: 10, NoAsk: 10},
+			},
+		},
+		{
+			name: "total ladder out of order",
+			quotes: []MarketQuote{
+				{Ticker: "KXNBATOTAL-26FEB04NOPMIL-225", YesAsk: 56, YesDepth: 40, NoAsk: 45, NoDepth: 15},
+				{Ticker: "KXNBATOTAL-26FEB04NOPMIL-220", YesAsk: 40, YesDepth: 25, NoAsk: 61, NoDepth: 25},
+			},
+			wantType:   "ladder_arb",
+			wantLegs:   []string{"KXNBATOTAL-26FEB04NOPMIL-220 yes", "KXNBATOTAL-26FEB04NOPMIL-225 no"},
+			wantProfit: 11.6,
+		},
+		{
+			name: "monotone total ladder",
+			quotes: []MarketQuote{
+				{Ticker: "KXNBATOTAL-26FEB04NOPMIL-220", YesAsk: 60, NoAsk: 41},
+				{Ticker: "KXNBATOTAL-26FEB04NOPMIL-225", YesAsk: 45, NoAsk: 56},
+				{Ticker: "KXNBATOTAL-26FEB04NOPMIL-230", YesAsk: 30, NoAsk: 71},
+			},
+		},
+		{
+			name: "spread ladders are per team",
+			quotes: []MarketQuote{
+				{Ticker: "KXNBASPREAD-26FEB04MEMSAC-SAC5", YesAsk: 40, NoAsk: 61},
+				{Ticker: "KXNBASPREAD-26FEB04MEMSAC-MEM8", YesAsk: 20, NoAsk: 45},
+			},
+		},
+		{
+			name: "prop ladder skipping a strike",
+			quotes: []MarketQuote{
+				{Ticker: "KXNBAPTS-26FEB04BOSHOU-HOUATHOMPSON1-15", YesAsk: 70, NoAsk: 31},
+				{Ticker: "KXNBAPTS-26FEB04BOSHOU-HOUATHOMPSON1-20", YesAsk: 50, NoAsk: 51},
+				{Ticker: "KXNBAPTS-26FEB04BOSHOU-HOUATHOMPSON1-25", YesAsk: 72, NoAsk: 20},
+			},
+			wantType:   "ladder_arb",
+			wantLegs:   []string{"KXNBAPTS-26FEB04BOSHOU-HOUATHOMPSON1-20 yes", "KXNBAPTS-26FEB04BOSHOU-HOUATHOMPSON1-25 no"},
+			wantProfit: 27.1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opps := FindCrossArbs(tt.quotes, config)
+			if tt.wantType == "" {
+				if len(opps) != 0 {
+					t.Errorf("FindCrossArbs = %+v, want none", opps)
+				}
+				return
+			}
+			if len(opps) != 1 {
+				t.Fatalf("FindCrossArbs = %+v, want one", opps)
+			}
+			opp := opps[0]
+			if opp.Type != tt.wantType {
+				t.Errorf("Type = %q, want %q", opp.Type, tt.wantType)
+			}
+			if len(opp.Legs) != len(tt.wantLegs) {
+				t.Fatalf("Legs = %+v, want %v", opp.Legs, tt.wantLegs)
+			}
+			for i, leg := range opp.Legs {
+				if got := leg.Ticker + " " + string(leg.Side); got != tt.wantLegs[i] {
+					t.Errorf("leg %d = %s, want %s", i, got, tt.wantLegs[i])
+				}
+			}
+			if math.Abs(opp.GuaranteedProfit-tt.wantProfit) > 0.1 {
+				t.Errorf("GuaranteedProfit = %.2f, want ~%.1f", opp.GuaranteedProfit, tt.wantProfit)
+			}
+		})
+	}
+}
+
+func TestCrossArbDepth(t *testing.T) {
+	opps := FindCrossArbs([]MarketQuote{
+		{Ticker: "KXNBATOTAL-26FEB04NOPMIL-220", YesAsk: 40, YesDepth: 25},
+		{Ticker: "KXNBATOTAL-26FEB04NOPMIL-225", NoAsk: 45, NoDepth: 15},
+	}, DefaultArbConfig())
+	if len(opps) != 1 || opps[0].MaxContracts != 15 || opps[0].Event != "KXNBATOTAL-26FEB04NOPMIL" {
+		t.Fatalf("FindCrossArbs = %+v, want one arb on the total ladder limited to 15", opps)
+	}
+}
+
+func TestMarketTeam(t *testing.T) {
+	tests := map[string]string{
+		"KXNBAGAME-26FEB04MEMSAC-SAC":             "SAC",
+		"KXNBASPREAD-26FEB04MEMSAC-MEM8":          "MEM",
+		"KXNBATOTAL-26FEB04MEMSAC-220":            "",
+		"KXNBAGAME-26FEB04MEMSAC":                 "",
+		"KXNBAPTS-26FEB04BOSHOU-HOUATHOMPSON1-25": "",
+	}
+	for ticker, want := range tests {
+		if got := MarketTeam(ticker); got != want {
+			t.Errorf("MarketTeam(%q) = %q, want %q", ticker, got, want)
+		}
+	}
+}
+
+// legPlacer fills each ticker up to a fixed count.
+type legPlacer struct {
+	mu     sync.Mutex
+	fills  map[string]int
+	failOn string
+	ids    map[string]string // ticker -> client order ID
+}
+
+func (p *legPlacer) PlaceOrder(ticker string, side Side, action OrderAction, contracts int, config OrderConfig) (*ExecutionResult, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ids[ticker] = config.ClientOrderID
+	if ticker == p.failOn {
+		return nil, errors.New("connection reset")
+	}
+	filled := min(contracts, p.fills[ticker])
+	return &ExecutionResult{
+		Success: filled > 0, OrderID: "ord-" + ticker, RequestedContracts: contracts,
+		FilledContracts: filled, AveragePrice: 45, TotalCost: int64(45 * filled),
+	}, nil
+}
+
+func TestExecuteCrossArb(t *testing.T) {
+	arb := &CrossArbOpportunity{
+		Legs: []ArbLeg{
+			{Ticker: "A", Side: SideYes, Price: 40, Depth: 20},
+			{Ticker: "B", Side: SideNo, Price: 45, Depth: 20},
+		},
+		MaxContracts: 20,
+	}
+
+	p := &legPlacer{fills: map[string]int{"A": 20, "B": 12}, ids: make(map[string]string)}
+	results, err := ExecuteCrossArb(p, arb, 50, OrderConfig{ClientOrderID: "base"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].RequestedContracts != 20 || MatchedContracts(results) != 12 {
+		t.Errorf("results = %+v, want 20 requested and 12 matched", results)
+	}
+	if p.ids["A"] != "base-0" || p.ids["B"] != "base-1" {
+		t.Errorf("client order IDs = %v, want base-0 and base-1", p.ids)
+	}
+
+	p = &legPlacer{fills: map[string]int{"A": 20, "B": 20}, failOn: "B", ids: make(map[string]string)}
+	results, err = ExecuteCrossArb(p, arb, 20, OrderConfig{})
+	if err == nil || results[0] == nil || results[1] != nil {
+		t.Fatalf("results = %+v, err = %v, want leg B failed", results, err)
+	}
+	if MatchedContracts(results) != 0 {
+		t.Errorf("MatchedContracts = %d, want 0 with a failed leg", MatchedContracts(results))
+	}
+}
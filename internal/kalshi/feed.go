@@ -0,0 +1,608 @@
+package kalshi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"sports-betting-bot/internal/metrics"
+	"sports-betting-bot/internal/websocket"
+)
+
+const (
+	// Market-data WebSocket endpoints
+	wsURL     = "wss://api.elections.kalshi.com/trade-api/ws/v2"
+	demoWSURL = "wss://demo-api.kalshi.co/trade-api/ws/v2"
+	wsPath    = "/trade-api/ws/v2" // Signed path for the upgrade request
+
+	feedDialTimeout  = 10 * time.Second
+	feedPingInterval = 10 * time.Second
+	feedReadTimeout  = 30 * time.Second // Kalshi pings every 10s
+	feedMaxBackoff   = 30 * time.Second
+
+	feedUpdateBuffer = 1024
+
+	channelOrderbook = "orderbook_delta"
+	channelTicker    = "ticker"
+)
+
+// TickerUpdate is the latest ticker channel message for a market.
+type TickerUpdate struct {
+	Ticker       string
+	Price        int // Last trade, cents
+	YesBid       int
+	YesAsk       int
+	Volume       int
+	OpenInterest int
+	Time         time.Time
+}
+
+// feedBook is the live book for one ticker, keyed by price.
+type feedBook struct {
+	yes, no map[int]int
+	sid     int  // Subscription the book's messages arrive on
+	ready   bool // False until a snapshot arrives, and again after a gap
+}
+
+// feedSub is one orderbook_delta subscription. Kalshi numbers every
+// message on a subscription consecutively, so a skipped seq means a lost
+// delta and the books on it can no longer be trusted.
+type feedSub struct {
+	tickers []string
+	seq     int
+}
+
+// MarketFeed keeps an in-memory order book per tracked ticker from Kalshi's
+// orderbook_delta channel, plus the latest ticker channel update. Books are
+// in the same shape as GetOrderBook, so CheckLiquidity, CalculateSlippage
+// and GetOptimalSize read them unchanged.
+//
+// A book is only served while the connection is up and the book's
+// subscription has no sequence gap; on a gap the feed drops the
+// subscription and re-subscribes for a fresh snapshot. Untrack removes
+// tickers from the live subscriptions once they're no longer needed.
+type MarketFeed struct {
+	url  string
+	auth func() (http.Header, error)
+
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	tracked map[string]bool
+	books   map[string]*feedBook
+	tickers map[string]TickerUpdate
+	subs    map[int]*feedSub // orderbook_delta sid -> subscription
+	tsubs   map[int][]string // ticker sid -> tickers
+	dropped map[int]bool     // sids unsubscribed after a gap or Untrack
+	pending map[int][]string // subscribe command id -> tickers
+	nextID  int
+
+	updates chan string
+}
+
+// NewMarketFeed creates a feed for the WebSocket at url. auth supplies the
+// upgrade request headers and is called on every (re)connect.
+func NewMarketFeed(url string, auth func() (http.Header, error)) *MarketFeed {
+	return &MarketFeed{
+		url:     url,
+		auth:    auth,
+		tracked: make(map[string]bool),
+		books:   make(map[string]*feedBook),
+		tickers: make(map[string]TickerUpdate),
+		subs:    make(map[int]*feedSub),
+		tsubs:   make(map[int][]string),
+		dropped: make(map[int]bool),
+		pending: make(map[int][]string),
+		updates: make(chan string, feedUpdateBuffer),
+	}
+}
+
+// StartMarketFeed connects a feed with the client's credentials, runs it
+// until ctx is cancelled and makes GetOrderBook read from it.
+func (c *KalshiClient) StartMarketFeed(ctx context.Context) *MarketFeed {
+	url := wsURL
+	if c.demo {
+		url = demoWSURL
+	}
+	feed := NewMarketFeed(url, func() (http.Header, error) {
+		ts := time.Now().UnixMilli()
+		sig, err := c.signPath(http.MethodGet, wsPath, ts)
+		if err != nil {
+			return nil, err
+		}
+		h := make(http.Header)
+		h.Set("KALSHI-ACCESS-KEY", c.apiKeyID)
+		h.Set("KALSHI-ACCESS-SIGNATURE", sig)
+		h.Set("KALSHI-ACCESS-TIMESTAMP", strconv.FormatInt(ts, 10))
+		return h, nil
+	})
+	c.feed = feed
+	go feed.Run(ctx)
+	return feed
+}
+
+// Track subscribes to the given tickers if they aren't already tracked.
+// Tickers added while disconnected are subscribed on the next connect.
+func (f *MarketFeed) Track(tickers ...string) {
+	f.mu.Lock()
+	var added []string
+	for _, t := range tickers {
+		if !f.tracked[t] {
+			f.tracked[t] = true
+			added = append(added, t)
+		}
+	}
+	conn := f.conn
+	var cmds [][]byte
+	if conn != nil && len(added) > 0 {
+		cmds = f.subscribeLocked(added)
+	}
+	f.mu.Unlock()
+
+	f.send(conn, cmds...)
+}
+
+// Untrack stops following tickers: their books and ticker updates are
+// forgotten and they're removed from the live subscriptions, which are
+// dropped entirely once they carry nothing else.
+func (f *MarketFeed) Untrack(tickers ...string) {
+	f.mu.Lock()
+	removed := false
+	for _, t := range tickers {
+		if f.tracked[t] {
+			delete(f.tracked, t)
+			delete(f.books, t)
+			delete(f.tickers, t)
+			removed = true
+		}
+	}
+	conn := f.conn
+	var cmds [][]byte
+	if removed {
+		for sid := range f.subs {
+			cmds = append(cmds, f.pruneSubLocked(sid)...)
+		}
+		for sid := range f.tsubs {
+			cmds = append(cmds, f.pruneSubLocked(sid)...)
+		}
+	}
+	f.mu.Unlock()
+
+	f.send(conn, cmds...)
+}
+
+// Tracked returns the tracked tickers, sorted.
+func (f *MarketFeed) Tracked() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tickers := make([]string, 0, len(f.tracked))
+	for t := range f.tracked {
+		tickers = append(tickers, t)
+	}
+	sort.Strings(tickers)
+	return tickers
+}
+
+// OrderBook returns a copy of the live book for ticker, or false if the
+// feed has no trustworthy book for it right now.
+func (f *MarketFeed) OrderBook(ticker string) (*OrderBookResponse, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	b := f.books[ticker]
+	if f.conn == nil || b == nil || !b.ready {
+		return nil, false
+	}
+	return &OrderBookResponse{
+		Ticker:    ticker,
+		OrderBook: OrderBookInner{Yes: levelsOf(b.yes), No: levelsOf(b.no)},
+	}, true
+}
+
+// Updates receives the ticker of every book that changes. Sends never
+// block the feed: when the reader falls behind, updates are dropped.
+func (f *MarketFeed) Updates() <-chan string {
+	return f.updates
+}
+
+func (f *MarketFeed) notify(ticker string) {
+	select {
+	case f.updates <- ticker:
+	default:
+	}
+}
+
+// Ticker returns the latest ticker channel update for ticker.
+func (f *MarketFeed) Ticker(ticker string) (TickerUpdate, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t, ok := f.tickers[ticker]
+	return t, ok
+}
+
+// levelsOf renders a price->count map as ascending [price, count] levels,
+// the order the REST endpoint uses.
+func levelsOf(m map[int]int) [][2]int {
+	levels := make([][2]int, 0, len(m))
+	for price, count := range m {
+		levels = append(levels, [2]int{price, count})
+	}
+	sort.Slice(levels, func(i, j int) bool { return levels[i][0] < levels[j][0] })
+	return levels
+}
+
+// Run connects and reconnects with exponential backoff until ctx is done.
+func (f *MarketFeed) Run(ctx context.Context) {
+	backoff := time.Second
+	for ctx.Err() == nil {
+		start := time.Now()
+		err := f.runOnce(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("WARN Kalshi feed disconnected: %v", err)
+
+		if time.Since(start) > feedMaxBackoff {
+			backoff = time.Second
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, feedMaxBackoff)
+	}
+}
+
+func (f *MarketFeed) runOnce(ctx context.Context) error {
+	header, err := f.auth()
+	if err != nil {
+		return fmt.Errorf("signing: %w", err)
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, feedDialTimeout)
+	conn, err := websocket.Dial(dialCtx, f.url, header)
+	cancel()
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.conn = conn
+	var tickers []string
+	for t := range f.tracked {
+		tickers = append(tickers, t)
+	}
+	sort.Strings(tickers)
+	var cmds [][]byte
+	if len(tickers) > 0 {
+		cmds = f.subscribeLocked(tickers)
+	}
+	f.mu.Unlock()
+	defer f.disconnect()
+
+	f.send(conn, cmds...)
+
+	// Keepalive, and unblock the read loop on shutdown
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		ping := time.NewTicker(feedPingInterval)
+		defer ping.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-done:
+				return
+			case <-ping.C:
+				conn.Ping()
+			}
+		}
+	}()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(feedReadTimeout))
+		data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		f.handle(conn, data)
+	}
+}
+
+// disconnect closes the connection and forgets every subscription; the
+// books stay unserved until the next connect delivers fresh snapshots.
+func (f *MarketFeed) disconnect() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.conn != nil {
+		f.conn.Close()
+		f.conn = nil
+	}
+	for _, b := range f.books {
+		b.ready = false
+	}
+	f.subs = make(map[int]*feedSub)
+	f.tsubs = make(map[int][]string)
+	f.dropped = make(map[int]bool)
+	f.pending = make(map[int][]string)
+}
+
+// feedCommand is a client -> server message.
+type feedCommand struct {
+	ID     int               `json:"id"`
+	Cmd    string            `json:"cmd"`
+	Params feedCommandParams `json:"params"`
+}
+
+type feedCommandParams struct {
+	Channels      []string `json:"channels,omitempty"`
+	MarketTickers []string `json:"market_tickers,omitempty"`
+	SIDs          []int    `json:"sids,omitempty"`
+	Action        string   `json:"action,omitempty"` // update_subscription only
+}
+
+// subscribeLocked registers and encodes subscribe commands for tickers.
+// The order book and ticker channels are subscribed separately so each
+// command's "subscribed" reply maps to one orderbook sid.
+func (f *MarketFeed) subscribeLocked(tickers []string) [][]byte {
+	f.nextID++
+	f.pending[f.nextID] = tickers
+	book, _ := json.Marshal(feedCommand{ID: f.nextID, Cmd: "subscribe",
+		Params: feedCommandParams{Channels: []string{channelOrderbook}, MarketTickers: tickers}})
+
+	f.nextID++
+	f.pending[f.nextID] = tickers
+	tick, _ := json.Marshal(feedCommand{ID: f.nextID, Cmd: "subscribe",
+		Params: feedCommandParams{Channels: []string{channelTicker}, MarketTickers: tickers}})
+	return [][]byte{book, tick}
+}
+
+func (f *MarketFeed) send(conn *websocket.Conn, cmds ...[]byte) {
+	if conn == nil {
+		return
+	}
+	for _, cmd := range cmds {
+		if err := conn.WriteMessage(cmd); err != nil {
+			log.Printf("WARN Kalshi feed send failed: %v", err)
+			return
+		}
+	}
+}
+
+// feedMessage is a server -> client message.
+type feedMessage struct {
+	ID   int             `json:"id"`
+	Type string          `json:"type"`
+	SID  int             `json:"sid"`
+	Seq  int             `json:"seq"`
+	Msg  json.RawMessage `json:"msg"`
+}
+
+type feedSubscribed struct {
+	Channel string `json:"channel"`
+	SID     int    `json:"sid"`
+}
+
+type feedSnapshot struct {
+	MarketTicker string   `json:"market_ticker"`
+	Yes          [][2]int `json:"yes"`
+	No           [][2]int `json:"no"`
+}
+
+type feedDelta struct {
+	MarketTicker string `json:"market_ticker"`
+	Price        int    `json:"price"`
+	Delta        int    `json:"delta"`
+	Side         Side   `json:"side"`
+}
+
+type feedTicker struct {
+	MarketTicker string `json:"market_ticker"`
+	Price        int    `json:"price"`
+	YesBid       int    `json:"yes_bid"`
+	YesAsk       int    `json:"yes_ask"`
+	Volume       int    `json:"volume"`
+	OpenInterest int    `json:"open_interest"`
+	TS           int64  `json:"ts"`
+}
+
+type feedError struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+func (f *MarketFeed) handle(conn *websocket.Conn, data []byte) {
+	var m feedMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		log.Printf("WARN Kalshi feed: bad message: %v", err)
+		return
+	}
+
+	f.mu.Lock()
+	var cmds [][]byte
+	if f.dropped[m.SID] {
+		m.Type = "" // In flight before the unsubscribe landed
+	}
+	switch m.Type {
+	case "subscribed":
+		var s feedSubscribed
+		if json.Unmarshal(m.Msg, &s) == nil {
+			switch s.Channel {
+			case channelOrderbook:
+				f.sub(s.SID).tickers = f.pending[m.ID]
+			case channelTicker:
+				f.tsubs[s.SID] = f.pending[m.ID]
+			}
+			// Some tickers may have been untracked while it was in flight
+			cmds = f.pruneSubLocked(s.SID)
+		}
+		delete(f.pending, m.ID)
+
+	case "orderbook_snapshot":
+		var s feedSnapshot
+		if json.Unmarshal(m.Msg, &s) != nil {
+			break
+		}
+		if cmds = f.checkSeqLocked(m.SID, m.Seq); cmds != nil || !f.tracked[s.MarketTicker] {
+			break
+		}
+		b := &feedBook{yes: make(map[int]int), no: make(map[int]int), sid: m.SID, ready: true}
+		for _, l := range s.Yes {
+			b.yes[l[0]] = l[1]
+		}
+		for _, l := range s.No {
+			b.no[l[0]] = l[1]
+		}
+		f.books[s.MarketTicker] = b
+		f.notify(s.MarketTicker)
+
+	case "orderbook_delta":
+		var d feedDelta
+		if json.Unmarshal(m.Msg, &d) != nil {
+			break
+		}
+		if cmds = f.checkSeqLocked(m.SID, m.Seq); cmds != nil {
+			break
+		}
+		b := f.books[d.MarketTicker]
+		if b == nil || !b.ready {
+			break
+		}
+		levels := b.yes
+		if d.Side == SideNo {
+			levels = b.no
+		}
+		if n := levels[d.Price] + d.Delta; n > 0 {
+			levels[d.Price] = n
+		} else {
+			delete(levels, d.Price)
+		}
+		f.notify(d.MarketTicker)
+
+	case "ticker":
+		var t feedTicker
+		if json.Unmarshal(m.Msg, &t) != nil || !f.tracked[t.MarketTicker] {
+			break
+		}
+		f.tickers[t.MarketTicker] = TickerUpdate{
+			Ticker:       t.MarketTicker,
+			Price:        t.Price,
+			YesBid:       t.YesBid,
+			YesAsk:       t.YesAsk,
+			Volume:       t.Volume,
+			OpenInterest: t.OpenInterest,
+			Time:         time.Unix(t.TS, 0),
+		}
+
+	case "error":
+		var e feedError
+		json.Unmarshal(m.Msg, &e)
+		log.Printf("WARN Kalshi feed error %d: %s", e.Code, e.Msg)
+	}
+	f.mu.Unlock()
+
+	f.send(conn, cmds...)
+}
+
+// pruneSubLocked removes untracked tickers from subscription sid and
+// returns the command telling Kalshi: an update_subscription deleting
+// them, or an unsubscribe once nothing tracked is left.
+func (f *MarketFeed) pruneSubLocked(sid int) [][]byte {
+	var tickers []string
+	if s := f.subs[sid]; s != nil {
+		tickers = s.tickers
+	} else {
+		tickers = f.tsubs[sid]
+	}
+	var kept, removed []string
+	for _, t := range tickers {
+		if f.tracked[t] {
+			kept = append(kept, t)
+		} else {
+			removed = append(removed, t)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	f.nextID++
+	cmd := feedCommand{ID: f.nextID, Cmd: "update_subscription",
+		Params: feedCommandParams{SIDs: []int{sid}, MarketTickers: removed, Action: "delete_markets"}}
+	switch {
+	case len(kept) == 0:
+		cmd = feedCommand{ID: f.nextID, Cmd: "unsubscribe", Params: feedCommandParams{SIDs: []int{sid}}}
+		delete(f.subs, sid)
+		delete(f.tsubs, sid)
+		f.dropped[sid] = true
+	case f.subs[sid] != nil:
+		f.subs[sid].tickers = kept
+	default:
+		f.tsubs[sid] = kept
+	}
+	data, _ := json.Marshal(cmd)
+	return [][]byte{data}
+}
+
+// sub returns the subscription for sid, creating it if a message beat the
+// "subscribed" reply.
+func (f *MarketFeed) sub(sid int) *feedSub {
+	s := f.subs[sid]
+	if s == nil {
+		s = &feedSub{}
+		f.subs[sid] = s
+	}
+	return s
+}
+
+// checkSeqLocked advances sid's sequence number. On a gap it drops the
+// subscription, marks its books stale and returns the unsubscribe and
+// re-subscribe commands that fetch fresh snapshots.
+func (f *MarketFeed) checkSeqLocked(sid, seq int) [][]byte {
+	s := f.sub(sid)
+	if s.seq == 0 || seq == s.seq+1 {
+		s.seq = seq
+		return nil
+	}
+
+	tickers := s.tickers
+	if len(tickers) == 0 {
+		for t, b := range f.books {
+			if b.sid == sid {
+				tickers = append(tickers, t)
+			}
+		}
+		sort.Strings(tickers)
+	}
+	log.Printf("WARN Kalshi feed sequence gap on sid %d (want %d, got %d); resyncing %d tickers",
+		sid, s.seq+1, seq, len(tickers))
+	metrics.FeedResyncs.Inc()
+
+	delete(f.subs, sid)
+	f.dropped[sid] = true
+	for _, t := range tickers {
+		if b := f.books[t]; b != nil {
+			b.ready = false
+		}
+	}
+
+	f.nextID++
+	unsub, _ := json.Marshal(feedCommand{ID: f.nextID, Cmd: "unsubscribe",
+		Params: feedCommandParams{SIDs: []int{sid}}})
+	cmds := [][]byte{unsub}
+	if len(tickers) > 0 {
+		f.nextID++
+		f.pending[f.nextID] = tickers
+		resub, _ := json.Marshal(feedCommand{ID: f.nextID, Cmd: "subscribe",
+			Params: feedCommandParams{Channels: []string{channelOrderbook}, MarketTickers: tickers}})
+		cmds = append(cmds, resub)
+	}
+	return cmds
+}
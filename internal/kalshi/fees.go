@@ -36,3 +36,10 @@ func TakerFeeCents(priceCents int) float64 {
 	price := float64(priceCents) / 100.0
 	return TakerFee(price) * 100.0
 }
+
+// OrderFeeDollars returns the taker fee for a fill of contracts at an average
+// price in cents, rounded up to the next cent as Kalshi charges per order.
+func OrderFeeDollars(avgPriceCents float64, contracts int) float64 {
+	cents := TakerFee(avgPriceCents/100) * 100 * float64(contracts)
+	return math.Ceil(cents-1e-9) / 100
+}
@@ -0,0 +1,599 @@
+// Package kalshitest provides an in-memory Kalshi exchange for offline tests.
+package kalshitest
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"sports-betting-bot/internal/kalshi"
+)
+
+// restingOrder is a bid on one side of a market. Kalshi books hold only bids:
+// a YES bid at P is also a NO offer at 100-P, and vice versa.
+type restingOrder struct {
+	order *kalshi.Order // nil for liquidity added with AddLiquidity
+	side  kalshi.Side
+	price int
+	count int
+	seq   int
+}
+
+// Exchange is an in-memory exchange with a price-time priority matching
+// engine. It implements the same methods the engine uses on *KalshiClient,
+// and PlaceOrder applies the same PlanOrder safeguards before matching.
+//
+// Liquidity from other traders is seeded with AddLiquidity and can take our
+// resting orders with ExternalTake. Fills are immediate and deterministic.
+// Like Kalshi, the reported balance is what's available: cash held by our
+// resting bids is reserved until they fill or are canceled.
+type Exchange struct {
+	mu sync.Mutex
+
+	balance     int64 // cents
+	reserved    int64 // cents held by our resting bids
+	positions   map[string]int
+	books       map[string][]*restingOrder
+	orders      map[string]*kalshi.Order
+	clientIDs   map[string]string // client_order_id -> order_id
+	propMarkets map[string][]kalshi.PlayerPropMarket
+	closed      map[string]bool
+	results     map[string]string
+	halted      bool
+	seq         int
+
+	// Now stamps orders; defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewExchange creates an exchange with the given account balance in dollars.
+func NewExchange(balanceDollars float64) *Exchange {
+	return &Exchange{
+		balance:     int64(math.Round(balanceDollars * 100)),
+		positions:   make(map[string]int),
+		books:       make(map[string][]*restingOrder),
+		orders:      make(map[string]*kalshi.Order),
+		clientIDs:   make(map[string]string),
+		propMarkets: make(map[string][]kalshi.PlayerPropMarket),
+		closed:      make(map[string]bool),
+		results:     make(map[string]string),
+		Now:         time.Now,
+	}
+}
+
+// AddLiquidity rests a bid from another trader. To offer YES at 40¢, add a
+// NO bid at 60¢.
+func (x *Exchange) AddLiquidity(ticker string, side kalshi.Side, priceCents, count int) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.seq++
+	x.books[ticker] = append(x.books[ticker], &restingOrder{side: side, price: priceCents, count: count, seq: x.seq})
+}
+
+// SetPlayerPropMarkets sets the listing returned by GetPlayerPropMarkets.
+func (x *Exchange) SetPlayerPropMarkets(markets map[string][]kalshi.PlayerPropMarket) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.propMarkets = markets
+}
+
+// SetHalted simulates the exchange being closed for trading.
+func (x *Exchange) SetHalted(halted bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.halted = halted
+}
+
+// CloseMarket stops trading on a ticker and cancels its resting orders.
+func (x *Exchange) CloseMarket(ticker string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.closed[ticker] = true
+	for _, r := range x.books[ticker] {
+		if r.order != nil {
+			x.reserved -= int64(r.price * r.order.RemainingCount)
+			r.order.Status = kalshi.OrderStatusCanceled
+			r.order.RemainingCount = 0
+		}
+	}
+	delete(x.books, ticker)
+}
+
+// Settle closes a market with result "yes" or "no" and pays $1 per winning
+// contract. The fake does not track cost basis, so a "void" result closes
+// the position without a refund.
+func (x *Exchange) Settle(ticker, result string) {
+	x.CloseMarket(ticker)
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.results[ticker] = result
+	pos := x.positions[ticker]
+	delete(x.positions, ticker)
+	if (pos > 0 && result == "yes") || (pos < 0 && result == "no") {
+		x.balance += int64(abs(pos) * 100)
+	}
+}
+
+// ExternalTake has another trader buy against the book, which can fill our
+// resting orders. Returns the contracts filled.
+func (x *Exchange) ExternalTake(ticker string, side kalshi.Side, count, limitCents int) int {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	filled, _ := x.match(ticker, side, kalshi.ActionBuy, count, limitCents, false)
+	return filled
+}
+
+// GetBalanceDollars returns the available balance: cash less what our
+// resting bids hold.
+func (x *Exchange) GetBalanceDollars() (float64, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return float64(x.balance-x.reserved) / 100, nil
+}
+
+// GetPositions returns non-zero positions sorted by ticker.
+func (x *Exchange) GetPositions() ([]kalshi.MarketPosition, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var out []kalshi.MarketPosition
+	for ticker, pos := range x.positions {
+		if pos != 0 {
+			out = append(out, kalshi.MarketPosition{Ticker: ticker, Position: pos})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Ticker < out[j].Ticker })
+	return out, nil
+}
+
+// GetPlayerPropMarkets returns the listing set with SetPlayerPropMarkets.
+func (x *Exchange) GetPlayerPropMarkets(date time.Time) (map[string][]kalshi.PlayerPropMarket, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.propMarkets, nil
+}
+
+// GetMarket returns a market's status, and its result once settled.
+func (x *Exchange) GetMarket(ticker string) (*kalshi.Market, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if result, ok := x.results[ticker]; ok {
+		return &kalshi.Market{Ticker: ticker, Status: "finalized", Result: result}, nil
+	}
+	status := "active"
+	if x.closed[ticker] {
+		status = "closed"
+	}
+	return &kalshi.Market{Ticker: ticker, Status: status}, nil
+}
+
+// GetOrderBook aggregates resting bids into Kalshi's [[price, count], ...] form.
+func (x *Exchange) GetOrderBook(ticker string) (*kalshi.OrderBookResponse, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if x.closed[ticker] {
+		return nil, fmt.Errorf("market %s is closed", ticker)
+	}
+	return x.snapshot(ticker), nil
+}
+
+// GetOrder returns a copy of an order by ID.
+func (x *Exchange) GetOrder(orderID string) (*kalshi.Order, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	o, ok := x.orders[orderID]
+	if !ok {
+		return nil, fmt.Errorf("order %s not found", orderID)
+	}
+	cp := *o
+	return &cp, nil
+}
+
+// GetOrders returns copies of every order on ticker, oldest first.
+func (x *Exchange) GetOrders(ticker string) ([]kalshi.Order, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var out []kalshi.Order
+	for _, o := range x.orders {
+		if o.Ticker == ticker {
+			out = append(out, *o)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return orderSeq(out[i].OrderID) < orderSeq(out[j].OrderID) })
+	return out, nil
+}
+
+// orderSeq recovers the sequence number from an order ID.
+func orderSeq(orderID string) int {
+	var n int
+	fmt.Sscanf(orderID, "fake-%d", &n)
+	return n
+}
+
+// CancelOrder cancels the unfilled remainder of a resting order.
+func (x *Exchange) CancelOrder(orderID string) error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	o, ok := x.orders[orderID]
+	if !ok {
+		return fmt.Errorf("order %s not found", orderID)
+	}
+	if o.Status != kalshi.OrderStatusResting {
+		return fmt.Errorf("order %s is %s", orderID, o.Status)
+	}
+	o.Status = kalshi.OrderStatusCanceled
+	o.RemainingCount = 0
+
+	book := x.books[o.Ticker]
+	for i, r := range book {
+		if r.order == o {
+			x.reserved -= int64(r.price * r.count)
+			x.books[o.Ticker] = append(book[:i], book[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// PlaceOrder mirrors (*KalshiClient).PlaceOrder: exchange and market checks,
+// then PlanOrder's liquidity, slippage and EV safeguards, then an IOC limit
+// order at the planned price.
+func (x *Exchange) PlaceOrder(ticker string, side kalshi.Side, action kalshi.OrderAction, contracts int, config kalshi.OrderConfig) (*kalshi.ExecutionResult, error) {
+	x.mu.Lock()
+	halted, closed := x.halted, x.closed[ticker]
+	x.mu.Unlock()
+
+	if halted {
+		return &kalshi.ExecutionResult{
+			Success:            false,
+			RequestedContracts: contracts,
+			RejectionReason:    "exchange is not active for trading",
+		}, nil
+	}
+	if closed {
+		return &kalshi.ExecutionResult{
+			Success:            false,
+			RequestedContracts: contracts,
+			RejectionReason:    "market status is closed, not open",
+		}, nil
+	}
+
+	book, err := x.GetOrderBook(ticker)
+	if err != nil {
+		return &kalshi.ExecutionResult{
+			Success:            false,
+			RequestedContracts: contracts,
+			RejectionReason:    fmt.Sprintf("failed to fetch order book: %v", err),
+		}, nil
+	}
+
+	plan, reason := kalshi.PlanOrder(book, side, action, contracts, config)
+	if reason != "" {
+		return &kalshi.ExecutionResult{
+			Success:            false,
+			RequestedContracts: plan.Contracts,
+			RejectionReason:    reason,
+		}, nil
+	}
+	contracts = plan.Contracts
+
+	if config.DryRun {
+		return &kalshi.ExecutionResult{
+			Success:            true,
+			RequestedContracts: contracts,
+			FilledContracts:    contracts,
+			AveragePrice:       plan.Slippage.AverageFillPrice,
+			TotalCost:          int64(float64(contracts) * plan.Slippage.AverageFillPrice),
+			RejectionReason:    "DRY_RUN: order not placed",
+		}, nil
+	}
+
+	req := kalshi.CreateOrderRequest{
+		Ticker:        ticker,
+		ClientOrderID: config.ClientOrderID,
+		Side:          side,
+		Action:        action,
+		Count:         contracts,
+		Type:          kalshi.OrderTypeLimit,
+		TimeInForce:   kalshi.TimeInForceIOC,
+	}
+	if side == kalshi.SideYes {
+		req.YesPrice = plan.LimitPrice
+	} else {
+		req.NoPrice = plan.LimitPrice
+	}
+
+	order, err := x.SubmitOrder(req)
+	if err != nil {
+		return &kalshi.ExecutionResult{
+			Success:            false,
+			RequestedContracts: contracts,
+			RejectionReason:    fmt.Sprintf("order submission failed: %v", err),
+		}, nil
+	}
+
+	avgPrice := order.AvgFillPrice()
+	rejectionReason := ""
+	if order.FillCount == 0 {
+		rejectionReason = fmt.Sprintf("order %s, no fills", order.Status)
+	} else if order.FillCount < contracts {
+		rejectionReason = fmt.Sprintf("partial fill: %d of %d", order.FillCount, contracts)
+	}
+
+	return &kalshi.ExecutionResult{
+		Success:            order.FillCount > 0,
+		OrderID:            order.OrderID,
+		RequestedContracts: contracts,
+		FilledContracts:    order.FillCount,
+		AveragePrice:       avgPrice,
+		TotalCost:          int64(float64(order.FillCount) * avgPrice),
+		RejectionReason:    rejectionReason,
+	}, nil
+}
+
+// SubmitOrder accepts a raw limit order. IOC orders cancel any unfilled
+// remainder; GTC buys rest it on the book. Sells never rest. PostOnly orders that would
+// cross are rejected. A repeated ClientOrderID returns the original order.
+func (x *Exchange) SubmitOrder(req kalshi.CreateOrderRequest) (*kalshi.Order, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if req.ClientOrderID != "" {
+		if id, ok := x.clientIDs[req.ClientOrderID]; ok {
+			cp := *x.orders[id]
+			return &cp, nil
+		}
+	}
+	if x.halted {
+		return nil, fmt.Errorf("exchange is not active for trading")
+	}
+	if x.closed[req.Ticker] {
+		return nil, fmt.Errorf("market %s is closed", req.Ticker)
+	}
+	if req.Count <= 0 {
+		return nil, fmt.Errorf("count must be positive")
+	}
+
+	limit := req.YesPrice
+	if req.Side == kalshi.SideNo {
+		limit = req.NoPrice
+	}
+	if limit < 1 || limit > 99 {
+		return nil, fmt.Errorf("limit price %d out of range", limit)
+	}
+
+	if req.Action == kalshi.ActionSell {
+		held := x.positions[req.Ticker]
+		if req.Side == kalshi.SideNo {
+			held = -held
+		}
+		if held < req.Count {
+			return nil, fmt.Errorf("cannot sell %d %s contracts, holding %d", req.Count, req.Side, max(held, 0))
+		}
+	} else {
+		maxCost := int64(limit*req.Count) + feeCents(float64(limit), req.Count)
+		if available := x.balance - x.reserved; maxCost > available {
+			return nil, fmt.Errorf("insufficient balance: need %d¢, have %d¢", maxCost, available)
+		}
+	}
+
+	if req.PostOnly && x.crossable(req.Ticker, req.Side, req.Action, limit) > 0 {
+		return nil, fmt.Errorf("post-only order would cross the book")
+	}
+	if req.TimeInForce == kalshi.TimeInForceFOK && x.crossable(req.Ticker, req.Side, req.Action, limit) < req.Count {
+		return nil, fmt.Errorf("fill-or-kill order cannot be fully filled")
+	}
+
+	x.seq++
+	order := &kalshi.Order{
+		OrderID:       fmt.Sprintf("fake-%d", x.seq),
+		ClientOrderID: req.ClientOrderID,
+		Ticker:        req.Ticker,
+		Side:          req.Side,
+		Action:        req.Action,
+		Type:          kalshi.OrderTypeLimit,
+		YesPrice:      req.YesPrice,
+		NoPrice:       req.NoPrice,
+		InitialCount:  req.Count,
+		CreatedTime:   x.Now().UTC().Format(time.RFC3339),
+	}
+	x.orders[order.OrderID] = order
+	if req.ClientOrderID != "" {
+		x.clientIDs[req.ClientOrderID] = order.OrderID
+	}
+
+	filled, cost := x.match(req.Ticker, req.Side, req.Action, req.Count, limit, true)
+	order.FillCount = filled
+	order.TakerFillCost = cost
+	if filled > 0 {
+		order.TakerFees = int(feeCents(float64(cost)/float64(filled), filled))
+		x.balance -= int64(order.TakerFees)
+	}
+	order.RemainingCount = req.Count - filled
+
+	switch {
+	case order.RemainingCount == 0:
+		order.Status = kalshi.OrderStatusExecuted
+	case req.TimeInForce == kalshi.TimeInForceIOC || req.TimeInForce == kalshi.TimeInForceFOK || req.Action == kalshi.ActionSell:
+		order.Status = kalshi.OrderStatusCanceled
+		order.RemainingCount = 0
+	default:
+		order.Status = kalshi.OrderStatusResting
+		x.reserved += int64(limit * order.RemainingCount)
+		x.seq++
+		x.books[req.Ticker] = append(x.books[req.Ticker], &restingOrder{
+			order: order, side: req.Side, price: limit, count: order.RemainingCount, seq: x.seq,
+		})
+	}
+
+	x.touch(order)
+	cp := *order
+	return &cp, nil
+}
+
+// touch stamps an order's last update time.
+func (x *Exchange) touch(o *kalshi.Order) {
+	o.LastUpdateTime = x.Now().UTC().Format(time.RFC3339)
+}
+
+// match crosses an incoming order against resting bids and applies fills to
+// both sides. own marks the incoming order as ours; resting orders that are
+// ours are skipped for our own orders (no self-trade). Returns contracts
+// filled and the incoming side's cost (buys) or proceeds (sells) in cents.
+func (x *Exchange) match(ticker string, side kalshi.Side, action kalshi.OrderAction, count, limit int, own bool) (int, int64) {
+	book := x.books[ticker]
+
+	// Buying YES at L crosses NO bids priced >= 100-L; selling YES at L
+	// crosses YES bids priced >= L. Same for NO with the sides swapped.
+	restSide, minRest := opposite(side), 100-limit
+	if action == kalshi.ActionSell {
+		restSide, minRest = side, limit
+	}
+
+	var candidates []*restingOrder
+	for _, r := range book {
+		if r.side == restSide && r.price >= minRest && r.count > 0 && !(own && r.order != nil) {
+			candidates = append(candidates, r)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].price != candidates[j].price {
+			return candidates[i].price > candidates[j].price
+		}
+		return candidates[i].seq < candidates[j].seq
+	})
+
+	filled := 0
+	var cost int64
+	for _, r := range candidates {
+		if filled == count {
+			break
+		}
+		n := min(count-filled, r.count)
+		r.count -= n
+		filled += n
+
+		price := r.price // sells fill at the bid
+		if action == kalshi.ActionBuy {
+			price = 100 - r.price
+		}
+		cost += int64(price * n)
+
+		if own {
+			x.applyFill(ticker, side, action, n, price)
+		}
+		if r.order != nil {
+			// Our resting bid was taken: we bought r.side at r.price as maker
+			x.reserved -= int64(r.price * n)
+			x.applyFill(ticker, r.side, kalshi.ActionBuy, n, r.price)
+			r.order.FillCount += n
+			r.order.RemainingCount -= n
+			r.order.MakerFillCost += int64(r.price * n)
+			if r.order.RemainingCount == 0 {
+				r.order.Status = kalshi.OrderStatusExecuted
+			}
+			x.touch(r.order)
+		}
+	}
+
+	// Drop exhausted levels
+	kept := book[:0]
+	for _, r := range book {
+		if r.count > 0 {
+			kept = append(kept, r)
+		}
+	}
+	x.books[ticker] = kept
+
+	return filled, cost
+}
+
+// applyFill updates our balance and position for a fill. Buying the side
+// opposite an open position nets out, and each netted pair pays $1.
+func (x *Exchange) applyFill(ticker string, side kalshi.Side, action kalshi.OrderAction, count, price int) {
+	signed := count
+	if side == kalshi.SideNo {
+		signed = -count
+	}
+
+	if action == kalshi.ActionSell {
+		x.balance += int64(price * count)
+		x.positions[ticker] -= signed
+		return
+	}
+
+	x.balance -= int64(price * count)
+	prev := x.positions[ticker]
+	if prev != 0 && (prev > 0) != (signed > 0) {
+		netted := min(abs(prev), count)
+		x.balance += int64(netted * 100)
+	}
+	x.positions[ticker] = prev + signed
+}
+
+// crossable returns how many contracts from other traders a limit order
+// could take on arrival.
+func (x *Exchange) crossable(ticker string, side kalshi.Side, action kalshi.OrderAction, limit int) int {
+	restSide, minRest := opposite(side), 100-limit
+	if action == kalshi.ActionSell {
+		restSide, minRest = side, limit
+	}
+	n := 0
+	for _, r := range x.books[ticker] {
+		if r.side == restSide && r.price >= minRest && r.order == nil {
+			n += r.count
+		}
+	}
+	return n
+}
+
+func (x *Exchange) snapshot(ticker string) *kalshi.OrderBookResponse {
+	yes := make(map[int]int)
+	no := make(map[int]int)
+	for _, r := range x.books[ticker] {
+		if r.side == kalshi.SideYes {
+			yes[r.price] += r.count
+		} else {
+			no[r.price] += r.count
+		}
+	}
+	return &kalshi.OrderBookResponse{
+		Ticker: ticker,
+		OrderBook: kalshi.OrderBookInner{
+			Yes: levels(yes),
+			No:  levels(no),
+		},
+	}
+}
+
+// levels returns aggregated [price, count] pairs in ascending price order,
+// matching the Kalshi REST response.
+func levels(byPrice map[int]int) [][2]int {
+	out := make([][2]int, 0, len(byPrice))
+	for price, count := range byPrice {
+		out = append(out, [2]int{price, count})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i][0] < out[j][0] })
+	return out
+}
+
+// feeCents is Kalshi's per-order taker fee in cents.
+func feeCents(priceCents float64, count int) int64 {
+	return int64(math.Round(kalshi.OrderFeeDollars(priceCents, count) * 100))
+}
+
+func opposite(side kalshi.Side) kalshi.Side {
+	if side == kalshi.SideYes {
+		return kalshi.SideNo
+	}
+	return kalshi.SideYes
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
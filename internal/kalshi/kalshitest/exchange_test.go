@@ -0,0 +1,214 @@
+package kalshitest
+
+import (
+	"testing"
+
+	"sports-betting-bot/internal/kalshi"
+)
+
+const ticker = "KXNBAGAME-26FEB05GSWPHX-PHX"
+
+func TestOrderBookAggregatesLevels(t *testing.T) {
+	x := NewExchange(100)
+	x.AddLiquidity(ticker, kalshi.SideYes, 40, 10)
+	x.AddLiquidity(ticker, kalshi.SideYes, 40, 5)
+	x.AddLiquidity(ticker, kalshi.SideYes, 38, 7)
+	x.AddLiquidity(ticker, kalshi.SideNo, 55, 20)
+
+	book, err := x.GetOrderBook(ticker)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]int{{38, 7}, {40, 15}}
+	if len(book.OrderBook.Yes) != 2 || book.OrderBook.Yes[0] != want[0] || book.OrderBook.Yes[1] != want[1] {
+		t.Errorf("Yes = %v, want %v", book.OrderBook.Yes, want)
+	}
+	if len(book.OrderBook.No) != 1 || book.OrderBook.No[0] != [2]int{55, 20} {
+		t.Errorf("No = %v, want [[55 20]]", book.OrderBook.No)
+	}
+}
+
+func TestSubmitOrderWalksBookInPriceTimeOrder(t *testing.T) {
+	x := NewExchange(100)
+	x.AddLiquidity(ticker, kalshi.SideNo, 58, 10) // YES at 42¢
+	x.AddLiquidity(ticker, kalshi.SideNo, 60, 5)  // YES at 40¢, best
+	x.AddLiquidity(ticker, kalshi.SideNo, 58, 10) // YES at 42¢, queued behind the first
+
+	order, err := x.SubmitOrder(kalshi.CreateOrderRequest{
+		Ticker: ticker, Side: kalshi.SideYes, Action: kalshi.ActionBuy,
+		Count: 12, YesPrice: 42, TimeInForce: kalshi.TimeInForceIOC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.FillCount != 12 || order.Status != kalshi.OrderStatusExecuted {
+		t.Fatalf("order = %+v, want 12 filled and executed", order)
+	}
+	if order.TakerFillCost != 5*40+7*42 {
+		t.Errorf("TakerFillCost = %d, want %d", order.TakerFillCost, 5*40+7*42)
+	}
+
+	book, _ := x.GetOrderBook(ticker)
+	if len(book.OrderBook.No) != 1 || book.OrderBook.No[0] != [2]int{58, 13} {
+		t.Errorf("No = %v, want [[58 13]] left", book.OrderBook.No)
+	}
+
+	fee := feeCents(float64(order.TakerFillCost)/12, 12)
+	balance, _ := x.GetBalanceDollars()
+	want := float64(10000-order.TakerFillCost-fee) / 100
+	if balance != want {
+		t.Errorf("balance = %.2f, want %.2f", balance, want)
+	}
+}
+
+func TestSubmitOrderRestsAndFillsAsMaker(t *testing.T) {
+	x := NewExchange(100)
+
+	order, err := x.SubmitOrder(kalshi.CreateOrderRequest{
+		Ticker: ticker, Side: kalshi.SideYes, Action: kalshi.ActionBuy,
+		Count: 10, YesPrice: 45, PostOnly: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != kalshi.OrderStatusResting || order.RemainingCount != 10 {
+		t.Fatalf("order = %+v, want 10 resting", order)
+	}
+
+	// Another trader buys 4 NO at 55¢, taking our YES bid at 45¢
+	if n := x.ExternalTake(ticker, kalshi.SideNo, 4, 55); n != 4 {
+		t.Fatalf("ExternalTake filled %d, want 4", n)
+	}
+
+	got, _ := x.GetOrder(order.OrderID)
+	if got.FillCount != 4 || got.RemainingCount != 6 || got.MakerFillCost != 180 {
+		t.Errorf("order = %+v, want 4 filled at 45¢ with 6 resting", got)
+	}
+	held, _ := x.GetPositions()
+	if len(held) != 1 || held[0].Position != 4 {
+		t.Errorf("positions = %+v, want 4 YES", held)
+	}
+
+	if err := x.CancelOrder(order.OrderID); err != nil {
+		t.Fatal(err)
+	}
+	book, _ := x.GetOrderBook(ticker)
+	if len(book.OrderBook.Yes) != 0 {
+		t.Errorf("Yes = %v, want empty after cancel", book.OrderBook.Yes)
+	}
+	if balance, _ := x.GetBalanceDollars(); balance != 98.20 {
+		t.Errorf("balance = %.2f, want 98.20 (maker fills are fee-free)", balance)
+	}
+}
+
+func TestSubmitOrderRejections(t *testing.T) {
+	tests := []struct {
+		name string
+		req  kalshi.CreateOrderRequest
+	}{
+		{"post-only crosses", kalshi.CreateOrderRequest{Side: kalshi.SideYes, Action: kalshi.ActionBuy, Count: 1, YesPrice: 50, PostOnly: true}},
+		{"fill-or-kill too deep", kalshi.CreateOrderRequest{Side: kalshi.SideYes, Action: kalshi.ActionBuy, Count: 20, YesPrice: 50, TimeInForce: kalshi.TimeInForceFOK}},
+		{"sell without position", kalshi.CreateOrderRequest{Side: kalshi.SideYes, Action: kalshi.ActionSell, Count: 1, YesPrice: 30}},
+		{"insufficient balance", kalshi.CreateOrderRequest{Side: kalshi.SideYes, Action: kalshi.ActionBuy, Count: 1000, YesPrice: 50}},
+		{"price out of range", kalshi.CreateOrderRequest{Side: kalshi.SideNo, Action: kalshi.ActionBuy, Count: 1, NoPrice: 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x := NewExchange(100)
+			x.AddLiquidity(ticker, kalshi.SideNo, 50, 10)
+			tt.req.Ticker = ticker
+			if _, err := x.SubmitOrder(tt.req); err == nil {
+				t.Error("SubmitOrder succeeded, want error")
+			}
+		})
+	}
+}
+
+func TestSubmitOrderDedupesClientOrderID(t *testing.T) {
+	x := NewExchange(100)
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 100)
+	req := kalshi.CreateOrderRequest{
+		Ticker: ticker, ClientOrderID: "abc", Side: kalshi.SideYes, Action: kalshi.ActionBuy,
+		Count: 10, YesPrice: 50, TimeInForce: kalshi.TimeInForceIOC,
+	}
+
+	first, err := x.SubmitOrder(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := x.SubmitOrder(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.OrderID != first.OrderID {
+		t.Errorf("resubmit OrderID = %s, want %s", second.OrderID, first.OrderID)
+	}
+	if held, _ := x.GetPositions(); held[0].Position != 10 {
+		t.Errorf("position = %d, want 10 (no double fill)", held[0].Position)
+	}
+}
+
+func TestPlaceOrderReusesClientOrderID(t *testing.T) {
+	x := NewExchange(100)
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 100)
+	config := kalshi.OrderConfig{MaxSlippagePct: 0.05, MinLiquidityContracts: 1, ClientOrderID: "retry"}
+
+	for range 2 {
+		if result, err := x.PlaceOrder(ticker, kalshi.SideYes, kalshi.ActionBuy, 10, config); err != nil || !result.Success {
+			t.Fatalf("PlaceOrder = %+v, %v", result, err)
+		}
+	}
+	orders, _ := x.GetOrders(ticker)
+	if len(orders) != 1 || orders[0].ClientOrderID != "retry" {
+		t.Errorf("orders = %+v, want one with client order ID retry", orders)
+	}
+	if held, _ := x.GetPositions(); held[0].Position != 10 {
+		t.Errorf("position = %d, want 10 (no double fill)", held[0].Position)
+	}
+}
+
+func TestOppositeSideNetsOut(t *testing.T) {
+	x := NewExchange(100)
+	x.AddLiquidity(ticker, kalshi.SideNo, 60, 10)  // YES at 40¢
+	x.AddLiquidity(ticker, kalshi.SideYes, 45, 10) // NO at 55¢
+
+	x.SubmitOrder(kalshi.CreateOrderRequest{Ticker: ticker, Side: kalshi.SideYes, Action: kalshi.ActionBuy, Count: 10, YesPrice: 40, TimeInForce: kalshi.TimeInForceIOC})
+	x.SubmitOrder(kalshi.CreateOrderRequest{Ticker: ticker, Side: kalshi.SideNo, Action: kalshi.ActionBuy, Count: 10, NoPrice: 55, TimeInForce: kalshi.TimeInForceIOC})
+
+	if held, _ := x.GetPositions(); len(held) != 0 {
+		t.Errorf("positions = %+v, want flat after buying both sides", held)
+	}
+	// Paid 400 + 550 + fees, received 1000 from the netted pairs
+	fees := feeCents(40, 10) + feeCents(55, 10)
+	want := float64(10000-950+1000-fees) / 100
+	if balance, _ := x.GetBalanceDollars(); balance != want {
+		t.Errorf("balance = %.2f, want %.2f", balance, want)
+	}
+}
+
+func TestPlaceOrderAppliesPlanOrderGuards(t *testing.T) {
+	x := NewExchange(100)
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 5)
+
+	res, err := x.PlaceOrder(ticker, kalshi.SideYes, kalshi.ActionBuy, 10, kalshi.OrderConfig{
+		MaxSlippagePct: 0.02, MinLiquidityContracts: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Success || res.RejectionReason == "" {
+		t.Errorf("result = %+v, want liquidity rejection", res)
+	}
+
+	x.AddLiquidity(ticker, kalshi.SideNo, 50, 20)
+	res, err = x.PlaceOrder(ticker, kalshi.SideYes, kalshi.ActionBuy, 10, kalshi.OrderConfig{
+		MaxSlippagePct: 0.02, MinLiquidityContracts: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Success || res.FilledContracts != 10 || res.AveragePrice != 50 {
+		t.Errorf("result = %+v, want 10 filled at 50¢", res)
+	}
+}
@@ -0,0 +1,280 @@
+package kalshitest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/websocket"
+)
+
+// FeedServer is a local stand-in for Kalshi's market-data WebSocket. It
+// answers subscribe, unsubscribe and update_subscription commands, sends an orderbook_snapshot
+// per ticker on subscribe and an orderbook_delta for every SetLevel.
+type FeedServer struct {
+	srv *httptest.Server
+
+	mu         sync.Mutex
+	books      map[string]map[kalshi.Side]map[int]int
+	conns      map[*feedConn]bool
+	nextSID    int
+	subscribes int // orderbook_delta subscribe commands received
+}
+
+// feedConn is one client connection and its subscriptions.
+type feedConn struct {
+	ws   *websocket.Conn
+	subs map[int]*feedSub
+}
+
+type feedSub struct {
+	channel string
+	tickers []string
+	seq     int
+}
+
+// NewFeedServer starts a stand-in feed on a local port.
+func NewFeedServer() *FeedServer {
+	s := &FeedServer{
+		books: make(map[string]map[kalshi.Side]map[int]int),
+		conns: make(map[*feedConn]bool),
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// URL is the ws:// address to pass to kalshi.NewMarketFeed.
+func (s *FeedServer) URL() string {
+	return "ws" + strings.TrimPrefix(s.srv.URL, "http")
+}
+
+// Close disconnects every client and stops the server.
+func (s *FeedServer) Close() {
+	s.Disconnect()
+	s.srv.Close()
+}
+
+// Disconnect drops every client connection, as a network failure would.
+func (s *FeedServer) Disconnect() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.conns {
+		c.ws.Close()
+		delete(s.conns, c)
+	}
+}
+
+// Subscribes returns how many orderbook_delta subscribe commands arrived,
+// including resyncs.
+func (s *FeedServer) Subscribes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.subscribes
+}
+
+// Subscriptions returns how many live subscriptions, on any channel,
+// carry ticker.
+func (s *FeedServer) Subscriptions(ticker string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for c := range s.conns {
+		for _, sub := range c.subs {
+			if slices.Contains(sub.tickers, ticker) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// SetLevel sets the bid size at price on one side of ticker's book (0
+// removes the level) and sends the change to subscribers as a delta.
+func (s *FeedServer) SetLevel(ticker string, side kalshi.Side, price, count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	levels := s.book(ticker)[side]
+	delta := count - levels[price]
+	if count > 0 {
+		levels[price] = count
+	} else {
+		delete(levels, price)
+	}
+	if delta == 0 {
+		return
+	}
+	s.broadcast(ticker, "orderbook_delta", map[string]any{
+		"market_ticker": ticker,
+		"price":         price,
+		"delta":         delta,
+		"side":          side,
+	})
+}
+
+// SkipSeq advances the sequence number of every subscription carrying
+// ticker without sending anything, so the next message shows a gap.
+func (s *FeedServer) SkipSeq(ticker string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.conns {
+		for _, sub := range c.subs {
+			if sub.channel == "orderbook_delta" && slices.Contains(sub.tickers, ticker) {
+				sub.seq++
+			}
+		}
+	}
+}
+
+// SendTicker publishes a ticker channel update.
+func (s *FeedServer) SendTicker(ticker string, price, yesBid, yesAsk int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for c := range s.conns {
+		for sid, sub := range c.subs {
+			if sub.channel == "ticker" && slices.Contains(sub.tickers, ticker) {
+				s.write(c, map[string]any{"type": "ticker", "sid": sid, "msg": map[string]any{
+					"market_ticker": ticker, "price": price, "yes_bid": yesBid, "yes_ask": yesAsk,
+				}})
+			}
+		}
+	}
+}
+
+// book returns ticker's levels, creating them. The caller must hold s.mu.
+func (s *FeedServer) book(ticker string) map[kalshi.Side]map[int]int {
+	b := s.books[ticker]
+	if b == nil {
+		b = map[kalshi.Side]map[int]int{kalshi.SideYes: {}, kalshi.SideNo: {}}
+		s.books[ticker] = b
+	}
+	return b
+}
+
+// broadcast sends an order book message to every subscription carrying
+// ticker, stamping each with its own next seq. The caller must hold s.mu.
+func (s *FeedServer) broadcast(ticker, typ string, msg any) {
+	for c := range s.conns {
+		for sid, sub := range c.subs {
+			if sub.channel == "orderbook_delta" && slices.Contains(sub.tickers, ticker) {
+				sub.seq++
+				s.write(c, map[string]any{"type": typ, "sid": sid, "seq": sub.seq, "msg": msg})
+			}
+		}
+	}
+}
+
+// write sends one JSON message; a failed write drops the connection.
+func (s *FeedServer) write(c *feedConn, v any) {
+	data, _ := json.Marshal(v)
+	if err := c.ws.WriteMessage(data); err != nil {
+		c.ws.Close()
+		delete(s.conns, c)
+	}
+}
+
+type feedCommand struct {
+	ID     int    `json:"id"`
+	Cmd    string `json:"cmd"`
+	Params struct {
+		Channels      []string `json:"channels"`
+		MarketTickers []string `json:"market_tickers"`
+		SIDs          []int    `json:"sids"`
+		Action        string   `json:"action"`
+	} `json:"params"`
+}
+
+func (s *FeedServer) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("KALSHI-ACCESS-KEY") == "" {
+		http.Error(w, "missing API key", http.StatusUnauthorized)
+		return
+	}
+	ws, err := websocket.Upgrade(w, r)
+	if err != nil {
+		return
+	}
+	c := &feedConn{ws: ws, subs: make(map[int]*feedSub)}
+	s.mu.Lock()
+	s.conns[c] = true
+	s.mu.Unlock()
+
+	for {
+		data, err := ws.ReadMessage()
+		if err != nil {
+			s.mu.Lock()
+			delete(s.conns, c)
+			s.mu.Unlock()
+			ws.Close()
+			return
+		}
+		var cmd feedCommand
+		if json.Unmarshal(data, &cmd) != nil {
+			continue
+		}
+		s.handle(c, cmd)
+	}
+}
+
+func (s *FeedServer) handle(c *feedConn, cmd feedCommand) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch cmd.Cmd {
+	case "subscribe":
+		for _, channel := range cmd.Params.Channels {
+			s.nextSID++
+			sid := s.nextSID
+			sub := &feedSub{channel: channel, tickers: cmd.Params.MarketTickers}
+			c.subs[sid] = sub
+			s.write(c, map[string]any{"id": cmd.ID, "type": "subscribed",
+				"msg": map[string]any{"channel": channel, "sid": sid}})
+
+			if channel != "orderbook_delta" {
+				continue
+			}
+			s.subscribes++
+			for _, ticker := range sub.tickers {
+				b := s.book(ticker)
+				sub.seq++
+				s.write(c, map[string]any{"type": "orderbook_snapshot", "sid": sid, "seq": sub.seq,
+					"msg": map[string]any{
+						"market_ticker": ticker,
+						"yes":           bookLevels(b[kalshi.SideYes]),
+						"no":            bookLevels(b[kalshi.SideNo]),
+					}})
+			}
+		}
+
+	case "unsubscribe":
+		for _, sid := range cmd.Params.SIDs {
+			delete(c.subs, sid)
+			s.write(c, map[string]any{"id": cmd.ID, "type": "unsubscribed", "sid": sid})
+		}
+
+	case "update_subscription":
+		if cmd.Params.Action != "delete_markets" {
+			break
+		}
+		for _, sid := range cmd.Params.SIDs {
+			if sub := c.subs[sid]; sub != nil {
+				sub.tickers = slices.DeleteFunc(slices.Clone(sub.tickers), func(t string) bool {
+					return slices.Contains(cmd.Params.MarketTickers, t)
+				})
+				s.write(c, map[string]any{"id": cmd.ID, "type": "ok", "sid": sid})
+			}
+		}
+	}
+}
+
+func bookLevels(m map[int]int) [][2]int {
+	out := [][2]int{}
+	for price, count := range m {
+		out = append(out, [2]int{price, count})
+	}
+	slices.SortFunc(out, func(a, b [2]int) int { return a[0] - b[0] })
+	return out
+}
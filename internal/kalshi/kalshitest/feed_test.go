@@ -0,0 +1,160 @@
+package kalshitest
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/kalshi"
+)
+
+const feedTicker = "KXNBAGAME-26FEB05LALBOS-BOS"
+
+// startFeed runs a MarketFeed against srv until the test ends.
+func startFeed(t *testing.T, srv *FeedServer) *kalshi.MarketFeed {
+	t.Helper()
+	feed := kalshi.NewMarketFeed(srv.URL(), func() (http.Header, error) {
+		return http.Header{"KALSHI-ACCESS-KEY": {"test"}}, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go feed.Run(ctx)
+	return feed
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// bookIs reports whether the feed's book for ticker has the given levels.
+func bookIs(feed *kalshi.MarketFeed, ticker string, yes, no [][2]int) func() bool {
+	return func() bool {
+		book, ok := feed.OrderBook(ticker)
+		return ok && reflect.DeepEqual(book.OrderBook.Yes, yes) && reflect.DeepEqual(book.OrderBook.No, no)
+	}
+}
+
+func TestMarketFeedSnapshotAndDeltas(t *testing.T) {
+	srv := NewFeedServer()
+	defer srv.Close()
+	srv.SetLevel(feedTicker, kalshi.SideYes, 45, 100)
+	srv.SetLevel(feedTicker, kalshi.SideNo, 52, 30) // YES offered at 48¢
+
+	feed := startFeed(t, srv)
+	if _, ok := feed.OrderBook(feedTicker); ok {
+		t.Fatal("OrderBook served a book before subscribing")
+	}
+	feed.Track(feedTicker)
+	waitFor(t, "snapshot", bookIs(feed, feedTicker, [][2]int{{45, 100}}, [][2]int{{52, 30}}))
+
+	// Deltas add, resize and remove levels
+	srv.SetLevel(feedTicker, kalshi.SideNo, 50, 40)
+	srv.SetLevel(feedTicker, kalshi.SideNo, 52, 10)
+	srv.SetLevel(feedTicker, kalshi.SideYes, 45, 0)
+	waitFor(t, "deltas", bookIs(feed, feedTicker, [][2]int{}, [][2]int{{50, 40}, {52, 10}}))
+
+	// The snapshot and each delta announce the ticker on Updates
+	for i := 0; i < 4; i++ {
+		select {
+		case got := <-feed.Updates():
+			if got != feedTicker {
+				t.Errorf("update %d = %q, want %q", i, got, feedTicker)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for update %d", i)
+		}
+	}
+
+	book, _ := feed.OrderBook(feedTicker)
+	slip := kalshi.CalculateSlippage(book, kalshi.SideYes, kalshi.ActionBuy, 20)
+	if slip.FillableContracts != 20 || slip.BestPrice != 48 || slip.AverageFillPrice != 49 {
+		t.Errorf("slippage on live book = %+v, want 10@48 + 10@50", slip)
+	}
+	if got := kalshi.CheckLiquidity(book, kalshi.SideYes, kalshi.ActionBuy, 100).Available; got != 50 {
+		t.Errorf("liquidity = %d, want 50", got)
+	}
+
+	srv.SendTicker(feedTicker, 49, 47, 48)
+	waitFor(t, "ticker update", func() bool {
+		tu, ok := feed.Ticker(feedTicker)
+		return ok && tu.Price == 49 && tu.YesBid == 47 && tu.YesAsk == 48
+	})
+}
+
+func TestMarketFeedResyncsOnSequenceGap(t *testing.T) {
+	srv := NewFeedServer()
+	defer srv.Close()
+	srv.SetLevel(feedTicker, kalshi.SideYes, 40, 10)
+
+	feed := startFeed(t, srv)
+	feed.Track(feedTicker)
+	waitFor(t, "snapshot", bookIs(feed, feedTicker, [][2]int{{40, 10}}, [][2]int{}))
+
+	// A lost delta: the book changes but the feed only sees a seq jump
+	srv.SkipSeq(feedTicker)
+	srv.SetLevel(feedTicker, kalshi.SideYes, 41, 5)
+
+	waitFor(t, "resubscribe", func() bool { return srv.Subscribes() == 2 })
+	waitFor(t, "fresh snapshot", bookIs(feed, feedTicker, [][2]int{{40, 10}, {41, 5}}, [][2]int{}))
+}
+
+func TestMarketFeedReconnects(t *testing.T) {
+	srv := NewFeedServer()
+	defer srv.Close()
+	srv.SetLevel(feedTicker, kalshi.SideYes, 40, 10)
+
+	feed := startFeed(t, srv)
+	feed.Track(feedTicker)
+	waitFor(t, "snapshot", bookIs(feed, feedTicker, [][2]int{{40, 10}}, [][2]int{}))
+
+	srv.Disconnect()
+	srv.SetLevel(feedTicker, kalshi.SideYes, 40, 25) // missed while down
+	waitFor(t, "snapshot after reconnect", bookIs(feed, feedTicker, [][2]int{{40, 25}}, [][2]int{}))
+}
+
+func TestMarketFeedUntrack(t *testing.T) {
+	const other = "KXNBAGAME-26FEB05GSWPHX-PHX"
+	srv := NewFeedServer()
+	defer srv.Close()
+	srv.SetLevel(feedTicker, kalshi.SideYes, 40, 10)
+	srv.SetLevel(other, kalshi.SideYes, 30, 10)
+
+	feed := startFeed(t, srv)
+	feed.Track(feedTicker, other)
+	waitFor(t, "snapshots", func() bool {
+		return bookIs(feed, feedTicker, [][2]int{{40, 10}}, [][2]int{})() &&
+			bookIs(feed, other, [][2]int{{30, 10}}, [][2]int{})()
+	})
+
+	// Dropping one ticker shrinks both subscriptions around the other
+	feed.Untrack(feedTicker)
+	if _, ok := feed.OrderBook(feedTicker); ok {
+		t.Error("OrderBook served an untracked ticker")
+	}
+	if got := feed.Tracked(); !reflect.DeepEqual(got, []string{other}) {
+		t.Errorf("Tracked = %v, want [%s]", got, other)
+	}
+	waitFor(t, "markets deleted", func() bool { return srv.Subscriptions(feedTicker) == 0 })
+	if n := srv.Subscriptions(other); n != 2 {
+		t.Errorf("%s on %d subscriptions, want book and ticker", other, n)
+	}
+	srv.SetLevel(other, kalshi.SideYes, 31, 5)
+	waitFor(t, "delta on the kept ticker", bookIs(feed, other, [][2]int{{30, 10}, {31, 5}}, [][2]int{}))
+
+	// Dropping the last one unsubscribes
+	feed.Untrack(other)
+	waitFor(t, "unsubscribe", func() bool { return srv.Subscriptions(other) == 0 })
+	if srv.Subscribes() != 1 {
+		t.Errorf("subscribes = %d, want no resync", srv.Subscribes())
+	}
+}
@@ -15,8 +15,17 @@ const (
 	MinLiquidityContracts = 10
 )
 
-// GetOrderBook fetches the order book for a market
+// GetOrderBook returns the order book for a market. With a market feed
+// running it serves the live WebSocket book, falling back to REST (and
+// subscribing the ticker) when the feed has no current book for it.
 func (c *KalshiClient) GetOrderBook(ticker string) (*OrderBookResponse, error) {
+	if c.feed != nil {
+		if book, ok := c.feed.OrderBook(ticker); ok {
+			return book, nil
+		}
+		c.feed.Track(ticker)
+	}
+
 	path := fmt.Sprintf("/markets/%s/orderbook", ticker)
 	body, err := c.doAuthenticatedRequest(http.MethodGet, path, nil)
 	if err != nil {
@@ -36,7 +45,7 @@ func (c *KalshiClient) GetOrderBook(ticker string) (*OrderBookResponse, error) {
 // side: "yes" or "no"
 // action: "buy" or "sell"
 // contracts: number of contracts we want to trade
-func (c *KalshiClient) CheckLiquidity(book *OrderBookResponse, side Side, action OrderAction, contracts int) *LiquidityCheck {
+func CheckLiquidity(book *OrderBookResponse, side Side, action OrderAction, contracts int) *LiquidityCheck {
 	levels := getLevelsForTrade(book, side, action)
 	if len(levels) == 0 {
 		return &LiquidityCheck{
@@ -74,8 +83,20 @@ func (c *KalshiClient) CheckLiquidity(book *OrderBookResponse, side Side, action
 	}
 }
 
+// DepthWithin returns the contracts that can be traded at limitPrice or
+// better: asks at or below it when buying, bids at or above it when selling.
+func DepthWithin(book *OrderBookResponse, side Side, action OrderAction, limitPrice int) int {
+	depth := 0
+	for _, level := range getLevelsForTrade(book, side, action) {
+		if (action == ActionBuy && level.Price <= limitPrice) || (action == ActionSell && level.Price >= limitPrice) {
+			depth += level.Count
+		}
+	}
+	return depth
+}
+
 // CalculateSlippage computes the slippage for a given trade size
-func (c *KalshiClient) CalculateSlippage(book *OrderBookResponse, side Side, action OrderAction, contracts int) *SlippageResult {
+func CalculateSlippage(book *OrderBookResponse, side Side, action OrderAction, contracts int) *SlippageResult {
 	levels := getLevelsForTrade(book, side, action)
 	if len(levels) == 0 {
 		return &SlippageResult{
@@ -150,7 +171,7 @@ func (c *KalshiClient) GetFillPrice(book *OrderBookResponse, side Side, action O
 }
 
 // GetOptimalSize returns the maximum contracts tradeable within slippage limits
-func (c *KalshiClient) GetOptimalSize(book *OrderBookResponse, side Side, action OrderAction, maxSlippage float64) int {
+func GetOptimalSize(book *OrderBookResponse, side Side, action OrderAction, maxSlippage float64) int {
 	levels := getLevelsForTrade(book, side, action)
 	if len(levels) == 0 {
 		return 0
@@ -167,7 +188,7 @@ func (c *KalshiClient) GetOptimalSize(book *OrderBookResponse, side Side, action
 	optimalSize := 0
 	for low <= high {
 		mid := (low + high) / 2
-		result := c.CalculateSlippage(book, side, action, mid)
+		result := CalculateSlippage(book, side, action, mid)
 
 		if result.FillableContracts == mid && result.SlippagePct <= maxSlippage {
 			optimalSize = mid
@@ -184,7 +205,7 @@ func (c *KalshiClient) GetOptimalSize(book *OrderBookResponse, side Side, action
 
 	// Ensure at least best level if within slippage
 	if optimalSize < levels[0].Count {
-		result := c.CalculateSlippage(book, side, action, levels[0].Count)
+		result := CalculateSlippage(book, side, action, levels[0].Count)
 		if result.SlippagePct <= maxSlippage {
 			return levels[0].Count
 		}
@@ -193,6 +214,24 @@ func (c *KalshiClient) GetOptimalSize(book *OrderBookResponse, side Side, action
 	return optimalSize
 }
 
+// CheckLiquidity analyzes available liquidity on the client's behalf.
+// See the package-level CheckLiquidity.
+func (c *KalshiClient) CheckLiquidity(book *OrderBookResponse, side Side, action OrderAction, contracts int) *LiquidityCheck {
+	return CheckLiquidity(book, side, action, contracts)
+}
+
+// CalculateSlippage computes slippage on the client's behalf.
+// See the package-level CalculateSlippage.
+func (c *KalshiClient) CalculateSlippage(book *OrderBookResponse, side Side, action OrderAction, contracts int) *SlippageResult {
+	return CalculateSlippage(book, side, action, contracts)
+}
+
+// GetOptimalSize returns the maximum size within slippage on the client's behalf.
+// See the package-level GetOptimalSize.
+func (c *KalshiClient) GetOptimalSize(book *OrderBookResponse, side Side, action OrderAction, maxSlippage float64) int {
+	return GetOptimalSize(book, side, action, maxSlippage)
+}
+
 // getLevelsForTrade returns the relevant order book levels for a trade
 // Per Kalshi docs: order book returns "yes bids and no bids only (no asks)"
 // In Kalshi's order book (nested under orderbook.yes and orderbook.no):
@@ -224,3 +224,31 @@ func TestGetOptimalSize(t *testing.T) {
 		t.Errorf("Optimal size = %d, expected at least 50", optimal)
 	}
 }
+
+func TestDepthWithin(t *testing.T) {
+	book := &OrderBookResponse{
+		OrderBook: OrderBookInner{
+			Yes: [][2]int{{45, 10}, {42, 30}},
+			No:  [][2]int{{40, 50}, {35, 50}}, // YES offers at 60 and 65
+		},
+	}
+
+	tests := []struct {
+		side   Side
+		action OrderAction
+		limit  int
+		want   int
+	}{
+		{SideYes, ActionBuy, 62, 50},
+		{SideYes, ActionBuy, 65, 100},
+		{SideYes, ActionBuy, 59, 0},
+		{SideNo, ActionBuy, 55, 10}, // YES bid at 45 = NO offer at 55
+		{SideYes, ActionSell, 43, 10},
+		{SideNo, ActionSell, 35, 100},
+	}
+	for _, tt := range tests {
+		if got := DepthWithin(book, tt.side, tt.action, tt.limit); got != tt.want {
+			t.Errorf("DepthWithin(%s %s @%d) = %d, want %d", tt.action, tt.side, tt.limit, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/google/uuid"
@@ -19,6 +20,11 @@ type OrderConfig struct {
 	// EV verification at execution time
 	TrueProb    float64 // Consensus probability (0-1), set to 0 to skip EV check
 	EVThreshold float64 // Minimum adjusted EV required (e.g., 0.03 = 3%)
+
+	// ClientOrderID is sent as the order's idempotency key. Kalshi returns
+	// the original order when an ID is reused, so a journaled ID can be
+	// resubmitted safely. Generated per order when empty.
+	ClientOrderID string
 }
 
 // DefaultOrderConfig returns sensible defaults
@@ -93,59 +99,92 @@ func (c *KalshiClient) PlaceOrder(
 		}, nil
 	}
 
-	// Step 4: Check liquidity
-	liquidity := c.CheckLiquidity(book, side, action, contracts)
-	if liquidity.Available < config.MinLiquidityContracts {
+	// Steps 4-7: liquidity, slippage, EV re-check and limit price
+	plan, reason := PlanOrder(book, side, action, contracts, config)
+	if reason != "" {
+		return &ExecutionResult{
+			Success:            false,
+			RequestedContracts: plan.Contracts,
+			RejectionReason:    reason,
+		}, nil
+	}
+	contracts = plan.Contracts
+	slippage := plan.Slippage
+	limitPrice := plan.LimitPrice
+
+	// Step 8: Dry run check
+	if config.DryRun {
+		return &ExecutionResult{
+			Success:            true,
+			RequestedContracts: contracts,
+			FilledContracts:    contracts,
+			AveragePrice:       slippage.AverageFillPrice,
+			TotalCost:          int64(float64(contracts) * slippage.AverageFillPrice),
+			RejectionReason:    "DRY_RUN: order not placed",
+		}, nil
+	}
+
+	// Step 9: Place the order
+	result, err := c.submitOrder(ticker, side, action, contracts, limitPrice, config.ClientOrderID)
+	if err != nil {
 		return &ExecutionResult{
 			Success:            false,
 			RequestedContracts: contracts,
-			RejectionReason:    fmt.Sprintf("insufficient liquidity: %d available, need %d minimum", liquidity.Available, config.MinLiquidityContracts),
+			RejectionReason:    fmt.Sprintf("order submission failed: %v", err),
 		}, nil
 	}
 
-	// Step 5: Calculate slippage
-	slippage := c.CalculateSlippage(book, side, action, contracts)
+	return result, nil
+}
+
+// OrderPlan is the outcome of PlanOrder: the size to submit, the expected
+// fill against the book, and the limit price to send.
+type OrderPlan struct {
+	Contracts  int
+	Slippage   *SlippageResult
+	LimitPrice int
+}
+
+// PlanOrder runs the book-level safeguards shared by PlaceOrder and any
+// simulated exchange: liquidity, slippage (shrinking to the optimal size when
+// needed), EV re-verification and limit price selection.
+// Returns a non-empty rejection reason if the order should not be sent.
+func PlanOrder(book *OrderBookResponse, side Side, action OrderAction, contracts int, config OrderConfig) (OrderPlan, string) {
+	// Check liquidity
+	liquidity := CheckLiquidity(book, side, action, contracts)
+	if liquidity.Available < config.MinLiquidityContracts {
+		return OrderPlan{Contracts: contracts}, fmt.Sprintf("insufficient liquidity: %d available, need %d minimum", liquidity.Available, config.MinLiquidityContracts)
+	}
+
+	// Calculate slippage
+	slippage := CalculateSlippage(book, side, action, contracts)
 	if !slippage.Acceptable || slippage.SlippagePct > config.MaxSlippagePct {
 		// Try to find optimal size within slippage
-		optimalSize := c.GetOptimalSize(book, side, action, config.MaxSlippagePct)
+		optimalSize := GetOptimalSize(book, side, action, config.MaxSlippagePct)
 		if optimalSize < config.MinLiquidityContracts {
-			return &ExecutionResult{
-				Success:            false,
-				RequestedContracts: contracts,
-				RejectionReason:    fmt.Sprintf("slippage %.2f%% exceeds max %.2f%%, optimal size %d too small", slippage.SlippagePct*100, config.MaxSlippagePct*100, optimalSize),
-			}, nil
+			return OrderPlan{Contracts: contracts}, fmt.Sprintf("slippage %.2f%% exceeds max %.2f%%, optimal size %d too small", slippage.SlippagePct*100, config.MaxSlippagePct*100, optimalSize)
 		}
 		// Reduce size to optimal
 		contracts = optimalSize
-		slippage = c.CalculateSlippage(book, side, action, contracts)
+		slippage = CalculateSlippage(book, side, action, contracts)
 	}
 
 	if slippage.FillableContracts < contracts {
-		return &ExecutionResult{
-			Success:            false,
-			RequestedContracts: contracts,
-			FilledContracts:    0,
-			RejectionReason:    fmt.Sprintf("only %d of %d contracts fillable", slippage.FillableContracts, contracts),
-		}, nil
+		return OrderPlan{Contracts: contracts}, fmt.Sprintf("only %d of %d contracts fillable", slippage.FillableContracts, contracts)
 	}
 
-	// Step 6: Re-verify EV at actual execution price (if TrueProb is set)
+	// Re-verify EV at actual execution price (if TrueProb is set)
 	if config.TrueProb > 0 {
 		actualPrice := slippage.AverageFillPrice / 100.0 // Convert cents to probability
 		rawEV := (config.TrueProb * (1 - actualPrice)) - ((1 - config.TrueProb) * actualPrice)
 		adjustedEV := rawEV - TakerFee(actualPrice)
 
 		if adjustedEV < config.EVThreshold {
-			return &ExecutionResult{
-				Success:            false,
-				RequestedContracts: contracts,
-				FilledContracts:    0,
-				RejectionReason:    fmt.Sprintf("EV dropped below threshold at execution price: %.2f%% < %.2f%% (price moved from opportunity to %.0f¢)", adjustedEV*100, config.EVThreshold*100, slippage.AverageFillPrice),
-			}, nil
+			return OrderPlan{Contracts: contracts}, fmt.Sprintf("EV dropped below threshold at execution price: %.2f%% < %.2f%% (price moved from opportunity to %.0f¢)", adjustedEV*100, config.EVThreshold*100, slippage.AverageFillPrice)
 		}
 	}
 
-	// Step 7: Calculate limit price (best price with small buffer for execution)
+	// Calculate limit price (best price with small buffer for execution)
 	limitPrice := slippage.BestPrice
 	if action == ActionBuy {
 		// For buying, set limit slightly above best ask to ensure fill
@@ -155,29 +194,7 @@ func (c *KalshiClient) PlaceOrder(
 		limitPrice = max(slippage.BestPrice-1, 1) // Min 1 cent
 	}
 
-	// Step 8: Dry run check
-	if config.DryRun {
-		return &ExecutionResult{
-			Success:            true,
-			RequestedContracts: contracts,
-			FilledContracts:    contracts,
-			AveragePrice:       slippage.AverageFillPrice,
-			TotalCost:          int64(float64(contracts) * slippage.AverageFillPrice),
-			RejectionReason:    "DRY_RUN: order not placed",
-		}, nil
-	}
-
-	// Step 9: Place the order
-	result, err := c.submitOrder(ticker, side, action, contracts, limitPrice)
-	if err != nil {
-		return &ExecutionResult{
-			Success:            false,
-			RequestedContracts: contracts,
-			RejectionReason:    fmt.Sprintf("order submission failed: %v", err),
-		}, nil
-	}
-
-	return result, nil
+	return OrderPlan{Contracts: contracts, Slippage: slippage, LimitPrice: limitPrice}, ""
 }
 
 // submitOrder sends the order to Kalshi
@@ -188,9 +205,11 @@ func (c *KalshiClient) submitOrder(
 	action OrderAction,
 	contracts int,
 	limitPrice int,
+	clientOrderID string,
 ) (*ExecutionResult, error) {
-	// Generate unique client order ID
-	clientOrderID := uuid.New().String()
+	if clientOrderID == "" {
+		clientOrderID = uuid.New().String()
+	}
 
 	// Create order request with IOC time-in-force
 	// Per Kalshi docs, time_in_force: "ioc" = immediate or cancel
@@ -211,22 +230,11 @@ func (c *KalshiClient) submitOrder(
 		orderReq.NoPrice = limitPrice
 	}
 
-	jsonBody, err := json.Marshal(orderReq)
+	placed, err := c.SubmitOrder(orderReq)
 	if err != nil {
-		return nil, fmt.Errorf("marshaling order request: %w", err)
-	}
-
-	body, err := c.doAuthenticatedRequest(http.MethodPost, "/portfolio/orders", bytes.NewReader(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("placing order: %w", err)
+		return nil, err
 	}
-
-	var resp OrderResponse
-	if err := json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("parsing order response: %w", err)
-	}
-
-	order := resp.Order
+	order := *placed
 
 	// IOC orders execute immediately, so initial response should have fill info
 	// But let's wait briefly and fetch updated status for accuracy
@@ -267,6 +275,27 @@ func (c *KalshiClient) submitOrder(
 	}, nil
 }
 
+// SubmitOrder sends a raw order request with no safeguards and returns the
+// order as Kalshi accepted it. GTC limit orders rest on the book until
+// filled or canceled; callers own their lifecycle.
+func (c *KalshiClient) SubmitOrder(req CreateOrderRequest) (*Order, error) {
+	jsonBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling order request: %w", err)
+	}
+
+	body, err := c.doAuthenticatedRequest(http.MethodPost, "/portfolio/orders", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("placing order: %w", err)
+	}
+
+	var resp OrderResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("parsing order response: %w", err)
+	}
+	return &resp.Order, nil
+}
+
 // GetOrder fetches an order by ID
 func (c *KalshiClient) GetOrder(orderID string) (*Order, error) {
 	path := fmt.Sprintf("/portfolio/orders/%s", orderID)
@@ -285,6 +314,47 @@ func (c *KalshiClient) GetOrder(orderID string) (*Order, error) {
 	return &resp.Order, nil
 }
 
+// OrdersResponse represents the API response for listing orders
+type OrdersResponse struct {
+	Orders []Order `json:"orders"`
+	Cursor string  `json:"cursor,omitempty"`
+}
+
+// GetOrders lists our orders on a ticker, following the cursor through
+// every page. Used to find orders by client order ID after a restart.
+func (c *KalshiClient) GetOrders(ticker string) ([]Order, error) {
+	var allOrders []Order
+	cursor := ""
+
+	for {
+		params := url.Values{}
+		params.Set("ticker", ticker)
+		params.Set("limit", "1000")
+		if cursor != "" {
+			params.Set("cursor", cursor)
+		}
+
+		body, err := c.doAuthenticatedRequest(http.MethodGet, "/portfolio/orders?"+params.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("fetching orders: %w", err)
+		}
+
+		var resp OrdersResponse
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("parsing orders response: %w", err)
+		}
+
+		allOrders = append(allOrders, resp.Orders...)
+
+		if resp.Cursor == "" {
+			break
+		}
+		cursor = resp.Cursor
+	}
+
+	return allOrders, nil
+}
+
 // CancelOrder cancels an open order
 func (c *KalshiClient) CancelOrder(orderID string) error {
 	path := fmt.Sprintf("/portfolio/orders/%s", orderID)
@@ -331,7 +401,7 @@ func (c *KalshiClient) PlaceMarketOrder(
 		}, nil
 	}
 
-	liquidity := c.CheckLiquidity(book, side, action, contracts)
+	liquidity := CheckLiquidity(book, side, action, contracts)
 	if liquidity.Available < config.MinLiquidityContracts {
 		return &ExecutionResult{
 			Success:            false,
@@ -342,7 +412,7 @@ func (c *KalshiClient) PlaceMarketOrder(
 
 	// Dry run
 	if config.DryRun {
-		slippage := c.CalculateSlippage(book, side, action, contracts)
+		slippage := CalculateSlippage(book, side, action, contracts)
 		return &ExecutionResult{
 			Success:            true,
 			RequestedContracts: contracts,
@@ -354,7 +424,7 @@ func (c *KalshiClient) PlaceMarketOrder(
 	}
 
 	// Submit market order
-	return c.submitMarketOrder(ticker, side, action, contracts)
+	return c.submitMarketOrder(ticker, side, action, contracts, config.ClientOrderID)
 }
 
 func (c *KalshiClient) submitMarketOrder(
@@ -362,8 +432,11 @@ func (c *KalshiClient) submitMarketOrder(
 	side Side,
 	action OrderAction,
 	contracts int,
+	clientOrderID string,
 ) (*ExecutionResult, error) {
-	clientOrderID := uuid.New().String()
+	if clientOrderID == "" {
+		clientOrderID = uuid.New().String()
+	}
 
 	orderReq := CreateOrderRequest{
 		Ticker:        ticker,
@@ -374,22 +447,10 @@ func (c *KalshiClient) submitMarketOrder(
 		Type:          OrderTypeMarket,
 	}
 
-	jsonBody, err := json.Marshal(orderReq)
-	if err != nil {
-		return nil, fmt.Errorf("marshaling order request: %w", err)
-	}
-
-	body, err := c.doAuthenticatedRequest(http.MethodPost, "/portfolio/orders", bytes.NewReader(jsonBody))
+	order, err := c.SubmitOrder(orderReq)
 	if err != nil {
-		return nil, fmt.Errorf("placing market order: %w", err)
+		return nil, err
 	}
-
-	var resp OrderResponse
-	if err := json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("parsing order response: %w", err)
-	}
-
-	order := resp.Order
 	avgPrice := order.AvgFillPrice()
 
 	return &ExecutionResult{
@@ -93,6 +93,65 @@ func BuildPlayerPropTicker(propType PropType, gameDate time.Time, awayTeam, home
 	return BuildNBATicker(series, gameDate, awayTeam, homeTeam)
 }
 
+// TickerInfo is the game identity encoded in an NBA ticker.
+type TickerInfo struct {
+	Series     KalshiSeries
+	GameDate   time.Time
+	AwayTeam   string
+	HomeTeam   string
+	PlayerTeam string // Player prop tickers only, e.g. "GSW" from "GSWSCURRY30"
+}
+
+// Game returns the ticker's game segment, e.g. "26FEB05GSWPHX".
+func (t TickerInfo) Game() string {
+	return FormatKalshiDate(t.GameDate) + t.AwayTeam + t.HomeTeam
+}
+
+// ParseNBATicker reverses BuildNBATicker. For player prop tickers the
+// player's team is read from the market segment; the line is ignored.
+func ParseNBATicker(ticker string) (TickerInfo, bool) {
+	parts := strings.Split(ticker, "-")
+	if len(parts) < 2 || len(parts[1]) != 13 {
+		return TickerInfo{}, false
+	}
+
+	game := parts[1]
+	date, err := time.Parse("06Jan02", game[:2]+game[2:3]+strings.ToLower(game[3:5])+game[5:7])
+	if err != nil {
+		return TickerInfo{}, false
+	}
+
+	info := TickerInfo{
+		Series:   KalshiSeries(parts[0]),
+		GameDate: date,
+		AwayTeam: game[7:10],
+		HomeTeam: game[10:13],
+	}
+	if len(parts) >= 3 && len(parts[2]) > 3 {
+		info.PlayerTeam = parts[2][:3]
+	}
+	return info, true
+}
+
+// MarketTypeForSeries returns the position market type for a series:
+// "moneyline", "spread", "total", or "prop_<type>" for player props.
+func MarketTypeForSeries(series KalshiSeries) string {
+	switch series {
+	case SeriesMoneyline:
+		return string(MarketMoneyline)
+	case SeriesSpread:
+		return string(MarketSpread)
+	case SeriesTotal:
+		return string(MarketTotal)
+	}
+	for _, propType := range []PropType{PropPoints, PropRebounds, PropAssists, PropThrees, PropSteals, PropBlocks} {
+		if GetSeriesForPropType(propType) == series {
+			return "prop_" + string(propType)
+		}
+	}
+	return ""
+}
+
 // FormatKalshiDate formats a date for Kalshi ticker (YYMONDD format)
 func FormatKalshiDate(t time.Time) string {
 	return fmt.Sprintf("%02d%s%02d",
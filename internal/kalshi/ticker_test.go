@@ -1,6 +1,7 @@
 package kalshi
 
 import (
+	"strings"
 	"testing"
 	"time"
 )
@@ -382,3 +383,51 @@ func TestFormatKalshiDate(t *testing.T) {
 		})
 	}
 }
+
+func TestParseNBATicker(t *testing.T) {
+	tests := []struct {
+		ticker string
+		want   TickerInfo
+		ok     bool
+	}{
+		{"KXNBAGAME-26FEB04MEMSAC", TickerInfo{SeriesMoneyline, time.Date(2026, 2, 4, 0, 0, 0, 0, time.UTC), "MEM", "SAC", ""}, true},
+		{"KXNBAPTS-26FEB05GSWPHX-GSWSCURRY30-25", TickerInfo{SeriesPlayerPoints, time.Date(2026, 2, 5, 0, 0, 0, 0, time.UTC), "GSW", "PHX", "GSW"}, true},
+		{"KXNBATOTAL-25DEC25LALGSW", TickerInfo{SeriesTotal, time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC), "LAL", "GSW", ""}, true},
+		{"KXNBA-26", TickerInfo{}, false},
+		{"KXNBAGAME-26XXX04MEMSAC", TickerInfo{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ticker, func(t *testing.T) {
+			got, ok := ParseNBATicker(tt.ticker)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("ParseNBATicker(%q) = %+v, %v, want %+v, %v", tt.ticker, got, ok, tt.want, tt.ok)
+			}
+			if ok && string(got.Series)+"-"+got.Game() != strings.Join(strings.Split(tt.ticker, "-")[:2], "-") {
+				t.Errorf("ParseNBATicker(%q) does not round-trip through BuildNBATicker", tt.ticker)
+			}
+		})
+	}
+}
+
+func TestMarketTypeForSeries(t *testing.T) {
+	tests := []struct {
+		series KalshiSeries
+		want   string
+	}{
+		{SeriesMoneyline, "moneyline"},
+		{SeriesSpread, "spread"},
+		{SeriesTotal, "total"},
+		{SeriesPlayerPoints, "prop_points"},
+		{SeriesPlayerThrees, "prop_threes"},
+		{SeriesChampionship, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.series), func(t *testing.T) {
+			if got := MarketTypeForSeries(tt.series); got != tt.want {
+				t.Errorf("MarketTypeForSeries(%q) = %q, want %q", tt.series, got, tt.want)
+			}
+		})
+	}
+}
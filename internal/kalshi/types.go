@@ -197,6 +197,7 @@ type Market struct {
 	EventTicker    string `json:"event_ticker"`
 	Title          string `json:"title"`
 	Status         string `json:"status"` // "active", "finalized", etc.
+	Result         string `json:"result"` // "yes", "no" or "void" once determined
 	YesBid         int    `json:"yes_bid"`
 	YesAsk         int    `json:"yes_ask"`
 	NoBid          int    `json:"no_bid"`
@@ -209,6 +210,19 @@ type Market struct {
 	CloseTime      string `json:"close_time"`
 }
 
+// SettledResult returns the market's result once it has settled.
+// ok is false while the market is open or awaiting determination.
+func (m *Market) SettledResult() (result string, ok bool) {
+	if m.Status != "settled" && m.Status != "finalized" {
+		return "", false
+	}
+	switch m.Result {
+	case "yes", "no", "void":
+		return m.Result, true
+	}
+	return "", false
+}
+
 // Error Response
 
 // APIError represents a Kalshi API error
@@ -0,0 +1,80 @@
+package metrics
+
+import "strings"
+
+// Upstream label values for the API metrics.
+const (
+	UpstreamBallDontLie = "balldontlie"
+	UpstreamKalshi      = "kalshi"
+)
+
+var (
+	latencyBuckets = []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	scanBuckets    = []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60}
+	// Fill price minus the opportunity's quoted price, in cents
+	slippageBuckets = []float64{-2, -1, 0, 1, 2, 3, 5, 10}
+)
+
+// Scan cycle
+var (
+	ScanDuration  = Default.NewHistogram("bot_scan_duration_seconds", "Time taken by one scan cycle.", scanBuckets)
+	GamesScanned  = Default.NewGauge("bot_scan_games", "Games evaluated in the last scan.")
+	PropsScanned  = Default.NewGauge("bot_scan_props", "Sportsbook player prop lines evaluated in the last scan.")
+	Opportunities = Default.NewCounter("bot_opportunities_total", "+EV opportunities found, counted each time a scan evaluates them.", "market_type")
+	EventScans    = Default.NewCounter("bot_event_scans_total", "Partial re-evaluations in event scan mode, by what triggered them.", "trigger")
+	LadderFlags   = Default.NewCounter("bot_ladder_flags_total", "Kalshi prop ladder rungs flagged, counted each time a scan evaluates them.", "kind")
+	InPlayGames   = Default.NewGauge("bot_scan_in_play_games", "Games in progress priced by the last scan.")
+	InPlaySkips   = Default.NewCounter("bot_in_play_skips_total", "Games in progress or their markets left out of a scan, by guard.", "reason")
+)
+
+// Execution
+var (
+	OrdersAttempted = Default.NewCounter("bot_orders_attempted_total", "Orders sent to PlaceOrder.", "market_type")
+	OrdersFilled    = Default.NewCounter("bot_orders_filled_total", "Orders with at least one contract filled.", "market_type")
+	OrdersRejected  = Default.NewCounter("bot_orders_rejected_total", "Orders that filled nothing, by rejection reason.", "market_type", "reason")
+	FillSlippage    = Default.NewHistogram("bot_fill_slippage_cents", "Average fill price minus the quoted price.", slippageBuckets, "market_type")
+)
+
+// Account
+var (
+	Bankroll     = Default.NewGauge("bot_bankroll_dollars", "Kalshi cash balance at the start of the last scan.")
+	OpenExposure = Default.NewGauge("bot_open_exposure_dollars", "Cost of unsettled positions in the DB.")
+)
+
+// Upstream APIs
+var (
+	APILatency        = Default.NewHistogram("bot_api_request_duration_seconds", "HTTP round trip per request attempt.", latencyBuckets, "upstream")
+	APIRateLimited    = Default.NewCounter("bot_api_rate_limited_total", "HTTP 429 responses.", "upstream")
+	RateLimiterWait   = Default.NewHistogram("bot_rate_limiter_wait_seconds", "Time spent waiting for a client-side rate limiter token.", latencyBuckets, "upstream")
+	FeedResyncs       = Default.NewCounter("bot_kalshi_feed_resyncs_total", "Order book resubscribes after a WebSocket sequence gap.")
+	APIListTruncated  = Default.NewCounter("bot_api_list_truncated_total", "Paginated list calls cut short, by what stopped them.", "upstream", "reason")
+	APIInvalidRecords = Default.NewCounter("bot_api_invalid_records_total", "balldontlie records or markets dropped for numeric fields that don't parse.", "kind")
+)
+
+// rejectionReasons maps RejectionReason prefixes from kalshi.PlaceOrder to
+// bounded label values; the raw reasons embed prices and counts.
+var rejectionReasons = []struct{ prefix, label string }{
+	{"insufficient liquidity", "liquidity"},
+	{"slippage", "slippage"},
+	{"only ", "not_fillable"},
+	{"EV dropped", "ev_dropped"},
+	{"failed to check exchange status", "exchange_status"},
+	{"exchange is not active", "exchange_inactive"},
+	{"failed to fetch market info", "market_info"},
+	{"market closes within", "market_closing"},
+	{"market status is", "market_not_open"},
+	{"failed to fetch order book", "orderbook"},
+	{"DRY_RUN", "dry_run"},
+	{"order submission failed", "submit_failed"},
+	{"order ", "no_fills"},
+}
+
+// RejectionReason buckets a free-text order rejection into a label value.
+func RejectionReason(reason string) string {
+	for _, r := range rejectionReasons {
+		if strings.HasPrefix(reason, r.prefix) {
+			return r.label
+		}
+	}
+	return "other"
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	r := NewRegistry()
+	orders := r.NewCounter("test_orders_total", "Orders placed.", "market_type", "reason")
+	bankroll := r.NewGauge("test_bankroll_dollars", "Cash balance.")
+	latency := r.NewHistogram("test_latency_seconds", "Request latency.", []float64{0.1, 1}, "upstream")
+
+	orders.Inc("spread", "slippage")
+	orders.Add(2, "moneyline", `say "no"`)
+	bankroll.Set(123.5)
+	latency.Observe(0.05, "kalshi")
+	latency.Observe(0.5, "kalshi")
+	latency.Observe(3, "kalshi")
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `# HELP test_bankroll_dollars Cash balance.
+# TYPE test_bankroll_dollars gauge
+test_bankroll_dollars 123.5
+# HELP test_latency_seconds Request latency.
+# TYPE test_latency_seconds histogram
+test_latency_seconds_bucket{upstream="kalshi",le="0.1"} 1
+test_latency_seconds_bucket{upstream="kalshi",le="1"} 2
+test_latency_seconds_bucket{upstream="kalshi",le="+Inf"} 3
+test_latency_seconds_sum{upstream="kalshi"} 3.55
+test_latency_seconds_count{upstream="kalshi"} 3
+# HELP test_orders_total Orders placed.
+# TYPE test_orders_total counter
+test_orders_total{market_type="moneyline",reason="say \"no\""} 2
+test_orders_total{market_type="spread",reason="slippage"} 1
+`
+	if got := b.String(); got != want {
+		t.Errorf("WriteText:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_scans_total", "Scans.").Inc()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q, want Prometheus text format", ct)
+	}
+	if !strings.Contains(rec.Body.String(), "test_scans_total 1\n") {
+		t.Errorf("body missing counter:\n%s", rec.Body.String())
+	}
+}
+
+func TestRejectionReason(t *testing.T) {
+	tests := map[string]string{
+		"insufficient liquidity: 3 available, need 10 minimum":         "liquidity",
+		"slippage 4.00% exceeds max 2.00%, optimal size 2 too small":   "slippage",
+		"only 4 of 10 contracts fillable":                              "not_fillable",
+		"EV dropped below threshold at execution price: 1.00% < 3.00%": "ev_dropped",
+		"exchange is not active for trading":                           "exchange_inactive",
+		"DRY_RUN: order not placed":                                    "dry_run",
+		"order submission failed: placing order: API error 400":        "submit_failed",
+		"order canceled, no fills":                                     "no_fills",
+		"something new":                                                "other",
+	}
+	for reason, want := range tests {
+		if got := RejectionReason(reason); got != want {
+			t.Errorf("RejectionReason(%q) = %q, want %q", reason, got, want)
+		}
+	}
+}
+
+func TestDuplicateMetricPanics(t *testing.T) {
+	r := NewRegistry()
+	r.NewGauge("test_dup", "First.")
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a duplicate name did not panic")
+		}
+	}()
+	r.NewCounter("test_dup", "Second.")
+}
@@ -87,7 +87,7 @@ type weightedProb struct {
 // isVendorFresh checks if a vendor's odds are within the staleness threshold.
 // Returns true if maxAgeSec is 0 (no filtering) or if the vendor's UpdatedAt
 // is within maxAgeSec of now.
-func isVendorFresh(vendor api.Vendor, maxAgeSec int) bool {
+func isVendorFresh(vendor api.Vendor, maxAgeSec int, now time.Time) bool {
 	if maxAgeSec <= 0 || vendor.UpdatedAt == "" {
 		return true // No filtering or no timestamp
 	}
@@ -98,17 +98,25 @@ func isVendorFresh(vendor api.Vendor, maxAgeSec int) bool {
 			return true // Can't parse, assume fresh
 		}
 	}
-	return time.Since(t) <= time.Duration(maxAgeSec)*time.Second
+	return now.Sub(t) <= time.Duration(maxAgeSec)*time.Second
 }
 
 // CalculateConsensus computes consensus true probabilities from multiple vendors
 // Normalizes spread/total probabilities to match Kalshi's line
-// Vendors are weighted per api.VendorGameWeight (e.g. DraftKings 1.5x, BetMGM 0.7x)
+// Vendors are weighted per market by api.VendorWeight (fitted weights, or
+// defaults such as DraftKings 1.5x, BetMGM 0.7x)
 func CalculateConsensus(gameOdds api.GameOdds, maxOddsAgeSec ...int) ConsensusOdds {
 	maxAge := 0
 	if len(maxOddsAgeSec) > 0 {
 		maxAge = maxOddsAgeSec[0]
 	}
+	return CalculateConsensusAt(gameOdds, time.Now(), maxAge)
+}
+
+// CalculateConsensusAt is CalculateConsensus with an explicit reference time
+// for the staleness filter, so recorded odds can be replayed as of when they
+// were captured.
+func CalculateConsensusAt(gameOdds api.GameOdds, now time.Time, maxAge int) ConsensusOdds {
 	consensus := ConsensusOdds{
 		GameID:   gameOdds.GameID,
 		GameDate: gameOdds.Game.Date,
@@ -128,10 +136,6 @@ func CalculateConsensus(gameOdds api.GameOdds, maxOddsAgeSec ...int) ConsensusOd
 		}
 	}
 
-	var mlProbs []weightedProb
-	var spreadProbs []weightedProb
-	var totalProbs []weightedProb
-
 	// Get Kalshi lines as targets for normalization
 	var kalshiSpreadLine, kalshiTotalLine float64
 	if consensus.KalshiOdds != nil {
@@ -143,57 +147,30 @@ func CalculateConsensus(gameOdds api.GameOdds, maxOddsAgeSec ...int) ConsensusOd
 		}
 	}
 
-	// Second pass: collect and normalize probabilities
+	// Second pass: collect normalized probabilities, weighted per market
+	byMarket := make(map[MarketType][]weightedProb)
 	for _, vendor := range gameOdds.Vendors {
 		if api.IsKalshi(vendor.Name) {
 			continue
 		}
 
 		// Skip stale vendor odds
-		if !isVendorFresh(vendor, maxAge) {
+		if !isVendorFresh(vendor, maxAge, now) {
 			continue
 		}
 
-		w := api.VendorGameWeight(vendor.Name)
-
-		// Moneyline (no normalization needed)
-		if vendor.Moneyline != nil && vendor.Moneyline.Home != 0 && vendor.Moneyline.Away != 0 {
-			homeProb, awayProb := RemoveVigPowerFromAmerican(vendor.Moneyline.Home, vendor.Moneyline.Away)
-			if homeProb > 0 && awayProb > 0 {
-				mlProbs = append(mlProbs, weightedProb{homeProb, awayProb, w})
-			}
-		}
-
-		// Spread (normalize to Kalshi line)
-		if vendor.Spread != nil && vendor.Spread.HomeOdds != 0 && vendor.Spread.AwayOdds != 0 {
-			homeCover, awayCover := RemoveVigPowerFromAmerican(vendor.Spread.HomeOdds, vendor.Spread.AwayOdds)
-			if homeCover > 0 && awayCover > 0 {
-				// Normalize to Kalshi line if available
-				if kalshiSpreadLine != 0 {
-					homeCover, awayCover = normalizeSpreadProb(
-						homeCover, awayCover,
-						vendor.Spread.HomeSpread, kalshiSpreadLine,
-					)
-				}
-				spreadProbs = append(spreadProbs, weightedProb{homeCover, awayCover, w})
-			}
-		}
-
-		// Total (normalize to Kalshi line)
-		if vendor.Total != nil && vendor.Total.OverOdds != 0 && vendor.Total.UnderOdds != 0 {
-			overProb, underProb := RemoveVigPowerFromAmerican(vendor.Total.OverOdds, vendor.Total.UnderOdds)
-			if overProb > 0 && underProb > 0 {
-				// Normalize to Kalshi line if available
-				if kalshiTotalLine != 0 {
-					overProb, underProb = normalizeTotalProb(
-						overProb, underProb,
-						vendor.Total.Line, kalshiTotalLine,
-					)
-				}
-				totalProbs = append(totalProbs, weightedProb{overProb, underProb, w})
+		for _, market := range []MarketType{MarketMoneyline, MarketSpread, MarketTotal} {
+			p, ok := vendorProb(vendor, market, kalshiSpreadLine, kalshiTotalLine)
+			if !ok {
+				continue
 			}
+			p.weight = api.VendorWeight(string(market), vendor.Name)
+			byMarket[market] = append(byMarket[market], p)
 		}
 	}
+	mlProbs := byMarket[MarketMoneyline]
+	spreadProbs := byMarket[MarketSpread]
+	totalProbs := byMarket[MarketTotal]
 
 	// Calculate weighted averages across all books
 	if len(mlProbs) > 0 {
@@ -228,6 +205,64 @@ func CalculateConsensus(gameOdds api.GameOdds, maxOddsAgeSec ...int) ConsensusOd
 	return consensus
 }
 
+// vendorProb returns a vendor's vig-free probability pair for a market:
+// home/away for moneylines, home/away cover for spreads and over/under for
+// totals. Spreads and totals are normalized to spreadLine and totalLine
+// when those are non-zero. Returns false when the vendor doesn't quote it.
+func vendorProb(vendor api.Vendor, market MarketType, spreadLine, totalLine float64) (weightedProb, bool) {
+	var a, b float64
+	switch market {
+	case MarketMoneyline:
+		// Moneyline (no normalization needed)
+		if vendor.Moneyline == nil || vendor.Moneyline.Home == 0 || vendor.Moneyline.Away == 0 {
+			return weightedProb{}, false
+		}
+		a, b = RemoveVigPowerFromAmerican(vendor.Moneyline.Home, vendor.Moneyline.Away)
+	case MarketSpread:
+		if vendor.Spread == nil || vendor.Spread.HomeOdds == 0 || vendor.Spread.AwayOdds == 0 {
+			return weightedProb{}, false
+		}
+		a, b = RemoveVigPowerFromAmerican(vendor.Spread.HomeOdds, vendor.Spread.AwayOdds)
+		if a > 0 && b > 0 && spreadLine != 0 {
+			a, b = normalizeSpreadProb(a, b, vendor.Spread.HomeSpread, spreadLine)
+		}
+	case MarketTotal:
+		if vendor.Total == nil || vendor.Total.OverOdds == 0 || vendor.Total.UnderOdds == 0 {
+			return weightedProb{}, false
+		}
+		a, b = RemoveVigPowerFromAmerican(vendor.Total.OverOdds, vendor.Total.UnderOdds)
+		if a > 0 && b > 0 && totalLine != 0 {
+			a, b = normalizeTotalProb(a, b, vendor.Total.Line, totalLine)
+		}
+	}
+	return weightedProb{a: a, b: b}, a > 0 && b > 0
+}
+
+// VendorProbs returns each sportsbook's vig-free P(YES) for a game's
+// markets, keyed by market type and then vendor: the home win, the home
+// cover at spreadLine and the over at totalLine, as CalculateConsensus
+// pools them. A zero line leaves each book at its own line. Kalshi is
+// skipped; no staleness filter is applied.
+func VendorProbs(gameOdds api.GameOdds, spreadLine, totalLine float64) map[MarketType]map[string]float64 {
+	probs := make(map[MarketType]map[string]float64)
+	for _, vendor := range gameOdds.Vendors {
+		if api.IsKalshi(vendor.Name) {
+			continue
+		}
+		for _, market := range []MarketType{MarketMoneyline, MarketSpread, MarketTotal} {
+			p, ok := vendorProb(vendor, market, spreadLine, totalLine)
+			if !ok {
+				continue
+			}
+			if probs[market] == nil {
+				probs[market] = make(map[string]float64)
+			}
+			probs[market][vendor.Name] = p.a
+		}
+	}
+	return probs
+}
+
 // logLinearConsensus averages probabilities in logit space (log-linear opinion pool).
 // Applies winsorization (±2σ) when 3+ books to cap outlier influence.
 // Returns (sigmoid(weightedAvgLogit), 1 - sigmoid(weightedAvgLogit)).
@@ -238,6 +238,43 @@ func TestConsensusVendorWeighting(t *testing.T) {
 	}
 }
 
+func TestConsensusFittedWeightsPerMarket(t *testing.T) {
+	// Fitted moneyline weights reverse the defaults; totals keep them
+	api.SetVendorWeights(api.VendorWeights{"moneyline": {"DraftKings": 0.7, "BetMGM": 1.5}})
+	t.Cleanup(func() { api.SetVendorWeights(nil) })
+
+	game := api.GameOdds{
+		GameID: 100,
+		Vendors: []api.Vendor{
+			{
+				Name:      "DraftKings",
+				Moneyline: &api.Moneyline{Home: -200, Away: 170},
+				Total:     &api.Total{Line: 220.5, OverOdds: -130, UnderOdds: 110},
+			},
+			{
+				Name:      "BetMGM",
+				Moneyline: &api.Moneyline{Home: -120, Away: 100},
+				Total:     &api.Total{Line: 220.5, OverOdds: 110, UnderOdds: -130},
+			},
+		},
+	}
+	consensus := CalculateConsensus(game)
+
+	dkHome, _ := RemoveVigPowerFromAmerican(-200, 170)
+	mgmHome, _ := RemoveVigPowerFromAmerican(-120, 100)
+	wantHome := mathutil.Sigmoid((mathutil.Logit(dkHome)*0.7 + mathutil.Logit(mgmHome)*1.5) / 2.2)
+	if math.Abs(consensus.Moneyline.HomeTrueProb-wantHome) > 0.001 {
+		t.Errorf("moneyline home = %.4f, want %.4f with fitted weights", consensus.Moneyline.HomeTrueProb, wantHome)
+	}
+
+	dkOver, _ := RemoveVigPowerFromAmerican(-130, 110)
+	mgmOver, _ := RemoveVigPowerFromAmerican(110, -130)
+	wantOver := mathutil.Sigmoid((mathutil.Logit(dkOver)*1.5 + mathutil.Logit(mgmOver)*0.7) / 2.2)
+	if math.Abs(consensus.Total.OverProb-wantOver) > 0.001 {
+		t.Errorf("total over = %.4f, want %.4f with default weights", consensus.Total.OverProb, wantOver)
+	}
+}
+
 func TestSpreadSD(t *testing.T) {
 	// Close game: tighter SD
 	if sd := spreadSD(-2.0); sd != 10.5 {
@@ -0,0 +1,122 @@
+package odds
+
+import (
+	"math"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/mathutil"
+)
+
+// In-play pricing: the rest of a game is modeled as Brownian motion. The
+// pre-game consensus sets the drift (the expected final margin and total
+// at the pre-game pace) and the full-game spread; the part still to be
+// played scales both by the fraction of regulation left. The current
+// score is then certain, so
+//
+//	final margin ~ N(diff + μ·f, σ²·f)
+//	final total  ~ N(points + T·f, σ_T²·f)
+//
+// where f is the fraction of the 48 minutes left, μ and T the pre-game
+// expected margin and total, and σ, σ_T their full-game deviations.
+
+// liveProbBound keeps live probabilities off 0 and 1: the model can't see
+// fouls, injuries or garbage time, so it never prices a game as settled
+// before the final horn.
+const liveProbBound = 0.01
+
+// GameState is a game's score and clock.
+type GameState struct {
+	HomeScore   int
+	AwayScore   int
+	SecondsLeft float64 // Game time remaining; see api.LiveBoxScore.SecondsLeft
+}
+
+// fraction returns the share of regulation left.
+func (s GameState) fraction() float64 {
+	return math.Max(s.SecondsLeft, 0) / api.RegulationSeconds
+}
+
+// PregameMargin returns the expected home margin implied by a pre-game
+// consensus, from the moneyline when there is one and otherwise from the
+// spread. Returns false when neither is priced.
+func PregameMargin(c ConsensusOdds) (float64, bool) {
+	if c.Moneyline != nil && c.Moneyline.HomeTrueProb > 0 && c.Moneyline.HomeTrueProb < 1 {
+		return NBASpreadStdDev * mathutil.NormalInvCDF(c.Moneyline.HomeTrueProb), true
+	}
+	if c.Spread != nil && c.Spread.HomeSpread != 0 && c.Spread.HomeCoverProb > 0 && c.Spread.HomeCoverProb < 1 {
+		// Home covers when margin + HomeSpread > 0
+		return NBASpreadStdDev*mathutil.NormalInvCDF(c.Spread.HomeCoverProb) - c.Spread.HomeSpread, true
+	}
+	return 0, false
+}
+
+// PregameTotal returns the expected total points implied by a pre-game
+// consensus. Returns false when totals aren't priced.
+func PregameTotal(c ConsensusOdds) (float64, bool) {
+	if c.Total == nil || c.Total.Line == 0 || c.Total.OverProb <= 0 || c.Total.OverProb >= 1 {
+		return 0, false
+	}
+	return c.Total.Line + totalSD(c.Total.Line)*mathutil.NormalInvCDF(c.Total.OverProb), true
+}
+
+// liveProb returns P(X > threshold) for X ~ N(mean, sd²), or the settled
+// outcome when nothing is left to play.
+func liveProb(mean, sd, threshold float64) float64 {
+	var p float64
+	switch {
+	case sd > 0:
+		p = mathutil.NormalCDF((mean - threshold) / sd)
+	case mean > threshold:
+		p = 1
+	case mean < threshold:
+		p = 0
+	default:
+		p = 0.5 // Tied at the horn: overtime is a coin flip
+	}
+	return math.Max(liveProbBound, math.Min(1-liveProbBound, p))
+}
+
+// LiveConsensus prices a game in progress from its pre-game consensus and
+// its score and clock. Spreads and totals are priced at the lines in
+// kalshiOdds, which becomes the result's KalshiOdds; markets without a
+// pre-game price or a Kalshi line are left nil. Book counts carry over
+// from the pre-game consensus. The moneyline is priced whenever the
+// pre-game margin is known, even if only the spread implied it.
+func LiveConsensus(pregame ConsensusOdds, state GameState, kalshiOdds *KalshiOdds) ConsensusOdds {
+	live := ConsensusOdds{
+		GameID:     pregame.GameID,
+		GameDate:   pregame.GameDate,
+		HomeTeam:   pregame.HomeTeam,
+		AwayTeam:   pregame.AwayTeam,
+		KalshiOdds: kalshiOdds,
+	}
+	f := state.fraction()
+	diff := float64(state.HomeScore - state.AwayScore)
+
+	if mu, ok := PregameMargin(pregame); ok {
+		mean, sd := diff+mu*f, NBASpreadStdDev*math.Sqrt(f)
+		home := liveProb(mean, sd, 0)
+		live.Moneyline = &MoneylineConsensus{HomeTrueProb: home, AwayTrueProb: 1 - home, BookCount: pregameBooks(pregame)}
+		if pregame.Spread != nil && kalshiOdds != nil && kalshiOdds.Spread != nil {
+			line := kalshiOdds.Spread.HomeSpread
+			cover := liveProb(mean, sd, -line)
+			live.Spread = &SpreadConsensus{HomeSpread: line, HomeCoverProb: cover, AwayCoverProb: 1 - cover, BookCount: pregame.Spread.BookCount}
+		}
+	}
+
+	if total, ok := PregameTotal(pregame); ok && kalshiOdds != nil && kalshiOdds.Total != nil {
+		points := float64(state.HomeScore + state.AwayScore)
+		line := kalshiOdds.Total.Line
+		over := liveProb(points+total*f, totalSD(pregame.Total.Line)*math.Sqrt(f), line)
+		live.Total = &TotalConsensus{Line: line, OverProb: over, UnderProb: 1 - over, BookCount: pregame.Total.BookCount}
+	}
+	return live
+}
+
+// pregameBooks is the book count behind the pre-game margin.
+func pregameBooks(c ConsensusOdds) int {
+	if c.Moneyline != nil {
+		return c.Moneyline.BookCount
+	}
+	return c.Spread.BookCount
+}
@@ -0,0 +1,108 @@
+package odds
+
+import (
+	"math"
+	"testing"
+
+	"sports-betting-bot/internal/api"
+)
+
+// pregameFavorite prices the home team at 65% and the total at 50% over 220.
+func pregameFavorite() ConsensusOdds {
+	return ConsensusOdds{
+		GameID:    1,
+		HomeTeam:  "PHX",
+		AwayTeam:  "GSW",
+		Moneyline: &MoneylineConsensus{HomeTrueProb: 0.65, AwayTrueProb: 0.35, BookCount: 6},
+		Spread:    &SpreadConsensus{HomeSpread: -4.5, HomeCoverProb: 0.5, AwayCoverProb: 0.5, BookCount: 6},
+		Total:     &TotalConsensus{Line: 220, OverProb: 0.5, UnderProb: 0.5, BookCount: 5},
+	}
+}
+
+func TestLiveConsensusAtTipOffMatchesPregame(t *testing.T) {
+	kalshiOdds := &KalshiOdds{
+		Moneyline: &api.Moneyline{Home: 60, Away: 42},
+		Total:     &api.Total{Line: 220, OverOdds: 50, UnderOdds: 52},
+	}
+	live := LiveConsensus(pregameFavorite(), GameState{SecondsLeft: api.RegulationSeconds}, kalshiOdds)
+
+	if math.Abs(live.Moneyline.HomeTrueProb-0.65) > 1e-6 || live.Moneyline.BookCount != 6 {
+		t.Errorf("moneyline = %+v, want the pre-game 65%% from 6 books", live.Moneyline)
+	}
+	if math.Abs(live.Total.OverProb-0.5) > 1e-6 || live.Total.BookCount != 5 {
+		t.Errorf("total = %+v, want the pre-game 50%% from 5 books", live.Total)
+	}
+	if live.Spread != nil {
+		t.Errorf("spread = %+v, want nil without a Kalshi spread line", live.Spread)
+	}
+	if live.KalshiOdds != kalshiOdds {
+		t.Error("KalshiOdds not carried into the live consensus")
+	}
+}
+
+func TestLiveConsensusFollowsScore(t *testing.T) {
+	kalshiOdds := &KalshiOdds{
+		Spread: &api.Spread{HomeSpread: -4.5, HomeOdds: 50, AwaySpread: 4.5, AwayOdds: 50},
+		Total:  &api.Total{Line: 220, OverOdds: 50, UnderOdds: 50},
+	}
+	// Favorite down 10 with a quarter left, 180 points already scored
+	state := GameState{HomeScore: 85, AwayScore: 95, SecondsLeft: api.PeriodSeconds}
+	live := LiveConsensus(pregameFavorite(), state, kalshiOdds)
+
+	// Remaining margin ~ N(4.4/4, (11.5/2)²): P(more than 10) ~ 6%
+	if p := live.Moneyline.HomeTrueProb; p < 0.04 || p > 0.09 {
+		t.Errorf("home win = %.4f, want ~6%%", p)
+	}
+	if p := live.Spread.HomeCoverProb; p >= live.Moneyline.HomeTrueProb {
+		t.Errorf("home -4.5 cover = %.4f, want below the %.4f win probability", p, live.Moneyline.HomeTrueProb)
+	}
+	// 55 expected points to come, SD 17/2: 235 projected against 220
+	if p := live.Total.OverProb; p < 0.94 || p > 0.98 {
+		t.Errorf("over 220 = %.4f, want ~96%%", p)
+	}
+
+	// Later the same deficit is nearly settled, but never priced at 0
+	state.SecondsLeft = 30
+	late := LiveConsensus(pregameFavorite(), state, kalshiOdds)
+	if p := late.Moneyline.HomeTrueProb; p != liveProbBound {
+		t.Errorf("home win down 10 with 30s left = %.4f, want the %.2f floor", p, liveProbBound)
+	}
+}
+
+func TestLiveConsensusAtTheHorn(t *testing.T) {
+	tied := LiveConsensus(pregameFavorite(), GameState{HomeScore: 100, AwayScore: 100}, nil)
+	if tied.Moneyline.HomeTrueProb != 0.5 {
+		t.Errorf("tied at the horn = %.4f, want 0.5 for overtime", tied.Moneyline.HomeTrueProb)
+	}
+	if tied.Total != nil {
+		t.Errorf("total = %+v, want nil without Kalshi odds", tied.Total)
+	}
+
+	// A spread-only pre-game consensus still prices the moneyline
+	pregame := pregameFavorite()
+	pregame.Moneyline = nil
+	won := LiveConsensus(pregame, GameState{HomeScore: 101, AwayScore: 100}, nil)
+	if won.Moneyline == nil || won.Moneyline.HomeTrueProb != 1-liveProbBound || won.Moneyline.BookCount != 6 {
+		t.Errorf("moneyline = %+v, want the home win priced from the spread", won.Moneyline)
+	}
+}
+
+func TestPregameMarginAndTotal(t *testing.T) {
+	if mu, ok := PregameMargin(pregameFavorite()); !ok || math.Abs(mu-4.43) > 0.01 {
+		t.Errorf("PregameMargin = %.2f, %v, want ~4.43 from the 65%% moneyline", mu, ok)
+	}
+	spreadOnly := pregameFavorite()
+	spreadOnly.Moneyline = nil
+	if mu, ok := PregameMargin(spreadOnly); !ok || mu != 4.5 {
+		t.Errorf("PregameMargin = %.2f, %v, want 4.5 from a coin-flip -4.5 spread", mu, ok)
+	}
+	if _, ok := PregameMargin(ConsensusOdds{}); ok {
+		t.Error("PregameMargin priced a game without a moneyline or spread")
+	}
+
+	over := pregameFavorite()
+	over.Total.OverProb = 0.6
+	if total, ok := PregameTotal(over); !ok || total <= 220 {
+		t.Errorf("PregameTotal = %.2f, %v, want above 220 when the over is favored", total, ok)
+	}
+}
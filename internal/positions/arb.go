@@ -0,0 +1,109 @@
+package positions
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// ArbFill is how an arb ended up once its legs were squared: pairs held to
+// settlement, where they pay $1 each whatever the result, and leftover
+// contracts of a leg that filled alone, sold back or still open.
+type ArbFill struct {
+	Event     string  // Ticker, or shared ticker prefix for cross-market arbs
+	Pairs     int     // Contracts held on every leg
+	Cost      float64 // Dollars paid for the pairs, fees included
+	Sold      int     // Leftover contracts sold back
+	UnwindPnL float64 // Realized selling them back
+	Open      int     // Leftover contracts that could not be sold
+}
+
+// LockedPnL is the pairs' profit at settlement.
+func (f ArbFill) LockedPnL() float64 {
+	return float64(f.Pairs) - f.Cost
+}
+
+// PnL is the arb's realized result: the locked profit plus the unwind.
+func (f ArbFill) PnL() float64 {
+	return f.LockedPnL() + f.UnwindPnL
+}
+
+// RecordArbLegs stores the fills of an arb's legs, one journal entry and
+// update per leg. The contracts every leg filled are stored locked,
+// each leg after the first with hedge_of pointing at the first, and settle
+// on their own tickers. A leg's fills beyond that are stored unlocked for
+// the caller to unwind. Each entry is linked to its leg's first row.
+// Returns the matched contract count and, per leg, the ID of the row
+// holding its excess (0 for none).
+func (d *DB) RecordArbLegs(legs []JournalEntry, updates []OrderUpdate) (int, []int64, error) {
+	if len(legs) != len(updates) {
+		return 0, nil, fmt.Errorf("%d arb legs with %d updates", len(legs), len(updates))
+	}
+	matched := -1
+	for _, u := range updates {
+		if matched < 0 || u.Filled < matched {
+			matched = u.Filled
+		}
+	}
+	matched = max(matched, 0)
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	excess := make([]int64, len(legs))
+	var firstID int64
+	for i, j := range legs {
+		u := updates[i]
+		var legID int64
+		if u.Filled > 0 {
+			pos := j.Position(u.Filled, u.AvgPrice, u.Fees)
+			if matched > 0 {
+				locked := pos
+				locked.Contracts = matched
+				locked.Fees = pos.Fees * float64(matched) / float64(u.Filled)
+				locked.HedgeOf = firstID
+				locked.Locked = true
+				if legID, err = insertPosition(tx, locked); err != nil {
+					return 0, nil, err
+				}
+				if firstID == 0 {
+					firstID = legID
+				}
+				pos.Contracts -= matched
+				pos.Fees -= locked.Fees
+			}
+			if pos.Contracts > 0 {
+				id, err := insertPosition(tx, pos)
+				if err != nil {
+					return 0, nil, err
+				}
+				excess[i] = id
+				if legID == 0 {
+					legID = id
+				}
+			}
+		}
+		if err := updateJournal(tx, u, legID); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, fmt.Errorf("committing arb legs: %w", err)
+	}
+	return matched, excess, nil
+}
+
+// insertPosition stores pos inside tx.
+func insertPosition(tx *sql.Tx, pos Position) (int64, error) {
+	result, err := tx.Exec(`
+		INSERT INTO positions (game_id, home_team, away_team, market_type, side, ticker, bet_side, entry_price, contracts, fees, hedge_of, locked)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, pos.GameID, pos.HomeTeam, pos.AwayTeam, pos.MarketType, pos.Side, pos.Ticker, pos.BetSide, pos.EntryPrice, pos.Contracts, pos.Fees, pos.HedgeOf, pos.Locked)
+	if err != nil {
+		return 0, fmt.Errorf("inserting position: %w", err)
+	}
+	return result.LastInsertId()
+}
@@ -0,0 +1,63 @@
+package positions
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRecordArbLegsLocksMatchedContracts(t *testing.T) {
+	db := newTestDB(t)
+	legs := []JournalEntry{
+		{ClientOrderID: "base-0", Kind: OrderKindArb, GameID: "7", MarketType: "total", Side: "over",
+			Ticker: "KXNBATOTAL-26FEB05GSWPHX-220", BetSide: "yes", Action: "buy", Contracts: 20},
+		{ClientOrderID: "base-1", Kind: OrderKindArb, GameID: "7", MarketType: "total", Side: "under",
+			Ticker: "KXNBATOTAL-26FEB05GSWPHX-225", BetSide: "no", Action: "buy", Contracts: 20},
+	}
+	for _, j := range legs {
+		if err := db.RecordIntent(j); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The first leg filled 20, the second 12: 8 are left over on the first
+	updates := []OrderUpdate{
+		{ClientOrderID: "base-0", OrderID: "ord-0", Status: JournalExecuted, Filled: 20, AvgPrice: 40, Fees: 0.40},
+		{ClientOrderID: "base-1", OrderID: "ord-1", Status: JournalCanceled, Filled: 12, AvgPrice: 45, Fees: 0.24},
+	}
+	matched, excess, err := db.RecordArbLegs(legs, updates)
+	if err != nil || matched != 12 {
+		t.Fatalf("RecordArbLegs = %d, %v, want 12 matched", matched, err)
+	}
+
+	open, _ := db.GetAllPositions()
+	if len(open) != 3 {
+		t.Fatalf("open = %+v, want two locked legs and one free row", open)
+	}
+	var first, second, free *Position
+	for i := range open {
+		switch p := &open[i]; {
+		case !p.Locked:
+			free = p
+		case p.HedgeOf == 0:
+			first = p
+		default:
+			second = p
+		}
+	}
+	if first == nil || first.Contracts != 12 || first.Side != "over" || math.Abs(first.Fees-0.24) > 1e-9 {
+		t.Errorf("first leg = %+v, want 12 locked over contracts carrying $0.24 of fees", first)
+	}
+	if second == nil || second.HedgeOf != first.ID || second.Contracts != 12 || second.EntryPrice != 0.45 || second.Side != "under" {
+		t.Errorf("second leg = %+v, want 12 locked under contracts at 0.45 tied to the first", second)
+	}
+	if free == nil || free.Ticker != legs[0].Ticker || free.Contracts != 8 || math.Abs(free.Fees-0.16) > 1e-9 {
+		t.Errorf("free = %+v, want the 8 excess contracts of the first leg", free)
+	}
+	if free != nil && (excess[0] != free.ID || excess[1] != 0) {
+		t.Errorf("excess = %v, want [%d 0]", excess, free.ID)
+	}
+
+	if j, _ := db.GetJournalEntry("base-1"); j == nil || j.Status != JournalCanceled || j.PositionID != second.ID {
+		t.Errorf("entry = %+v, want canceled and linked to the second leg", j)
+	}
+}
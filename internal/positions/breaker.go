@@ -0,0 +1,67 @@
+package positions
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// BreakerState is the persisted state of the daily loss circuit breaker.
+// A tripped breaker stays tripped across restarts and new days until it is
+// explicitly reset.
+type BreakerState struct {
+	Day          string  // Trading day (ET) the start values belong to, "2006-01-02"
+	StartBalance float64 // Cash balance at the first check of Day
+	StartEquity  float64 // Balance plus open position value at the first check of Day
+	PeakEquity   float64 // Highest equity seen since the last reset
+	Tripped      bool
+	Reason       string
+	TrippedAt    time.Time
+}
+
+// GetBreakerState returns the breaker state, or the zero state if the
+// breaker has never run or was reset.
+func (d *DB) GetBreakerState() (BreakerState, error) {
+	var s BreakerState
+	var trippedAt sql.NullTime
+	err := d.db.QueryRow(`
+		SELECT day, start_balance, start_equity, peak_equity, tripped, reason, tripped_at
+		FROM breaker_state WHERE id = 1
+	`).Scan(&s.Day, &s.StartBalance, &s.StartEquity, &s.PeakEquity, &s.Tripped, &s.Reason, &trippedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return BreakerState{}, nil
+	}
+	if err != nil {
+		return BreakerState{}, fmt.Errorf("querying breaker state: %w", err)
+	}
+	if trippedAt.Valid {
+		s.TrippedAt = trippedAt.Time
+	}
+	return s, nil
+}
+
+// SaveBreakerState stores the breaker state, replacing any previous state.
+func (d *DB) SaveBreakerState(s BreakerState) error {
+	var trippedAt any
+	if !s.TrippedAt.IsZero() {
+		trippedAt = s.TrippedAt.UTC()
+	}
+	_, err := d.db.Exec(`
+		INSERT OR REPLACE INTO breaker_state (id, day, start_balance, start_equity, peak_equity, tripped, reason, tripped_at)
+		VALUES (1, ?, ?, ?, ?, ?, ?, ?)
+	`, s.Day, s.StartBalance, s.StartEquity, s.PeakEquity, s.Tripped, s.Reason, trippedAt)
+	if err != nil {
+		return fmt.Errorf("saving breaker state: %w", err)
+	}
+	return nil
+}
+
+// ResetBreaker clears the breaker, including its start-of-day and peak
+// values, so the next check starts from the current balance.
+func (d *DB) ResetBreaker() error {
+	if _, err := d.db.Exec("DELETE FROM breaker_state"); err != nil {
+		return fmt.Errorf("resetting breaker: %w", err)
+	}
+	return nil
+}
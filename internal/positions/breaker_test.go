@@ -0,0 +1,40 @@
+package positions
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreakerStateRoundTrip(t *testing.T) {
+	db := newTestDB(t)
+
+	if s, err := db.GetBreakerState(); err != nil || s != (BreakerState{}) {
+		t.Fatalf("fresh state = %+v, %v, want zero", s, err)
+	}
+
+	tripped := BreakerState{
+		Day: "2026-02-05", StartBalance: 1000, StartEquity: 1000, PeakEquity: 1020,
+		Tripped: true, Reason: "daily loss", TrippedAt: time.Date(2026, 2, 5, 20, 0, 0, 0, time.UTC),
+	}
+	if err := db.SaveBreakerState(tripped); err != nil {
+		t.Fatal(err)
+	}
+	got, err := db.GetBreakerState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.TrippedAt.Equal(tripped.TrippedAt) {
+		t.Errorf("TrippedAt = %v, want %v", got.TrippedAt, tripped.TrippedAt)
+	}
+	got.TrippedAt = tripped.TrippedAt
+	if got != tripped {
+		t.Errorf("state = %+v, want %+v", got, tripped)
+	}
+
+	if err := db.ResetBreaker(); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := db.GetBreakerState(); s.Tripped || s.Day != "" {
+		t.Errorf("state after reset = %+v, want zero", s)
+	}
+}
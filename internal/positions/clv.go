@@ -0,0 +1,270 @@
+package positions
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ClosingLine pairs the entry on a ticker the bot alerted on or traded with
+// the consensus and Kalshi price at its last scan before tip-off.
+type ClosingLine struct {
+	Ticker       string
+	BetSide      string // "yes" or "no"
+	GameID       string
+	MarketType   string  // "moneyline", "spread", "total", "prop_points", etc.
+	Side         string  // "home", "away", "over", "under", or player-specific
+	EntryProb    float64 // Consensus probability at the first alert; 0 when only traded
+	EntryPrice   float64 // Kalshi price at the first alert, or the fill price (0-1)
+	EntryEV      float64 // Adjusted EV at the first alert
+	BookCount    int     // Books behind the entry consensus; 0 when unknown
+	ClosingProb  float64 // Consensus probability at the last scan before tip-off
+	ClosingPrice float64 // Kalshi ask at the last scan before tip-off (0-1)
+	ClosedAt     time.Time
+	FillPrice    float64 // Average fill price of positions on the ticker+side; 0 if never traded
+	CreatedAt    time.Time
+}
+
+// Closed reports whether a pre-game scan has priced the line since entry.
+func (l ClosingLine) Closed() bool {
+	return !l.ClosedAt.IsZero()
+}
+
+// RecordEntry stores the entry for a ticker+side. Only the first entry is
+// kept: later alerts on the same ticker+side are no-ops.
+func (d *DB) RecordEntry(l ClosingLine) error {
+	_, err := d.db.Exec(`
+		INSERT OR IGNORE INTO closing_lines (ticker, bet_side, game_id, market_type, side, entry_prob, entry_price, entry_ev, book_count)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, l.Ticker, l.BetSide, l.GameID, l.MarketType, l.Side, l.EntryProb, l.EntryPrice, l.EntryEV, l.BookCount)
+	if err != nil {
+		return fmt.Errorf("inserting closing line entry: %w", err)
+	}
+	return nil
+}
+
+// RecordClose overwrites the closing consensus and price of a ticker+side,
+// so the last call before tip-off is the close.
+func (d *DB) RecordClose(ticker, betSide string, prob, price float64, at time.Time) error {
+	_, err := d.db.Exec(`
+		UPDATE closing_lines SET closing_prob = ?, closing_price = ?, closed_at = ?
+		WHERE ticker = ? AND bet_side = ?
+	`, prob, price, at.UTC(), ticker, betSide)
+	if err != nil {
+		return fmt.Errorf("updating closing line: %w", err)
+	}
+	return nil
+}
+
+const closingLineColumns = `c.ticker, c.bet_side, c.game_id, c.market_type, c.side, c.entry_prob, c.entry_price,
+		c.entry_ev, c.book_count, c.closing_prob, c.closing_price, c.closed_at, c.created_at`
+
+// GetClosingLinesByGame retrieves the closing lines of a game. FillPrice is
+// not filled in.
+func (d *DB) GetClosingLinesByGame(gameID string) ([]ClosingLine, error) {
+	rows, err := d.db.Query(`
+		SELECT `+closingLineColumns+`, 0
+		FROM closing_lines c
+		WHERE c.game_id = ?
+		ORDER BY c.ticker, c.bet_side
+	`, gameID)
+	if err != nil {
+		return nil, fmt.Errorf("querying closing lines by game: %w", err)
+	}
+	return scanClosingLines(rows)
+}
+
+// GetClosingLines retrieves every closing line, oldest first, with the
+// average fill price of directional positions on its ticker+side. Settled
+// positions count: a fill's CLV is fixed before the game starts.
+func (d *DB) GetClosingLines() ([]ClosingLine, error) {
+	rows, err := d.db.Query(`
+		SELECT ` + closingLineColumns + `, COALESCE(f.price, 0)
+		FROM closing_lines c
+		LEFT JOIN (
+			SELECT ticker, bet_side, SUM(entry_price * contracts) / SUM(contracts) AS price
+			FROM positions
+			WHERE locked = 0 AND hedge_of = 0 AND market_type NOT LIKE 'arb_%' AND contracts > 0
+			GROUP BY ticker, bet_side
+		) f ON f.ticker = c.ticker AND f.bet_side = c.bet_side
+		ORDER BY c.created_at, c.ticker, c.bet_side
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying closing lines: %w", err)
+	}
+	return scanClosingLines(rows)
+}
+
+func scanClosingLines(rows *sql.Rows) ([]ClosingLine, error) {
+	defer rows.Close()
+
+	var lines []ClosingLine
+	for rows.Next() {
+		var l ClosingLine
+		var closedAt sql.NullTime
+		if err := rows.Scan(&l.Ticker, &l.BetSide, &l.GameID, &l.MarketType, &l.Side,
+			&l.EntryProb, &l.EntryPrice, &l.EntryEV, &l.BookCount,
+			&l.ClosingProb, &l.ClosingPrice, &closedAt, &l.CreatedAt, &l.FillPrice); err != nil {
+			return nil, fmt.Errorf("scanning closing line row: %w", err)
+		}
+		if closedAt.Valid {
+			l.ClosedAt = closedAt.Time
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+// CLV summary dimensions.
+const (
+	CLVByMarket = "market" // Market type: "moneyline", "prop_points", ...
+	CLVByBooks  = "books"  // Book count bucket
+	CLVByProp   = "prop"   // Prop type, with every game market as "game"
+	CLVByEV     = "ev"     // Adjusted EV bucket at entry
+)
+
+// CLVDimensions lists the dimensions SummarizeCLV accepts.
+var CLVDimensions = []string{CLVByMarket, CLVByBooks, CLVByProp, CLVByEV}
+
+// CLVSummary aggregates the closing-line value of a group of entries.
+// CLV is measured in cents per contract against the entry price: price CLV
+// against the closing Kalshi ask, consensus CLV against the closing
+// consensus probability. Positive means the entry beat the close.
+type CLVSummary struct {
+	Group        string
+	Entries      int     // Entries in the group
+	Closed       int     // Entries with a recorded close
+	Beat         int     // Closed entries priced below the closing consensus
+	PriceCLV     float64 // Sum over closed entries, in cents
+	ConsensusCLV float64 // Sum over closed entries, in cents
+	Fills        int     // Closed entries that were traded
+	FillCLV      float64 // Consensus CLV of the fill prices, summed, in cents
+}
+
+// AvgPriceCLV returns the mean price CLV in cents per contract.
+func (s CLVSummary) AvgPriceCLV() float64 {
+	return avg(s.PriceCLV, s.Closed)
+}
+
+// AvgConsensusCLV returns the mean consensus CLV in cents per contract.
+func (s CLVSummary) AvgConsensusCLV() float64 {
+	return avg(s.ConsensusCLV, s.Closed)
+}
+
+// AvgFillCLV returns the mean consensus CLV of traded entries at their
+// fill price, in cents per contract.
+func (s CLVSummary) AvgFillCLV() float64 {
+	return avg(s.FillCLV, s.Fills)
+}
+
+// BeatRate returns the fraction of closed entries that beat the closing
+// consensus.
+func (s CLVSummary) BeatRate() float64 {
+	return avg(float64(s.Beat), s.Closed)
+}
+
+func avg(sum float64, n int) float64 {
+	if n == 0 {
+		return 0
+	}
+	return sum / float64(n)
+}
+
+// SummarizeCLV groups closing lines along one of CLVDimensions, sorted by
+// group, followed by an "all" row.
+func SummarizeCLV(lines []ClosingLine, by string) ([]CLVSummary, error) {
+	var group func(ClosingLine) string
+	switch by {
+	case CLVByMarket:
+		group = func(l ClosingLine) string { return l.MarketType }
+	case CLVByBooks:
+		group = func(l ClosingLine) string { return BookBucket(l.BookCount) }
+	case CLVByProp:
+		group = func(l ClosingLine) string { return PropGroup(l.MarketType) }
+	case CLVByEV:
+		group = evBucket
+	default:
+		return nil, fmt.Errorf("unknown CLV dimension %q (want one of %s)", by, strings.Join(CLVDimensions, ", "))
+	}
+
+	groups := make(map[string]*CLVSummary)
+	total := &CLVSummary{Group: "all"}
+	for _, l := range lines {
+		key := group(l)
+		s, ok := groups[key]
+		if !ok {
+			s = &CLVSummary{Group: key}
+			groups[key] = s
+		}
+		for _, s := range []*CLVSummary{s, total} {
+			s.add(l)
+		}
+	}
+
+	summaries := make([]CLVSummary, 0, len(groups)+1)
+	for _, s := range groups {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Group < summaries[j].Group
+	})
+	return append(summaries, *total), nil
+}
+
+func (s *CLVSummary) add(l ClosingLine) {
+	s.Entries++
+	if !l.Closed() {
+		return
+	}
+	s.Closed++
+	s.PriceCLV += (l.ClosingPrice - l.EntryPrice) * 100
+	s.ConsensusCLV += (l.ClosingProb - l.EntryPrice) * 100
+	if l.ClosingProb > l.EntryPrice {
+		s.Beat++
+	}
+	if l.FillPrice > 0 {
+		s.Fills++
+		s.FillCLV += (l.ClosingProb - l.FillPrice) * 100
+	}
+}
+
+// BookBucket groups book counts around the analysis thresholds: below the
+// default minimum of 4, shrunk toward Kalshi below 6, and full weight.
+func BookBucket(n int) string {
+	switch {
+	case n <= 0:
+		return "unknown"
+	case n < 4:
+		return "1-3"
+	case n < 6:
+		return "4-5"
+	default:
+		return "6+"
+	}
+}
+
+// evBucket groups entries by their adjusted EV at the first alert.
+func evBucket(l ClosingLine) string {
+	switch ev := l.EntryEV; {
+	case l.EntryProb == 0:
+		return "unknown"
+	case ev < 0.03:
+		return "<3%"
+	case ev < 0.05:
+		return "3-5%"
+	case ev < 0.10:
+		return "5-10%"
+	default:
+		return "10%+"
+	}
+}
+
+// PropGroup is the prop type of a prop market type, or "game" for the rest.
+func PropGroup(marketType string) string {
+	if propType, ok := strings.CutPrefix(marketType, "prop_"); ok {
+		return propType
+	}
+	return "game"
+}
@@ -0,0 +1,122 @@
+package positions
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestClosingLinesKeepFirstEntryAndLastClose(t *testing.T) {
+	db := newTestDB(t)
+	ticker := "KXNBAGAME-26FEB05GSWPHX"
+	entry := ClosingLine{
+		Ticker: ticker, BetSide: "yes", GameID: "7", MarketType: "moneyline", Side: "home",
+		EntryProb: 0.60, EntryPrice: 0.52, EntryEV: 0.06, BookCount: 6,
+	}
+	if err := db.RecordEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+	later := entry
+	later.EntryPrice = 0.55
+	if err := db.RecordEntry(later); err != nil {
+		t.Fatal(err)
+	}
+
+	tip := time.Date(2026, 2, 5, 0, 0, 0, 0, time.UTC)
+	for i, price := range []float64{0.54, 0.57} {
+		if err := db.RecordClose(ticker, "yes", 0.58, price, tip.Add(time.Duration(i)*time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Fills at 0.50 and 0.53
+	for _, p := range []Position{
+		{GameID: "7", MarketType: "moneyline", Side: "home", Ticker: ticker, BetSide: "yes", EntryPrice: 0.50, Contracts: 10},
+		{GameID: "7", MarketType: "moneyline", Side: "home", Ticker: ticker, BetSide: "yes", EntryPrice: 0.53, Contracts: 20},
+	} {
+		if _, err := db.AddPosition(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lines, err := db.GetClosingLines()
+	if err != nil || len(lines) != 1 {
+		t.Fatalf("GetClosingLines = %+v, %v, want one line", lines, err)
+	}
+	l := lines[0]
+	if l.EntryPrice != 0.52 || l.ClosingPrice != 0.57 || !l.ClosedAt.Equal(tip.Add(time.Minute)) {
+		t.Errorf("line = %+v, want the first entry at 0.52 and the last close at 0.57", l)
+	}
+	if math.Abs(l.FillPrice-0.52) > 1e-9 {
+		t.Errorf("FillPrice = %v, want the 0.52 contract-weighted average", l.FillPrice)
+	}
+
+	byGame, err := db.GetClosingLinesByGame("7")
+	if err != nil || len(byGame) != 1 || byGame[0].Ticker != ticker {
+		t.Errorf("GetClosingLinesByGame = %+v, %v, want the line", byGame, err)
+	}
+}
+
+func TestSummarizeCLV(t *testing.T) {
+	closed := time.Date(2026, 2, 5, 0, 0, 0, 0, time.UTC)
+	lines := []ClosingLine{
+		// Beat the close by 6¢ of consensus, the price moved 4¢ our way
+		{MarketType: "moneyline", EntryProb: 0.60, EntryPrice: 0.52, EntryEV: 0.06, BookCount: 6,
+			ClosingProb: 0.58, ClosingPrice: 0.56, ClosedAt: closed, FillPrice: 0.50},
+		// Lost 4¢ to the close
+		{MarketType: "prop_points", EntryProb: 0.50, EntryPrice: 0.45, EntryEV: 0.04, BookCount: 4,
+			ClosingProb: 0.41, ClosingPrice: 0.43, ClosedAt: closed},
+		// Traded before alerts were recorded, never priced again
+		{MarketType: "prop_points", EntryPrice: 0.30},
+	}
+
+	byMarket, err := SummarizeCLV(lines, CLVByMarket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(byMarket) != 3 || byMarket[0].Group != "moneyline" || byMarket[2].Group != "all" {
+		t.Fatalf("groups = %+v, want moneyline, prop_points, all", byMarket)
+	}
+	all := byMarket[2]
+	if all.Entries != 3 || all.Closed != 2 || all.Beat != 1 || all.Fills != 1 {
+		t.Errorf("all = %+v, want 3 entries, 2 closed, 1 beat, 1 fill", all)
+	}
+	if math.Abs(all.AvgConsensusCLV()-1) > 1e-9 || math.Abs(all.AvgPriceCLV()-1) > 1e-9 {
+		t.Errorf("avg CLV = %.2f¢ consensus, %.2f¢ price, want 1¢ each", all.AvgConsensusCLV(), all.AvgPriceCLV())
+	}
+	if math.Abs(all.AvgFillCLV()-8) > 1e-9 {
+		t.Errorf("fill CLV = %.2f¢, want 8¢", all.AvgFillCLV())
+	}
+
+	tests := []struct {
+		by   string
+		want []string
+	}{
+		{CLVByBooks, []string{"4-5", "6+", "unknown", "all"}},
+		{CLVByProp, []string{"game", "points", "all"}},
+		{CLVByEV, []string{"3-5%", "5-10%", "unknown", "all"}},
+	}
+	for _, tt := range tests {
+		got, err := SummarizeCLV(lines, tt.by)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var groups []string
+		for _, s := range got {
+			groups = append(groups, s.Group)
+		}
+		if len(groups) != len(tt.want) {
+			t.Errorf("by %s = %v, want %v", tt.by, groups, tt.want)
+			continue
+		}
+		for i := range groups {
+			if groups[i] != tt.want[i] {
+				t.Errorf("by %s = %v, want %v", tt.by, groups, tt.want)
+				break
+			}
+		}
+	}
+
+	if _, err := SummarizeCLV(lines, "team"); err == nil {
+		t.Error("SummarizeCLV accepted an unknown dimension")
+	}
+}
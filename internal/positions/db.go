@@ -3,6 +3,7 @@ package positions
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
@@ -20,9 +21,21 @@ type Position struct {
 	BetSide    string // "yes" or "no" for duplicate prevention
 	EntryPrice float64
 	Contracts  int
+	Fees       float64 // Taker fees paid at entry, in dollars
+	HedgeOf    int64   // Position this row hedges, for the hedge leg of a locked pair
+	Locked     bool    // Part of a hedged pair, which pays $1 per contract either way
 	CreatedAt  time.Time
 }
 
+// Cost returns the dollars paid for the position, excluding fees.
+// Arb rows store their total spend in EntryPrice rather than a per-contract price.
+func (p Position) Cost() float64 {
+	if strings.HasPrefix(p.MarketType, "arb_") {
+		return p.EntryPrice
+	}
+	return p.EntryPrice * float64(p.Contracts)
+}
+
 // DB handles position storage
 type DB struct {
 	db *sql.DB
@@ -56,12 +69,153 @@ func createTables(db *sql.DB) error {
 		bet_side TEXT DEFAULT '',
 		entry_price REAL NOT NULL,
 		contracts INTEGER NOT NULL,
+		fees REAL DEFAULT 0,
+		hedge_of INTEGER NOT NULL DEFAULT 0,
+		locked INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS settlements (
+		position_id INTEGER PRIMARY KEY REFERENCES positions(id),
+		ticker TEXT NOT NULL,
+		result TEXT NOT NULL,
+		payout REAL NOT NULL,
+		fees REAL NOT NULL,
+		realized_pnl REAL NOT NULL,
+		settled_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS breaker_state (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		day TEXT NOT NULL,
+		start_balance REAL NOT NULL,
+		start_equity REAL NOT NULL,
+		peak_equity REAL NOT NULL,
+		tripped INTEGER NOT NULL DEFAULT 0,
+		reason TEXT NOT NULL DEFAULT '',
+		tripped_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS orders (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		order_id TEXT NOT NULL UNIQUE,
+		client_order_id TEXT NOT NULL DEFAULT '',
+		game_id TEXT NOT NULL,
+		home_team TEXT NOT NULL,
+		away_team TEXT NOT NULL,
+		market_type TEXT NOT NULL,
+		side TEXT NOT NULL,
+		ticker TEXT NOT NULL,
+		bet_side TEXT NOT NULL,
+		limit_price INTEGER NOT NULL,
+		contracts INTEGER NOT NULL,
+		filled INTEGER NOT NULL DEFAULT 0,
+		fees REAL NOT NULL DEFAULT 0,
+		true_prob REAL NOT NULL DEFAULT 0,
+		starts_at TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS order_journal (
+		client_order_id TEXT PRIMARY KEY,
+		kind TEXT NOT NULL,
+		order_id TEXT NOT NULL DEFAULT '',
+		game_id TEXT NOT NULL,
+		home_team TEXT NOT NULL,
+		away_team TEXT NOT NULL,
+		market_type TEXT NOT NULL,
+		side TEXT NOT NULL,
+		ticker TEXT NOT NULL,
+		bet_side TEXT NOT NULL,
+		action TEXT NOT NULL,
+		contracts INTEGER NOT NULL,
+		limit_price INTEGER NOT NULL DEFAULT 0,
+		true_prob REAL NOT NULL DEFAULT 0,
+		starts_at TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		filled INTEGER NOT NULL DEFAULT 0,
+		avg_price REAL NOT NULL DEFAULT 0,
+		fees REAL NOT NULL DEFAULT 0,
+		position_id INTEGER NOT NULL DEFAULT 0,
+		hedge_of INTEGER NOT NULL DEFAULT 0,
+		detail TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS order_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		client_order_id TEXT NOT NULL REFERENCES order_journal(client_order_id),
+		status TEXT NOT NULL,
+		filled INTEGER NOT NULL DEFAULT 0,
+		detail TEXT NOT NULL DEFAULT '',
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
+	CREATE TABLE IF NOT EXISTS closing_lines (
+		ticker TEXT NOT NULL,
+		bet_side TEXT NOT NULL,
+		game_id TEXT NOT NULL,
+		market_type TEXT NOT NULL,
+		side TEXT NOT NULL,
+		entry_prob REAL NOT NULL DEFAULT 0,
+		entry_price REAL NOT NULL,
+		entry_ev REAL NOT NULL DEFAULT 0,
+		book_count INTEGER NOT NULL DEFAULT 0,
+		closing_prob REAL NOT NULL DEFAULT 0,
+		closing_price REAL NOT NULL DEFAULT 0,
+		closed_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (ticker, bet_side)
+	);
+
+	CREATE TABLE IF NOT EXISTS alert_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		kind TEXT NOT NULL,
+		game_id TEXT NOT NULL,
+		ticker TEXT NOT NULL,
+		bet_side TEXT NOT NULL,
+		market_type TEXT NOT NULL,
+		side TEXT NOT NULL,
+		true_prob REAL NOT NULL DEFAULT 0,
+		kalshi_price REAL NOT NULL DEFAULT 0,
+		book_count INTEGER NOT NULL DEFAULT 0,
+		adjusted_ev REAL NOT NULL DEFAULT 0,
+		kelly_stake REAL NOT NULL DEFAULT 0,
+		position_id INTEGER NOT NULL DEFAULT 0,
+		guaranteed_profit REAL NOT NULL DEFAULT 0,
+		result TEXT NOT NULL DEFAULT '',
+		suppressed INTEGER NOT NULL DEFAULT 0,
+		alerted_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS predictions (
+		ticker TEXT PRIMARY KEY,
+		game_id TEXT NOT NULL,
+		market_type TEXT NOT NULL,
+		line REAL NOT NULL DEFAULT 0,
+		prob REAL NOT NULL,
+		book_count INTEGER NOT NULL DEFAULT 0,
+		predicted_at DATETIME NOT NULL,
+		result TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE IF NOT EXISTS pregame_consensus (
+		game_id INTEGER PRIMARY KEY,
+		consensus TEXT NOT NULL,
+		scanned_at DATETIME NOT NULL
+	);
+
 	CREATE INDEX IF NOT EXISTS idx_positions_game ON positions(game_id);
 	CREATE INDEX IF NOT EXISTS idx_positions_active ON positions(market_type, side);
 	CREATE INDEX IF NOT EXISTS idx_positions_ticker ON positions(ticker, bet_side);
+	CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status, ticker, bet_side);
+	CREATE INDEX IF NOT EXISTS idx_journal_status ON order_journal(status, ticker, bet_side);
+	CREATE INDEX IF NOT EXISTS idx_order_events_client ON order_events(client_order_id);
+	CREATE INDEX IF NOT EXISTS idx_closing_lines_game ON closing_lines(game_id);
+	CREATE INDEX IF NOT EXISTS idx_alert_history_ticker ON alert_history(ticker, bet_side);
 	`
 
 	_, err := db.Exec(schema)
@@ -73,6 +227,11 @@ func createTables(db *sql.DB) error {
 	migrations := []string{
 		"ALTER TABLE positions ADD COLUMN ticker TEXT DEFAULT ''",
 		"ALTER TABLE positions ADD COLUMN bet_side TEXT DEFAULT ''",
+		"ALTER TABLE positions ADD COLUMN fees REAL DEFAULT 0",
+		"ALTER TABLE positions ADD COLUMN hedge_of INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE positions ADD COLUMN locked INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE order_journal ADD COLUMN hedge_of INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE alert_history ADD COLUMN suppressed INTEGER NOT NULL DEFAULT 0",
 	}
 
 	for _, migration := range migrations {
@@ -91,9 +250,9 @@ func (d *DB) Close() error {
 // AddPosition adds a new position
 func (d *DB) AddPosition(pos Position) (int64, error) {
 	result, err := d.db.Exec(`
-		INSERT INTO positions (game_id, home_team, away_team, market_type, side, ticker, bet_side, entry_price, contracts)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, pos.GameID, pos.HomeTeam, pos.AwayTeam, pos.MarketType, pos.Side, pos.Ticker, pos.BetSide, pos.EntryPrice, pos.Contracts)
+		INSERT INTO positions (game_id, home_team, away_team, market_type, side, ticker, bet_side, entry_price, contracts, fees)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, pos.GameID, pos.HomeTeam, pos.AwayTeam, pos.MarketType, pos.Side, pos.Ticker, pos.BetSide, pos.EntryPrice, pos.Contracts, pos.Fees)
 	if err != nil {
 		return 0, fmt.Errorf("inserting position: %w", err)
 	}
@@ -116,16 +275,28 @@ func (d *DB) HasPositionOnTicker(ticker, betSide string) (bool, error) {
 	return count > 0, nil
 }
 
+// IsTickerLocked reports whether an open hedged pair holds ticker. A locked
+// ticker takes no further bets on either side.
+func (d *DB) IsTickerLocked(ticker string) (bool, error) {
+	var count int
+	err := d.db.QueryRow(`
+		SELECT COUNT(*) FROM positions
+		WHERE ticker = ? AND locked = 1 AND id NOT IN (SELECT position_id FROM settlements)
+	`, ticker).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("checking locked ticker: %w", err)
+	}
+	return count > 0, nil
+}
+
 // GetPosition retrieves a position by ID
 func (d *DB) GetPosition(id int64) (*Position, error) {
 	row := d.db.QueryRow(`
-		SELECT id, game_id, home_team, away_team, market_type, side, entry_price, contracts, created_at
+		SELECT `+positionColumns+`
 		FROM positions WHERE id = ?
 	`, id)
 
-	var pos Position
-	err := row.Scan(&pos.ID, &pos.GameID, &pos.HomeTeam, &pos.AwayTeam,
-		&pos.MarketType, &pos.Side, &pos.EntryPrice, &pos.Contracts, &pos.CreatedAt)
+	pos, err := scanPosition(row)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -136,23 +307,30 @@ func (d *DB) GetPosition(id int64) (*Position, error) {
 	return &pos, nil
 }
 
-// GetAllPositions retrieves all positions
-func (d *DB) GetAllPositions() ([]Position, error) {
-	rows, err := d.db.Query(`
-		SELECT id, game_id, home_team, away_team, market_type, side, entry_price, contracts, created_at
-		FROM positions
-		ORDER BY created_at DESC
-	`)
-	if err != nil {
-		return nil, fmt.Errorf("querying positions: %w", err)
-	}
+// positionColumns is the column list scanPosition expects.
+const positionColumns = `id, game_id, home_team, away_team, market_type, side, ticker, bet_side,
+		entry_price, contracts, fees, hedge_of, locked, created_at`
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPosition(row rowScanner) (Position, error) {
+	var pos Position
+	err := row.Scan(&pos.ID, &pos.GameID, &pos.HomeTeam, &pos.AwayTeam,
+		&pos.MarketType, &pos.Side, &pos.Ticker, &pos.BetSide,
+		&pos.EntryPrice, &pos.Contracts, &pos.Fees, &pos.HedgeOf, &pos.Locked, &pos.CreatedAt)
+	return pos, err
+}
+
+func scanPositions(rows *sql.Rows) ([]Position, error) {
 	defer rows.Close()
 
 	var positions []Position
 	for rows.Next() {
-		var pos Position
-		if err := rows.Scan(&pos.ID, &pos.GameID, &pos.HomeTeam, &pos.AwayTeam,
-			&pos.MarketType, &pos.Side, &pos.EntryPrice, &pos.Contracts, &pos.CreatedAt); err != nil {
+		pos, err := scanPosition(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scanning position row: %w", err)
 		}
 		positions = append(positions, pos)
@@ -161,34 +339,39 @@ func (d *DB) GetAllPositions() ([]Position, error) {
 	return positions, rows.Err()
 }
 
-// GetPositionsByGame retrieves positions for a specific game
+// GetAllPositions retrieves all open positions. Settled positions are excluded.
+func (d *DB) GetAllPositions() ([]Position, error) {
+	rows, err := d.db.Query(`
+		SELECT ` + positionColumns + `
+		FROM positions
+		WHERE id NOT IN (SELECT position_id FROM settlements)
+		ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying positions: %w", err)
+	}
+	return scanPositions(rows)
+}
+
+// GetPositionsByGame retrieves open positions for a specific game
 func (d *DB) GetPositionsByGame(gameID string) ([]Position, error) {
 	rows, err := d.db.Query(`
-		SELECT id, game_id, home_team, away_team, market_type, side, entry_price, contracts, created_at
+		SELECT `+positionColumns+`
 		FROM positions
-		WHERE game_id = ?
+		WHERE game_id = ? AND id NOT IN (SELECT position_id FROM settlements)
 		ORDER BY created_at DESC
 	`, gameID)
 	if err != nil {
 		return nil, fmt.Errorf("querying positions by game: %w", err)
 	}
-	defer rows.Close()
-
-	var positions []Position
-	for rows.Next() {
-		var pos Position
-		if err := rows.Scan(&pos.ID, &pos.GameID, &pos.HomeTeam, &pos.AwayTeam,
-			&pos.MarketType, &pos.Side, &pos.EntryPrice, &pos.Contracts, &pos.CreatedAt); err != nil {
-			return nil, fmt.Errorf("scanning position row: %w", err)
-		}
-		positions = append(positions, pos)
-	}
-
-	return positions, rows.Err()
+	return scanPositions(rows)
 }
 
-// DeletePosition removes a position
+// DeletePosition removes a position and its settlement record, if any
 func (d *DB) DeletePosition(id int64) error {
+	if _, err := d.db.Exec("DELETE FROM settlements WHERE position_id = ?", id); err != nil {
+		return fmt.Errorf("deleting settlement: %w", err)
+	}
 	_, err := d.db.Exec("DELETE FROM positions WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("deleting position: %w", err)
@@ -0,0 +1,236 @@
+package positions
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"sports-betting-bot/internal/kalshi"
+)
+
+// ExitRules are the thresholds for selling open positions. A zero field
+// turns its rule off.
+type ExitRules struct {
+	TakeProfit  float64       // Sell once the bid reaches this price (0-1)
+	StopEdge    float64       // Sell once the consensus sits this far below the net bid
+	CloseBefore time.Duration // Sell everything this long before tip-off
+}
+
+// Enabled reports whether any rule is on.
+func (r ExitRules) Enabled() bool {
+	return r.TakeProfit > 0 || r.StopEdge > 0 || r.CloseBefore > 0
+}
+
+// ExitReason returns why a holding should be sold at bid (0-1), or "" to
+// keep it. trueProb is the consensus probability of the held side, 0 when
+// there is none; nearTip reports whether tip-off is within CloseBefore.
+//
+// The stop compares holding to selling: the edge is the consensus minus
+// what a sale nets after the taker fee.
+func (r ExitRules) ExitReason(bid, trueProb float64, nearTip bool) string {
+	switch {
+	case bid <= 0:
+		return ""
+	case r.CloseBefore > 0 && nearTip:
+		return "tip-off"
+	case r.TakeProfit > 0 && bid >= r.TakeProfit:
+		return fmt.Sprintf("take profit at %.0f¢", bid*100)
+	case r.StopEdge > 0 && trueProb > 0 && trueProb-(bid-kalshi.TakerFee(bid)) <= -r.StopEdge:
+		return fmt.Sprintf("edge flipped: consensus %.1f%% vs bid %.0f¢", trueProb*100, bid*100)
+	}
+	return ""
+}
+
+// Holding is the open contracts on one ticker and side, summed over every
+// position row that holds them. Arb rows and locked hedge pairs are left
+// out: their two sides net to nothing on Kalshi.
+type Holding struct {
+	GameID     string
+	HomeTeam   string
+	AwayTeam   string
+	MarketType string
+	Side       string
+	Ticker     string
+	BetSide    string
+	Contracts  int
+}
+
+// Holdings groups open positions into holdings, ordered by ticker and side.
+func Holdings(open []Position) []Holding {
+	byKey := make(map[string]*Holding)
+	for _, pos := range open {
+		if pos.Ticker == "" || pos.Side == "arb" || pos.Locked || pos.Contracts <= 0 {
+			continue
+		}
+		key := pos.Ticker + ":" + pos.BetSide
+		h, ok := byKey[key]
+		if !ok {
+			h = &Holding{
+				GameID:     pos.GameID,
+				HomeTeam:   pos.HomeTeam,
+				AwayTeam:   pos.AwayTeam,
+				MarketType: pos.MarketType,
+				Side:       pos.Side,
+				Ticker:     pos.Ticker,
+				BetSide:    pos.BetSide,
+			}
+			byKey[key] = h
+		}
+		h.Contracts += pos.Contracts
+	}
+
+	out := make([]Holding, 0, len(byKey))
+	for _, h := range byKey {
+		out = append(out, *h)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Ticker != out[j].Ticker {
+			return out[i].Ticker < out[j].Ticker
+		}
+		return out[i].BetSide < out[j].BetSide
+	})
+	return out
+}
+
+// RecordExit stores the result of a sell journaled as j. The u.Filled
+// contracts sold are closed out of the open positions on j's ticker and
+// side, oldest first: a row sold in full settles as ResultClosed, and a row
+// sold in part is split so the sold contracts settle and the rest stay
+// open. Entry fees are split pro rata and exit fees charged to the closed
+// contracts. Returns one settlement per row closed.
+func (d *DB) RecordExit(j JournalEntry, u OrderUpdate, closedAt time.Time) ([]Settlement, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var closes []Settlement
+	if u.Filled > 0 {
+		rows, err := tx.Query(`
+			SELECT `+positionColumns+`
+			FROM positions
+			WHERE ticker = ? AND bet_side = ? AND side != 'arb' AND locked = 0
+				AND id NOT IN (SELECT position_id FROM settlements)
+			ORDER BY created_at, id
+		`, j.Ticker, j.BetSide)
+		if err != nil {
+			return nil, fmt.Errorf("querying positions to close: %w", err)
+		}
+		open, err := scanPositions(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		closes, err = closeRows(tx, open, u.Filled, u.AvgPrice, u.Fees/float64(u.Filled), closedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := updateJournal(tx, u, 0); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing exit: %w", err)
+	}
+	return closes, nil
+}
+
+// CloseMissingContracts closes n contracts Kalshi no longer holds out of
+// rows, in the order given, as ResultClosed. The bot never saw the sale, so
+// the contracts are valued at bidCents, or at each row's entry price when
+// there is no bid, and no exit fee is charged. Returns one settlement per
+// row closed.
+func (d *DB) CloseMissingContracts(rows []Position, n, bidCents int, closedAt time.Time) ([]Settlement, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	closes, err := closeRows(tx, rows, n, float64(bidCents), 0, closedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing close: %w", err)
+	}
+	return closes, nil
+}
+
+// closeRows settles count contracts out of open, in order, as ResultClosed at
+// priceCents each (0 for the row's entry price), splitting a row closed in
+// part. Entry fees are split pro rata and exitFeePer is charged per
+// contract closed.
+func closeRows(tx *sql.Tx, open []Position, count int, priceCents, exitFeePer float64, closedAt time.Time) ([]Settlement, error) {
+	var closes []Settlement
+	remaining := count
+	for _, pos := range open {
+		if remaining == 0 {
+			break
+		}
+		n := min(remaining, pos.Contracts)
+		remaining -= n
+		entryFees := pos.Fees * float64(n) / float64(pos.Contracts)
+
+		closedID, err := splitPosition(tx, pos, n)
+		if err != nil {
+			return nil, err
+		}
+
+		price := priceCents / 100
+		if price == 0 {
+			price = pos.EntryPrice
+		}
+		payout := price * float64(n)
+		fees := entryFees + exitFeePer*float64(n)
+		s := Settlement{
+			PositionID:  closedID,
+			Ticker:      pos.Ticker,
+			Result:      ResultClosed,
+			Payout:      payout,
+			Fees:        fees,
+			RealizedPnL: payout - pos.EntryPrice*float64(n) - fees,
+			SettledAt:   closedAt,
+		}
+		_, err = tx.Exec(`
+			INSERT INTO settlements (position_id, ticker, result, payout, fees, realized_pnl, settled_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, s.PositionID, s.Ticker, s.Result, s.Payout, s.Fees, s.RealizedPnL, closedAt.UTC())
+		if err != nil {
+			return nil, fmt.Errorf("inserting close: %w", err)
+		}
+		closes = append(closes, s)
+	}
+	return closes, nil
+}
+
+// splitPosition splits n contracts of pos off into a row of their own,
+// carrying a pro rata share of the fees and the original created_at so
+// FIFO order holds. Returns the ID of the row holding the n contracts,
+// pos itself when n covers it.
+func splitPosition(tx *sql.Tx, pos Position, n int) (int64, error) {
+	if n >= pos.Contracts {
+		return pos.ID, nil
+	}
+	fees := pos.Fees * float64(n) / float64(pos.Contracts)
+	result, err := tx.Exec(`
+		INSERT INTO positions (game_id, home_team, away_team, market_type, side, ticker, bet_side, entry_price, contracts, fees, hedge_of, locked, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, pos.GameID, pos.HomeTeam, pos.AwayTeam, pos.MarketType, pos.Side, pos.Ticker, pos.BetSide, pos.EntryPrice, n, fees, pos.HedgeOf, pos.Locked, pos.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("splitting position %d: %w", pos.ID, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec(`UPDATE positions SET contracts = ?, fees = ? WHERE id = ?`,
+		pos.Contracts-n, pos.Fees-fees, pos.ID)
+	if err != nil {
+		return 0, fmt.Errorf("reducing position %d: %w", pos.ID, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,99 @@
+package positions
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExitReason(t *testing.T) {
+	rules := ExitRules{TakeProfit: 0.85, StopEdge: 0.05, CloseBefore: 30 * time.Minute}
+	tests := []struct {
+		name     string
+		rules    ExitRules
+		bid      float64
+		trueProb float64
+		nearTip  bool
+		want     string
+	}{
+		{"hold", rules, 0.60, 0.62, false, ""},
+		{"no bid", rules, 0, 0.20, true, ""},
+		{"tip-off", rules, 0.60, 0.62, true, "tip-off"},
+		{"take profit", rules, 0.88, 0.90, false, "take profit"},
+		{"edge flipped", rules, 0.60, 0.50, false, "edge flipped"},
+		{"inside the stop", rules, 0.60, 0.57, false, ""},
+		{"no consensus", rules, 0.60, 0, false, ""},
+		{"rules off", ExitRules{}, 0.95, 0.10, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.rules.ExitReason(tt.bid, tt.trueProb, tt.nearTip)
+			if (tt.want == "") != (got == "") || !strings.HasPrefix(got, tt.want) {
+				t.Errorf("ExitReason(%v, %v, %v) = %q, want %q", tt.bid, tt.trueProb, tt.nearTip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHoldingsSkipsArbRows(t *testing.T) {
+	open := []Position{
+		{Ticker: "T1", BetSide: "yes", Side: "home", Contracts: 10},
+		{Ticker: "T1", BetSide: "yes", Side: "home", Contracts: 5},
+		{Ticker: "T1", BetSide: "no", Side: "arb", Contracts: 8},
+		{Ticker: "T0", BetSide: "no", Side: "away", Contracts: 3},
+	}
+	got := Holdings(open)
+	if len(got) != 2 || got[0].Ticker != "T0" || got[1].Contracts != 15 {
+		t.Errorf("Holdings = %+v, want T0 then T1 with 15 contracts", got)
+	}
+}
+
+func TestRecordExitClosesOldestFirst(t *testing.T) {
+	db := newTestDB(t)
+	ticker := "KXNBAGAME-26FEB05GSWPHX"
+	for _, n := range []int{10, 10} {
+		_, err := db.AddPosition(Position{
+			GameID: "7", HomeTeam: "PHX", AwayTeam: "GSW", MarketType: "moneyline", Side: "home",
+			Ticker: ticker, BetSide: "yes", EntryPrice: 0.50, Contracts: n, Fees: 0.20,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	j := JournalEntry{
+		ClientOrderID: "coid-exit", Kind: OrderKindExit, GameID: "7", MarketType: "moneyline", Side: "home",
+		Ticker: ticker, BetSide: "yes", Action: "sell", Contracts: 15,
+	}
+	if err := db.RecordIntent(j); err != nil {
+		t.Fatal(err)
+	}
+	u := OrderUpdate{ClientOrderID: j.ClientOrderID, OrderID: "ord-9", Status: JournalExecuted, Filled: 15, AvgPrice: 70, Fees: 0.30}
+	closes, err := db.RecordExit(j, u, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first row closes whole; 5 are split off the second
+	if len(closes) != 2 {
+		t.Fatalf("closes = %+v, want two", closes)
+	}
+	if c := closes[0]; c.Result != ResultClosed || c.Payout != 7 || math.Abs(c.Fees-0.40) > 1e-9 || math.Abs(c.RealizedPnL-1.60) > 1e-9 {
+		t.Errorf("first close = %+v, want $7 payout, $0.40 fees, $1.60 P&L", c)
+	}
+	if c := closes[1]; c.Payout != 3.5 || math.Abs(c.Fees-0.20) > 1e-9 {
+		t.Errorf("second close = %+v, want $3.50 payout and $0.20 fees", c)
+	}
+
+	open, _ := db.GetAllPositions()
+	if len(open) != 1 || open[0].Contracts != 5 || math.Abs(open[0].Fees-0.10) > 1e-9 {
+		t.Errorf("open = %+v, want 5 contracts left carrying $0.10 of fees", open)
+	}
+	if got, _ := db.GetJournalEntry(j.ClientOrderID); got == nil || got.Status != JournalExecuted || got.Filled != 15 {
+		t.Errorf("entry = %+v, want executed with 15 filled", got)
+	}
+	if pnl, _ := db.RealizedPnL(); math.Abs(pnl-2.40) > 1e-9 {
+		t.Errorf("RealizedPnL = %.2f, want 2.40", pnl)
+	}
+}
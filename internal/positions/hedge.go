@@ -1,6 +1,7 @@
 package positions
 
 import (
+	"database/sql"
 	"fmt"
 	"sports-betting-bot/internal/kalshi"
 	"sports-betting-bot/internal/odds"
@@ -23,7 +24,7 @@ func FindHedgeOpportunities(positions []Position, consensus odds.ConsensusOdds)
 	gameIDStr := fmt.Sprintf("%d", consensus.GameID)
 
 	for _, pos := range positions {
-		if pos.GameID != gameIDStr {
+		if pos.GameID != gameIDStr || pos.Locked {
 			continue
 		}
 
@@ -142,3 +143,97 @@ func CalculateHedgeSize(entryPrice, oppositePrice float64, contracts int) (hedge
 
 	return contracts, profit
 }
+
+// HedgeEntry describes a buy of contracts on the other side of pos's
+// ticker, locking pos.
+func HedgeEntry(pos Position, clientOrderID string, contracts int) JournalEntry {
+	betSide := string(kalshi.SideNo)
+	if pos.BetSide == string(kalshi.SideNo) {
+		betSide = string(kalshi.SideYes)
+	}
+	return JournalEntry{
+		ClientOrderID: clientOrderID,
+		Kind:          OrderKindHedge,
+		GameID:        pos.GameID,
+		HomeTeam:      pos.HomeTeam,
+		AwayTeam:      pos.AwayTeam,
+		MarketType:    pos.MarketType,
+		Side:          oppositeSide(pos.Side),
+		Ticker:        pos.Ticker,
+		BetSide:       betSide,
+		Action:        string(kalshi.ActionBuy),
+		Contracts:     contracts,
+		HedgeOf:       pos.ID,
+	}
+}
+
+// oppositeSide returns the position side that loses when side wins.
+func oppositeSide(side string) string {
+	switch side {
+	case "home":
+		return "away"
+	case "away":
+		return "home"
+	case "over":
+		return "under"
+	case "under":
+		return "over"
+	}
+	return side
+}
+
+// RecordHedge stores the result of a hedge journaled as j. The u.Filled
+// contracts bought are stored as a hedge leg linked to j.HedgeOf, and as
+// many contracts of the hedged position are locked with it, split off if
+// the hedge covered only part of it. Returns the hedge leg's ID, or 0 if
+// nothing filled.
+func (d *DB) RecordHedge(j JournalEntry, u OrderUpdate) (int64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var legID int64
+	if u.Filled > 0 {
+		pos, err := scanPosition(tx.QueryRow(`
+			SELECT `+positionColumns+`
+			FROM positions
+			WHERE id = ? AND locked = 0 AND id NOT IN (SELECT position_id FROM settlements)
+		`, j.HedgeOf))
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("position %d is not open to hedge", j.HedgeOf)
+		}
+		if err != nil {
+			return 0, fmt.Errorf("querying hedged position: %w", err)
+		}
+
+		lockedID, err := splitPosition(tx, pos, min(u.Filled, pos.Contracts))
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.Exec(`UPDATE positions SET locked = 1 WHERE id = ?`, lockedID); err != nil {
+			return 0, fmt.Errorf("locking position %d: %w", lockedID, err)
+		}
+
+		leg := j.Position(u.Filled, u.AvgPrice, u.Fees)
+		result, err := tx.Exec(`
+			INSERT INTO positions (game_id, home_team, away_team, market_type, side, ticker, bet_side, entry_price, contracts, fees, hedge_of, locked)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)
+		`, leg.GameID, leg.HomeTeam, leg.AwayTeam, leg.MarketType, leg.Side, leg.Ticker, leg.BetSide, leg.EntryPrice, leg.Contracts, leg.Fees, lockedID)
+		if err != nil {
+			return 0, fmt.Errorf("inserting hedge leg: %w", err)
+		}
+		if legID, err = result.LastInsertId(); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := updateJournal(tx, u, legID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing hedge: %w", err)
+	}
+	return legID, nil
+}
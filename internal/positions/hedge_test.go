@@ -0,0 +1,80 @@
+package positions
+
+import (
+	"testing"
+
+	"sports-betting-bot/internal/kalshi"
+)
+
+func TestRecordHedgeLocksHedgedContracts(t *testing.T) {
+	db := newTestDB(t)
+	ticker := "KXNBAGAME-26FEB05GSWPHX"
+	id, err := db.AddPosition(Position{
+		GameID: "7", HomeTeam: "PHX", AwayTeam: "GSW", MarketType: "moneyline", Side: "home",
+		Ticker: ticker, BetSide: "yes", EntryPrice: 0.40, Contracts: 20, Fees: 0.40,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos, _ := db.GetPosition(id)
+
+	j := HedgeEntry(*pos, "coid-hedge", 20)
+	if j.Side != "away" || j.BetSide != "no" || j.HedgeOf != id {
+		t.Fatalf("HedgeEntry = %+v, want a NO buy on the away side hedging %d", j, id)
+	}
+	if err := db.RecordIntent(j); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only 15 of 20 filled: 15 of the position lock with the leg
+	u := OrderUpdate{ClientOrderID: j.ClientOrderID, OrderID: "ord-h", Status: JournalCanceled, Filled: 15, AvgPrice: 50, Fees: 0.27}
+	legID, err := db.RecordHedge(j, u)
+	if err != nil || legID == 0 {
+		t.Fatalf("RecordHedge = %d, %v, want a stored leg", legID, err)
+	}
+
+	open, _ := db.GetAllPositions()
+	var free, locked, leg *Position
+	for i := range open {
+		switch p := &open[i]; {
+		case p.ID == legID:
+			leg = p
+		case p.Locked:
+			locked = p
+		default:
+			free = p
+		}
+	}
+	if free == nil || free.Contracts != 5 {
+		t.Fatalf("open = %+v, want 5 contracts left unlocked", open)
+	}
+	if locked == nil || locked.Contracts != 15 || locked.BetSide != "yes" {
+		t.Fatalf("open = %+v, want 15 YES contracts locked", open)
+	}
+	if leg == nil || !leg.Locked || leg.HedgeOf != locked.ID || leg.Contracts != 15 || leg.EntryPrice != 0.50 || leg.Side != "away" {
+		t.Errorf("leg = %+v, want 15 locked away contracts at 0.50 hedging %d", leg, locked.ID)
+	}
+
+	if yes, _ := db.IsTickerLocked(ticker); !yes {
+		t.Error("IsTickerLocked = false, want true")
+	}
+	if h := Holdings(open); len(h) != 1 || h[0].Contracts != 5 {
+		t.Errorf("Holdings = %+v, want only the 5 unlocked contracts", h)
+	}
+	// Kalshi nets the pair, leaving the unlocked 5
+	if d := Reconcile(open, []kalshi.MarketPosition{{Ticker: ticker, Position: 5}}); len(d) != 0 {
+		t.Errorf("Reconcile = %v, want no discrepancies", d)
+	}
+	if got, _ := db.GetJournalEntry(j.ClientOrderID); got == nil || got.PositionID != legID || got.HedgeOf != id {
+		t.Errorf("entry = %+v, want linked to leg %d hedging %d", got, legID, id)
+	}
+
+	// A locked position can't be hedged again
+	j2 := HedgeEntry(*locked, "coid-hedge-2", 15)
+	if err := db.RecordIntent(j2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.RecordHedge(j2, OrderUpdate{ClientOrderID: j2.ClientOrderID, Status: JournalExecuted, Filled: 15, AvgPrice: 50}); err == nil {
+		t.Error("RecordHedge on a locked position succeeded, want error")
+	}
+}
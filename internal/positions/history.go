@@ -0,0 +1,227 @@
+package positions
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Alert kinds stored in the alert history.
+const (
+	AlertKindGame  = "game"
+	AlertKindProp  = "prop"
+	AlertKindHedge = "hedge"
+)
+
+// AlertRecord is one alert, with the inputs it was priced from. Repeats
+// the notifier's cooldown held back are stored too, flagged Suppressed.
+type AlertRecord struct {
+	ID               int64
+	Kind             string // "game", "prop" or "hedge"
+	GameID           string
+	Ticker           string
+	BetSide          string // Side the alert buys: "yes" or "no"
+	MarketType       string // "moneyline", "spread", "total", "prop_points", etc.
+	Side             string // "home", "away", "over", "under", or player-specific
+	TrueProb         float64
+	KalshiPrice      float64 // Price of BetSide when alerted (0-1)
+	BookCount        int
+	AdjustedEV       float64
+	KellyStake       float64 // Stake as a fraction of bankroll
+	PositionID       int64   // Hedge alerts: the position to hedge
+	GuaranteedProfit float64 // Hedge alerts: profit locked in by hedging
+	Result           string  // Market result once settled: "yes", "no" or "void"
+	Suppressed       bool    // Held back by the notifier's cooldown
+	AlertedAt        time.Time
+}
+
+// Won reports whether a resolved alert's side won.
+func (a AlertRecord) Won() bool {
+	return a.Result != "" && a.Result == a.BetSide
+}
+
+// RecordAlert stores an alert and returns its ID.
+func (d *DB) RecordAlert(a AlertRecord) (int64, error) {
+	alertedAt := a.AlertedAt
+	if alertedAt.IsZero() {
+		alertedAt = time.Now()
+	}
+	result, err := d.db.Exec(`
+		INSERT INTO alert_history (kind, game_id, ticker, bet_side, market_type, side, true_prob, kalshi_price,
+			book_count, adjusted_ev, kelly_stake, position_id, guaranteed_profit, suppressed, alerted_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, a.Kind, a.GameID, a.Ticker, a.BetSide, a.MarketType, a.Side, a.TrueProb, a.KalshiPrice,
+		a.BookCount, a.AdjustedEV, a.KellyStake, a.PositionID, a.GuaranteedProfit, a.Suppressed, alertedAt.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("inserting alert: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// UnresolvedAlertTickers returns the tickers of alerts made at or after
+// since whose market result is not yet recorded.
+func (d *DB) UnresolvedAlertTickers(since time.Time) ([]string, error) {
+	rows, err := d.db.Query(`
+		SELECT DISTINCT ticker FROM alert_history
+		WHERE result = '' AND ticker != '' AND alerted_at >= ?
+		ORDER BY ticker
+	`, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("querying unresolved alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var tickers []string
+	for rows.Next() {
+		var ticker string
+		if err := rows.Scan(&ticker); err != nil {
+			return nil, fmt.Errorf("scanning alert ticker: %w", err)
+		}
+		tickers = append(tickers, ticker)
+	}
+	return tickers, rows.Err()
+}
+
+// ResolveAlerts records a market's result on every alert for its ticker.
+func (d *DB) ResolveAlerts(ticker, result string) error {
+	_, err := d.db.Exec("UPDATE alert_history SET result = ? WHERE ticker = ? AND result = ''", result, ticker)
+	if err != nil {
+		return fmt.Errorf("resolving alerts: %w", err)
+	}
+	return nil
+}
+
+// AlertOutcome is an alert with the fills it led to, if any, and their
+// settlement. A maker order fills over several position rows, and exits
+// split rows, so the fills are summed.
+type AlertOutcome struct {
+	AlertRecord
+	FillID      int64   // First position bought on the alert; 0 if none
+	FillPrice   float64 // Contract-weighted average entry price
+	Contracts   int     // Contracts across all fills
+	Settled     bool    // Every fill has settled or been sold
+	Settlement  string  // Market result if any fill was held to it, else "closed"
+	RealizedPnL float64 // Summed over the settled fills
+}
+
+// Traded reports whether the alert was filled.
+func (o AlertOutcome) Traded() bool {
+	return o.FillID != 0
+}
+
+// GetAlertOutcomes retrieves every alert, oldest first, linked to its
+// fills and their settlements. A game or prop alert's fills are the
+// directional positions on its ticker and side, which duplicate prevention
+// keeps to one order; a hedge alert's fills are its position's hedge legs.
+func (d *DB) GetAlertOutcomes() ([]AlertOutcome, error) {
+	rows, err := d.db.Query(`
+		SELECT a.id, a.kind, a.game_id, a.ticker, a.bet_side, a.market_type, a.side, a.true_prob, a.kalshi_price,
+			a.book_count, a.adjusted_ev, a.kelly_stake, a.position_id, a.guaranteed_profit, a.result, a.suppressed, a.alerted_at,
+			COALESCE(f.fill_id, 0), COALESCE(f.fill_price, 0), COALESCE(f.contracts, 0),
+			COALESCE(f.settled, 0), COALESCE(f.settlement, ''), COALESCE(f.realized_pnl, 0)
+		FROM alert_history a
+		LEFT JOIN (
+			SELECT a.id AS alert_id, MIN(p.id) AS fill_id,
+				SUM(p.entry_price * p.contracts) / SUM(p.contracts) AS fill_price,
+				SUM(p.contracts) AS contracts,
+				COUNT(s.position_id) = COUNT(*) AS settled,
+				COALESCE(MAX(NULLIF(s.result, 'closed')), MAX(s.result)) AS settlement,
+				SUM(COALESCE(s.realized_pnl, 0)) AS realized_pnl
+			FROM alert_history a
+			JOIN positions p ON CASE
+				WHEN a.kind = 'hedge' THEN a.position_id != 0 AND p.hedge_of = a.position_id
+				ELSE p.ticker = a.ticker AND p.bet_side = a.bet_side AND p.hedge_of = 0 AND p.market_type NOT LIKE 'arb_%'
+			END
+			LEFT JOIN settlements s ON s.position_id = p.id
+			GROUP BY a.id
+		) f ON f.alert_id = a.id
+		ORDER BY a.alerted_at, a.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying alert outcomes: %w", err)
+	}
+	defer rows.Close()
+
+	var outcomes []AlertOutcome
+	for rows.Next() {
+		var o AlertOutcome
+		if err := rows.Scan(&o.ID, &o.Kind, &o.GameID, &o.Ticker, &o.BetSide, &o.MarketType, &o.Side,
+			&o.TrueProb, &o.KalshiPrice, &o.BookCount, &o.AdjustedEV, &o.KellyStake, &o.PositionID,
+			&o.GuaranteedProfit, &o.Result, &o.Suppressed, &o.AlertedAt,
+			&o.FillID, &o.FillPrice, &o.Contracts, &o.Settled, &o.Settlement, &o.RealizedPnL); err != nil {
+			return nil, fmt.Errorf("scanning alert outcome row: %w", err)
+		}
+		outcomes = append(outcomes, o)
+	}
+	return outcomes, rows.Err()
+}
+
+// AlertPrecision summarizes how often game or prop alerts of one kind won,
+// split by whether they were traded. A ticker and side re-alerted after
+// the cooldown counts once, at its first alert, and suppressed repeats are
+// skipped. Void markets are left out.
+type AlertPrecision struct {
+	Kind     string
+	Traded   bool
+	Alerts   int     // Distinct tickers and sides alerted
+	Resolved int     // Alerts whose market settled yes or no
+	Won      int     // Resolved alerts whose side won
+	ProbSum  float64 // Sum of TrueProb over resolved alerts
+	PnL      float64 // Realized P&L of the settled fills, in dollars
+}
+
+// HitRate returns the fraction of resolved alerts that won.
+func (p AlertPrecision) HitRate() float64 {
+	return avg(float64(p.Won), p.Resolved)
+}
+
+// ExpectedRate returns the mean alerted probability of the resolved
+// alerts, the hit rate a calibrated model would reach.
+func (p AlertPrecision) ExpectedRate() float64 {
+	return avg(p.ProbSum, p.Resolved)
+}
+
+// SummarizeAlertPrecision groups game and prop alert outcomes by kind and
+// by whether they were traded, sorted by kind with untraded alerts first.
+func SummarizeAlertPrecision(outcomes []AlertOutcome) []AlertPrecision {
+	type key struct {
+		kind   string
+		traded bool
+	}
+	groups := make(map[key]*AlertPrecision)
+	seen := make(map[string]bool)
+	for _, o := range outcomes {
+		if o.Kind == AlertKindHedge || o.Suppressed || seen[o.Ticker+"|"+o.BetSide] {
+			continue
+		}
+		seen[o.Ticker+"|"+o.BetSide] = true
+		k := key{o.Kind, o.Traded()}
+		p, ok := groups[k]
+		if !ok {
+			p = &AlertPrecision{Kind: o.Kind, Traded: o.Traded()}
+			groups[k] = p
+		}
+		p.Alerts++
+		p.PnL += o.RealizedPnL
+		if o.Result != ResultYes && o.Result != ResultNo {
+			continue
+		}
+		p.Resolved++
+		p.ProbSum += o.TrueProb
+		if o.Won() {
+			p.Won++
+		}
+	}
+
+	summaries := make([]AlertPrecision, 0, len(groups))
+	for _, p := range groups {
+		summaries = append(summaries, *p)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Kind != summaries[j].Kind {
+			return summaries[i].Kind < summaries[j].Kind
+		}
+		return !summaries[i].Traded && summaries[j].Traded
+	})
+	return summaries
+}
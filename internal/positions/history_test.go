@@ -0,0 +1,125 @@
+package positions
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAlertOutcomesLinkHedgeLegs(t *testing.T) {
+	db := newTestDB(t)
+	ticker := "KXNBAGAME-26FEB05GSWPHX"
+	alertedAt := time.Date(2026, 2, 5, 18, 0, 0, 0, time.UTC)
+	id, err := db.AddPosition(Position{
+		GameID: "7", HomeTeam: "PHX", AwayTeam: "GSW", MarketType: "moneyline", Side: "home",
+		Ticker: ticker, BetSide: "yes", EntryPrice: 0.40, Contracts: 20, Fees: 0.40,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos, _ := db.GetPosition(id)
+
+	alerts := []AlertRecord{
+		{Kind: AlertKindGame, GameID: "7", Ticker: ticker, BetSide: "yes", MarketType: "moneyline", Side: "home",
+			TrueProb: 0.55, KalshiPrice: 0.40, BookCount: 6, AdjustedEV: 0.12, KellyStake: 0.05, AlertedAt: alertedAt},
+		{Kind: AlertKindHedge, GameID: "7", Ticker: ticker, BetSide: "no", MarketType: "moneyline", Side: "away",
+			KalshiPrice: 0.50, PositionID: id, GuaranteedProfit: 1.80, AlertedAt: alertedAt.Add(time.Hour)},
+	}
+	for _, a := range alerts {
+		if _, err := db.RecordAlert(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	j := HedgeEntry(*pos, "coid-hedge", 20)
+	if err := db.RecordIntent(j); err != nil {
+		t.Fatal(err)
+	}
+	legID, err := db.RecordHedge(j, OrderUpdate{ClientOrderID: j.ClientOrderID, Status: JournalExecuted, Filled: 20, AvgPrice: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only alerts made since the cutoff are looked up
+	if tickers, err := db.UnresolvedAlertTickers(alertedAt.Add(2 * time.Hour)); err != nil || len(tickers) != 0 {
+		t.Errorf("UnresolvedAlertTickers after the alerts = %v, %v, want none", tickers, err)
+	}
+	if tickers, err := db.UnresolvedAlertTickers(alertedAt); err != nil || len(tickers) != 1 || tickers[0] != ticker {
+		t.Fatalf("UnresolvedAlertTickers = %v, %v, want %s", tickers, err, ticker)
+	}
+	if err := db.ResolveAlerts(ticker, ResultYes); err != nil {
+		t.Fatal(err)
+	}
+	if tickers, _ := db.UnresolvedAlertTickers(alertedAt); len(tickers) != 0 {
+		t.Errorf("UnresolvedAlertTickers after resolving = %v, want none", tickers)
+	}
+
+	outcomes, err := db.GetAlertOutcomes()
+	if err != nil || len(outcomes) != 2 {
+		t.Fatalf("GetAlertOutcomes = %+v, %v, want both alerts", outcomes, err)
+	}
+	game, hedge := outcomes[0], outcomes[1]
+	if game.FillID != id || game.FillPrice != 0.40 || game.Contracts != 20 || !game.Won() {
+		t.Errorf("game outcome = %+v, want the winning 20-contract position %d", game, id)
+	}
+	if game.BookCount != 6 || game.KellyStake != 0.05 || !game.AlertedAt.Equal(alertedAt) {
+		t.Errorf("game alert = %+v, want its inputs stored", game.AlertRecord)
+	}
+	if hedge.FillID != legID || hedge.FillPrice != 0.50 || hedge.GuaranteedProfit != 1.80 || hedge.Won() {
+		t.Errorf("hedge outcome = %+v, want hedge leg %d at 0.50", hedge, legID)
+	}
+}
+
+func TestAlertOutcomesSumMakerFills(t *testing.T) {
+	db := newTestDB(t)
+	ticker := "KXNBATOTAL-26FEB05GSWPHX"
+	alertedAt := time.Date(2026, 2, 5, 18, 0, 0, 0, time.UTC)
+	if _, err := db.RecordAlert(AlertRecord{Kind: AlertKindGame, GameID: "7", Ticker: ticker, BetSide: "yes",
+		MarketType: "total", Side: "over", TrueProb: 0.55, KalshiPrice: 0.45, AlertedAt: alertedAt}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A resting order filled in two pieces
+	var fills []Position
+	for _, f := range []struct {
+		price     float64
+		contracts int
+	}{{0.44, 10}, {0.45, 30}} {
+		id, err := db.AddPosition(Position{GameID: "7", MarketType: "total", Side: "over", Ticker: ticker,
+			BetSide: "yes", EntryPrice: f.price, Contracts: f.contracts, Fees: 0.10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pos, _ := db.GetPosition(id)
+		fills = append(fills, *pos)
+	}
+
+	outcome := func() AlertOutcome {
+		t.Helper()
+		outcomes, err := db.GetAlertOutcomes()
+		if err != nil || len(outcomes) != 1 {
+			t.Fatalf("GetAlertOutcomes = %+v, %v, want one alert", outcomes, err)
+		}
+		return outcomes[0]
+	}
+
+	if err := db.RecordSettlement(Settle(fills[0], ResultYes)); err != nil {
+		t.Fatal(err)
+	}
+	o := outcome()
+	if o.FillID != fills[0].ID || o.Contracts != 40 || math.Abs(o.FillPrice-0.4475) > 1e-9 {
+		t.Errorf("outcome = %+v, want 40 contracts at 44.75¢ from position %d", o, fills[0].ID)
+	}
+	if o.Settled {
+		t.Error("outcome settled with a fill still open")
+	}
+
+	if err := db.RecordSettlement(Settle(fills[1], ResultYes)); err != nil {
+		t.Fatal(err)
+	}
+	o = outcome()
+	wantPnL := 40 - (0.44*10 + 0.45*30) - 0.20
+	if !o.Settled || o.Settlement != ResultYes || math.Abs(o.RealizedPnL-wantPnL) > 1e-9 {
+		t.Errorf("outcome = %+v, want settled yes with $%.2f across both fills", o, wantPnL)
+	}
+}
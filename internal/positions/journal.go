@@ -0,0 +1,307 @@
+package positions
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Journal statuses. An entry starts as JournalIntent before the order is
+// sent and leaves it once the exchange's answer is known; the rest mirror
+// Kalshi's order statuses, plus JournalFailed for orders Kalshi never took.
+const (
+	JournalIntent   = "intent"
+	JournalResting  = OrderResting
+	JournalExecuted = OrderExecuted
+	JournalCanceled = OrderCanceled
+	JournalFailed   = "failed"
+)
+
+// Journal entry kinds: which execution path placed the order.
+const (
+	OrderKindTaker = "taker" // IOC order from ExecuteTrade
+	OrderKindMaker = "maker" // Resting bid tracked in the orders table
+	OrderKindArb   = "arb"   // One leg of an arb
+	OrderKindExit  = "exit"  // Sell closing open positions
+	OrderKindHedge = "hedge" // Buy of the opposite side locking a position
+)
+
+// JournalEntry is one order the bot decided to send, keyed by the client
+// order ID it was sent with. It carries everything needed to store the
+// order's fills as a position, so an order orphaned by a crash can be
+// recovered without the process that placed it.
+type JournalEntry struct {
+	ClientOrderID string
+	Kind          string
+	OrderID       string // Kalshi order ID, once known
+	GameID        string
+	HomeTeam      string
+	AwayTeam      string
+	MarketType    string
+	Side          string // Position side; "arb" for same-ticker arb legs
+	Ticker        string
+	BetSide       string  // "yes" or "no"
+	Action        string  // "buy" or "sell"
+	Contracts     int     // Requested
+	LimitPrice    int     // Cents; 0 for IOC orders priced by PlaceOrder
+	TrueProb      float64 // Consensus probability when sent
+	StartsAt      string  // Game start time
+	Status        string
+	Filled        int
+	AvgPrice      float64 // Average fill price in cents
+	Fees          float64 // Dollars
+	PositionID    int64   // Position stored for the fills, if any
+	HedgeOf       int64   // Position a hedge locks
+	Detail        string  // Last rejection reason or error
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// InFlight reports whether the exchange's answer for the order is unknown.
+func (j JournalEntry) InFlight() bool {
+	return j.Status == JournalIntent
+}
+
+// Position returns a position for filled contracts at avgPrice cents.
+// A same-ticker arb leg on its own is a plain bet on its side; cross-market
+// legs are journaled with their side already.
+func (j JournalEntry) Position(filled int, avgPrice, fees float64) Position {
+	side := j.Side
+	if j.Kind == OrderKindArb && side == "arb" {
+		side = sideForBet(j.MarketType, j.BetSide)
+	}
+	return Position{
+		GameID:     j.GameID,
+		HomeTeam:   j.HomeTeam,
+		AwayTeam:   j.AwayTeam,
+		MarketType: j.MarketType,
+		Side:       side,
+		Ticker:     j.Ticker,
+		BetSide:    j.BetSide,
+		EntryPrice: avgPrice / 100,
+		Contracts:  filled,
+		Fees:       fees,
+	}
+}
+
+// Order returns the maker order row for an entry Kalshi accepted as orderID.
+func (j JournalEntry) Order(orderID string) Order {
+	return Order{
+		OrderID:       orderID,
+		ClientOrderID: j.ClientOrderID,
+		GameID:        j.GameID,
+		HomeTeam:      j.HomeTeam,
+		AwayTeam:      j.AwayTeam,
+		MarketType:    j.MarketType,
+		Side:          j.Side,
+		Ticker:        j.Ticker,
+		BetSide:       j.BetSide,
+		LimitPrice:    j.LimitPrice,
+		Contracts:     j.Contracts,
+		TrueProb:      j.TrueProb,
+		StartsAt:      j.StartsAt,
+		Status:        OrderResting,
+	}
+}
+
+// OrderUpdate is a change to a journal entry, as reported by the exchange.
+type OrderUpdate struct {
+	ClientOrderID string
+	OrderID       string
+	Status        string
+	Filled        int
+	AvgPrice      float64 // Cents
+	Fees          float64 // Dollars
+	Detail        string
+}
+
+// OrderEvent is one status transition in an entry's history.
+type OrderEvent struct {
+	ClientOrderID string
+	Status        string
+	Filled        int
+	Detail        string
+	CreatedAt     time.Time
+}
+
+// RecordIntent journals an order before it is sent. The entry stays in
+// flight until RecordOrderResult stores the exchange's answer.
+func (d *DB) RecordIntent(j JournalEntry) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO order_journal (client_order_id, kind, game_id, home_team, away_team, market_type, side,
+			ticker, bet_side, action, contracts, limit_price, true_prob, starts_at, hedge_of, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, j.ClientOrderID, j.Kind, j.GameID, j.HomeTeam, j.AwayTeam, j.MarketType, j.Side,
+		j.Ticker, j.BetSide, j.Action, j.Contracts, j.LimitPrice, j.TrueProb, j.StartsAt, j.HedgeOf, JournalIntent)
+	if err != nil {
+		return fmt.Errorf("inserting journal entry: %w", err)
+	}
+	if err := appendOrderEvent(tx, j.ClientOrderID, JournalIntent, 0, ""); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing journal entry: %w", err)
+	}
+	return nil
+}
+
+// RecordOrderResult stores u on its journal entry. A non-nil pos is stored
+// in the same transaction and linked to the entry, so fills are recorded
+// exactly when the entry stops being in flight. Returns the position's ID,
+// or 0 if pos is nil.
+func (d *DB) RecordOrderResult(u OrderUpdate, pos *Position) (int64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var positionID int64
+	if pos != nil {
+		result, err := tx.Exec(`
+			INSERT INTO positions (game_id, home_team, away_team, market_type, side, ticker, bet_side, entry_price, contracts, fees)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, pos.GameID, pos.HomeTeam, pos.AwayTeam, pos.MarketType, pos.Side, pos.Ticker, pos.BetSide, pos.EntryPrice, pos.Contracts, pos.Fees)
+		if err != nil {
+			return 0, fmt.Errorf("inserting fill position: %w", err)
+		}
+		if positionID, err = result.LastInsertId(); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := updateJournal(tx, u, positionID); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing journal update: %w", err)
+	}
+	return positionID, nil
+}
+
+// InFlightOrders returns entries whose outcome is unknown, oldest first.
+func (d *DB) InFlightOrders() ([]JournalEntry, error) {
+	rows, err := d.db.Query(`
+		SELECT `+journalColumns+`
+		FROM order_journal WHERE status = ?
+		ORDER BY created_at, client_order_id
+	`, JournalIntent)
+	if err != nil {
+		return nil, fmt.Errorf("querying in-flight orders: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []JournalEntry
+	for rows.Next() {
+		j, err := scanJournalEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning journal row: %w", err)
+		}
+		entries = append(entries, j)
+	}
+	return entries, rows.Err()
+}
+
+// HasInFlightOrder reports whether an order on ticker+side may have been
+// sent without its outcome being recorded yet.
+func (d *DB) HasInFlightOrder(ticker, betSide string) (bool, error) {
+	var count int
+	err := d.db.QueryRow(`
+		SELECT COUNT(*) FROM order_journal WHERE ticker = ? AND bet_side = ? AND status = ?
+	`, ticker, betSide, JournalIntent).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("checking in-flight orders: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetJournalEntry returns the entry for a client order ID, or nil if none.
+func (d *DB) GetJournalEntry(clientOrderID string) (*JournalEntry, error) {
+	row := d.db.QueryRow(`
+		SELECT `+journalColumns+`
+		FROM order_journal WHERE client_order_id = ?
+	`, clientOrderID)
+	j, err := scanJournalEntry(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("querying journal entry: %w", err)
+	}
+	return &j, nil
+}
+
+// OrderEvents returns an entry's status history, oldest first.
+func (d *DB) OrderEvents(clientOrderID string) ([]OrderEvent, error) {
+	rows, err := d.db.Query(`
+		SELECT client_order_id, status, filled, detail, created_at
+		FROM order_events WHERE client_order_id = ?
+		ORDER BY id
+	`, clientOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("querying order events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []OrderEvent
+	for rows.Next() {
+		var ev OrderEvent
+		if err := rows.Scan(&ev.ClientOrderID, &ev.Status, &ev.Filled, &ev.Detail, &ev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning order event: %w", err)
+		}
+		events = append(events, ev)
+	}
+	return events, rows.Err()
+}
+
+// updateJournal stores u on its entry and appends it to the history.
+// positionID links the entry to a position when non-zero.
+func updateJournal(tx *sql.Tx, u OrderUpdate, positionID int64) error {
+	result, err := tx.Exec(`
+		UPDATE order_journal SET order_id = ?, status = ?, filled = ?, avg_price = ?, fees = ?, detail = ?,
+			position_id = CASE WHEN ? > 0 THEN ? ELSE position_id END, updated_at = CURRENT_TIMESTAMP
+		WHERE client_order_id = ?
+	`, u.OrderID, u.Status, u.Filled, u.AvgPrice, u.Fees, u.Detail, positionID, positionID, u.ClientOrderID)
+	if err != nil {
+		return fmt.Errorf("updating journal entry: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return fmt.Errorf("no journal entry for client order %s", u.ClientOrderID)
+	}
+	return appendOrderEvent(tx, u.ClientOrderID, u.Status, u.Filled, u.Detail)
+}
+
+// appendOrderEvent adds a transition to an entry's history.
+func appendOrderEvent(tx *sql.Tx, clientOrderID, status string, filled int, detail string) error {
+	_, err := tx.Exec(`
+		INSERT INTO order_events (client_order_id, status, filled, detail) VALUES (?, ?, ?, ?)
+	`, clientOrderID, status, filled, detail)
+	if err != nil {
+		return fmt.Errorf("inserting order event: %w", err)
+	}
+	return nil
+}
+
+// journalColumns is the column list scanJournalEntry expects.
+const journalColumns = `client_order_id, kind, order_id, game_id, home_team, away_team, market_type, side,
+		ticker, bet_side, action, contracts, limit_price, true_prob, starts_at, status, filled,
+		avg_price, fees, position_id, hedge_of, detail, created_at, updated_at`
+
+func scanJournalEntry(row rowScanner) (JournalEntry, error) {
+	var j JournalEntry
+	var updatedAt sql.NullTime
+	err := row.Scan(&j.ClientOrderID, &j.Kind, &j.OrderID, &j.GameID, &j.HomeTeam, &j.AwayTeam,
+		&j.MarketType, &j.Side, &j.Ticker, &j.BetSide, &j.Action, &j.Contracts, &j.LimitPrice,
+		&j.TrueProb, &j.StartsAt, &j.Status, &j.Filled, &j.AvgPrice, &j.Fees, &j.PositionID,
+		&j.HedgeOf, &j.Detail, &j.CreatedAt, &updatedAt)
+	if updatedAt.Valid {
+		j.UpdatedAt = updatedAt.Time
+	}
+	return j, err
+}
@@ -0,0 +1,89 @@
+package positions
+
+import "testing"
+
+func TestJournalRecordsIntentThenResult(t *testing.T) {
+	db := newTestDB(t)
+
+	j := JournalEntry{
+		ClientOrderID: "coid-1", Kind: OrderKindTaker, GameID: "7", HomeTeam: "PHX", AwayTeam: "GSW",
+		MarketType: "moneyline", Side: "home", Ticker: "KXNBAGAME-26FEB05GSWPHX", BetSide: "yes",
+		Action: "buy", Contracts: 20, TrueProb: 0.65,
+	}
+	if err := db.RecordIntent(j); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.RecordIntent(j); err == nil {
+		t.Error("second RecordIntent with the same client order ID succeeded, want error")
+	}
+	if has, _ := db.HasInFlightOrder(j.Ticker, "yes"); !has {
+		t.Error("HasInFlightOrder = false after RecordIntent, want true")
+	}
+	if inFlight, err := db.InFlightOrders(); err != nil || len(inFlight) != 1 || !inFlight[0].InFlight() {
+		t.Fatalf("InFlightOrders = %+v, %v, want the intent", inFlight, err)
+	}
+
+	pos := j.Position(12, 48, 0.21)
+	u := OrderUpdate{ClientOrderID: j.ClientOrderID, OrderID: "ord-1", Status: JournalCanceled, Filled: 12, AvgPrice: 48, Fees: 0.21}
+	id, err := db.RecordOrderResult(u, &pos)
+	if err != nil || id == 0 {
+		t.Fatalf("RecordOrderResult = %d, %v, want a stored position", id, err)
+	}
+
+	if has, _ := db.HasInFlightOrder(j.Ticker, "yes"); has {
+		t.Error("HasInFlightOrder = true after the result, want false")
+	}
+	got, err := db.GetJournalEntry(j.ClientOrderID)
+	if err != nil || got == nil {
+		t.Fatalf("GetJournalEntry = %v, %v", got, err)
+	}
+	if got.OrderID != "ord-1" || got.Status != JournalCanceled || got.Filled != 12 || got.PositionID != id {
+		t.Errorf("entry = %+v, want ord-1 canceled with 12 filled linked to position %d", got, id)
+	}
+
+	stored, _ := db.GetAllPositions()
+	if len(stored) != 1 || stored[0].Contracts != 12 || stored[0].EntryPrice != 0.48 || stored[0].Side != "home" {
+		t.Errorf("stored = %+v, want 12 home contracts at 0.48", stored)
+	}
+
+	events, err := db.OrderEvents(j.ClientOrderID)
+	if err != nil || len(events) != 2 || events[0].Status != JournalIntent || events[1].Status != JournalCanceled {
+		t.Errorf("events = %+v, %v, want intent then canceled", events, err)
+	}
+
+	if _, err := db.RecordOrderResult(OrderUpdate{ClientOrderID: "unknown", Status: JournalFailed}, nil); err == nil {
+		t.Error("RecordOrderResult for an unknown client order ID succeeded, want error")
+	}
+}
+
+func TestJournalArbLegPositionIsPlainBet(t *testing.T) {
+	j := JournalEntry{Kind: OrderKindArb, MarketType: "moneyline", Side: "arb", BetSide: "no"}
+	if pos := j.Position(5, 40, 0); pos.Side != "away" || pos.Cost() != 2 {
+		t.Errorf("arb NO leg position = %+v, want an away bet costing $2", pos)
+	}
+}
+
+func TestRecordOrderStateJournalsTransitions(t *testing.T) {
+	db := newTestDB(t)
+
+	j := JournalEntry{
+		ClientOrderID: "coid-maker", Kind: OrderKindMaker, GameID: "7", HomeTeam: "PHX", AwayTeam: "GSW",
+		MarketType: "moneyline", Side: "home", Ticker: "KXNBAGAME-26FEB05GSWPHX", BetSide: "yes",
+		Action: "buy", Contracts: 10, LimitPrice: 46,
+	}
+	if err := db.RecordIntent(j); err != nil {
+		t.Fatal(err)
+	}
+	o := j.Order("ord-2")
+	if _, err := db.AddOrder(o); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.RecordOrderState(o, OrderExecuted, 10, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	got, _ := db.GetJournalEntry(j.ClientOrderID)
+	if got == nil || got.Status != JournalExecuted || got.Filled != 10 || got.AvgPrice != 46 || got.PositionID == 0 {
+		t.Errorf("entry = %+v, want executed with 10 filled at 46¢", got)
+	}
+}
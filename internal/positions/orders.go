@@ -0,0 +1,194 @@
+package positions
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Order statuses, matching Kalshi's.
+const (
+	OrderResting  = "resting"
+	OrderExecuted = "executed"
+	OrderCanceled = "canceled"
+)
+
+// Order is a resting limit order the bot owns. Fills become positions as
+// they are seen, so the row carries everything a Position needs.
+type Order struct {
+	ID            int64
+	OrderID       string // Kalshi order ID
+	ClientOrderID string
+	GameID        string
+	HomeTeam      string
+	AwayTeam      string
+	MarketType    string
+	Side          string // Position side, e.g. "home" or "<player>_over_24.5"
+	Ticker        string
+	BetSide       string  // "yes" or "no"
+	LimitPrice    int     // Cents
+	Contracts     int     // Requested
+	Filled        int     // Filled so far, already recorded as positions
+	Fees          float64 // Fees charged on Filled, in dollars
+	TrueProb      float64 // Consensus probability when posted
+	StartsAt      string  // Game start time, for cancelling before tip-off
+	Status        string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Open reports whether the order may still fill.
+func (o Order) Open() bool {
+	return o.Status == OrderResting
+}
+
+// Remaining returns the contracts still resting.
+func (o Order) Remaining() int {
+	if !o.Open() {
+		return 0
+	}
+	return o.Contracts - o.Filled
+}
+
+// Position returns a position for contracts filled at the order's limit.
+func (o Order) Position(contracts int, fees float64) Position {
+	return Position{
+		GameID:     o.GameID,
+		HomeTeam:   o.HomeTeam,
+		AwayTeam:   o.AwayTeam,
+		MarketType: o.MarketType,
+		Side:       o.Side,
+		Ticker:     o.Ticker,
+		BetSide:    o.BetSide,
+		EntryPrice: float64(o.LimitPrice) / 100,
+		Contracts:  contracts,
+		Fees:       fees,
+	}
+}
+
+// AddOrder stores a newly placed order.
+func (d *DB) AddOrder(o Order) (int64, error) {
+	result, err := d.db.Exec(`
+		INSERT INTO orders (order_id, client_order_id, game_id, home_team, away_team, market_type, side,
+			ticker, bet_side, limit_price, contracts, filled, fees, true_prob, starts_at, status)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, o.OrderID, o.ClientOrderID, o.GameID, o.HomeTeam, o.AwayTeam, o.MarketType, o.Side,
+		o.Ticker, o.BetSide, o.LimitPrice, o.Contracts, o.Filled, o.Fees, o.TrueProb, o.StartsAt, o.Status)
+	if err != nil {
+		return 0, fmt.Errorf("inserting order: %w", err)
+	}
+	return result.LastInsertId()
+}
+
+// RecordOrderState updates an order to its latest exchange state. Any
+// contracts filled beyond o.Filled are stored as a new position in the same
+// transaction, so a restart never records a fill twice. The transition is
+// also journaled under the order's client order ID. Returns the new
+// position's ID, or 0 if nothing new filled.
+func (d *DB) RecordOrderState(o Order, status string, filled int, fees float64) (int64, error) {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var positionID int64
+	if n := filled - o.Filled; n > 0 {
+		pos := o.Position(n, fees-o.Fees)
+		result, err := tx.Exec(`
+			INSERT INTO positions (game_id, home_team, away_team, market_type, side, ticker, bet_side, entry_price, contracts, fees)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, pos.GameID, pos.HomeTeam, pos.AwayTeam, pos.MarketType, pos.Side, pos.Ticker, pos.BetSide, pos.EntryPrice, pos.Contracts, pos.Fees)
+		if err != nil {
+			return 0, fmt.Errorf("inserting fill position: %w", err)
+		}
+		if positionID, err = result.LastInsertId(); err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE orders SET status = ?, filled = ?, fees = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE order_id = ?
+	`, status, filled, fees, o.OrderID)
+	if err != nil {
+		return 0, fmt.Errorf("updating order: %w", err)
+	}
+
+	if o.ClientOrderID != "" {
+		avgPrice := 0.0
+		if filled > 0 {
+			avgPrice = float64(o.LimitPrice) // Maker fills are at the limit
+		}
+		result, err := tx.Exec(`
+			UPDATE order_journal SET order_id = ?, status = ?, filled = ?, avg_price = ?, fees = ?,
+				position_id = CASE WHEN ? > 0 THEN ? ELSE position_id END, updated_at = CURRENT_TIMESTAMP
+			WHERE client_order_id = ?
+		`, o.OrderID, status, filled, avgPrice, fees, positionID, positionID, o.ClientOrderID)
+		if err != nil {
+			return 0, fmt.Errorf("updating journal entry: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			if err := appendOrderEvent(tx, o.ClientOrderID, status, filled, ""); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing order update: %w", err)
+	}
+	return positionID, nil
+}
+
+// GetOpenOrders returns resting orders, oldest first.
+func (d *DB) GetOpenOrders() ([]Order, error) {
+	rows, err := d.db.Query(`
+		SELECT `+orderColumns+`
+		FROM orders WHERE status = ?
+		ORDER BY id
+	`, OrderResting)
+	if err != nil {
+		return nil, fmt.Errorf("querying open orders: %w", err)
+	}
+	defer rows.Close()
+
+	var orders []Order
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scanning order row: %w", err)
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
+
+// HasOpenOrder reports whether a resting order exists on ticker+side.
+func (d *DB) HasOpenOrder(ticker, betSide string) (bool, error) {
+	var count int
+	err := d.db.QueryRow(`
+		SELECT COUNT(*) FROM orders WHERE ticker = ? AND bet_side = ? AND status = ?
+	`, ticker, betSide, OrderResting).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("checking open orders: %w", err)
+	}
+	return count > 0, nil
+}
+
+// orderColumns is the column list scanOrder expects.
+const orderColumns = `id, order_id, client_order_id, game_id, home_team, away_team, market_type, side,
+		ticker, bet_side, limit_price, contracts, filled, fees, true_prob, starts_at, status,
+		created_at, updated_at`
+
+func scanOrder(row rowScanner) (Order, error) {
+	var o Order
+	var updatedAt sql.NullTime
+	err := row.Scan(&o.ID, &o.OrderID, &o.ClientOrderID, &o.GameID, &o.HomeTeam, &o.AwayTeam,
+		&o.MarketType, &o.Side, &o.Ticker, &o.BetSide, &o.LimitPrice, &o.Contracts, &o.Filled,
+		&o.Fees, &o.TrueProb, &o.StartsAt, &o.Status, &o.CreatedAt, &updatedAt)
+	if updatedAt.Valid {
+		o.UpdatedAt = updatedAt.Time
+	}
+	return o, err
+}
@@ -0,0 +1,51 @@
+package positions
+
+import "testing"
+
+func TestRecordOrderStateStoresEachFillOnce(t *testing.T) {
+	db := newTestDB(t)
+
+	o := Order{
+		OrderID: "ord-1", GameID: "7", HomeTeam: "PHX", AwayTeam: "GSW",
+		MarketType: "moneyline", Side: "home", Ticker: "KXNBAGAME-26FEB05GSWPHX", BetSide: "yes",
+		LimitPrice: 46, Contracts: 20, TrueProb: 0.65, Status: OrderResting,
+	}
+	if _, err := db.AddOrder(o); err != nil {
+		t.Fatal(err)
+	}
+	if has, _ := db.HasOpenOrder(o.Ticker, "yes"); !has {
+		t.Error("HasOpenOrder = false after AddOrder, want true")
+	}
+
+	// Partial fill, then the same state again as a restart would see it
+	if id, err := db.RecordOrderState(o, OrderResting, 8, 0); err != nil || id == 0 {
+		t.Fatalf("RecordOrderState(8 filled) = %d, %v, want a new position", id, err)
+	}
+	open, err := db.GetOpenOrders()
+	if err != nil || len(open) != 1 || open[0].Filled != 8 || open[0].Remaining() != 12 {
+		t.Fatalf("open orders = %+v, %v, want one with 8 filled", open, err)
+	}
+	if id, _ := db.RecordOrderState(open[0], OrderResting, 8, 0); id != 0 {
+		t.Errorf("repeating the same fill stored position %d, want none", id)
+	}
+
+	if _, err := db.RecordOrderState(open[0], OrderExecuted, 20, 0.05); err != nil {
+		t.Fatal(err)
+	}
+	if open, _ := db.GetOpenOrders(); len(open) != 0 {
+		t.Errorf("open orders after execution = %+v, want none", open)
+	}
+
+	stored, _ := db.GetAllPositions()
+	total, fees := 0, 0.0
+	for _, p := range stored {
+		if p.EntryPrice != 0.46 || p.BetSide != "yes" || p.Side != "home" {
+			t.Errorf("fill position = %+v, want home YES at 0.46", p)
+		}
+		total += p.Contracts
+		fees += p.Fees
+	}
+	if len(stored) != 2 || total != 20 || fees != 0.05 {
+		t.Errorf("stored %d positions with %d contracts and $%.2f fees, want 2, 20 and $0.05", len(stored), total, fees)
+	}
+}
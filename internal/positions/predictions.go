@@ -0,0 +1,179 @@
+package positions
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sports-betting-bot/internal/odds"
+)
+
+// Prediction is the consensus probability that a Kalshi market settles
+// YES, as of the last pre-game scan that priced it.
+type Prediction struct {
+	Ticker      string
+	GameID      string
+	MarketType  string  // "moneyline", "spread", "total", "prop_points", etc.
+	Line        float64 // Spread, total or prop strike; 0 for moneylines
+	Prob        float64 // Consensus P(YES): home win, home cover, over
+	BookCount   int
+	PredictedAt time.Time
+	Result      string // Market result once settled: "yes", "no" or "void"
+}
+
+// Outcome returns 1 if the market settled YES and 0 if it settled NO.
+// Returns false while unresolved or when voided.
+func (p Prediction) Outcome() (float64, bool) {
+	switch p.Result {
+	case ResultYes:
+		return 1, true
+	case ResultNo:
+		return 0, true
+	}
+	return 0, false
+}
+
+// RecordPredictions stores each prediction, replacing the ticker's earlier
+// one, so the last pre-game scan before tip-off is what remains.
+func (d *DB) RecordPredictions(preds []Prediction) error {
+	if len(preds) == 0 {
+		return nil
+	}
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning predictions transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, p := range preds {
+		_, err := tx.Exec(`
+			INSERT INTO predictions (ticker, game_id, market_type, line, prob, book_count, predicted_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(ticker) DO UPDATE SET
+				line = excluded.line, prob = excluded.prob, book_count = excluded.book_count,
+				predicted_at = excluded.predicted_at
+			WHERE result = ''
+		`, p.Ticker, p.GameID, p.MarketType, p.Line, p.Prob, p.BookCount, p.PredictedAt.UTC())
+		if err != nil {
+			return fmt.Errorf("inserting prediction: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// UnresolvedPredictionTickers returns the tickers of unresolved predictions
+// last made between since and before.
+func (d *DB) UnresolvedPredictionTickers(since, before time.Time) ([]string, error) {
+	rows, err := d.db.Query(`
+		SELECT ticker FROM predictions
+		WHERE result = '' AND predicted_at >= ? AND predicted_at < ?
+		ORDER BY ticker
+	`, since.UTC(), before.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("querying unresolved predictions: %w", err)
+	}
+	defer rows.Close()
+
+	var tickers []string
+	for rows.Next() {
+		var ticker string
+		if err := rows.Scan(&ticker); err != nil {
+			return nil, fmt.Errorf("scanning prediction ticker: %w", err)
+		}
+		tickers = append(tickers, ticker)
+	}
+	return tickers, rows.Err()
+}
+
+// ResolvePrediction records a market's result on its prediction.
+func (d *DB) ResolvePrediction(ticker, result string) error {
+	_, err := d.db.Exec("UPDATE predictions SET result = ? WHERE ticker = ?", result, ticker)
+	if err != nil {
+		return fmt.Errorf("resolving prediction: %w", err)
+	}
+	return nil
+}
+
+// GetResolvedPredictions retrieves every prediction whose market settled
+// YES or NO, oldest first.
+func (d *DB) GetResolvedPredictions() ([]Prediction, error) {
+	rows, err := d.db.Query(`
+		SELECT ticker, game_id, market_type, line, prob, book_count, predicted_at, result
+		FROM predictions
+		WHERE result IN (?, ?)
+		ORDER BY predicted_at, ticker
+	`, ResultYes, ResultNo)
+	if err != nil {
+		return nil, fmt.Errorf("querying predictions: %w", err)
+	}
+	defer rows.Close()
+
+	var preds []Prediction
+	for rows.Next() {
+		var p Prediction
+		if err := rows.Scan(&p.Ticker, &p.GameID, &p.MarketType, &p.Line, &p.Prob,
+			&p.BookCount, &p.PredictedAt, &p.Result); err != nil {
+			return nil, fmt.Errorf("scanning prediction row: %w", err)
+		}
+		preds = append(preds, p)
+	}
+	return preds, rows.Err()
+}
+
+// SavePregame stores each game's consensus as of a pre-game scan at
+// scannedAt, replacing the game's earlier one. In-play pricing starts from
+// it, so a restart during a game can still price it.
+func (d *DB) SavePregame(consensus []odds.ConsensusOdds, scannedAt time.Time) error {
+	if len(consensus) == 0 {
+		return nil
+	}
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning pregame transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, c := range consensus {
+		data, err := json.Marshal(c)
+		if err != nil {
+			return fmt.Errorf("encoding pregame consensus: %w", err)
+		}
+		_, err = tx.Exec(`
+			INSERT INTO pregame_consensus (game_id, consensus, scanned_at)
+			VALUES (?, ?, ?)
+			ON CONFLICT(game_id) DO UPDATE SET
+				consensus = excluded.consensus, scanned_at = excluded.scanned_at
+		`, c.GameID, string(data), scannedAt.UTC())
+		if err != nil {
+			return fmt.Errorf("inserting pregame consensus: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetPregame retrieves the pre-game consensus saved at or after since, by
+// game ID.
+func (d *DB) GetPregame(since time.Time) (map[int]odds.ConsensusOdds, error) {
+	rows, err := d.db.Query(`
+		SELECT game_id, consensus FROM pregame_consensus WHERE scanned_at >= ?
+	`, since.UTC())
+	if err != nil {
+		return nil, fmt.Errorf("querying pregame consensus: %w", err)
+	}
+	defer rows.Close()
+
+	out := make(map[int]odds.ConsensusOdds)
+	for rows.Next() {
+		var gameID int
+		var data string
+		if err := rows.Scan(&gameID, &data); err != nil {
+			return nil, fmt.Errorf("scanning pregame consensus row: %w", err)
+		}
+		var c odds.ConsensusOdds
+		if err := json.Unmarshal([]byte(data), &c); err != nil {
+			return nil, fmt.Errorf("decoding pregame consensus for game %d: %w", gameID, err)
+		}
+		out[gameID] = c
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,90 @@
+package positions
+
+import (
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/odds"
+)
+
+func TestPredictionsKeepLastPregameScan(t *testing.T) {
+	db := newTestDB(t)
+	scan := time.Date(2026, 2, 5, 23, 0, 0, 0, time.UTC)
+	ticker := "KXNBAGAME-26FEB05GSWPHX"
+	for i, prob := range []float64{0.60, 0.64} {
+		err := db.RecordPredictions([]Prediction{
+			{Ticker: ticker, GameID: "7", MarketType: "moneyline", Prob: prob, BookCount: 6,
+				PredictedAt: scan.Add(time.Duration(i) * time.Minute)},
+			{Ticker: "KXNBATOTAL-26FEB05GSWPHX", GameID: "7", MarketType: "total", Line: 221.5, Prob: 0.48, BookCount: 5,
+				PredictedAt: scan.Add(time.Duration(i) * time.Minute)},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Only predictions last made before the cutoff are looked up
+	if tickers, _ := db.UnresolvedPredictionTickers(scan.Add(-time.Hour), scan); len(tickers) != 0 {
+		t.Errorf("UnresolvedPredictionTickers before the scans = %v, want none", tickers)
+	}
+	tickers, err := db.UnresolvedPredictionTickers(scan.Add(-time.Hour), scan.Add(time.Hour))
+	if err != nil || len(tickers) != 2 {
+		t.Fatalf("UnresolvedPredictionTickers = %v, %v, want both tickers", tickers, err)
+	}
+
+	if err := db.ResolvePrediction(ticker, ResultYes); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.ResolvePrediction("KXNBATOTAL-26FEB05GSWPHX", ResultVoid); err != nil {
+		t.Fatal(err)
+	}
+	// A resolved prediction is not overwritten by a late scan
+	if err := db.RecordPredictions([]Prediction{{Ticker: ticker, GameID: "7", MarketType: "moneyline", Prob: 0.9, PredictedAt: scan.Add(time.Hour)}}); err != nil {
+		t.Fatal(err)
+	}
+
+	preds, err := db.GetResolvedPredictions()
+	if err != nil || len(preds) != 1 {
+		t.Fatalf("GetResolvedPredictions = %+v, %v, want the moneyline; void is left out", preds, err)
+	}
+	p := preds[0]
+	if p.Prob != 0.64 || p.BookCount != 6 || !p.PredictedAt.Equal(scan.Add(time.Minute)) {
+		t.Errorf("prediction = %+v, want the last scan's 64%%", p)
+	}
+	if y, ok := p.Outcome(); !ok || y != 1 {
+		t.Errorf("Outcome = %v, %v, want 1", y, ok)
+	}
+}
+
+func TestPregameConsensusRoundTrip(t *testing.T) {
+	db := newTestDB(t)
+	scan := time.Date(2026, 2, 5, 23, 0, 0, 0, time.UTC)
+	c := odds.ConsensusOdds{
+		GameID: 7, GameDate: "2026-02-05", HomeTeam: "PHX", AwayTeam: "GSW",
+		Moneyline: &odds.MoneylineConsensus{HomeTrueProb: 0.6, AwayTrueProb: 0.4, BookCount: 6},
+		Total:     &odds.TotalConsensus{Line: 221.5, OverProb: 0.5, UnderProb: 0.5, BookCount: 5},
+	}
+	old := odds.ConsensusOdds{GameID: 3, GameDate: "2026-02-04"}
+	if err := db.SavePregame([]odds.ConsensusOdds{old}, scan.Add(-24*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	for _, at := range []time.Time{scan, scan.Add(time.Minute)} {
+		if err := db.SavePregame([]odds.ConsensusOdds{c}, at); err != nil {
+			t.Fatal(err)
+		}
+		c.Moneyline.HomeTrueProb = 0.62
+	}
+
+	saved, err := db.GetPregame(scan.Add(-6 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved) != 1 {
+		t.Fatalf("GetPregame = %+v, want only game 7", saved)
+	}
+	got := saved[7]
+	if got.HomeTeam != "PHX" || got.Moneyline == nil || got.Moneyline.HomeTrueProb != 0.62 ||
+		got.Total == nil || got.Total.Line != 221.5 || got.Spread != nil {
+		t.Errorf("saved = %+v, want the last scan's consensus", got)
+	}
+}
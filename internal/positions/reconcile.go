@@ -0,0 +1,182 @@
+package positions
+
+import (
+	"fmt"
+	"sort"
+
+	"sports-betting-bot/internal/kalshi"
+)
+
+// DiscrepancyKind classifies a difference between the DB and Kalshi
+type DiscrepancyKind string
+
+const (
+	DiscrepancyMissing DiscrepancyKind = "missing" // Held on Kalshi, not in the DB
+	DiscrepancyCount   DiscrepancyKind = "count"   // Held in both, contract counts differ
+	DiscrepancyOrphan  DiscrepancyKind = "orphan"  // In the DB, not held on Kalshi
+)
+
+// Discrepancy is one ticker+side where the DB and Kalshi disagree
+type Discrepancy struct {
+	Kind            DiscrepancyKind
+	Ticker          string
+	BetSide         string // "yes" or "no"
+	LocalContracts  int
+	RemoteContracts int
+	Local           []Position             // DB rows on this ticker+side, newest first
+	Remote          *kalshi.MarketPosition // nil for orphans
+}
+
+func (d Discrepancy) String() string {
+	return fmt.Sprintf("%s %s %s: db=%d kalshi=%d",
+		d.Kind, d.Ticker, d.BetSide, d.LocalContracts, d.RemoteContracts)
+}
+
+// Reconcile diffs open DB positions against Kalshi's positions by ticker and
+// side. Kalshi reports one net position per ticker (positive = yes).
+//
+// Arb rows, and locked rows on a ticker where locked rows hold both yes
+// and no, are pairs Kalshi nets to zero, so they are left out. Locked legs
+// on their own ticker, like a cross-market arb's, are compared as usual.
+// Rows without a ticker predate ticker tracking and cannot be compared.
+func Reconcile(local []Position, remote []kalshi.MarketPosition) []Discrepancy {
+	type key struct{ ticker, side string }
+
+	lockedSides := make(map[string]map[string]bool)
+	for _, pos := range local {
+		if pos.Locked {
+			if lockedSides[pos.Ticker] == nil {
+				lockedSides[pos.Ticker] = make(map[string]bool)
+			}
+			lockedSides[pos.Ticker][pos.BetSide] = true
+		}
+	}
+
+	localByKey := make(map[key][]Position)
+	for _, pos := range local {
+		if pos.Ticker == "" || pos.Side == "arb" || pos.Locked && len(lockedSides[pos.Ticker]) == 2 {
+			continue
+		}
+		k := key{pos.Ticker, pos.BetSide}
+		localByKey[k] = append(localByKey[k], pos)
+	}
+
+	remoteByKey := make(map[key]*kalshi.MarketPosition)
+	for i := range remote {
+		mp := &remote[i]
+		if mp.Position == 0 {
+			continue
+		}
+		side := string(kalshi.SideYes)
+		if mp.Position < 0 {
+			side = string(kalshi.SideNo)
+		}
+		remoteByKey[key{mp.Ticker, side}] = mp
+	}
+
+	var out []Discrepancy
+	for k, rows := range localByKey {
+		sort.SliceStable(rows, func(i, j int) bool { return rows[i].CreatedAt.After(rows[j].CreatedAt) })
+		localCount := 0
+		for _, r := range rows {
+			localCount += r.Contracts
+		}
+
+		mp, held := remoteByKey[k]
+		switch {
+		case !held:
+			out = append(out, Discrepancy{
+				Kind: DiscrepancyOrphan, Ticker: k.ticker, BetSide: k.side,
+				LocalContracts: localCount, Local: rows,
+			})
+		case abs(mp.Position) != localCount:
+			out = append(out, Discrepancy{
+				Kind: DiscrepancyCount, Ticker: k.ticker, BetSide: k.side,
+				LocalContracts: localCount, RemoteContracts: abs(mp.Position),
+				Local: rows, Remote: mp,
+			})
+		}
+	}
+
+	for k, mp := range remoteByKey {
+		if _, ok := localByKey[k]; ok {
+			continue
+		}
+		out = append(out, Discrepancy{
+			Kind: DiscrepancyMissing, Ticker: k.ticker, BetSide: k.side,
+			RemoteContracts: abs(mp.Position), Remote: mp,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Ticker != out[j].Ticker {
+			return out[i].Ticker < out[j].Ticker
+		}
+		return out[i].BetSide < out[j].BetSide
+	})
+	return out
+}
+
+// ImportedPosition builds a DB row for a position found only on Kalshi.
+// Game and team fields come from the ticker; the entry price is Kalshi's
+// cost basis (market exposure) spread over the contracts held.
+func ImportedPosition(mp kalshi.MarketPosition) Position {
+	contracts := abs(mp.Position)
+	betSide := string(kalshi.SideYes)
+	if mp.Position < 0 {
+		betSide = string(kalshi.SideNo)
+	}
+
+	pos := Position{
+		MarketType: "imported",
+		Side:       betSide,
+		Ticker:     mp.Ticker,
+		BetSide:    betSide,
+		Contracts:  contracts,
+		Fees:       float64(mp.FeesPaid) / 100,
+	}
+	if contracts > 0 {
+		pos.EntryPrice = float64(mp.MarketExposure) / 100 / float64(contracts)
+	}
+
+	info, ok := kalshi.ParseNBATicker(mp.Ticker)
+	if !ok {
+		return pos
+	}
+	pos.HomeTeam = info.HomeTeam
+	pos.AwayTeam = info.AwayTeam
+	if marketType := kalshi.MarketTypeForSeries(info.Series); marketType != "" {
+		pos.MarketType = marketType
+	}
+	pos.Side = sideForBet(pos.MarketType, betSide)
+	return pos
+}
+
+// sideForBet maps a yes/no contract to the Side the executor would store.
+// Game tickers here are home-team markets, so yes is home (or over).
+func sideForBet(marketType, betSide string) string {
+	yes := betSide == string(kalshi.SideYes)
+	switch marketType {
+	case "moneyline", "spread":
+		if yes {
+			return "home"
+		}
+		return "away"
+	case "total":
+		if yes {
+			return "over"
+		}
+		return "under"
+	}
+	if yes {
+		return "over"
+	}
+	return "under"
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,91 @@
+package positions
+
+import (
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/kalshi"
+)
+
+func TestReconcile(t *testing.T) {
+	now := time.Date(2026, 2, 5, 18, 0, 0, 0, time.UTC)
+	local := []Position{
+		{ID: 1, Ticker: "MATCH", BetSide: "yes", Contracts: 10},
+		{ID: 2, Ticker: "SHORT", BetSide: "no", Contracts: 5, CreatedAt: now},
+		{ID: 3, Ticker: "SHORT", BetSide: "no", Contracts: 5, CreatedAt: now.Add(time.Minute)},
+		{ID: 4, Ticker: "ORPHAN", BetSide: "yes", Contracts: 3},
+		{ID: 5, Ticker: "FLIPPED", BetSide: "yes", Contracts: 4},
+		{ID: 6, Ticker: "MATCH", BetSide: "yes", Side: "arb", Contracts: 50}, // nets to zero on Kalshi
+		{ID: 7, Ticker: "", BetSide: "yes", Contracts: 8},                    // legacy row
+		// A hedged pair on one ticker nets to zero on Kalshi
+		{ID: 8, Ticker: "HEDGED", BetSide: "yes", Contracts: 6, Locked: true},
+		{ID: 9, Ticker: "HEDGED", BetSide: "no", Contracts: 6, Locked: true, HedgeOf: 8},
+		// A cross-market arb's legs sit on their own tickers
+		{ID: 10, Ticker: "ARB-HOME", BetSide: "yes", Contracts: 9, Locked: true},
+		{ID: 11, Ticker: "ARB-AWAY", BetSide: "yes", Contracts: 9, Locked: true, HedgeOf: 10},
+	}
+	remote := []kalshi.MarketPosition{
+		{Ticker: "ARB-HOME", Position: 9},
+		{Ticker: "ARB-AWAY", Position: 7},
+		{Ticker: "MATCH", Position: 10},
+		{Ticker: "SHORT", Position: -12},
+		{Ticker: "FLIPPED", Position: -4},
+		{Ticker: "MANUAL", Position: 7},
+		{Ticker: "CLOSED", Position: 0},
+	}
+
+	diffs := Reconcile(local, remote)
+
+	want := []struct {
+		kind          DiscrepancyKind
+		ticker, side  string
+		local, remote int
+	}{
+		{DiscrepancyCount, "ARB-AWAY", "yes", 9, 7},
+		{DiscrepancyMissing, "FLIPPED", "no", 0, 4},
+		{DiscrepancyOrphan, "FLIPPED", "yes", 4, 0},
+		{DiscrepancyMissing, "MANUAL", "yes", 0, 7},
+		{DiscrepancyOrphan, "ORPHAN", "yes", 3, 0},
+		{DiscrepancyCount, "SHORT", "no", 10, 12},
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("got %d discrepancies %v, want %d", len(diffs), diffs, len(want))
+	}
+	for i, w := range want {
+		d := diffs[i]
+		if d.Kind != w.kind || d.Ticker != w.ticker || d.BetSide != w.side ||
+			d.LocalContracts != w.local || d.RemoteContracts != w.remote {
+			t.Errorf("diffs[%d] = %v, want %s %s %s: db=%d kalshi=%d",
+				i, d, w.kind, w.ticker, w.side, w.local, w.remote)
+		}
+	}
+
+	// Count fixes apply to the newest row first
+	if short := diffs[5]; short.Local[0].ID != 3 {
+		t.Errorf("SHORT rows = %+v, want newest (ID 3) first", short.Local)
+	}
+}
+
+func TestImportedPosition(t *testing.T) {
+	pos := ImportedPosition(kalshi.MarketPosition{
+		Ticker:         "KXNBATOTAL-26FEB05GSWPHX",
+		Position:       -20,
+		MarketExposure: 900,
+		FeesPaid:       35,
+	})
+
+	if pos.MarketType != "total" || pos.Side != "under" || pos.BetSide != "no" {
+		t.Errorf("type/side/bet = %s/%s/%s, want total/under/no", pos.MarketType, pos.Side, pos.BetSide)
+	}
+	if pos.HomeTeam != "PHX" || pos.AwayTeam != "GSW" {
+		t.Errorf("teams = %s@%s, want GSW@PHX", pos.AwayTeam, pos.HomeTeam)
+	}
+	if pos.Contracts != 20 || pos.EntryPrice != 0.45 || pos.Fees != 0.35 {
+		t.Errorf("contracts/entry/fees = %d/%v/%v, want 20/0.45/0.35", pos.Contracts, pos.EntryPrice, pos.Fees)
+	}
+
+	unknown := ImportedPosition(kalshi.MarketPosition{Ticker: "KXFED-26MAR", Position: 3})
+	if unknown.MarketType != "imported" || unknown.BetSide != "yes" {
+		t.Errorf("unparseable ticker = %+v, want imported yes row", unknown)
+	}
+}
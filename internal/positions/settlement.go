@@ -0,0 +1,116 @@
+package positions
+
+import (
+	"fmt"
+	"time"
+)
+
+// Market results as reported by Kalshi once a market settles.
+const (
+	ResultYes  = "yes"
+	ResultNo   = "no"
+	ResultVoid = "void"
+
+	// ResultClosed marks contracts sold before the market settled; see
+	// RecordExit.
+	ResultClosed = "closed"
+)
+
+// Settlement records how a position resolved
+type Settlement struct {
+	PositionID  int64
+	Ticker      string
+	Result      string  // "yes", "no", "void" or "closed"
+	Payout      float64 // Dollars credited at settlement, or sale proceeds
+	Fees        float64 // Taker fees paid at entry (and exit), in dollars
+	RealizedPnL float64 // Payout - cost - fees
+	SettledAt   time.Time
+}
+
+// Settle computes the settlement of pos for a market result.
+// Winning contracts pay $1 each; an arb holds both sides so its matched
+// contracts pay $1 whichever side wins. A void market refunds the cost and
+// the fees Kalshi charged, so it realizes nothing.
+func Settle(pos Position, result string) Settlement {
+	var payout float64
+	switch {
+	case result == ResultVoid:
+		payout = pos.Cost() + pos.Fees
+	case pos.Side == "arb":
+		payout = float64(pos.Contracts)
+	case pos.BetSide == result:
+		payout = float64(pos.Contracts)
+	}
+
+	return Settlement{
+		PositionID:  pos.ID,
+		Ticker:      pos.Ticker,
+		Result:      result,
+		Payout:      payout,
+		Fees:        pos.Fees,
+		RealizedPnL: payout - pos.Cost() - pos.Fees,
+	}
+}
+
+// RecordSettlement stores a settlement, removing its position from the open set.
+// Recording the same position twice is a no-op.
+func (d *DB) RecordSettlement(s Settlement) error {
+	settledAt := s.SettledAt
+	if settledAt.IsZero() {
+		settledAt = time.Now()
+	}
+	_, err := d.db.Exec(`
+		INSERT OR IGNORE INTO settlements (position_id, ticker, result, payout, fees, realized_pnl, settled_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, s.PositionID, s.Ticker, s.Result, s.Payout, s.Fees, s.RealizedPnL, settledAt.UTC())
+	if err != nil {
+		return fmt.Errorf("inserting settlement: %w", err)
+	}
+	return nil
+}
+
+// GetSettlements retrieves all settlements, newest first
+func (d *DB) GetSettlements() ([]Settlement, error) {
+	rows, err := d.db.Query(`
+		SELECT position_id, ticker, result, payout, fees, realized_pnl, settled_at
+		FROM settlements
+		ORDER BY settled_at DESC, position_id DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying settlements: %w", err)
+	}
+	defer rows.Close()
+
+	var settlements []Settlement
+	for rows.Next() {
+		var s Settlement
+		if err := rows.Scan(&s.PositionID, &s.Ticker, &s.Result, &s.Payout,
+			&s.Fees, &s.RealizedPnL, &s.SettledAt); err != nil {
+			return nil, fmt.Errorf("scanning settlement row: %w", err)
+		}
+		settlements = append(settlements, s)
+	}
+
+	return settlements, rows.Err()
+}
+
+// RealizedPnL returns the total realized P&L across all settlements, in dollars
+func (d *DB) RealizedPnL() (float64, error) {
+	var total float64
+	err := d.db.QueryRow("SELECT COALESCE(SUM(realized_pnl), 0) FROM settlements").Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("summing realized P&L: %w", err)
+	}
+	return total, nil
+}
+
+// RealizedPnLSince returns realized P&L from settlements at or after since, in dollars
+func (d *DB) RealizedPnLSince(since time.Time) (float64, error) {
+	var total float64
+	err := d.db.QueryRow("SELECT COALESCE(SUM(realized_pnl), 0) FROM settlements WHERE settled_at >= ?",
+		since.UTC()).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("summing realized P&L: %w", err)
+	}
+	return total, nil
+}
@@ -0,0 +1,105 @@
+package positions
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func newTestDB(t *testing.T) *DB {
+	t.Helper()
+	db, err := NewDB(filepath.Join(t.TempDir(), "positions.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestSettle(t *testing.T) {
+	yes := Position{MarketType: "moneyline", Side: "home", BetSide: "yes", EntryPrice: 0.40, Contracts: 10, Fees: 0.17}
+	arb := Position{MarketType: "arb_moneyline", Side: "arb", BetSide: "yes", EntryPrice: 9.50, Contracts: 10, Fees: 0.35}
+
+	tests := []struct {
+		name       string
+		pos        Position
+		result     string
+		wantPayout float64
+		wantPnL    float64
+	}{
+		{"win", yes, ResultYes, 10, 10 - 4 - 0.17},
+		{"loss", yes, ResultNo, 0, -4 - 0.17},
+		{"void refunds cost and fees", yes, ResultVoid, 4 + 0.17, 0},
+		{"void arb", arb, ResultVoid, 9.50 + 0.35, 0},
+		{"arb pays either way", arb, ResultNo, 10, 10 - 9.50 - 0.35},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Settle(tt.pos, tt.result)
+			if math.Abs(s.Payout-tt.wantPayout) > 1e-9 {
+				t.Errorf("Payout = %v, want %v", s.Payout, tt.wantPayout)
+			}
+			if math.Abs(s.RealizedPnL-tt.wantPnL) > 1e-9 {
+				t.Errorf("RealizedPnL = %v, want %v", s.RealizedPnL, tt.wantPnL)
+			}
+		})
+	}
+}
+
+func TestSettledPositionsLeaveActiveSet(t *testing.T) {
+	db := newTestDB(t)
+
+	open := Position{GameID: "1", HomeTeam: "PHX", AwayTeam: "GSW", MarketType: "moneyline",
+		Side: "home", Ticker: "KXNBAGAME-26FEB05GSWPHX", BetSide: "yes", EntryPrice: 0.5, Contracts: 10, Fees: 0.18}
+	done := open
+	done.Ticker = "KXNBAGAME-26FEB04MEMSAC"
+
+	if _, err := db.AddPosition(open); err != nil {
+		t.Fatal(err)
+	}
+	doneID, err := db.AddPosition(done)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done.ID = doneID
+
+	s := Settle(done, ResultYes)
+	if err := db.RecordSettlement(s); err != nil {
+		t.Fatal(err)
+	}
+	// Recording twice must not double-count
+	if err := db.RecordSettlement(s); err != nil {
+		t.Fatal(err)
+	}
+
+	active, err := db.GetAllPositions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(active) != 1 || active[0].Ticker != open.Ticker {
+		t.Fatalf("active = %+v, want only %s", active, open.Ticker)
+	}
+	if active[0].BetSide != "yes" || active[0].Fees != 0.18 {
+		t.Errorf("active[0] = %+v, want bet_side and fees round-tripped", active[0])
+	}
+	if byGame, _ := db.GetPositionsByGame("1"); len(byGame) != 1 {
+		t.Errorf("GetPositionsByGame = %d rows, want 1 open", len(byGame))
+	}
+
+	settlements, err := db.GetSettlements()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(settlements) != 1 || settlements[0].PositionID != doneID || settlements[0].Result != ResultYes {
+		t.Errorf("settlements = %+v, want one yes for position %d", settlements, doneID)
+	}
+
+	pnl, err := db.RealizedPnL()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(pnl-(10-5-0.18)) > 1e-9 {
+		t.Errorf("RealizedPnL = %v, want %v", pnl, 10-5-0.18)
+	}
+}
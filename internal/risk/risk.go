@@ -0,0 +1,200 @@
+// Package risk enforces portfolio-level exposure caps across open positions.
+package risk
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"sports-betting-bot/internal/config"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/positions"
+)
+
+// Limits caps open exposure in dollars. A zero limit is disabled.
+type Limits struct {
+	MaxPerGame       float64
+	MaxPerTeam       float64
+	MaxPerPlayer     float64
+	MaxPerMarketType float64
+	MaxTotal         float64
+}
+
+// LimitsFromConfig reads the exposure caps from cfg.
+func LimitsFromConfig(cfg config.Config) Limits {
+	return Limits{
+		MaxPerGame:       cfg.MaxGameExposure,
+		MaxPerTeam:       cfg.MaxTeamExposure,
+		MaxPerPlayer:     cfg.MaxPlayerExposure,
+		MaxPerMarketType: cfg.MaxMarketTypeExposure,
+		MaxTotal:         cfg.MaxTotalExposure,
+	}
+}
+
+// Trade identifies what a position or prospective order is exposed to.
+type Trade struct {
+	Ticker     string
+	GameID     string
+	HomeTeam   string
+	AwayTeam   string
+	MarketType string // "moneyline", "spread", "total", "prop_points", ...
+	Side       string // "home", "away", "over", "under"
+	Player     string // Player props only
+}
+
+// TradeFromPosition builds the exposure identity of a stored position.
+// Prop positions store "<player>_<side>_<line>" in Side.
+func TradeFromPosition(pos positions.Position) Trade {
+	t := Trade{
+		Ticker:     pos.Ticker,
+		GameID:     pos.GameID,
+		HomeTeam:   pos.HomeTeam,
+		AwayTeam:   pos.AwayTeam,
+		MarketType: pos.MarketType,
+		Side:       pos.Side,
+	}
+	if strings.HasPrefix(pos.MarketType, "prop_") {
+		if parts := strings.Split(pos.Side, "_"); len(parts) >= 3 {
+			t.Player = strings.Join(parts[:len(parts)-2], "_")
+			t.Side = parts[len(parts)-2]
+		}
+	}
+	return t
+}
+
+// Game keys the trade's game. The ticker's game segment is preferred so
+// positions imported from Kalshi, which have no game ID, still group.
+func (t Trade) Game() string {
+	if info, ok := kalshi.ParseNBATicker(t.Ticker); ok {
+		return info.Game()
+	}
+	return t.GameID
+}
+
+// Team returns the team whose performance the trade backs, or "" if none.
+// Moneyline and spread back the chosen side; player props count toward
+// the player's team whichever way they go. Totals back neither team.
+func (t Trade) Team() string {
+	switch t.MarketType {
+	case "moneyline", "spread":
+		switch t.Side {
+		case "home":
+			return t.HomeTeam
+		case "away":
+			return t.AwayTeam
+		}
+	}
+	if strings.HasPrefix(t.MarketType, "prop_") {
+		if info, ok := kalshi.ParseNBATicker(t.Ticker); ok {
+			return info.PlayerTeam
+		}
+	}
+	return ""
+}
+
+// Exposure is open dollars at risk, grouped each way the caps apply.
+type Exposure struct {
+	Total      float64
+	Game       map[string]float64
+	Team       map[string]float64
+	Player     map[string]float64
+	MarketType map[string]float64
+}
+
+// ExposureOf sums the cost of open positions. Arb rows and locked hedge
+// pairs hold both sides of a market and pay out either way, so they carry
+// no exposure.
+func ExposureOf(open []positions.Position) Exposure {
+	e := Exposure{
+		Game:       make(map[string]float64),
+		Team:       make(map[string]float64),
+		Player:     make(map[string]float64),
+		MarketType: make(map[string]float64),
+	}
+	for _, pos := range open {
+		if pos.Side == "arb" || pos.Locked {
+			continue
+		}
+		t := TradeFromPosition(pos)
+		cost := pos.Cost()
+
+		e.Total += cost
+		e.Game[t.Game()] += cost
+		e.MarketType[t.MarketType] += cost
+		if team := t.Team(); team != "" {
+			e.Team[team] += cost
+		}
+		if t.Player != "" {
+			e.Player[t.Player] += cost
+		}
+	}
+	return e
+}
+
+// Headroom returns how many more dollars t can commit before a cap is hit,
+// and a description of the binding cap. With no applicable caps it returns
+// +Inf and "".
+func (l Limits) Headroom(e Exposure, t Trade) (float64, string) {
+	room, binding := math.Inf(1), ""
+	check := func(limit, used float64, name string) {
+		if limit <= 0 {
+			return
+		}
+		if r := limit - used; r < room {
+			room, binding = r, name
+		}
+	}
+
+	check(l.MaxTotal, e.Total, "total")
+	game := t.Game()
+	check(l.MaxPerGame, e.Game[game], fmt.Sprintf("game %s", game))
+	if team := t.Team(); team != "" {
+		check(l.MaxPerTeam, e.Team[team], fmt.Sprintf("team %s", team))
+	}
+	if t.Player != "" {
+		check(l.MaxPerPlayer, e.Player[t.Player], fmt.Sprintf("player %s", t.Player))
+	}
+	check(l.MaxPerMarketType, e.MarketType[t.MarketType], fmt.Sprintf("market type %s", t.MarketType))
+
+	return math.Max(room, 0), binding
+}
+
+// Manager checks prospective trades against Limits using the open positions
+// in the DB, so fills recorded earlier in the same scan count immediately.
+// The unfilled remainder of resting orders counts as if already filled.
+type Manager struct {
+	limits Limits
+	db     *positions.DB
+}
+
+// NewManager creates a risk manager. db may be nil, in which case only the
+// trade itself counts toward the caps.
+func NewManager(limits Limits, db *positions.DB) *Manager {
+	return &Manager{limits: limits, db: db}
+}
+
+// Headroom returns the dollars t may still commit and the binding cap.
+func (m *Manager) Headroom(t Trade) (float64, string, error) {
+	if m.limits == (Limits{}) {
+		return math.Inf(1), "", nil
+	}
+
+	var open []positions.Position
+	if m.db != nil {
+		var err error
+		open, err = m.db.GetAllPositions()
+		if err != nil {
+			return 0, "", fmt.Errorf("loading open positions: %w", err)
+		}
+		orders, err := m.db.GetOpenOrders()
+		if err != nil {
+			return 0, "", fmt.Errorf("loading open orders: %w", err)
+		}
+		for _, o := range orders {
+			open = append(open, o.Position(o.Remaining(), 0))
+		}
+	}
+
+	room, binding := m.limits.Headroom(ExposureOf(open), t)
+	return room, binding, nil
+}
@@ -0,0 +1,72 @@
+package risk
+
+import (
+	"math"
+	"testing"
+
+	"sports-betting-bot/internal/positions"
+)
+
+// Two open positions on GSW@PHX: a PHX moneyline and a Curry points over.
+var open = []positions.Position{
+	{GameID: "1", HomeTeam: "PHX", AwayTeam: "GSW", MarketType: "moneyline", Side: "home",
+		Ticker: "KXNBAGAME-26FEB05GSWPHX", BetSide: "yes", EntryPrice: 0.50, Contracts: 100},
+	{GameID: "1", HomeTeam: "PHX", AwayTeam: "GSW", MarketType: "prop_points", Side: "Stephen Curry_over_29.5",
+		Ticker: "KXNBAPTS-26FEB05GSWPHX-GSWSCURRY30-30", BetSide: "yes", EntryPrice: 0.40, Contracts: 50},
+	{GameID: "1", HomeTeam: "PHX", AwayTeam: "GSW", MarketType: "arb_moneyline", Side: "arb",
+		Ticker: "KXNBAGAME-26FEB05GSWPHX", EntryPrice: 95, Contracts: 100},
+}
+
+func TestExposureOf(t *testing.T) {
+	e := ExposureOf(open)
+
+	if e.Total != 70 {
+		t.Errorf("Total = %v, want 70 (arb excluded)", e.Total)
+	}
+	if e.Game["26FEB05GSWPHX"] != 70 {
+		t.Errorf("Game = %v, want 70 on 26FEB05GSWPHX", e.Game)
+	}
+	if e.Team["PHX"] != 50 || e.Team["GSW"] != 20 {
+		t.Errorf("Team = %v, want PHX 50 and GSW 20", e.Team)
+	}
+	if e.Player["Stephen Curry"] != 20 {
+		t.Errorf("Player = %v, want Stephen Curry 20", e.Player)
+	}
+	if e.MarketType["moneyline"] != 50 || e.MarketType["prop_points"] != 20 {
+		t.Errorf("MarketType = %v, want moneyline 50 and prop_points 20", e.MarketType)
+	}
+}
+
+func TestHeadroom(t *testing.T) {
+	e := ExposureOf(open)
+	spreadPHX := Trade{Ticker: "KXNBASPREAD-26FEB05GSWPHX", HomeTeam: "PHX", AwayTeam: "GSW", MarketType: "spread", Side: "home"}
+	curryReb := Trade{Ticker: "KXNBAREB-26FEB05GSWPHX-GSWSCURRY30-6", HomeTeam: "PHX", AwayTeam: "GSW",
+		MarketType: "prop_rebounds", Side: "over", Player: "Stephen Curry"}
+	otherGame := Trade{Ticker: "KXNBAGAME-26FEB05LALBOS", HomeTeam: "BOS", AwayTeam: "LAL", MarketType: "moneyline", Side: "home"}
+
+	tests := []struct {
+		name        string
+		limits      Limits
+		trade       Trade
+		wantRoom    float64
+		wantBinding string
+	}{
+		{"no caps", Limits{}, spreadPHX, math.Inf(1), ""},
+		{"total", Limits{MaxTotal: 100}, otherGame, 30, "total"},
+		{"game", Limits{MaxPerGame: 80, MaxTotal: 500}, spreadPHX, 10, "game 26FEB05GSWPHX"},
+		{"team", Limits{MaxPerTeam: 60}, spreadPHX, 10, "team PHX"},
+		{"player", Limits{MaxPerPlayer: 25, MaxPerTeam: 100}, curryReb, 5, "player Stephen Curry"},
+		{"market type", Limits{MaxPerMarketType: 55}, otherGame, 5, "market type moneyline"},
+		{"game cap ignores other games", Limits{MaxPerGame: 80}, otherGame, 80, "game 26FEB05LALBOS"},
+		{"exhausted", Limits{MaxTotal: 50}, otherGame, 0, "total"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			room, binding := tt.limits.Headroom(e, tt.trade)
+			if room != tt.wantRoom || binding != tt.wantBinding {
+				t.Errorf("Headroom = %v, %q, want %v, %q", room, binding, tt.wantRoom, tt.wantBinding)
+			}
+		})
+	}
+}
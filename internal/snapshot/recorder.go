@@ -0,0 +1,215 @@
+package snapshot
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const filePrefix = "snapshots-"
+
+// Recorder appends Records to gzip-compressed JSONL files in a directory.
+// A new file is started each day (Eastern Time, matching the NBA schedule)
+// and whenever the current file exceeds maxFileBytes. When maxTotalBytes is
+// set, the oldest files are deleted to keep the directory under that size.
+// Every record is flushed so a crash loses at most the record being written.
+type Recorder struct {
+	mu            sync.Mutex
+	dir           string
+	maxFileBytes  int64
+	maxTotalBytes int64
+	loc           *time.Location
+	now           func() time.Time
+
+	day  string
+	seq  int
+	file *os.File
+	gz   *gzip.Writer
+	size *countingWriter
+}
+
+// NewRecorder creates a recorder writing to dir. A limit of 0 disables it.
+func NewRecorder(dir string, maxFileBytes, maxTotalBytes int64) (*Recorder, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating snapshot dir: %w", err)
+	}
+
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		loc = time.FixedZone("ET", -5*60*60)
+	}
+
+	return &Recorder{
+		dir:           dir,
+		maxFileBytes:  maxFileBytes,
+		maxTotalBytes: maxTotalBytes,
+		loc:           loc,
+		now:           time.Now,
+	}, nil
+}
+
+// Record marshals v and writes it as a record of the given kind, stamped now.
+// gameID and ticker are stored only when non-zero.
+func (r *Recorder) Record(kind Kind, gameID int, ticker string, v any) error {
+	rec, err := NewRecord(r.now(), kind, v)
+	if err != nil {
+		return err
+	}
+	rec.GameID = gameID
+	rec.Ticker = ticker
+	return r.Write(rec)
+}
+
+// Write appends rec to the current file, rotating first if needed.
+func (r *Recorder) Write(rec Record) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("marshaling record: %w", err)
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.rotateIfNeeded(rec.Time); err != nil {
+		return err
+	}
+	if _, err := r.gz.Write(line); err != nil {
+		return fmt.Errorf("writing record: %w", err)
+	}
+	if err := r.gz.Flush(); err != nil {
+		return fmt.Errorf("flushing record: %w", err)
+	}
+	return nil
+}
+
+// Close finishes the current file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closeFile()
+}
+
+func (r *Recorder) rotateIfNeeded(t time.Time) error {
+	day := t.In(r.loc).Format("2006-01-02")
+	full := r.maxFileBytes > 0 && r.size != nil && r.size.n >= r.maxFileBytes
+	if r.file != nil && day == r.day && !full {
+		return nil
+	}
+
+	if err := r.closeFile(); err != nil {
+		return err
+	}
+
+	if day != r.day {
+		r.day = day
+		seq, err := r.nextSeq(day)
+		if err != nil {
+			return err
+		}
+		r.seq = seq
+	} else {
+		r.seq++
+	}
+
+	path := filepath.Join(r.dir, fmt.Sprintf("%s%s-%03d.jsonl.gz", filePrefix, r.day, r.seq))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("creating snapshot file: %w", err)
+	}
+	r.file = f
+	r.size = &countingWriter{w: f}
+	r.gz = gzip.NewWriter(r.size)
+
+	return r.prune(path)
+}
+
+// nextSeq returns the first unused sequence number for day, so a restart
+// starts a fresh file instead of appending to one that may be truncated.
+func (r *Recorder) nextSeq(day string) (int, error) {
+	matches, err := filepath.Glob(filepath.Join(r.dir, filePrefix+day+"-*.jsonl.gz"))
+	if err != nil {
+		return 0, fmt.Errorf("listing snapshot files: %w", err)
+	}
+	next := 0
+	for _, m := range matches {
+		var seq int
+		name := strings.TrimPrefix(filepath.Base(m), filePrefix+day+"-")
+		if _, err := fmt.Sscanf(name, "%03d.jsonl.gz", &seq); err == nil && seq >= next {
+			next = seq + 1
+		}
+	}
+	return next, nil
+}
+
+// prune deletes the oldest snapshot files until the directory fits within
+// maxTotalBytes. The file currently being written is never removed.
+func (r *Recorder) prune(current string) error {
+	if r.maxTotalBytes <= 0 {
+		return nil
+	}
+
+	files, err := ListFiles(r.dir)
+	if err != nil {
+		return err
+	}
+
+	sizes := make([]int64, len(files))
+	var total int64
+	for i, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		sizes[i] = info.Size()
+		total += sizes[i]
+	}
+
+	for i, f := range files {
+		if total <= r.maxTotalBytes {
+			break
+		}
+		if f == current || !strings.HasPrefix(filepath.Base(f), filePrefix) {
+			continue
+		}
+		if err := os.Remove(f); err != nil {
+			return fmt.Errorf("pruning snapshot file: %w", err)
+		}
+		total -= sizes[i]
+	}
+	return nil
+}
+
+func (r *Recorder) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	gzErr := r.gz.Close()
+	fileErr := r.file.Close()
+	r.file, r.gz, r.size = nil, nil, nil
+	if gzErr != nil {
+		return fmt.Errorf("closing gzip stream: %w", gzErr)
+	}
+	if fileErr != nil {
+		return fmt.Errorf("closing snapshot file: %w", fileErr)
+	}
+	return nil
+}
+
+// countingWriter tracks compressed bytes written for size-based rotation.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
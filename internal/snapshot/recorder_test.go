@@ -0,0 +1,145 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestRecorder(t *testing.T, maxFile, maxTotal int64, now *time.Time) (*Recorder, string) {
+	t.Helper()
+	dir := t.TempDir()
+	r, err := NewRecorder(dir, maxFile, maxTotal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.loc = time.UTC
+	r.now = func() time.Time { return *now }
+	return r, dir
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	now := time.Date(2026, 2, 5, 18, 0, 0, 0, time.UTC)
+	r, dir := newTestRecorder(t, 0, 0, &now)
+
+	if err := r.Record(KindPlayerProps, 42, "", []map[string]int{{"player_id": 7}}); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Second)
+	if err := r.Record(KindOrderBook, 0, "KXNBAGAME-26FEB05GSWPHX", map[string]any{"orderbook": nil}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Records are flushed per write, so they are readable before Close
+	recs, err := ReadPath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(recs) != 2 {
+		t.Fatalf("read %d records before close, want 2", len(recs))
+	}
+	if recs[0].Kind != KindPlayerProps || recs[0].GameID != 42 {
+		t.Errorf("first record = %+v, want player_props for game 42", recs[0])
+	}
+	if recs[1].Ticker != "KXNBAGAME-26FEB05GSWPHX" {
+		t.Errorf("second record ticker = %q", recs[1].Ticker)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	recs, err = ReadPath(dir)
+	if err != nil || len(recs) != 2 {
+		t.Fatalf("after close: %d records, err %v", len(recs), err)
+	}
+}
+
+func TestRecorderRotatesByDay(t *testing.T) {
+	now := time.Date(2026, 2, 5, 23, 59, 0, 0, time.UTC)
+	r, dir := newTestRecorder(t, 0, 0, &now)
+	defer r.Close()
+
+	r.Record(KindOdds, 0, "", []int{1})
+	now = now.Add(2 * time.Minute)
+	r.Record(KindOdds, 0, "", []int{2})
+
+	files, _ := ListFiles(dir)
+	if len(files) != 2 {
+		t.Fatalf("files = %v, want one per day", files)
+	}
+	if !strings.Contains(files[0], "2026-02-05") || !strings.Contains(files[1], "2026-02-06") {
+		t.Errorf("files = %v, want 2026-02-05 then 2026-02-06", files)
+	}
+}
+
+func TestRecorderRotatesBySize(t *testing.T) {
+	now := time.Date(2026, 2, 5, 18, 0, 0, 0, time.UTC)
+	r, dir := newTestRecorder(t, 1, 0, &now) // every write fills the file
+	defer r.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := r.Record(KindOdds, 0, "", []int{i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, _ := ListFiles(dir)
+	if len(files) != 3 {
+		t.Fatalf("files = %v, want 3 after size rotation", files)
+	}
+	recs, err := ReadPath(dir)
+	if err != nil || len(recs) != 3 {
+		t.Fatalf("read %d records, err %v, want 3", len(recs), err)
+	}
+}
+
+func TestRecorderResumesSequence(t *testing.T) {
+	now := time.Date(2026, 2, 5, 18, 0, 0, 0, time.UTC)
+	r, dir := newTestRecorder(t, 0, 0, &now)
+	r.Record(KindOdds, 0, "", []int{1})
+	r.Close()
+
+	// A restart on the same day must not overwrite the earlier file
+	r2, err := NewRecorder(dir, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2.loc = time.UTC
+	r2.now = func() time.Time { return now }
+	r2.Record(KindOdds, 0, "", []int{2})
+	r2.Close()
+
+	recs, err := ReadPath(dir)
+	if err != nil || len(recs) != 2 {
+		t.Fatalf("read %d records, err %v, want 2", len(recs), err)
+	}
+}
+
+func TestRecorderPrunesOldest(t *testing.T) {
+	now := time.Date(2026, 2, 5, 18, 0, 0, 0, time.UTC)
+	r, dir := newTestRecorder(t, 1, 0, &now)
+	defer r.Close()
+
+	for i := 0; i < 4; i++ {
+		r.Record(KindOdds, 0, "", []int{i})
+	}
+	files, _ := ListFiles(dir)
+	info, err := os.Stat(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Allow roughly two files; the next rotation should drop the oldest ones
+	r.maxTotalBytes = 2 * info.Size()
+	r.Record(KindOdds, 0, "", []int{4})
+
+	files, _ = ListFiles(dir)
+	if len(files) > 3 {
+		t.Fatalf("files = %v, want oldest pruned", files)
+	}
+	if filepath.Base(files[0]) == "snapshots-2026-02-05-000.jsonl.gz" {
+		t.Errorf("oldest file was not pruned: %v", files)
+	}
+}
@@ -0,0 +1,156 @@
+package snapshot
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Kind identifies what a Record holds.
+type Kind string
+
+const (
+	KindOdds        Kind = "odds"         // []api.GameOdds from GetTodaysOdds
+	KindPlayerProps Kind = "player_props" // []api.PlayerProp from GetPlayerProps (GameID set)
+	KindPlayerNames Kind = "player_names" // map[int]string from GetPlayerNames
+	KindPropMarkets Kind = "prop_markets" // map[string][]kalshi.PlayerPropMarket from GetPlayerPropMarkets
+	KindOrderBook   Kind = "orderbook"    // kalshi.OrderBookResponse from GetOrderBook (Ticker set)
+	KindResult      Kind = "result"       // MarketResult for a finalized market (Ticker set)
+)
+
+// MarketResult is the settled outcome of a Kalshi market.
+type MarketResult struct {
+	Result string `json:"result"` // "yes" or "no"
+}
+
+// Record is one raw input the bot saw, stored as a single JSONL line.
+type Record struct {
+	Time   time.Time       `json:"t"`
+	Kind   Kind            `json:"kind"`
+	GameID int             `json:"game_id,omitempty"`
+	Ticker string          `json:"ticker,omitempty"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// NewRecord marshals v into a Record of the given kind.
+func NewRecord(t time.Time, kind Kind, v any) (Record, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return Record{}, fmt.Errorf("marshaling %s record: %w", kind, err)
+	}
+	return Record{Time: t, Kind: kind, Data: data}, nil
+}
+
+// Decode unmarshals the record payload into v.
+func (r Record) Decode(v any) error {
+	if err := json.Unmarshal(r.Data, v); err != nil {
+		return fmt.Errorf("decoding %s record: %w", r.Kind, err)
+	}
+	return nil
+}
+
+// ReadFile reads all records from a .jsonl or .jsonl.gz file.
+func ReadFile(path string) ([]Record, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening snapshot file: %w", err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("opening gzip stream %s: %w", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	return readRecords(r, path)
+}
+
+func readRecords(r io.Reader, name string) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 1024*1024), 64*1024*1024) // Odds pages can be large
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%s:%d: parsing record: %w", name, line, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		// A truncated gzip tail (process killed mid-write) still yields the
+		// records before it; keep them rather than failing the whole file.
+		if len(records) > 0 && errors.Is(err, io.ErrUnexpectedEOF) {
+			return records, nil
+		}
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+	return records, nil
+}
+
+// ReadPath reads records from a single file or every .jsonl/.jsonl.gz file in
+// a directory, returned in time order.
+func ReadPath(path string) ([]Record, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot path: %w", err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		files, err = ListFiles(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var all []Record
+	for _, f := range files {
+		recs, err := ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, recs...)
+	}
+
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Time.Before(all[j].Time)
+	})
+	return all, nil
+}
+
+// ListFiles returns the snapshot files in dir sorted by name.
+func ListFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("listing snapshot dir: %w", err)
+	}
+	var files []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !(strings.HasSuffix(name, ".jsonl") || strings.HasSuffix(name, ".jsonl.gz")) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,390 @@
+// Package vendorfit fits per-vendor consensus weights, per market type, to
+// our own recorded sportsbook lines and settled Kalshi results: the weights
+// that minimize the log loss of the log-linear pool the consensus uses.
+package vendorfit
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/mathutil"
+	"sports-betting-bot/internal/odds"
+	"sports-betting-bot/internal/positions"
+	"sports-betting-bot/internal/snapshot"
+)
+
+// Fit defaults.
+const (
+	DefaultMinSamples = 50   // Vendors quoting fewer markets keep their default weight
+	DefaultL2         = 0.01 // Pull of each log-weight toward its default
+)
+
+// Fitted weights are kept within [minWeight, maxWeight].
+const (
+	minWeight = 0.1
+	maxWeight = 10.0
+)
+
+// probFloor keeps logits finite for a vendor probability of exactly 0 or 1.
+const probFloor = 1e-6
+
+// Sample is one settled Kalshi market with every vendor's vig-free P(YES)
+// at its line.
+type Sample struct {
+	Market  string             // "moneyline", "spread", "total", "prop_points", ...
+	Probs   map[string]float64 // Vendor name -> P(YES)
+	Outcome float64            // 1 if the market settled YES, 0 if NO
+}
+
+// Collect pairs each settled prediction with the vendor lines of the last
+// odds or props record for its game at or before the game's last
+// prediction, the scan the consensus was taken from. Game markets use
+// odds.VendorProbs at the prediction's line; props use the vendors whose
+// over line is the Kalshi strike less a half point. Predictions without
+// recorded lines are skipped.
+func Collect(records []snapshot.Record, preds []positions.Prediction) ([]Sample, error) {
+	cutoff := make(map[int]time.Time)
+	for _, p := range preds {
+		if _, ok := p.Outcome(); !ok {
+			continue
+		}
+		id, err := strconv.Atoi(p.GameID)
+		if err != nil {
+			continue
+		}
+		if p.PredictedAt.After(cutoff[id]) {
+			cutoff[id] = p.PredictedAt
+		}
+	}
+
+	gameOdds := make(map[int]api.GameOdds)
+	props := make(map[int][]api.PlayerProp)
+	names := make(map[int]string)
+	propMarkets := make(map[string]kalshi.PlayerPropMarket)
+	before := func(id int, t time.Time) bool {
+		c, ok := cutoff[id]
+		return ok && !t.After(c)
+	}
+	for _, rec := range records {
+		switch rec.Kind {
+		case snapshot.KindOdds:
+			var games []api.GameOdds
+			if err := rec.Decode(&games); err != nil {
+				return nil, err
+			}
+			for _, g := range games {
+				if before(g.GameID, rec.Time) {
+					gameOdds[g.GameID] = g
+				}
+			}
+		case snapshot.KindPlayerProps:
+			if !before(rec.GameID, rec.Time) {
+				continue
+			}
+			var gameProps []api.PlayerProp
+			if err := rec.Decode(&gameProps); err != nil {
+				return nil, err
+			}
+			props[rec.GameID] = gameProps
+		case snapshot.KindPlayerNames:
+			var batch map[int]string
+			if err := rec.Decode(&batch); err != nil {
+				return nil, err
+			}
+			for id, name := range batch {
+				names[id] = name
+			}
+		case snapshot.KindPropMarkets:
+			var markets map[string][]kalshi.PlayerPropMarket
+			if err := rec.Decode(&markets); err != nil {
+				return nil, err
+			}
+			for _, ms := range markets {
+				for _, km := range ms {
+					propMarkets[km.Ticker] = km
+				}
+			}
+		}
+	}
+
+	var samples []Sample
+	for _, p := range preds {
+		y, ok := p.Outcome()
+		if !ok {
+			continue
+		}
+		id, err := strconv.Atoi(p.GameID)
+		if err != nil {
+			continue
+		}
+		var probs map[string]float64
+		if propType, isProp := strings.CutPrefix(p.MarketType, "prop_"); isProp {
+			km, ok := propMarkets[p.Ticker]
+			if !ok {
+				continue
+			}
+			probs = propProbs(props[id], names, km.PlayerName, propType, p.Line)
+		} else {
+			g, ok := gameOdds[id]
+			if !ok {
+				continue
+			}
+			var spreadLine, totalLine float64
+			switch odds.MarketType(p.MarketType) {
+			case odds.MarketSpread:
+				spreadLine = p.Line
+			case odds.MarketTotal:
+				totalLine = p.Line
+			}
+			probs = odds.VendorProbs(g, spreadLine, totalLine)[odds.MarketType(p.MarketType)]
+		}
+		if len(probs) == 0 {
+			continue
+		}
+		samples = append(samples, Sample{Market: p.MarketType, Probs: probs, Outcome: y})
+	}
+	return samples, nil
+}
+
+// propProbs returns each vendor's vig-free over probability for a player's
+// Kalshi strike: sportsbook "over 24.5" is Kalshi "25+".
+func propProbs(props []api.PlayerProp, names map[int]string, player, propType string, strike float64) map[string]float64 {
+	probs := make(map[string]float64)
+	for _, prop := range props {
+		if prop.PropType != propType || prop.Market.Type != "over_under" || api.IsKalshi(prop.Vendor) {
+			continue
+		}
+		if prop.Market.OverOdds == 0 || prop.Market.UnderOdds == 0 || prop.Line()+0.5 != strike {
+			continue
+		}
+		if !kalshi.PlayerNamesMatch(names[prop.PlayerID], player) {
+			continue
+		}
+		over, under := odds.RemoveVigPowerFromAmerican(prop.Market.OverOdds, prop.Market.UnderOdds)
+		if over > 0 && under > 0 {
+			probs[prop.Vendor] = over
+		}
+	}
+	return probs
+}
+
+// Config controls a fit.
+type Config struct {
+	MinSamples int     // Vendors in fewer samples keep their default weight
+	L2         float64 // Penalty on each log-weight's distance from its default
+}
+
+// VendorFit is one vendor's weight in one market type.
+type VendorFit struct {
+	Vendor  string
+	Samples int
+	Default float64 // api.DefaultVendorWeight
+	Weight  float64
+	Fitted  bool // False when below MinSamples; Weight is then Default
+}
+
+// MarketFit is the fit for one market type. Log losses are in-sample, with
+// the default and the fitted weights.
+type MarketFit struct {
+	Market         string
+	Samples        int
+	Vendors        []VendorFit // Sorted by vendor name
+	DefaultLogLoss float64
+	FittedLogLoss  float64
+}
+
+// Fit fits weights for each market type in samples, sorted by market type.
+// Each fit minimizes the mean log loss of sigmoid(Σ w·logit(p) / Σ w) plus
+// cfg.L2 times the squared distance of each log-weight from its default,
+// by projected gradient descent. The consensus also winsorizes outlying
+// logits; the fit pools them as quoted.
+func Fit(samples []Sample, cfg Config) []MarketFit {
+	byMarket := make(map[string][]Sample)
+	for _, s := range samples {
+		byMarket[s.Market] = append(byMarket[s.Market], s)
+	}
+	var fits []MarketFit
+	for market, ss := range byMarket {
+		fits = append(fits, fitMarket(market, ss, cfg))
+	}
+	sort.Slice(fits, func(i, j int) bool { return fits[i].Market < fits[j].Market })
+	return fits
+}
+
+// quote is one vendor's logit in a sample, by index into the market's
+// vendor list.
+type quote struct {
+	vendor int
+	logit  float64
+}
+
+func fitMarket(market string, samples []Sample, cfg Config) MarketFit {
+	counts := make(map[string]int)
+	for _, s := range samples {
+		for vendor := range s.Probs {
+			counts[vendor]++
+		}
+	}
+	vendors := make([]VendorFit, 0, len(counts))
+	for vendor, n := range counts {
+		def := api.DefaultVendorWeight(market, vendor)
+		vendors = append(vendors, VendorFit{
+			Vendor:  vendor,
+			Samples: n,
+			Default: def,
+			Weight:  def,
+			Fitted:  n >= cfg.MinSamples,
+		})
+	}
+	sort.Slice(vendors, func(i, j int) bool { return vendors[i].Vendor < vendors[j].Vendor })
+	index := make(map[string]int, len(vendors))
+	for i, v := range vendors {
+		index[v.Vendor] = i
+	}
+
+	quotes := make([][]quote, len(samples))
+	outcomes := make([]float64, len(samples))
+	for i, s := range samples {
+		for vendor, p := range s.Probs {
+			p = math.Min(math.Max(p, probFloor), 1-probFloor)
+			quotes[i] = append(quotes[i], quote{index[vendor], mathutil.Logit(p)})
+		}
+		outcomes[i] = s.Outcome
+	}
+
+	// Optimize log-weights so they stay positive; theta0 is the prior.
+	theta := make([]float64, len(vendors))
+	theta0 := make([]float64, len(vendors))
+	for i, v := range vendors {
+		theta[i] = math.Log(v.Default)
+		theta0[i] = theta[i]
+	}
+	objective := func(theta []float64, grad []float64) float64 {
+		loss := poolLoss(quotes, outcomes, theta, grad)
+		for i, v := range vendors {
+			if !v.Fitted {
+				if grad != nil {
+					grad[i] = 0
+				}
+				continue
+			}
+			d := theta[i] - theta0[i]
+			loss += cfg.L2 * d * d
+			if grad != nil {
+				grad[i] += 2 * cfg.L2 * d
+			}
+		}
+		return loss
+	}
+
+	fit := MarketFit{Market: market, Samples: len(samples), Vendors: vendors}
+	fit.DefaultLogLoss = poolLoss(quotes, outcomes, theta, nil)
+
+	const maxIter = 1000
+	grad := make([]float64, len(theta))
+	next := make([]float64, len(theta))
+	loss := objective(theta, grad)
+	step := 1.0
+	for iter := 0; iter < maxIter && step > 1e-10; iter++ {
+		for i := range theta {
+			next[i] = math.Min(math.Max(theta[i]-step*grad[i], math.Log(minWeight)), math.Log(maxWeight))
+		}
+		if l := objective(next, nil); l < loss {
+			copy(theta, next)
+			loss = objective(theta, grad)
+			step *= 2
+		} else {
+			step /= 2
+		}
+	}
+
+	for i := range vendors {
+		vendors[i].Weight = math.Exp(theta[i])
+	}
+	fit.FittedLogLoss = poolLoss(quotes, outcomes, theta, nil)
+	return fit
+}
+
+// poolLoss returns the mean log loss of the weighted logit pool. When grad
+// is non-nil it is overwritten with the gradient by log-weight.
+func poolLoss(quotes [][]quote, outcomes, theta, grad []float64) float64 {
+	for i := range grad {
+		grad[i] = 0
+	}
+	if len(quotes) == 0 {
+		return 0
+	}
+	var loss float64
+	for i, qs := range quotes {
+		var sum, wSum float64
+		for _, q := range qs {
+			w := math.Exp(theta[q.vendor])
+			sum += w * q.logit
+			wSum += w
+		}
+		pooled := sum / wSum
+		prob := math.Min(math.Max(mathutil.Sigmoid(pooled), probFloor), 1-probFloor)
+		y := outcomes[i]
+		loss -= y*math.Log(prob) + (1-y)*math.Log(1-prob)
+		if grad == nil {
+			continue
+		}
+		// d(pooled)/d(theta_v) = w_v (logit_v - pooled) / Σw
+		r := prob - y
+		for _, q := range qs {
+			w := math.Exp(theta[q.vendor])
+			grad[q.vendor] += r * w * (q.logit - pooled) / wSum
+		}
+	}
+	n := float64(len(quotes))
+	for i := range grad {
+		grad[i] /= n
+	}
+	return loss / n
+}
+
+// Weights returns the fitted vendors' weights in weights-file form.
+// Vendors kept at their default are left out, so they fall back to it.
+func Weights(fits []MarketFit) api.VendorWeights {
+	weights := make(api.VendorWeights)
+	for _, f := range fits {
+		for _, v := range f.Vendors {
+			if !v.Fitted {
+				continue
+			}
+			if weights[f.Market] == nil {
+				weights[f.Market] = make(map[string]float64)
+			}
+			weights[f.Market][v.Vendor] = math.Round(v.Weight*1000) / 1000
+		}
+	}
+	return weights
+}
+
+// WriteText writes each market's log loss and vendor weights.
+func WriteText(w io.Writer, fits []MarketFit) {
+	fmt.Fprintf(w, "%-16s %7s %11s %11s\n", "MARKET", "N", "DEFAULT LL", "FITTED LL")
+	fmt.Fprintln(w, strings.Repeat("-", 48))
+	for _, f := range fits {
+		fmt.Fprintf(w, "%-16s %7d %11.4f %11.4f\n", f.Market, f.Samples, f.DefaultLogLoss, f.FittedLogLoss)
+	}
+
+	for _, f := range fits {
+		fmt.Fprintf(w, "\n%s\n", f.Market)
+		fmt.Fprintf(w, "  %-14s %7s %8s %8s\n", "VENDOR", "N", "DEFAULT", "FITTED")
+		for _, v := range f.Vendors {
+			note := ""
+			if !v.Fitted {
+				note = "  (too few samples, default kept)"
+			}
+			fmt.Fprintf(w, "  %-14s %7d %8.2f %8.2f%s\n", v.Vendor, v.Samples, v.Default, v.Weight, note)
+		}
+	}
+}
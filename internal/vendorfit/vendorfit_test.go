@@ -0,0 +1,164 @@
+package vendorfit
+
+import (
+	"bytes"
+	"math"
+	"math/rand/v2"
+	"strings"
+	"testing"
+	"time"
+
+	"sports-betting-bot/internal/api"
+	"sports-betting-bot/internal/kalshi"
+	"sports-betting-bot/internal/mathutil"
+	"sports-betting-bot/internal/odds"
+	"sports-betting-bot/internal/positions"
+	"sports-betting-bot/internal/snapshot"
+)
+
+// syntheticSamples draws n moneylines whose true log-odds BetMGM quotes
+// with little noise and DraftKings with a lot: the reverse of the defaults.
+func syntheticSamples(n int) []Sample {
+	rng := rand.New(rand.NewPCG(1, 2))
+	samples := make([]Sample, n)
+	for i := range samples {
+		z := rng.NormFloat64()
+		y := 0.0
+		if rng.Float64() < mathutil.Sigmoid(z) {
+			y = 1
+		}
+		samples[i] = Sample{
+			Market: "moneyline",
+			Probs: map[string]float64{
+				"BetMGM":     mathutil.Sigmoid(z + 0.1*rng.NormFloat64()),
+				"DraftKings": mathutil.Sigmoid(z + 0.8*rng.NormFloat64()),
+			},
+			Outcome: y,
+		}
+	}
+	return samples
+}
+
+func TestFitFavorsSharperVendor(t *testing.T) {
+	fits := Fit(syntheticSamples(3000), Config{MinSamples: DefaultMinSamples, L2: DefaultL2})
+	if len(fits) != 1 || fits[0].Market != "moneyline" || fits[0].Samples != 3000 {
+		t.Fatalf("fits = %+v, want one moneyline fit over 3000 samples", fits)
+	}
+	f := fits[0]
+	mgm, dk := f.Vendors[0], f.Vendors[1]
+	if mgm.Vendor != "BetMGM" || dk.Vendor != "DraftKings" {
+		t.Fatalf("vendors = %+v, want BetMGM then DraftKings", f.Vendors)
+	}
+	if !mgm.Fitted || !dk.Fitted {
+		t.Errorf("both vendors should be fitted: %+v", f.Vendors)
+	}
+	if mgm.Weight <= dk.Weight {
+		t.Errorf("BetMGM weight %.2f should exceed DraftKings %.2f", mgm.Weight, dk.Weight)
+	}
+	if f.FittedLogLoss >= f.DefaultLogLoss {
+		t.Errorf("fitted log loss %.4f should beat default %.4f", f.FittedLogLoss, f.DefaultLogLoss)
+	}
+}
+
+func TestFitKeepsDefaultBelowMinSamples(t *testing.T) {
+	samples := syntheticSamples(200)
+	for i := range 20 {
+		samples[i].Probs["Caesars"] = 0.5
+	}
+	fits := Fit(samples, Config{MinSamples: 50, L2: DefaultL2})
+	for _, v := range fits[0].Vendors {
+		if v.Vendor == "Caesars" && (v.Fitted || v.Weight != v.Default || v.Samples != 20) {
+			t.Errorf("Caesars = %+v, want 20 samples at its default weight", v)
+		}
+	}
+
+	weights := Weights(fits)
+	if _, ok := weights["moneyline"]["Caesars"]; ok {
+		t.Error("unfitted vendor should be left out of the weights file")
+	}
+	if len(weights["moneyline"]) != 2 {
+		t.Errorf("weights = %v, want BetMGM and DraftKings", weights)
+	}
+
+	var buf bytes.Buffer
+	WriteText(&buf, fits)
+	if !strings.Contains(buf.String(), "Caesars") || !strings.Contains(buf.String(), "default kept") {
+		t.Errorf("report should flag Caesars as kept at default:\n%s", buf.String())
+	}
+}
+
+func record(t *testing.T, at time.Time, kind snapshot.Kind, gameID int, v any) snapshot.Record {
+	t.Helper()
+	rec, err := snapshot.NewRecord(at, kind, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.GameID = gameID
+	return rec
+}
+
+func TestCollect(t *testing.T) {
+	scan := time.Date(2026, 2, 3, 23, 0, 0, 0, time.UTC)
+	game := func(dkHome int) api.GameOdds {
+		return api.GameOdds{
+			GameID: 7,
+			Vendors: []api.Vendor{
+				{Name: "DraftKings", Moneyline: &api.Moneyline{Home: dkHome, Away: 170},
+					Total: &api.Total{Line: 221.5, OverOdds: -110, UnderOdds: -110}},
+				{Name: "FanDuel", Moneyline: &api.Moneyline{Home: -180, Away: 150}},
+				{Name: "Kalshi", Moneyline: &api.Moneyline{Home: 64, Away: 38},
+					Total: &api.Total{Line: 220.5, OverOdds: 50, UnderOdds: 52}},
+			},
+		}
+	}
+	prop := func(vendor, line string, over, under int) api.PlayerProp {
+		return api.PlayerProp{GameID: 7, PlayerID: 23, Vendor: vendor, PropType: "points", LineStr: line,
+			Market: api.PlayerPropMarket{Type: "over_under", OverOdds: over, UnderOdds: under}}
+	}
+	records := []snapshot.Record{
+		record(t, scan.Add(-time.Hour), snapshot.KindOdds, 0, []api.GameOdds{game(-150)}),
+		record(t, scan, snapshot.KindOdds, 0, []api.GameOdds{game(-200)}),
+		record(t, scan, snapshot.KindPlayerProps, 7, []api.PlayerProp{
+			prop("DraftKings", "24.5", -120, 100),
+			prop("FanDuel", "24.5", -115, -105),
+			prop("BetMGM", "25.5", 110, -130), // Another strike
+		}),
+		record(t, scan, snapshot.KindPlayerNames, 0, map[int]string{23: "LeBron James"}),
+		record(t, scan, snapshot.KindPropMarkets, 0, map[string][]kalshi.PlayerPropMarket{
+			"points": {{Ticker: "PTS-LEBRON-25", PlayerName: "LeBron James", PropType: "points", Line: 25}},
+		}),
+		// After tip-off: ignored
+		record(t, scan.Add(2*time.Hour), snapshot.KindOdds, 0, []api.GameOdds{game(-900)}),
+	}
+	preds := []positions.Prediction{
+		{Ticker: "ML", GameID: "7", MarketType: "moneyline", PredictedAt: scan, Result: positions.ResultYes},
+		{Ticker: "TOT", GameID: "7", MarketType: "total", Line: 220.5, PredictedAt: scan, Result: positions.ResultNo},
+		{Ticker: "PTS-LEBRON-25", GameID: "7", MarketType: "prop_points", Line: 25, PredictedAt: scan, Result: positions.ResultYes},
+		{Ticker: "SPR", GameID: "7", MarketType: "spread", Line: -4.5, PredictedAt: scan, Result: positions.ResultYes}, // No spread quoted
+		{Ticker: "OPEN", GameID: "7", MarketType: "moneyline", PredictedAt: scan},                                      // Unresolved
+	}
+
+	samples, err := Collect(records, preds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 3 {
+		t.Fatalf("samples = %+v, want moneyline, total and points", samples)
+	}
+
+	ml := samples[0]
+	dkHome, _ := odds.RemoveVigPowerFromAmerican(-200, 170)
+	if ml.Market != "moneyline" || ml.Outcome != 1 || len(ml.Probs) != 2 || math.Abs(ml.Probs["DraftKings"]-dkHome) > 1e-9 {
+		t.Errorf("moneyline = %+v, want DraftKings at the last pre-game scan (%.4f)", ml, dkHome)
+	}
+
+	total := samples[1]
+	if total.Market != "total" || total.Outcome != 0 || len(total.Probs) != 1 || total.Probs["DraftKings"] <= 0.5 {
+		t.Errorf("total = %+v, want DraftKings' 221.5 over moved to 220.5, above 50%%", total)
+	}
+
+	pts := samples[2]
+	if pts.Market != "prop_points" || len(pts.Probs) != 2 || pts.Probs["DraftKings"] <= pts.Probs["FanDuel"] {
+		t.Errorf("points = %+v, want the two 24.5 lines", pts)
+	}
+}
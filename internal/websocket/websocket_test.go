@@ -0,0 +1,87 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoServer echoes each message back, then closes after "bye".
+func echoServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "secret" {
+			http.Error(w, "bad token", http.StatusUnauthorized)
+			return
+		}
+		c, err := Upgrade(w, r)
+		if err != nil {
+			t.Errorf("Upgrade: %v", err)
+			return
+		}
+		defer c.Close()
+		for {
+			msg, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			if string(msg) == "bye" {
+				return
+			}
+			if err := c.WriteMessage(msg); err != nil {
+				return
+			}
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func dial(t *testing.T, srv *httptest.Server, token string) (*Conn, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http"), http.Header{"X-Token": {token}})
+}
+
+func TestRoundTrip(t *testing.T) {
+	c, err := dial(t, echoServer(t), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	// Sizes cover the 7-bit, 16-bit and 64-bit length encodings
+	for _, n := range []int{5, 300, 70000} {
+		want := strings.Repeat("x", n)
+		if err := c.WriteMessage([]byte(want)); err != nil {
+			t.Fatal(err)
+		}
+		c.SetReadDeadline(time.Now().Add(5 * time.Second))
+		got, err := c.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("echo of %d bytes returned %d bytes", n, len(got))
+		}
+	}
+
+	if err := c.WriteMessage([]byte("bye")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.ReadMessage(); !errors.Is(err, ErrClosed) {
+		t.Errorf("ReadMessage after server close = %v, want ErrClosed", err)
+	}
+}
+
+func TestDialRejected(t *testing.T) {
+	_, err := dial(t, echoServer(t), "wrong")
+	if err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Dial = %v, want a 401 upgrade failure", err)
+	}
+}